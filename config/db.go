@@ -57,10 +57,10 @@ func SeedDatabase() {
 
 	if rtCount == 0 {
 		roomTypes := []models.RoomType{
-			{TypeName: "Standard", Description: "Standard Room", MaxGuests: 2},
-			{TypeName: "Superior", Description: "Superior Room", MaxGuests: 3},
-			{TypeName: "Deluxe", Description: "Deluxe Room", MaxGuests: 4},
-			{TypeName: "Connecting", Description: "Connecting Room", MaxGuests: 5},
+			{TypeName: "Standard", Description: "Standard Room", MaxGuests: 2, BaseOccupancy: 2},
+			{TypeName: "Superior", Description: "Superior Room", MaxGuests: 3, BaseOccupancy: 2},
+			{TypeName: "Deluxe", Description: "Deluxe Room", MaxGuests: 4, BaseOccupancy: 2},
+			{TypeName: "Connecting", Description: "Connecting Room", MaxGuests: 5, BaseOccupancy: 4},
 		}
 		DB.Create(&roomTypes)
 		log.Println("RoomTypes seeded")
@@ -110,6 +110,14 @@ func SeedDatabase() {
 		"roomManagement.edit",
 		"roomManagement.delete",
 		"roomManagement.editStatus",
+		"housekeeping.view",
+		"housekeeping.work",
+		"housekeeping.assign",
+		"housekeeping.inspect",
+		"maintenance.view",
+		"maintenance.create",
+		"maintenance.assign",
+		"maintenance.work",
 		"customerList.view",
 		"customerList.create",
 		"customerList.edit",
@@ -126,10 +134,23 @@ func SeedDatabase() {
 		"emailManagement.view",
 		"emailManagement.send",
 		"emailManagement.config",
+		"webhookManagement.view",
+		"webhookManagement.config",
+		"webhookManagement.replay",
 		"roomAccess.view",
 		"roomAccess.reset",
 		"auditLogs.view",
 		"auditLogs.export",
+		"guestDocuments.view",
+		"guestDocuments.review",
+		"dataRetention.view",
+		"dataRetention.run",
+		"consentManagement.view",
+		"consentManagement.edit",
+		"consentManagement.publish",
+		"privacyRequests.view",
+		"privacyRequests.manage",
+		"hotelSettings.edit",
 	}
 
 	rolesByKey := map[string]models.Role{}
@@ -198,6 +219,21 @@ func SeedDatabase() {
 		}
 	}
 
+	// Cleaner: สิทธิ์ตั้งต้นสำหรับงาน housekeeping (ตั้งครั้งเดียวตอน role ยังไม่มีสิทธิ์ — admin ปรับต่อเองได้)
+	if cleanerRole, ok := rolesByKey["cleaner"]; ok && cleanerRole.ID != 0 {
+		var permCount int64
+		DB.Model(&models.RolePermission{}).Where("role_id = ?", cleanerRole.ID).Count(&permCount)
+		if permCount == 0 {
+			perms := []models.RolePermission{
+				{RoleID: cleanerRole.ID, Permission: "housekeeping.view"},
+				{RoleID: cleanerRole.ID, Permission: "housekeeping.work"},
+			}
+			if err := DB.Create(&perms).Error; err != nil {
+				log.Printf("warning: failed to seed cleaner permissions: %v", err)
+			}
+		}
+	}
+
 	// Ensure default admin stays in owner role
 	if ownerRole, ok := rolesByKey["owner"]; ok && ownerRole.ID != 0 {
 		var admin models.Admin
@@ -352,18 +388,46 @@ func ConnectDatabase() error {
 		&models.RolePermission{},
 		&models.RoleMember{},
 		&models.RoomType{},
+		&models.RoomTypePhoto{},
 		&models.Customer{},
 		&models.Room{},
 		&models.Booking{},
 		&models.BookingInfo{},
 		&models.Guest{},
 		&models.Consent{},    // parent (consents)
+		&models.ConsentTranslation{},
 		&models.ConsentLog{}, // child (consent_logs)
+		&models.ConsentChainHead{},
 		&models.BookingRoom{},
+		&models.AdminSession{},
+		&models.DocumentAccessLog{},
+		&models.PurgeLog{},
+		&models.DataSubjectRequest{},
+		&models.ConsentReceipt{},
+		&models.GuestSignature{},
+		&models.CheckinInvitation{},
+		&models.CheckinRateLimit{},
+		&models.EmailOutbox{},
+		&models.EmailTemplate{},
+		&models.EmailEvent{},
+		&models.EmailSuppression{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
+		&models.AuditEvent{},
+		&models.HousekeepingTask{},
+		&models.RoomStatusHistory{},
+		&models.RoomBlock{},
+		&models.WorkOrder{},
+		&models.WorkOrderPhoto{},
 	); err != nil {
 		return err
 	}
 
+	// consent เดิมก่อนมี lifecycle ถือว่า publish แล้ว (ยังใช้ตอนเช็คอินได้ตามเดิม)
+	if err := DB.Exec("UPDATE consents SET status = ?, published_at = COALESCE(published_at, created_at, NOW()) WHERE status IS NULL OR status = ''", models.ConsentStatusPublished).Error; err != nil {
+		log.Printf("warning: failed to backfill consent status: %v", err)
+	}
+
 	SeedDatabase()
 	return nil
 }
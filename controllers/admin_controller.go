@@ -29,6 +29,7 @@ type inviteAdminPayload struct {
 	Email string `json:"email"`
 	Role  string `json:"role"`
 	FromEmail string `json:"from_email"`
+	Locale    string `json:"locale"` // ภาษาอีเมลของ admin (en | th, ว่าง = ภาษาตั้งต้นของโรงแรม)
 }
 
 type activateAdminPayload struct {
@@ -112,6 +113,13 @@ func InviteAdmin(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid email"})
 		return
 	}
+	locale := ""
+	if raw := strings.TrimSpace(payload.Locale); raw != "" {
+		if locale = utils.NormalizeEmailLocale(raw); locale == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "locale must be one of: " + strings.Join(utils.EmailLocales, ", ")})
+			return
+		}
+	}
 
 	token, err := utils.GenerateSecureToken(24)
 	if err != nil {
@@ -136,6 +144,7 @@ func InviteAdmin(c *gin.Context) {
 	if exists {
 		if err := config.DB.Unscoped().Model(&admin).Updates(map[string]any{
 			"full_name":           name,
+			"locale":              locale,
 			"reset_token":         token,
 			"reset_token_expires": expiry,
 			"deleted_at":          nil,
@@ -147,6 +156,7 @@ func InviteAdmin(c *gin.Context) {
 		admin = models.Admin{
 			FullName:          name,
 			Username:          email,
+			Locale:            locale,
 			ResetToken:        &token,
 			ResetTokenExpires: &expiry,
 		}
@@ -184,15 +194,10 @@ func InviteAdmin(c *gin.Context) {
 		return
 	}
 
-	adminFrontendURL := utils.EnvOrDefault("FRONTEND_ADMIN_URL", "")
-	if adminFrontendURL == "" {
-		adminFrontendURL = utils.EnvOrDefault("FRONTEND_URL", "http://localhost:3000")
-	}
-	adminFrontendURL = strings.TrimRight(adminFrontendURL, "/")
-	inviteLink := fmt.Sprintf("%s/#/setup-account?token=%s&email=%s", adminFrontendURL, token, url.QueryEscape(email))
+	inviteLink := adminSetupLink(token, email)
 
 	fromEmail := strings.TrimSpace(payload.FromEmail)
-	if err := utils.SendAdminInviteEmail(email, inviteLink, name, roleName, fromEmail); err != nil {
+	if err := utils.SendAdminInviteEmail(email, inviteLink, name, roleName, fromEmail, locale); err != nil {
 		_ = config.DB.Unscoped().Where("admin_id = ?", admin.ID).Delete(&models.RoleMember{}).Error
 		if exists {
 			_ = config.DB.Unscoped().Model(&admin).Update("deleted_at", time.Now()).Error
@@ -333,3 +338,13 @@ func UpdateAdminRole(c *gin.Context) {
 		"role":  roleName,
 	})
 }
+
+// adminSetupLink ลิงก์ตั้งรหัสผ่านในหน้า admin (ใช้ทั้งคำเชิญและลืมรหัสผ่าน)
+func adminSetupLink(token, email string) string {
+	adminFrontendURL := utils.EnvOrDefault("FRONTEND_ADMIN_URL", "")
+	if adminFrontendURL == "" {
+		adminFrontendURL = utils.EnvOrDefault("FRONTEND_URL", "http://localhost:3000")
+	}
+	adminFrontendURL = strings.TrimRight(adminFrontendURL, "/")
+	return fmt.Sprintf("%s/#/setup-account?token=%s&email=%s", adminFrontendURL, token, url.QueryEscape(email))
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hotel-backend/config"
+	"hotel-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/audit-events?event=&bookingId=&guestId=&from=YYYY-MM-DD&to=YYYY-MM-DD&page=&limit=
+// ประวัติ domain event (BookingCreated, CheckInCompleted, ...) ที่ handler "audit" บันทึกไว้
+func ListAuditEvents(c *gin.Context) {
+	bookingID, _ := strconv.ParseUint(c.Query("bookingId"), 10, 64)
+	guestID, _ := strconv.ParseUint(c.Query("guestId"), 10, 64)
+	page, _ := strconv.Atoi(c.Query("page"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	f := services.AuditEventFilter{
+		Event:     strings.TrimSpace(c.Query("event")),
+		BookingID: uint(bookingID),
+		GuestID:   uint(guestID),
+		Page:      page,
+		Limit:     limit,
+	}
+	for _, p := range []struct {
+		key string
+		dst **time.Time
+		add int
+	}{{"from", &f.From, 0}, {"to", &f.To, 1}} {
+		v := strings.TrimSpace(c.Query(p.key))
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidDate", "message": "รูปแบบวันที่ต้องเป็น YYYY-MM-DD", "details": p.key}})
+			return
+		}
+		t = t.AddDate(0, 0, p.add) // to รวมทั้งวัน
+		*p.dst = &t
+	}
+
+	rows, total, err := services.ListAuditEvents(config.DB, f)
+	if err != nil {
+		log.Printf("ListAuditEvents error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": rows, "total": total})
+}
@@ -3,12 +3,15 @@
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"log"
 	"net/http"
 	"strings"
 	"time"
 
 	"hotel-backend/config"
 	"hotel-backend/models"
+	"hotel-backend/services"
+	"hotel-backend/utils"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
@@ -121,8 +124,15 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	expiresAt, err := services.CreateAdminSession(config.DB, admin.ID, token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"token": token,
+		"token":      token,
+		"expires_at": expiresAt,
 		"admin": gin.H{
 			"id":        admin.ID,
 			"full_name": admin.FullName,
@@ -131,6 +141,24 @@ func Login(c *gin.Context) {
 	})
 }
 
+func Logout(c *gin.Context) {
+	token := ""
+	parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+	if len(parts) == 2 && strings.EqualFold(parts[0], "Bearer") {
+		token = strings.TrimSpace(parts[1])
+	}
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token required"})
+		return
+	}
+
+	if err := services.RevokeAdminSession(config.DB, token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to logout"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
 func ForgotPassword(c *gin.Context) {
 	var payload forgotPayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
@@ -153,6 +181,10 @@ func ForgotPassword(c *gin.Context) {
 				"reset_token":         token,
 				"reset_token_expires": expiry,
 			})
+			// ไม่บอกผลการส่งกับ client (กันเดาว่ามีอีเมลนี้ในระบบ)
+			if err := utils.SendPasswordResetEmail(admin.Username, admin.FullName, adminSetupLink(token, admin.Username), expiry); err != nil {
+				log.Printf("ForgotPassword: send reset email: %v", err)
+			}
 		}
 	}
 
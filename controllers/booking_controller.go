@@ -32,14 +32,18 @@ type InitiateCheckInPayload struct {
 }
 
 type ValidateCodePayload struct {
-	CheckinCode string `json:"checkinCode" binding:"required"`
-	Query       string `json:"query" binding:"required"` // lastName หรือ bookingRef
+	CheckinCode  string `json:"checkinCode" binding:"required"`
+	Query        string `json:"query" binding:"required"` // lastName หรือ bookingRef (ตรงทั้งคำ)
+	CaptchaToken string `json:"captchaToken"`
 }
 
 type ConfirmCheckInPayload struct {
 	Token    string           `json:"token" binding:"required"`
 	Guests   []models.Guest   `json:"guests"`
 	Consents []models.Consent `json:"consents"`
+
+	// ลายเซ็นของแขกหลัก: { image: data URI } หรือ { strokes: [[{x,y}]], width, height }
+	Signature *services.SignatureInput `json:"signature"`
 }
 
 // RoomItem รองรับ per-room details ที่ frontend อาจส่งมา
@@ -72,10 +76,14 @@ type CreateBookingRequest struct {
 
 type BookingController struct {
 	BookingSvc *services.BookingService
+	Guard      *services.CheckinGuard
 }
 
 func NewBookingController(svc *services.BookingService) *BookingController {
-	return &BookingController{BookingSvc: svc}
+	return &BookingController{
+		BookingSvc: svc,
+		Guard:      services.NewCheckinGuard(svc.DB, services.NewCaptchaVerifierFromEnv()),
+	}
 }
 
 func generateUniqueRoomAccessCode() (string, error) {
@@ -251,26 +259,14 @@ func (ctrl *BookingController) ValidateCheckinCode(c *gin.Context) {
 		return
 	}
 
-	bi, err := ctrl.BookingSvc.ValidateCheckinCodeByBooking(p.CheckinCode, p.Query)
+	bi, err := ctrl.Guard.Validate(c.Request.Context(), services.CheckinAttempt{
+		Code:         p.CheckinCode,
+		Query:        p.Query,
+		CaptchaToken: p.CaptchaToken,
+		Audit:        consentAudit(c),
+	}, time.Now())
 	if err != nil {
-
-		// ✅ เพิ่มตรงนี้
-		if strings.Contains(err.Error(), "booking_checked_out") {
-			c.JSON(http.StatusGone, gin.H{
-				"error": gin.H{
-					"code":    "error.bookingCheckedOut",
-					"message": "การจองนี้เช็คเอาท์แล้ว ไม่สามารถใช้รหัสนี้ได้",
-				},
-			})
-			return
-		}
-
-		if strings.Contains(err.Error(), "invalid_or_expired_code") || strings.Contains(err.Error(), "invalid_or_expired") {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": gin.H{"code": "error.invalidOrExpiredCode", "message": "รหัสยืนยันไม่ถูกต้องหรือหมดอายุ"}})
-			return
-		}
-
-		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดขณะตรวจสอบรหัส", "details": err.Error()}})
+		checkinGuardError(c, bi, err)
 		return
 	}
 
@@ -344,6 +340,12 @@ func (ctrl *BookingController) VerifyToken(c *gin.Context) {
 		return
 	}
 
+	// ลิงก์ส่งล่วงหน้าได้ แต่เปิดใช้ตาม checkin_link_open_days_before
+	if err := services.CheckinOpen(bi, now); err != nil {
+		checkinNotOpen(c, bi)
+		return
+	}
+
 	// --------------------
 	// 3) Load Booking
 	// --------------------
@@ -430,6 +432,38 @@ func (ctrl *BookingController) VerifyToken(c *gin.Context) {
 	// ✅ Parse accompanying guests
 	accompanyingGuests := parseAccompanyingGuests([]byte(booking.AccompanyingGuests))
 
+	// --------------------
+	// 5) Waiting for identity review (hotel policy)
+	// --------------------
+	if booking.Status == "Pending-Review" {
+		var reviewed []models.Guest
+		if err := ctrl.BookingSvc.DB.
+			Select("id", "full_name", "review_status", "review_reason").
+			Where("booking_id = ?", booking.ID).
+			Order("is_main_guest DESC, id ASC").
+			Find(&reviewed).Error; err != nil {
+			log.Printf("verify token: failed to load guests for booking %d: %v", booking.ID, err)
+		}
+		guestStatuses := make([]gin.H, 0, len(reviewed))
+		for _, g := range reviewed {
+			guestStatuses = append(guestStatuses, gin.H{
+				"guestId":      g.ID,
+				"fullName":     g.FullName,
+				"reviewStatus": g.ReviewStatus,
+				"reviewReason": g.ReviewReason,
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "pending_review",
+			"data": gin.H{
+				"bookingId": booking.ID,
+				"guests":    guestStatuses,
+			},
+		})
+		return
+	}
+
 	// --------------------
 	// 6) Already checked-in
 	// --------------------
@@ -527,12 +561,44 @@ func (ctrl *BookingController) ConfirmCheckIn(c *gin.Context) {
 		guestModels = append(guestModels, g)
 	}
 
-	if err := ctrl.BookingSvc.FinalizeCheckInTransaction(payload.Token, guestModels, payload.Consents); err != nil {
+	if err := ctrl.BookingSvc.FinalizeCheckInTransaction(payload.Token, guestModels, payload.Consents, payload.Signature, consentAudit(c)); err != nil {
+		if strings.Contains(err.Error(), "checkin_pending_review") {
+			c.JSON(http.StatusAccepted, gin.H{
+				"status":  "pending_review",
+				"message": "บันทึกข้อมูลแล้ว รอพนักงานตรวจสอบเอกสารก่อนเช็คอินเสร็จสมบูรณ์",
+			})
+			return
+		}
 		log.Printf("FinalizeCheckInTransaction error (token=%s): %v", payload.Token, err)
+		var missing *services.MissingConsentsError
+		if errors.As(err, &missing) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": gin.H{"code": "error.consentRequired", "message": "กรุณายอมรับเงื่อนไขที่จำเป็นให้ครบก่อนเช็คอิน", "missing": missing.Missing}})
+			return
+		}
+		if strings.Contains(err.Error(), "consent_text_mismatch") {
+			c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.consentTextChanged", "message": "ข้อความเงื่อนไขมีการเปลี่ยนแปลง กรุณาโหลดหน้าใหม่และยอมรับอีกครั้ง", "details": err.Error()}})
+			return
+		}
+		if strings.Contains(err.Error(), "invalid_consent") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidConsent", "message": "เงื่อนไขที่ส่งมาไม่ใช่เวอร์ชันที่ใช้งานอยู่ กรุณาโหลดหน้าใหม่", "details": err.Error()}})
+			return
+		}
+		if strings.Contains(err.Error(), "signature_required") {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": gin.H{"code": "error.signatureRequired", "message": "กรุณาเซ็นชื่อก่อนยืนยันการเช็คอิน"}})
+			return
+		}
+		if strings.Contains(err.Error(), "invalid_guest_signature") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidSignature", "message": "ลายเซ็นไม่ถูกต้อง", "details": err.Error()}})
+			return
+		}
 		if strings.Contains(err.Error(), "invalid_or_expired_token") {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": gin.H{"code": "error.invalidOrExpiredToken", "message": "ลิงก์การเช็คอินไม่ถูกต้องหรือหมดอายุ"}})
 			return
 		}
+		if strings.Contains(err.Error(), "checkin_not_open") {
+			c.JSON(http.StatusForbidden, gin.H{"error": gin.H{"code": "error.checkinNotOpen", "message": "ยังไม่ถึงเวลาเช็คอินออนไลน์"}})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.finalizeFailed", "message": "ไม่สามารถยืนยันการเช็คอินได้", "details": err.Error()}})
 		return
 	}
@@ -540,6 +606,55 @@ func (ctrl *BookingController) ConfirmCheckIn(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "เช็คอินเสร็จสิ้นและบันทึกข้อมูลแล้ว"})
 }
 
+// GET /api/checkin/required-consents?token=&locale=  (หรือ Accept-Language)
+// consent ที่ต้องยอมรับตอนเช็คอิน + ธงว่าลูกค้าเดิมต้องยอมรับเวอร์ชันใหม่หรือไม่
+func (ctrl *BookingController) RequiredConsents(c *gin.Context) {
+	token := strings.TrimSpace(c.Query("token"))
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.missingToken", "message": "ไม่พบ token กรุณาตรวจสอบลิงก์"}})
+		return
+	}
+
+	now := time.Now().UTC()
+	var bi models.BookingInfo
+	if err := ctrl.BookingSvc.DB.
+		Where("token = ? AND (expires_at IS NULL OR expires_at > ?)", token, now).
+		First(&bi).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": gin.H{"code": "error.invalidOrExpiredToken", "message": "ลิงก์ยืนยันไม่ถูกต้องหรือหมดอายุ"}})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+		return
+	}
+
+	var booking models.Booking
+	if err := ctrl.BookingSvc.DB.Select("id", "customer_id").First(&booking, bi.BookingID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.bookingNotFound", "message": "ไม่พบข้อมูลการจอง"}})
+		return
+	}
+
+	locale := services.NegotiateConsentLocale(c.Query("locale"), c.GetHeader("Accept-Language"))
+	reqs, err := services.NewConsentService(ctrl.BookingSvc.DB).RequirementsForCustomer(booking.CustomerID, locale, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ", "details": err.Error()}})
+		return
+	}
+
+	reconsent := false
+	for _, r := range reqs {
+		if r.Reconsent {
+			reconsent = true
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"locale":            locale,
+		"consents":          reqs,
+		"reconsentRequired": reconsent,
+		"signatureRequired": services.SignatureRequired(ctrl.BookingSvc.DB),
+	})
+}
+
 // ---------------------------
 // CRUD: Bookings
 // ---------------------------
@@ -639,6 +754,10 @@ func (ctrl *BookingController) CreateBooking(c *gin.Context) {
 
 	if err != nil {
 		log.Printf("Service error creating booking: %v", err)
+		if strings.Contains(err.Error(), "room_unavailable") {
+			c.JSON(http.StatusConflict, gin.H{"error": "Room is blocked for the requested dates", "details": err.Error()})
+			return
+		}
 		if strings.Contains(err.Error(), "validation") || strings.Contains(err.Error(), "invalid") {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create booking", "details": err.Error()})
 			return
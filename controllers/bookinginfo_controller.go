@@ -22,27 +22,38 @@ import (
 // BookingInfoController ตัวเดิมของคุณ (constructor อยู่ที่ไฟล์เดิม)
 type BookingInfoController struct {
 	InfoSvc *services.BookingInfoService
+	Guard   *services.CheckinGuard
 }
 
 func NewBookingInfoController(svc *services.BookingInfoService) *BookingInfoController {
-	return &BookingInfoController{InfoSvc: svc}
+	return &BookingInfoController{
+		InfoSvc: svc,
+		Guard:   services.NewCheckinGuard(svc.DB, services.NewCaptchaVerifierFromEnv()),
+	}
 }
 
 // --- Existing CRUD methods (SaveBookingInfo, GetBookingInfoByID, DeleteBookingInfo) ---
 
-// SaveBookingInfo creates or updates a BookingInfo entry
+// SaveBookingInfo updates an existing BookingInfo entry (admin only)
 func (ctrl *BookingInfoController) SaveBookingInfo(c *gin.Context) {
 	var bi models.BookingInfo
 	if err := c.ShouldBindJSON(&bi); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
 		return
 	}
-	if err := ctrl.InfoSvc.SaveBookingInfo(bi); err != nil {
-		log.Printf("SaveBookingInfo DB error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save booking info"})
+	if err := ctrl.InfoSvc.SaveBookingInfo(&bi); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "missing_booking_info"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "missing id"})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		default:
+			log.Printf("SaveBookingInfo DB error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save booking info"})
+		}
 		return
 	}
-	c.JSON(http.StatusCreated, bi)
+	c.JSON(http.StatusOK, bi)
 }
 
 // GetBookingInfoByID returns a BookingInfo by ID
@@ -81,81 +92,101 @@ func (ctrl *BookingInfoController) DeleteBookingInfo(c *gin.Context) {
 // Checkin: Validate / Resend
 // ------------------------------
 
-// ValidateCheckinCode (POST /api/checkin/validate)
-// Body: { "checkinCode": "AWLI-TEJN", "query": "lastnameOrRef" }
-func (ctrl *BookingInfoController) ValidateCheckinCode(c *gin.Context) {
-	var req struct {
-		CheckinCode string `json:"checkinCode"`
-		Query       string `json:"query"` // optional
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
-		return
-	}
-
-	codeRaw := strings.TrimSpace(req.CheckinCode)
-	if codeRaw == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "checkinCode required"})
-		return
-	}
-
-	norm := utils.NormalizeCheckinCode(codeRaw)
-	if len(norm) != 8 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid checkin code format"})
-		return
-	}
-	formatted := strings.ToUpper(norm[:4] + "-" + norm[4:])
-	noDash := strings.ToUpper(norm)
-
-	bi, expired, err := ctrl.InfoSvc.FindByCodeWithExpiry(formatted, noDash)
-	if err != nil {
-		// not found or DB error
-		// If it's a DB error different from not found, log it
-		if !errors.Is(err, gorm.ErrRecordNotFound) {
-			log.Printf("ValidateCheckinCode error: %v", err)
+// checkinGuardError แปลง error ของ CheckinGuard เป็น HTTP response
+// (ไม่พบรหัส กับ ชื่อไม่ตรง ตอบเหมือนกันเพื่อไม่ให้ใช้เดารหัสได้)
+func checkinGuardError(c *gin.Context, bi models.BookingInfo, err error) {
+	var throttled *services.CheckinThrottleError
+	if errors.As(err, &throttled) {
+		retry := int(throttled.RetryAfter.Seconds())
+		if retry < 1 {
+			retry = 1
 		}
-		c.JSON(http.StatusNotFound, gin.H{"error": "confirmation code not found"})
+		c.Header("Retry-After", strconv.Itoa(retry))
+		if throttled.Code == "code_locked" {
+			c.JSON(http.StatusLocked, gin.H{"error": gin.H{"code": "error.codeLocked", "message": "กรอกข้อมูลผิดหลายครั้ง รหัสนี้ถูกล็อกชั่วคราว", "retryAfter": retry}})
+			return
+		}
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": gin.H{"code": "error.tooManyAttempts", "message": "ลองหลายครั้งเกินไป กรุณารอสักครู่", "retryAfter": retry}})
 		return
 	}
 
-	if expired {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "captcha_required"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.captchaRequired", "message": "กรุณายืนยันว่าไม่ใช่บอท"}})
+	case strings.Contains(msg, "captcha_failed"):
+		c.JSON(http.StatusForbidden, gin.H{"error": gin.H{"code": "error.captchaFailed", "message": "ยืนยัน CAPTCHA ไม่สำเร็จ"}})
+	case strings.Contains(msg, "captcha_unavailable"):
+		log.Printf("checkin captcha error: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": gin.H{"code": "error.captchaUnavailable", "message": "ไม่สามารถตรวจสอบ CAPTCHA ได้ในขณะนี้"}})
+	case strings.Contains(msg, "invalid_code_format"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidCodeFormat", "message": "รูปแบบรหัสเช็คอินไม่ถูกต้อง"}})
+	case strings.Contains(msg, "query_required"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.queryRequired", "message": "กรุณาระบุนามสกุลหรือหมายเลขการจอง"}})
+	case strings.Contains(msg, "missing_booking_info"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "ต้องระบุ bookingInfoId หรือ checkinCode"}})
+	case strings.Contains(msg, "invalid_or_expired_code"), strings.Contains(msg, "booking_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.invalidOrExpiredCode", "message": "รหัสยืนยันหรือนามสกุลไม่ถูกต้อง"}})
+	case strings.Contains(msg, "code_expired"):
 		var expiresAt interface{}
 		if bi.CodeExpiresAt != nil {
 			expiresAt = bi.CodeExpiresAt.UTC().Format(time.RFC3339)
-		} else {
-			expiresAt = nil
 		}
 		c.JSON(http.StatusGone, gin.H{
-			"error":         "confirmation code expired",
+			"error":         gin.H{"code": "error.codeExpired", "message": "รหัสยืนยันหมดอายุแล้ว กรุณาขอรหัสใหม่"},
 			"bookingInfoId": bi.ID,
 			"expiresAt":     expiresAt,
 		})
-		return
+	case strings.Contains(msg, "booking_checked_out"):
+		c.JSON(http.StatusGone, gin.H{"error": gin.H{"code": "error.bookingCheckedOut", "message": "การจองนี้เช็คเอาท์แล้ว ไม่สามารถใช้รหัสนี้ได้"}})
+	case strings.Contains(msg, "checkin_link_expired"):
+		c.JSON(http.StatusGone, gin.H{"error": gin.H{"code": "error.checkinLinkExpired", "message": "ลิงก์เช็คอินหมดอายุแล้ว กรุณาติดต่อโรงแรม"}})
+	case strings.Contains(msg, "checkin_not_open"):
+		checkinNotOpen(c, bi)
+	default:
+		log.Printf("checkin guard error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดขณะตรวจสอบรหัส"}})
 	}
+}
 
-	// Block if booking already Checked-Out
-	var booking models.Booking
-	if err := config.DB.Select("status", "check_out").Where("id = ?", bi.BookingID).First(&booking).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "booking not found"})
-			return
-		}
-		log.Printf("ValidateCheckinCode booking fetch error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load booking"})
+// checkinNotOpen: ยังไม่ถึงเวลาเปิดเช็คอินออนไลน์ (checkin_link_open_days_before)
+func checkinNotOpen(c *gin.Context, bi models.BookingInfo) {
+	var opensAt interface{}
+	if bi.OpensAt != nil {
+		opensAt = bi.OpensAt.UTC().Format(time.RFC3339)
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": gin.H{"code": "error.checkinNotOpen", "message": "ยังไม่ถึงเวลาเช็คอินออนไลน์", "opensAt": opensAt}})
+}
+
+// ValidateCheckinCode (POST /api/checkin/validate)
+// Body: { "checkinCode": "AWLI-TEJN", "query": "lastnameOrRef", "captchaToken": "..." }
+// query ต้องตรงกับนามสกุล/ชื่อเต็ม/booking reference ทั้งคำ — ผิดเกินกำหนดรหัสจะถูกล็อก
+func (ctrl *BookingInfoController) ValidateCheckinCode(c *gin.Context) {
+	var req struct {
+		CheckinCode  string `json:"checkinCode"`
+		Query        string `json:"query"`
+		CaptchaToken string `json:"captchaToken"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "ต้องระบุ checkinCode และ query (lastName หรือ bookingRef)"}})
 		return
 	}
-	if strings.EqualFold(strings.TrimSpace(booking.Status), "Checked-Out") {
-		c.JSON(http.StatusGone, gin.H{
-			"error":   "booking checked out",
-			"message": "การจองนี้เช็คเอาท์แล้ว ไม่สามารถใช้รหัสนี้ได้",
-		})
+	if strings.TrimSpace(req.CheckinCode) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "ต้องระบุ checkinCode"}})
 		return
 	}
 
-	// Optionally validate query (last name or booking reference) if provided
-	// Current implementation returns success if code is valid and not expired.
-	// If you want to enforce query matching, implement additional checks here.
+	bi, err := ctrl.Guard.Validate(c.Request.Context(), services.CheckinAttempt{
+		Code:         req.CheckinCode,
+		Query:        req.Query,
+		CaptchaToken: req.CaptchaToken,
+		Audit:        consentAudit(c),
+	}, time.Now())
+	if err != nil {
+		checkinGuardError(c, bi, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status":        "ok",
@@ -167,72 +198,69 @@ func (ctrl *BookingInfoController) ValidateCheckinCode(c *gin.Context) {
 }
 
 // ResendCheckinCode (POST /api/checkin/resend)
-// Body: { "bookingInfoId": 10 } OR { "checkinCode": "AWLI-TEJN" }
+// Body: { "bookingInfoId": 10, "query": "lastname" } OR { "checkinCode": "AWLI-TEJN", "query": "lastname" } (+ "captchaToken")
 func (ctrl *BookingInfoController) ResendCheckinCode(c *gin.Context) {
 	var req struct {
 		BookingInfoId uint   `json:"bookingInfoId"`
 		CheckinCode   string `json:"checkinCode"`
+		Query         string `json:"query"`
+		CaptchaToken  string `json:"captchaToken"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
-		return
-	}
-
-	var bi models.BookingInfo
-	var err error
-
-	if req.BookingInfoId > 0 {
-		bi, err = ctrl.InfoSvc.GetByID(req.BookingInfoId)
-	} else if strings.TrimSpace(req.CheckinCode) != "" {
-		norm := utils.NormalizeCheckinCode(req.CheckinCode)
-		if len(norm) != 8 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid checkin code format"})
-			return
-		}
-		formatted := strings.ToUpper(norm[:4] + "-" + norm[4:])
-		noDash := strings.ToUpper(norm)
-		bi, _, err = ctrl.InfoSvc.FindByCodeWithExpiry(formatted, noDash)
-	} else {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing bookingInfoId or checkinCode"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "invalid request"}})
 		return
 	}
 
+	bi, err := ctrl.Guard.AuthorizeResend(c.Request.Context(), services.CheckinAttempt{
+		BookingInfoID: req.BookingInfoId,
+		Code:          req.CheckinCode,
+		Query:         req.Query,
+		CaptchaToken:  req.CaptchaToken,
+		Audit:         consentAudit(c),
+	}, time.Now())
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "booking info not found"})
+		checkinGuardError(c, bi, err)
 		return
 	}
 
-	newExpiry, err := ctrl.InfoSvc.ExtendExpiry(bi.ID, 15) // extend by 15 minutes
+	// ต่ออายุรหัสตาม CheckinLinkPolicy (checkin_code_resend_minutes ใน hotel settings)
+	newExpiry, err := ctrl.InfoSvc.ExtendExpiry(bi.ID)
 	if err != nil {
+		if strings.Contains(err.Error(), "checkin_link_expired") {
+			checkinGuardError(c, bi, err)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to extend expiry"})
 		return
 	}
+	var expiresAt interface{}
+	if newExpiry != nil {
+		expiresAt = newExpiry.UTC().Format(time.RFC3339)
+	}
 
-	// async resend email (best-effort)
-	go func(b models.BookingInfo) {
-		// build checkin link
-		link := utils.BuildCheckinLink(utils.EnvOrDefault("FRONTEND_URL", "http://localhost:3000"), b.Token, true)
-		link = utils.AppendBookingIDParam(link, b.BookingID)
-
-		// Use helper to gather rooms & send email (silently ignore error)
-		if err := ctrl.sendCheckInEmail(
-			b.GuestEmail,
-			"", // bookingRef optional - helper will load booking and use reference if present
-			link,
-			b.GuestLastName,
-			b.BookingID,
-			"", // checkInDate (unknown here)
-			"", // checkOutDate
-			b.CheckinCode,
-		); err != nil {
-			log.Printf("ResendCheckinCode: send email failed for bookingInfo %d: %v", b.ID, err)
-		}
-	}(bi)
+	// อีเมลเข้าคิว outbox (worker ส่งและ retry ให้) จึงเรียกตรงได้โดยไม่ต้องแยก goroutine
+	link := utils.BuildCheckinLink(utils.EnvOrDefault("FRONTEND_URL", "http://localhost:3000"), bi.Token, true)
+	link = utils.AppendBookingIDParam(link, bi.BookingID)
+	if err := ctrl.sendCheckInEmail(
+		bi.ID,
+		bi.GuestEmail,
+		"", // bookingRef optional - helper will load booking and use reference if present
+		link,
+		bi.GuestLastName,
+		bi.BookingID,
+		"", // checkInDate (unknown here)
+		"", // checkOutDate
+		bi.CheckinCode,
+	); err != nil {
+		log.Printf("ResendCheckinCode: queue email failed for bookingInfo %d: %v", bi.ID, err)
+	}
+	// รหัสทาง SMS / LINE ด้วยถ้าลูกค้าเลือกไว้
+	services.NotifyCheckinCode(ctrl.InfoSvc.DB, bi)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":       "code resent",
 		"bookingInfoId": bi.ID,
-		"expiresAt":     newExpiry.UTC().Format(time.RFC3339),
+		"expiresAt":     expiresAt,
 	})
 }
 
@@ -290,6 +318,7 @@ func (ctrl *BookingInfoController) InitiateCheckIn(c *gin.Context) {
 // Helper: load rooms for booking and send email
 // -----------------------------
 func (ctrl *BookingInfoController) sendCheckInEmail(
+	bookingInfoID uint,
 	recipientEmail string,
 	bookingRef string,
 	checkinLink string,
@@ -299,6 +328,9 @@ func (ctrl *BookingInfoController) sendCheckInEmail(
 	checkOutDate string,
 	confirmationCode string,
 ) error {
+	// ภาษาของแขก (ลูกค้าเลือกไว้ / ภาษาตอนให้ consent)
+	locale := services.GuestEmailLocale(config.DB, bookingID)
+
 	// Load booking (we only need reference and maybe legacy Room)
 	var booking models.Booking
 	if err := config.DB.
@@ -310,6 +342,8 @@ func (ctrl *BookingInfoController) sendCheckInEmail(
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			log.Printf("sendCheckInEmail: booking %d not found: %v", bookingID, err)
 			return utils.SendCheckInLinkEmail(
+				bookingInfoID,
+				locale,
 				recipientEmail,
 				bookingRef,
 				checkinLink,
@@ -367,6 +401,8 @@ func (ctrl *BookingInfoController) sendCheckInEmail(
 
 	// Finally call the utils email sender
 	if err := utils.SendCheckInLinkEmail(
+		bookingInfoID,
+		locale,
 		recipientEmail,
 		bookingRef,
 		checkinLink,
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"hotel-backend/config"
+	"hotel-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// -----------------------------
+// Pre-arrival check-in invitations (ส่งอัตโนมัติก่อนวันเข้าพัก)
+// -----------------------------
+
+// GET /api/bookings/:id/checkin-invitations  (ประวัติคำเชิญ/การเตือนของ booking)
+func ListCheckinInvitations(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	rows, err := services.NewCheckinCampaignService(config.DB).ListForBooking(id)
+	if err != nil {
+		log.Printf("ListCheckinInvitations error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": rows})
+}
+
+// POST /api/checkin/invitations/run  (รันรอบส่งทันที ไม่ต้องรอ job)
+func RunCheckinCampaign(c *gin.Context) {
+	summary, err := services.NewCheckinCampaignService(config.DB).RunDue(c.Request.Context(), time.Now())
+	if err != nil {
+		log.Printf("RunCheckinCampaign error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": summary})
+}
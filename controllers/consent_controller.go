@@ -10,6 +10,7 @@ import (
 
 	"hotel-backend/config"
 	"hotel-backend/models"
+	"hotel-backend/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,9 +20,34 @@ import (
 // -----------------------------
 
 // GET /api/consents
+// ค่าเริ่มต้น = consent ที่มีผลอยู่ตอนนี้ (ใช้ในหน้าเช็คอิน) แปลตาม ?locale= หรือ Accept-Language
+// พร้อม locale + text_hash ของข้อความที่แสดง (ส่งกลับมาตอนยอมรับ)
+// ?status=all|draft|published|retired สำหรับหน้าจัดการ (คืนคำแปลทุกภาษา)
 func GetConsents(c *gin.Context) {
-	var consents []models.Consent
-	if err := config.DB.Order("consent_id desc").Find(&consents).Error; err != nil {
+	svc := services.NewConsentService(nil)
+
+	var (
+		consents []models.Consent
+		err      error
+	)
+	switch status := strings.TrimSpace(c.Query("status")); status {
+	case "":
+		locale := services.NegotiateConsentLocale(c.Query("locale"), c.GetHeader("Accept-Language"))
+		if consents, err = svc.Current(time.Now()); err == nil {
+			for i := range consents {
+				consents[i] = services.LocalizeConsent(consents[i], locale)
+			}
+		}
+		c.Header("Content-Language", locale)
+	case "all":
+		consents, err = svc.List("")
+	case models.ConsentStatusDraft, models.ConsentStatusPublished, models.ConsentStatusRetired:
+		consents, err = svc.List(status)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":  "failed to load consents",
 			"detail": err.Error(),
@@ -31,16 +57,64 @@ func GetConsents(c *gin.Context) {
 	c.JSON(http.StatusOK, consents)
 }
 
-// POST /api/consents
-// POST /api/consents
-func CreateConsent(c *gin.Context) {
-	var req struct {
-		Title       string `json:"title" binding:"required"`
-		Slug        string `json:"slug" binding:"required"`
-		Description string `json:"description"`
-		Version     string `json:"version"`
+type consentPayload struct {
+	Title         string     `json:"title"`
+	Slug          string     `json:"slug"`
+	Description   string     `json:"description"`
+	Version       string     `json:"version"`
+	Required      *bool      `json:"required"`
+	EffectiveFrom *time.Time `json:"effective_from"`
+	BaseLocale    string     `json:"base_locale"`
+
+	Translations []services.ConsentTranslationInput `json:"translations"`
+}
+
+func (p consentPayload) draft() services.ConsentDraft {
+	return services.ConsentDraft{
+		Slug:          p.Slug,
+		Title:         p.Title,
+		Description:   p.Description,
+		Version:       p.Version,
+		Required:      p.Required,
+		EffectiveFrom: p.EffectiveFrom,
+		BaseLocale:    p.BaseLocale,
+		Translations:  p.Translations,
+	}
+}
+
+// consentAudit: IP / user agent ของ request สำหรับบันทึกใน consent log
+func consentAudit(c *gin.Context) services.ConsentAudit {
+	return services.ConsentAudit{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
+// consentError แปลง error ของ ConsentService เป็น HTTP response
+func consentError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "consent_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": "consent not found"})
+	case strings.Contains(msg, "slug_and_title_required"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "slug and title are required"})
+	case strings.Contains(msg, "unsupported_locale"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported locale", "supported": services.ConsentLocales})
+	case strings.Contains(msg, "consent_immutable"):
+		c.JSON(http.StatusConflict, gin.H{"error": "published consent versions cannot be modified; create a new version instead"})
+	case strings.Contains(msg, "consent_version_exists"):
+		c.JSON(http.StatusConflict, gin.H{"error": "version already exists for this slug"})
+	case strings.Contains(msg, "consent_not_draft"):
+		c.JSON(http.StatusConflict, gin.H{"error": "only draft consents can be published"})
+	case strings.Contains(msg, "consent_not_published"):
+		c.JSON(http.StatusConflict, gin.H{"error": "only published consents can be retired"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "consent operation failed", "detail": msg})
 	}
+}
 
+// POST /api/consents
+// สร้างเวอร์ชันใหม่เป็น draft เสมอ (publish ผ่าน /:id/publish ซึ่งต้องมีสิทธิ์ consentManagement.publish)
+// slug+version ที่มีอยู่แล้ว -> คืนแถวเดิม
+func CreateConsent(c *gin.Context) {
+	var req consentPayload
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":  "invalid payload",
@@ -49,36 +123,106 @@ func CreateConsent(c *gin.Context) {
 		return
 	}
 
-	version := "1.0"
-	if strings.TrimSpace(req.Version) != "" {
-		version = strings.TrimSpace(req.Version)
+	svc := services.NewConsentService(nil)
+	consent, created, err := svc.Create(req.draft())
+	if err != nil {
+		consentError(c, err)
+		return
 	}
 
-	// 🔑 1) หา consent เดิมก่อน
-	var consent models.Consent
-	err := config.DB.
-		Where("slug = ? AND version = ?", req.Slug, version).
-		First(&consent).Error
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, consent)
+}
+
+// PUT /api/consents/:id  (draft เท่านั้น)
+func UpdateConsent(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	var req consentPayload
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload", "detail": err.Error()})
+		return
+	}
 
+	consent, err := services.NewConsentService(nil).UpdateDraft(id, req.draft())
 	if err != nil {
-		// 🔑 2) ไม่เจอ → ค่อยสร้างใหม่
-		consent = models.Consent{
-			Title:       req.Title,
-			Slug:        req.Slug,
-			Description: req.Description,
-			Version:     version,
-		}
+		consentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, consent)
+}
 
-		if err := config.DB.Create(&consent).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":  "failed to create consent",
-				"detail": err.Error(),
-			})
-			return
-		}
+// PUT /api/consents/:id/translations/:locale  (draft เท่านั้น)
+func SetConsentTranslation(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload", "detail": err.Error()})
+		return
 	}
 
-	// ✅ คืน consent เดิมหรือใหม่ (แต่มีแถวเดียว)
+	consent, err := services.NewConsentService(nil).SetTranslation(id, services.ConsentTranslationInput{
+		Locale:      c.Param("locale"),
+		Title:       req.Title,
+		Description: req.Description,
+	})
+	if err != nil {
+		consentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, consent)
+}
+
+// DELETE /api/consents/:id/translations/:locale  (draft เท่านั้น)
+func DeleteConsentTranslation(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	consent, err := services.NewConsentService(nil).DeleteTranslation(id, c.Param("locale"))
+	if err != nil {
+		consentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, consent)
+}
+
+// POST /api/consents/:id/publish
+func PublishConsent(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	consent, err := services.NewConsentService(nil).Publish(id, time.Now())
+	if err != nil {
+		consentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, consent)
+}
+
+// POST /api/consents/:id/retire
+func RetireConsent(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	consent, err := services.NewConsentService(nil).Retire(id, time.Now())
+	if err != nil {
+		consentError(c, err)
+		return
+	}
 	c.JSON(http.StatusOK, consent)
 }
 
@@ -91,6 +235,8 @@ func AcceptConsent(c *gin.Context) {
     BookingID interface{} `json:"bookingId"` // ✅ ไม่ required
     ConsentID uint        `json:"consentId" binding:"required"`
     Action    string      `json:"action,omitempty"`
+    Locale    string      `json:"locale,omitempty"`   // ภาษาที่แสดงให้แขก
+    TextHash  string      `json:"textHash,omitempty"` // text_hash จาก GET /api/consents
     Accepted  bool        `json:"accepted"`
 }
 
@@ -105,12 +251,26 @@ func AcceptConsent(c *gin.Context) {
 
     // 🔴 🔴 🔴 เพิ่มตรงนี้ 🔴 🔴 🔴
     var consent models.Consent
-    if err := config.DB.First(&consent, req.ConsentID).Error; err != nil {
+    if err := config.DB.Preload("Translations").First(&consent, req.ConsentID).Error; err != nil {
         c.JSON(http.StatusBadRequest, gin.H{
             "error": "invalid consentId",
         })
         return
     }
+    // draft/retired ยอมรับไม่ได้
+    if consent.Status != models.ConsentStatusPublished {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "error": "consent is not published",
+        })
+        return
+    }
+    shown := services.LocalizeConsent(consent, req.Locale)
+    if req.TextHash != "" && !strings.EqualFold(req.TextHash, shown.TextHash) {
+        c.JSON(http.StatusConflict, gin.H{
+            "error": "consent text has changed",
+        })
+        return
+    }
     // 🔴 🔴 🔴 จบตรงนี้ 🔴 🔴 🔴
 
     // 2️⃣ ตรวจ bookingId
@@ -135,16 +295,26 @@ cl := models.ConsentLog{
     AcceptedAt: time.Now().UTC(),
     Status:     status,
     Action:     action,
+    Locale:     shown.Locale,
+    TextHash:   shown.TextHash,
 }
 
 
-    if err := config.DB.Create(&cl).Error; err != nil {
+    if err := services.AppendConsentLog(config.DB, &cl, consentAudit(c)); err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{
             "error":  "db_error",
             "detail": err.Error(),
         })
         return
     }
+    services.PublishEvents(services.ConsentAccepted{
+        BookingID:     cl.BookingID,
+        GuestIDs:      []uint{localGuestID},
+        ConsentIDs:    []uint{cl.ConsentID},
+        ConsentLogIDs: []uint{cl.ID},
+        Source:        services.ConsentSourceAPI,
+        At:            cl.AcceptedAt,
+    })
 
     c.JSON(http.StatusCreated, gin.H{
         "ok":             true,
@@ -171,19 +341,15 @@ func normalizeBookingIdentifier(raw interface{}) (*uint, string) {
 	return nil, s
 }
 
-// DELETE /api/consents/:id
+// DELETE /api/consents/:id  (draft เท่านั้น — เวอร์ชันที่ publish แล้วให้ retire)
 func DeleteConsent(c *gin.Context) {
-	id := c.Param("id")
-	if strings.TrimSpace(id) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing id parameter"})
+	id, ok := idParam(c)
+	if !ok {
 		return
 	}
 
-	if err := config.DB.Delete(&models.Consent{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":  "failed to delete consent",
-			"detail": err.Error(),
-		})
+	if err := services.NewConsentService(nil).Delete(id); err != nil {
+		consentError(c, err)
 		return
 	}
 
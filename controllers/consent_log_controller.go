@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -9,10 +10,12 @@ import (
 	"time"
 
 	"hotel-backend/config"
+	"hotel-backend/middleware"
 	"hotel-backend/models"
-	"hotel-backend/utils"
+	"hotel-backend/services"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // GET /api/consent-logs
@@ -28,6 +31,9 @@ func GetConsentLogs(c *gin.Context) {
 }
 
 // POST /api/consent-logs
+// แขก: ต้องส่ง booking_token ของลิงก์เช็คอินที่ยังใช้ได้ — booking มาจาก token เท่านั้น
+// admin (Authorization: Bearer): บันทึกแทนแขกด้วย booking_id ได้
+// accepted_at ใช้เวลาของ server เสมอ (ห้าม client กำหนดเพราะอยู่ใน hash chain)
 func CreateConsentLog(c *gin.Context) {
 	var payload struct {
 		BookingID       *uint   `json:"booking_id,omitempty"`
@@ -35,9 +41,8 @@ func CreateConsentLog(c *gin.Context) {
 		ConsentID        uint `json:"consentId" binding:"required"`
 		GuestID         *uint   `json:"guest_id,omitempty"`
 		Action          string  `json:"action,omitempty"`
-		AcceptedAt      *string `json:"accepted_at,omitempty"` // accept string to parse multiple formats
-		AcceptedBy      string  `json:"accepted_by,omitempty"`
 		FaceImageBase64 *string `json:"face_image_base64,omitempty"` // optional base64 data URI or raw base64
+		Locale          string  `json:"locale,omitempty"`                  // ภาษาที่แสดงให้แขก
 	}
 
 	if err := c.ShouldBindJSON(&payload); err != nil {
@@ -46,37 +51,69 @@ func CreateConsentLog(c *gin.Context) {
 		return
 	}
 
-	// parse accepted_at if provided (support RFC3339 or YYYY-MM-DD)
-	acceptedAt := time.Now().UTC()
-	if payload.AcceptedAt != nil && *payload.AcceptedAt != "" {
-		if t, err := tryParseAcceptedAt(*payload.AcceptedAt); err == nil {
-			acceptedAt = t
-		} else {
-			log.Printf("⚠️ could not parse accepted_at=%q: %v — using now", *payload.AcceptedAt, err)
-		}
-	}
-
+	now := time.Now().UTC()
 	status := "pending"
 	var bookingPtr *uint
-	if payload.BookingID != nil && *payload.BookingID != 0 {
-		bookingPtr = payload.BookingID
-		status = "sent"
-	}
-
 	var tokenPtr *string
-	if payload.BookingToken != nil && strings.TrimSpace(*payload.BookingToken) != "" {
-		tmp := strings.TrimSpace(*payload.BookingToken)
-		tokenPtr = &tmp
-		// keep status pending if only token present
+	acceptedBy := "guest"
+
+	if admin, err := services.ResolveAdminSession(config.DB, middleware.BearerToken(c)); err == nil {
+		acceptedBy = fmt.Sprintf("admin:%d", admin.ID)
+		if payload.BookingID != nil && *payload.BookingID != 0 {
+			bookingPtr = payload.BookingID
+			status = "sent"
+		}
+		if payload.BookingToken != nil && strings.TrimSpace(*payload.BookingToken) != "" {
+			tmp := strings.TrimSpace(*payload.BookingToken)
+			tokenPtr = &tmp
+		}
+	} else {
+		token := ""
+		if payload.BookingToken != nil {
+			token = strings.TrimSpace(*payload.BookingToken)
+		}
+		if token == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": gin.H{"code": "error.missingToken", "message": "ไม่พบ token กรุณาตรวจสอบลิงก์"}})
+			return
+		}
+		var bi models.BookingInfo
+		if err := config.DB.
+			Where("token = ? AND (expires_at IS NULL OR expires_at > ?)", token, now).
+			First(&bi).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": gin.H{"code": "error.invalidOrExpiredToken", "message": "ลิงก์ยืนยันไม่ถูกต้องหรือหมดอายุ"}})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+			return
+		}
+		if err := services.CheckinOpen(bi, now); err != nil {
+			checkinNotOpen(c, bi)
+			return
+		}
+		// guest_id ต้องเป็นแขกของ booking นี้
+		if payload.GuestID != nil {
+			var n int64
+			if err := config.DB.Model(&models.Guest{}).
+				Where("id = ? AND booking_id = ?", *payload.GuestID, bi.BookingID).
+				Count(&n).Error; err != nil || n == 0 {
+				c.JSON(http.StatusForbidden, gin.H{"error": gin.H{"code": "error.forbidden", "message": "ไม่มีสิทธิ์เข้าถึง"}})
+				return
+			}
+		}
+		bookingID := bi.BookingID
+		bookingPtr = &bookingID
+		tokenPtr = &token
+		status = "sent"
 	}
 
 	// try to save face image if provided
 	var savedImagePath string
 	if payload.FaceImageBase64 != nil && strings.TrimSpace(*payload.FaceImageBase64) != "" {
 		// SaveBase64Image will accept either a data URI ("data:image/png;base64,...") or a raw base64 string
-		if path, err := utils.SaveBase64Image(*payload.FaceImageBase64, "./uploads/faces"); err == nil {
+		if path, err := services.SaveGuestImage(*payload.FaceImageBase64, "faces"); err == nil {
 			savedImagePath = path
-			log.Printf("✅ saved face image: %s", path)
+			log.Println("✅ saved face image")
 		} else {
 			log.Printf("⚠️ could not save face image: %v", err)
 			// do not block creation — just continue without image
@@ -88,12 +125,20 @@ func CreateConsentLog(c *gin.Context) {
 		BookingID:    bookingPtr,
 		BookingToken: tokenPtr,
 		ConsentID:    payload.ConsentID,
-		AcceptedAt:   acceptedAt,
-		AcceptedBy:   payload.AcceptedBy,
+		AcceptedAt:   now,
+		AcceptedBy:   acceptedBy,
 		Status:       status,
 		Action:       payload.Action,
 	}
 
+	// บันทึกภาษา + hash ของข้อความที่แขกเห็น
+	var consent models.Consent
+	if err := config.DB.Preload("Translations").First(&consent, payload.ConsentID).Error; err == nil {
+		shown := services.LocalizeConsent(consent, payload.Locale)
+		entry.Locale = shown.Locale
+		entry.TextHash = shown.TextHash
+	}
+
 	// set guest id if provided — models.ConsentLog.GuestID is *uint, so assign pointer directly
 	if payload.GuestID != nil {
 		entry.GuestID = payload.GuestID
@@ -102,42 +147,44 @@ func CreateConsentLog(c *gin.Context) {
 	// If we saved a face image and the Guest model has a FaceImagePath, update the Guest record.
 	// This avoids adding a non-existent FaceImagePath field to ConsentLog.
 	if savedImagePath != "" && payload.GuestID != nil {
-		var g models.Guest
-		if err := config.DB.First(&g, *payload.GuestID).Error; err == nil {
-			g.FaceImagePath = savedImagePath
-			if err := config.DB.Save(&g).Error; err != nil {
-				log.Printf("⚠️ failed to save face image path to guest %d: %v", *payload.GuestID, err)
-			}
-		} else {
-			log.Printf("⚠️ could not find guest %d to save face image path: %v", *payload.GuestID, err)
+		// อัปเดตเฉพาะคอลัมน์รูป (path ถูกเข้ารหัสมาจาก SaveGuestImage แล้ว)
+		updates := map[string]interface{}{"face_image_path": savedImagePath}
+		if acceptedBy == "guest" {
+			// แขกเปลี่ยนรูปเอง — ต้องให้พนักงานตรวจใหม่ (เหมือน GuestReviewService.Reupload)
+			updates["review_status"] = models.GuestReviewPending
+			updates["reviewed_by"] = nil
+			updates["reviewed_at"] = nil
+		}
+		var previous models.Guest
+		config.DB.Select("id", "face_image_path").First(&previous, *payload.GuestID)
+		res := config.DB.Model(&models.Guest{}).Where("id = ?", *payload.GuestID).Updates(updates)
+		if res.Error != nil {
+			log.Printf("⚠️ failed to save face image path to guest %d: %v", *payload.GuestID, res.Error)
+		} else if res.RowsAffected == 0 {
+			log.Printf("⚠️ could not find guest %d to save face image path", *payload.GuestID)
+		} else if err := services.DeleteGuestImage(c.Request.Context(), services.DefaultBlobStore(), previous.FaceImagePath); err != nil {
+			log.Printf("⚠️ failed to delete previous face image of guest %d: %v", *payload.GuestID, err)
 		}
 	}
 
-	if err := config.DB.Create(&entry).Error; err != nil {
+	if err := services.AppendConsentLog(config.DB, &entry, consentAudit(c)); err != nil {
 		log.Printf("❌ DB Error creating consent_log: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create consent log", "detail": err.Error()})
 		return
 	}
-
-	c.JSON(http.StatusCreated, entry)
-}
-
-
-// helper
-func tryParseAcceptedAt(s string) (time.Time, error) {
-	layouts := []string{
-		time.RFC3339,
-		"2006-01-02",
-		"02/01/2006",
-		"02-01-2006",
-		time.RFC1123,
+	ev := services.ConsentAccepted{
+		BookingID:     entry.BookingID,
+		ConsentIDs:    []uint{entry.ConsentID},
+		ConsentLogIDs: []uint{entry.ID},
+		Source:        services.ConsentSourceAPI,
+		At:            entry.AcceptedAt,
 	}
-	for _, l := range layouts {
-		if t, err := time.Parse(l, s); err == nil {
-			return t.UTC(), nil
-		}
+	if entry.GuestID != nil {
+		ev.GuestIDs = []uint{*entry.GuestID}
 	}
-	return time.Time{}, fmt.Errorf("unsupported date format")
+	services.PublishEvents(ev)
+
+	c.JSON(http.StatusCreated, entry)
 }
 
 
@@ -297,44 +344,25 @@ func AttachBookingToPending(c *gin.Context) {
 	// Debug: show parsed booking and guest ids
 	log.Printf("AttachBookingToPending parsed bookingID=%v bookingToken=%v guestIDs=%v", bookingID, bookingToken, guestIDs)
 
-	// Ensure there is at least one matching consent_log row to update (optional check to provide better feedback)
-	var matchCount int64
-	cond := config.DB.Model(&models.ConsentLog{}).Where("guest_id IN ?", guestIDs).Where("booking_id IS NULL")
-	if err := cond.Count(&matchCount).Error; err != nil {
-		log.Printf("AttachBookingToPending: failed to count matching consent_logs: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "db_error", "detail": err.Error()})
+	// consent_logs เป็น append-only: ไม่แก้แถว pending เดิม แต่เพิ่มแถว "attached" ใน chain ของ booking
+	n, err := services.NewConsentLogService(config.DB).LinkPendingByGuestIDs(bookingID, bookingToken, guestIDs, consentAudit(c))
+	if err != nil {
+		log.Printf("AttachBookingToPending error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to attach booking", "detail": err.Error()})
 		return
 	}
-	if matchCount == 0 {
+	if n == 0 {
 		log.Printf("AttachBookingToPending: no pending consent_logs found for guestIDs=%v", guestIDs)
 		c.JSON(http.StatusOK, gin.H{"message": "no pending consent logs matched", "rows_affected": 0})
 		return
 	}
 
-	// Build update map and perform update
-	updateMap := map[string]interface{}{
-		"status":     "sent",
-		"updated_at": time.Now().UTC(),
-	}
-	if bookingID != nil {
-		updateMap["booking_id"] = *bookingID
-	} else if bookingToken != nil {
-		updateMap["booking_token"] = *bookingToken
-	}
-
-	// Execute update
-	res := cond.Updates(updateMap)
-	if res.Error != nil {
-		log.Printf("AttachBookingToPending update error: %v", res.Error)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to attach booking", "detail": res.Error.Error()})
-		return
-	}
-
-	log.Printf("AttachBookingToPending updated rows: %d", res.RowsAffected)
-	c.JSON(http.StatusOK, gin.H{"message": "pending consent logs updated", "rows_affected": res.RowsAffected})
+	log.Printf("AttachBookingToPending attached rows: %d", n)
+	c.JSON(http.StatusOK, gin.H{"message": "pending consent logs updated", "rows_affected": n})
 }
 
 // DELETE /api/consent-logs/:id
+// ไม่ลบจริง — บันทึกการถอนความยินยอมเป็นแถวใหม่ (หลักฐานเดิมยังอยู่)
 func DeleteConsentLog(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 64)
@@ -343,12 +371,38 @@ func DeleteConsentLog(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Delete(&models.ConsentLog{}, uint(id)).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete consent log"})
+	by := fmt.Sprintf("admin:%d", c.GetUint("adminId"))
+	entry, err := services.NewConsentLogService(config.DB).Withdraw(uint(id), by, consentAudit(c))
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "consent_log_not_found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "consent log not found"})
+		case strings.Contains(err.Error(), "already_withdrawn"):
+			c.JSON(http.StatusConflict, gin.H{"error": "consent log is already a withdrawal entry"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to withdraw consent log"})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Consent log deleted"})
+	c.JSON(http.StatusOK, gin.H{"message": "Consent withdrawn", "withdrawal": entry})
+}
+
+// GET /api/consent-logs/verify?bookingId=
+// ตรวจ hash chain ของประวัติ consent ทั้งหมดของ booking
+func VerifyConsentLogs(c *gin.Context) {
+	bookingID, err := strconv.ParseUint(strings.TrimSpace(c.Query("bookingId")), 10, 64)
+	if err != nil || bookingID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bookingId is required"})
+		return
+	}
+
+	report, err := services.NewConsentLogService(config.DB).VerifyBooking(uint(bookingID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify consent logs", "detail": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
 }
 
 // helper to list map keys (for debugging logs)
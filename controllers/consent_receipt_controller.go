@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"hotel-backend/config"
+	"hotel-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// -----------------------------
+// Consent receipts (ใบยืนยันการให้ความยินยอม PDF ต่อ booking)
+// -----------------------------
+
+func consentReceiptService() *services.ConsentReceiptService {
+	return services.NewConsentReceiptService(config.DB, services.DefaultBlobStore())
+}
+
+func consentReceiptError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "booking_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.bookingNotFound", "message": "ไม่พบการจอง"}})
+	case strings.Contains(msg, "receipt_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.receiptNotFound", "message": "ยังไม่มีใบยืนยันสำหรับการจองนี้"}})
+	case strings.Contains(msg, "no_consents"):
+		c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.noConsents", "message": "การจองนี้ยังไม่มีการให้ความยินยอม"}})
+	case strings.Contains(msg, "receipt_tampered"):
+		c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.receiptTampered", "message": "ไฟล์ใบยืนยันไม่ตรงกับ hash ที่บันทึกไว้"}})
+	case strings.Contains(msg, "pdf_font_not_configured"):
+		log.Printf("consent receipt error: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": gin.H{"code": "error.pdfFontNotConfigured", "message": "ยังไม่ได้ตั้งค่า font ภาษาไทยสำหรับ PDF (PDF_FONT_PATH)"}})
+	default:
+		log.Printf("consent receipt error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+	}
+}
+
+// GET /api/bookings/:id/consent-receipts  (ประวัติการออกใบ)
+func ListConsentReceipts(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	list, err := consentReceiptService().List(id)
+	if err != nil {
+		consentReceiptError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": list})
+}
+
+// GET /api/bookings/:id/consent-receipt  (ดาวน์โหลดใบล่าสุด)
+func DownloadConsentReceipt(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	svc := consentReceiptService()
+	receipt, err := svc.Latest(id)
+	if err != nil {
+		consentReceiptError(c, err)
+		return
+	}
+	pdf, err := svc.Open(c.Request.Context(), receipt)
+	if err != nil {
+		consentReceiptError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+services.ConsentReceiptFilename(receipt)+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// POST /api/bookings/:id/consent-receipt  { sendEmail?: bool }
+// ออกใบใหม่จาก consent log ปัจจุบัน (เช่น หลังถอนความยินยอม) — ค่าเริ่มต้นส่งอีเมลด้วย
+func IssueConsentReceipt(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		SendEmail *bool `json:"sendEmail"`
+	}
+	_ = c.ShouldBindJSON(&body)
+	sendEmail := body.SendEmail == nil || *body.SendEmail
+
+	receipt, err := consentReceiptService().Issue(c.Request.Context(), id, sendEmail, time.Now())
+	if err != nil {
+		consentReceiptError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": receipt})
+}
+
+// POST /api/bookings/:id/consent-receipt/resend  (ส่งใบล่าสุดซ้ำ)
+func ResendConsentReceipt(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	receipt, err := consentReceiptService().Resend(c.Request.Context(), id, time.Now())
+	if err != nil {
+		consentReceiptError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": receipt})
+}
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"hotel-backend/models"
 	"hotel-backend/services"
+	"hotel-backend/utils"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -30,6 +32,9 @@ func (ctrl *CustomerController) CreateCustomer(c *gin.Context) {
 
 	// 1. สร้าง Customer Record (Customer.ID จะถูกอัปเดตโดย GORM)
 	if err := ctrl.CustomerSvc.Create(&customer); err != nil { // 💡 ส่ง Address (&customer)
+		if customerContactError(c, err) {
+			return
+		}
 		log.Printf("❌ DB ERROR during customer creation: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": fmt.Sprintf("Failed to create customer: %s", err.Error())})
 		return
@@ -41,4 +46,81 @@ func (ctrl *CustomerController) CreateCustomer(c *gin.Context) {
 	c.JSON(http.StatusCreated, customer)
 }
 
-// 💡 คุณสามารถเพิ่ม CRUD Methods อื่น ๆ ได้ที่นี่
+// customerContactError ตอบ 400 สำหรับข้อมูลติดต่อ/ช่องทางแจ้งเตือนที่ไม่ถูกต้อง (คืน false = error อื่น)
+func customerContactError(c *gin.Context, err error) bool {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "invalid_phone"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPhone", "message": "เบอร์โทรศัพท์ไม่ถูกต้อง"}})
+	case strings.Contains(msg, "invalid_line_user_id"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidLineUserId", "message": "LINE userId ไม่ถูกต้อง"}})
+	case strings.Contains(msg, "notify_channel_unknown"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.notifyChannelUnknown", "message": "ไม่รู้จักช่องทางแจ้งเตือน", "details": msg}})
+	case strings.Contains(msg, "invalid_locale"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidLocale", "message": "ภาษาต้องเป็น " + strings.Join(utils.EmailLocales, ", ")}})
+	case strings.Contains(msg, "notify_contact_missing"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.notifyContactMissing", "message": "ช่องทางที่เลือกยังไม่มีข้อมูลติดต่อ (เบอร์โทร / LINE)", "details": msg}})
+	default:
+		return false
+	}
+	return true
+}
+
+// GET /api/customers/:id/notifications
+func (ctrl *CustomerController) GetNotifyPreferences(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	customer, err := ctrl.CustomerSvc.Get(id)
+	if err != nil {
+		ctrl.notifyPreferencesError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": customerNotifyView(customer), "available": utils.NotifyChannelsConfigured()})
+}
+
+// PUT /api/customers/:id/notifications  Body: { "phone": "0812345678", "lineUserId": "U...", "channels": ["sms","line"], "locale": "th" }
+func (ctrl *CustomerController) UpdateNotifyPreferences(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	var payload services.CustomerNotifyPreferences
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "payload ไม่ถูกต้อง", "details": err.Error()}})
+		return
+	}
+	customer, err := ctrl.CustomerSvc.UpdateNotifyPreferences(id, payload)
+	if err != nil {
+		ctrl.notifyPreferencesError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": customerNotifyView(customer), "available": utils.NotifyChannelsConfigured()})
+}
+
+func (ctrl *CustomerController) notifyPreferencesError(c *gin.Context, err error) {
+	if customerContactError(c, err) {
+		return
+	}
+	if strings.Contains(err.Error(), "customer_not_found") {
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.customerNotFound", "message": "ไม่พบลูกค้า"}})
+		return
+	}
+	log.Printf("customer notify preferences: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+}
+
+func customerNotifyView(customer models.Customer) gin.H {
+	channels, _ := utils.ParseNotifyChannels(customer.NotifyChannels)
+	if channels == nil {
+		channels = []string{}
+	}
+	return gin.H{
+		"customerId": customer.ID,
+		"phone":      customer.Phone,
+		"lineUserId": customer.LineUserID,
+		"channels":   channels,
+		"locale":     customer.Locale,
+	}
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hotel-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DocumentController: เข้าถึงรูปเอกสาร/ใบหน้าของแขกผ่าน signed URL เท่านั้น
+type DocumentController struct {
+	DocumentSvc *services.DocumentAccessService
+}
+
+func NewDocumentController(svc *services.DocumentAccessService) *DocumentController {
+	return &DocumentController{DocumentSvc: svc}
+}
+
+func respondDocumentError(c *gin.Context, err error) {
+	switch {
+	case strings.Contains(err.Error(), "guest_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.guestNotFound", "message": "ไม่พบข้อมูลแขก"}})
+	case strings.Contains(err.Error(), "file_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.fileNotFound", "message": "ไม่พบไฟล์"}})
+	case strings.Contains(err.Error(), "invalid_document_kind"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidDocumentKind", "message": "ชนิดเอกสารต้องเป็น face หรือ document"}})
+	case strings.Contains(err.Error(), "invalid_signature"), strings.Contains(err.Error(), "link_expired"):
+		c.JSON(http.StatusForbidden, gin.H{"error": gin.H{"code": "error.linkInvalidOrExpired", "message": "ลิงก์ไม่ถูกต้องหรือหมดอายุ"}})
+	case strings.Contains(err.Error(), "pdf_font_not_configured"):
+		log.Printf("document access error: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": gin.H{"code": "error.pdfFontNotConfigured", "message": "ยังไม่ได้ตั้งค่า font ภาษาไทยสำหรับ PDF (PDF_FONT_PATH)"}})
+	default:
+		log.Printf("document access error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+	}
+}
+
+// POST /api/guests/:id/document-links  (admin + guestDocuments.view)
+func (ctrl *DocumentController) IssueLinks(c *gin.Context) {
+	id, ok := parseGuestIDParam(c)
+	if !ok {
+		return
+	}
+
+	links, err := ctrl.DocumentSvc.IssueLinks(id, c.GetUint("adminId"), c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		respondDocumentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": links})
+}
+
+// GET /api/bookings/:id/registration-card  (admin + guestDocuments.view)
+// ใบลงทะเบียนผู้เข้าพัก (PDF) พร้อมลายเซ็นแขกหลัก — log การเปิดดูของแขกทุกคนใน booking
+func (ctrl *DocumentController) RegistrationCard(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidId", "message": "id ไม่ถูกต้อง"}})
+		return
+	}
+
+	pdf, err := ctrl.DocumentSvc.RegistrationCard(c.Request.Context(), uint(id), c.GetUint("adminId"), c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		if strings.Contains(err.Error(), "booking_not_found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.bookingNotFound", "message": "ไม่พบการจอง"}})
+			return
+		}
+		respondDocumentError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="registration-card-`+strconv.FormatUint(id, 10)+`.pdf"`)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// GET /api/guests/:id/document-access-logs  (admin + auditLogs.view)
+func (ctrl *DocumentController) GetAccessLogs(c *gin.Context) {
+	id, ok := parseGuestIDParam(c)
+	if !ok {
+		return
+	}
+
+	logs, err := ctrl.DocumentSvc.ListAccessLogs(id)
+	if err != nil {
+		respondDocumentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": logs})
+}
+
+// GET /api/documents/guests/:id/:kind?aid=&exp=&sig=
+// ไม่ต้องใช้ Authorization header (ใช้ใน <img src>) แต่ต้องมีลายเซ็นที่ยังไม่หมดอายุ
+func (ctrl *DocumentController) Serve(c *gin.Context) {
+	id, ok := parseGuestIDParam(c)
+	if !ok {
+		return
+	}
+
+	adminID, err := strconv.ParseUint(c.Query("aid"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": gin.H{"code": "error.linkInvalidOrExpired", "message": "ลิงก์ไม่ถูกต้องหรือหมดอายุ"}})
+		return
+	}
+	exp, err := strconv.ParseInt(c.Query("exp"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": gin.H{"code": "error.linkInvalidOrExpired", "message": "ลิงก์ไม่ถูกต้องหรือหมดอายุ"}})
+		return
+	}
+
+	data, contentType, err := ctrl.DocumentSvc.Open(c.Request.Context(), id, c.Param("kind"), uint(adminID), exp, c.Query("sig"), c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		respondDocumentError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, contentType, data)
+}
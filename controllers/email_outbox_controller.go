@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hotel-backend/services"
+	"hotel-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailOutboxController: หน้า admin ดูสถานะการส่งอีเมลและสั่งส่งซ้ำ
+type EmailOutboxController struct {
+	OutboxSvc *services.EmailOutboxService
+}
+
+func NewEmailOutboxController(svc *services.EmailOutboxService) *EmailOutboxController {
+	return &EmailOutboxController{OutboxSvc: svc}
+}
+
+// emailOutboxError แปลง error ของ EmailOutboxService เป็น HTTP response
+func emailOutboxError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "email_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.emailNotFound", "message": "ไม่พบอีเมล"}})
+	case strings.Contains(msg, "recipient_suppressed"):
+		c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.recipientSuppressed", "message": "ผู้รับอยู่ในรายการห้ามส่ง (bounce / แจ้ง spam)"}})
+	case strings.Contains(msg, "email_contains_secret"):
+		c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.emailContainsSecret", "message": "อีเมลนี้มีลิงก์หรือรหัสลับ ส่งซ้ำจากคิวไม่ได้ กรุณาออกลิงก์ใหม่จากหน้าที่เกี่ยวข้อง"}})
+	case strings.Contains(msg, "checkin_link_expired"):
+		c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.checkinLinkExpired", "message": "ลิงก์เช็คอินหมดอายุหรือใช้ไปแล้ว กรุณาออกลิงก์ใหม่จากหน้าการจอง"}})
+	case strings.Contains(msg, "customer_email_missing"):
+		c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.customerEmailMissing", "message": "ไม่พบอีเมลของแขก"}})
+	case strings.Contains(msg, "email_still_queued"):
+		c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.emailStillQueued", "message": "อีเมลนี้ยังอยู่ในคิวรอส่ง"}})
+	default:
+		log.Printf("email outbox error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+	}
+}
+
+// GET /api/email-outbox?status=&kind=&to=&page=&limit=
+func (ctrl *EmailOutboxController) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.Query("page"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	rows, total, err := ctrl.OutboxSvc.List(services.EmailOutboxFilter{
+		Status: strings.TrimSpace(c.Query("status")),
+		Kind:   strings.TrimSpace(c.Query("kind")),
+		To:     strings.TrimSpace(c.Query("to")),
+		Page:   page,
+		Limit:  limit,
+	})
+	if err != nil {
+		emailOutboxError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": rows, "total": total, "providers": utils.EmailProviders()})
+}
+
+// GET /api/email-outbox/:id  (รวม body และรายชื่อไฟล์แนบ — อีเมลที่มีลิงก์/รหัสลับไม่คืน body)
+func (ctrl *EmailOutboxController) Get(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	row, err := ctrl.OutboxSvc.Get(id)
+	if err != nil {
+		emailOutboxError(c, err)
+		return
+	}
+	attachments, err := services.EmailOutboxAttachments(row)
+	if err != nil {
+		emailOutboxError(c, err)
+		return
+	}
+	events, err := services.NewEmailDeliveryService(ctrl.OutboxSvc.DB).EventsForOutbox(row.ID)
+	if err != nil {
+		emailOutboxError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": row, "attachments": attachments, "events": events})
+}
+
+// POST /api/email-outbox/:id/resend  (เข้าคิวเป็นแถวใหม่ อ้างอิงแถวเดิม)
+func (ctrl *EmailOutboxController) Resend(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	row, err := ctrl.OutboxSvc.Resend(c.Request.Context(), id, c.GetUint("adminId"))
+	if err != nil {
+		emailOutboxError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"status": "success", "data": row})
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"hotel-backend/config"
+	"hotel-backend/services"
+	"hotel-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// -----------------------------
+// Email templates (แก้ไข template อีเมลได้โดยไม่ต้อง deploy ใหม่)
+// -----------------------------
+
+// emailTemplateError แปลง error ของ EmailTemplateService เป็น HTTP response
+func emailTemplateError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "template_kind_unknown"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.templateKindUnknown", "message": "ไม่พบประเภทอีเมลนี้"}})
+	case strings.Contains(msg, "locale_unsupported"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.localeUnsupported", "message": "ไม่รองรับภาษานี้ (en | th)"}})
+	case strings.Contains(msg, "template_fields_required"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.templateFieldsRequired", "message": "กรุณาระบุ subject, html และ text"}})
+	case strings.Contains(msg, "template_invalid"):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": gin.H{"code": "error.templateInvalid", "message": "template ไม่ถูกต้อง", "details": msg}})
+	case strings.Contains(msg, "booking_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.bookingNotFound", "message": "ไม่พบการจอง"}})
+	default:
+		log.Printf("email template error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+	}
+}
+
+// GET /api/email-templates  (ทุกประเภท x ทุกภาษา + ตัวแปรที่ใช้ได้)
+func ListEmailTemplates(c *gin.Context) {
+	list, err := services.NewEmailTemplateService(config.DB).List()
+	if err != nil {
+		emailTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": list, "locales": utils.EmailLocales})
+}
+
+// GET /api/email-templates/:kind/:locale
+func GetEmailTemplate(c *gin.Context) {
+	view, err := services.NewEmailTemplateService(config.DB).Get(c.Param("kind"), c.Param("locale"))
+	if err != nil {
+		emailTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": view})
+}
+
+// PUT /api/email-templates/:kind/:locale  { subject, html, text }
+func SaveEmailTemplate(c *gin.Context) {
+	var req utils.EmailTemplateSource
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "payload ไม่ถูกต้อง", "details": err.Error()}})
+		return
+	}
+	view, err := services.NewEmailTemplateService(config.DB).Save(c.Param("kind"), c.Param("locale"), req, c.GetUint("adminId"))
+	if err != nil {
+		emailTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": view})
+}
+
+// DELETE /api/email-templates/:kind/:locale  (กลับไปใช้ template ตั้งต้น)
+func ResetEmailTemplate(c *gin.Context) {
+	view, err := services.NewEmailTemplateService(config.DB).Reset(c.Param("kind"), c.Param("locale"))
+	if err != nil {
+		emailTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": view})
+}
+
+type emailTemplatePreviewPayload struct {
+	// ไม่ส่ง subject/html/text = preview template ที่ใช้อยู่
+	Subject   *string `json:"subject"`
+	HTML      *string `json:"html"`
+	Text      *string `json:"text"`
+	BookingID uint    `json:"bookingId"`
+}
+
+// POST /api/email-templates/:kind/:locale/preview  { subject?, html?, text?, bookingId? }
+func PreviewEmailTemplate(c *gin.Context) {
+	var req emailTemplatePreviewPayload
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "payload ไม่ถูกต้อง", "details": err.Error()}})
+			return
+		}
+	}
+
+	svc := services.NewEmailTemplateService(config.DB)
+	var src *utils.EmailTemplateSource
+	if req.Subject != nil || req.HTML != nil || req.Text != nil {
+		// ช่องที่ไม่ได้ส่งมาใช้ค่าของ template ปัจจุบัน
+		current, err := svc.Get(c.Param("kind"), c.Param("locale"))
+		if err != nil {
+			emailTemplateError(c, err)
+			return
+		}
+		draft := utils.EmailTemplateSource{Subject: current.Subject, HTML: current.HTML, Text: current.Text}
+		if req.Subject != nil {
+			draft.Subject = *req.Subject
+		}
+		if req.HTML != nil {
+			draft.HTML = *req.HTML
+		}
+		if req.Text != nil {
+			draft.Text = *req.Text
+		}
+		src = &draft
+	}
+
+	out, err := svc.Preview(c.Param("kind"), c.Param("locale"), src, req.BookingID)
+	if err != nil {
+		emailTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": out})
+}
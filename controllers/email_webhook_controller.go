@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"hotel-backend/config"
+	"hotel-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// -----------------------------
+// Delivery webhooks ของ provider อีเมล (public — ตรวจลายเซ็นแทน auth) + suppression list
+// -----------------------------
+
+const emailWebhookMaxBody = 1 << 20
+
+// emailDeliveryError แปลง error ของ EmailDeliveryService เป็น HTTP response
+func emailDeliveryError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "webhook_not_configured"):
+		log.Printf("email webhook: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": gin.H{"code": "error.webhookNotConfigured", "message": "ยังไม่ได้ตั้งค่า webhook"}})
+	case strings.Contains(msg, "invalid_signature"):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": gin.H{"code": "error.invalidSignature", "message": "ลายเซ็น webhook ไม่ถูกต้อง"}})
+	case strings.Contains(msg, "invalid_payload"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "payload ไม่ถูกต้อง"}})
+	case strings.Contains(msg, "invalid_email"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidEmail", "message": "อีเมลไม่ถูกต้อง"}})
+	case strings.Contains(msg, "suppression_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.suppressionNotFound", "message": "ไม่พบรายการ"}})
+	default:
+		log.Printf("email delivery error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+	}
+}
+
+func readWebhookBody(c *gin.Context) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, emailWebhookMaxBody))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": gin.H{"code": "error.payloadTooLarge", "message": "payload ใหญ่เกินไป"}})
+		return nil, false
+	}
+	return body, true
+}
+
+// POST /api/webhooks/email/sendgrid  (Event Webhook แบบ signed — SENDGRID_WEBHOOK_PUBLIC_KEY)
+func SendGridEmailWebhook(c *gin.Context) {
+	body, ok := readWebhookBody(c)
+	if !ok {
+		return
+	}
+	if err := services.VerifySendGridSignature(
+		os.Getenv("SENDGRID_WEBHOOK_PUBLIC_KEY"),
+		c.GetHeader("X-Twilio-Email-Event-Webhook-Signature"),
+		c.GetHeader("X-Twilio-Email-Event-Webhook-Timestamp"),
+		body, time.Now(),
+	); err != nil {
+		emailDeliveryError(c, err)
+		return
+	}
+	events, err := services.ParseSendGridEvents(body)
+	if err != nil {
+		emailDeliveryError(c, err)
+		return
+	}
+	recorded, err := services.NewEmailDeliveryService(config.DB).RecordAll(events)
+	if err != nil {
+		emailDeliveryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "received": len(events), "recorded": recorded})
+}
+
+// POST /api/webhooks/email/resend  (Svix signature — RESEND_WEBHOOK_SECRET)
+func ResendEmailWebhook(c *gin.Context) {
+	body, ok := readWebhookBody(c)
+	if !ok {
+		return
+	}
+	eventID := c.GetHeader("svix-id")
+	if err := services.VerifyResendSignature(
+		os.Getenv("RESEND_WEBHOOK_SECRET"),
+		eventID,
+		c.GetHeader("svix-timestamp"),
+		c.GetHeader("svix-signature"),
+		body, time.Now(),
+	); err != nil {
+		emailDeliveryError(c, err)
+		return
+	}
+	events, err := services.ParseResendEvent(body, eventID)
+	if err != nil {
+		emailDeliveryError(c, err)
+		return
+	}
+	recorded, err := services.NewEmailDeliveryService(config.DB).RecordAll(events)
+	if err != nil {
+		emailDeliveryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "received": len(events), "recorded": recorded})
+}
+
+// GET /api/email-suppressions?q=
+func ListEmailSuppressions(c *gin.Context) {
+	rows, err := services.NewEmailDeliveryService(config.DB).ListSuppressions(c.Query("q"))
+	if err != nil {
+		emailDeliveryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": rows})
+}
+
+type emailSuppressionPayload struct {
+	Email  string `json:"email" binding:"required"`
+	Detail string `json:"detail"`
+}
+
+// POST /api/email-suppressions  { email, detail? }
+func AddEmailSuppression(c *gin.Context) {
+	var req emailSuppressionPayload
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "payload ไม่ถูกต้อง", "details": err.Error()}})
+		return
+	}
+	row, err := services.NewEmailDeliveryService(config.DB).AddSuppression(req.Email, req.Detail, c.GetUint("adminId"))
+	if err != nil {
+		emailDeliveryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": row})
+}
+
+// DELETE /api/email-suppressions/:id  (ส่งถึงที่อยู่นี้ได้อีกครั้ง)
+func RemoveEmailSuppression(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	if err := services.NewEmailDeliveryService(config.DB).RemoveSuppression(id); err != nil {
+		emailDeliveryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
@@ -11,7 +11,6 @@ import (
 	"strings"
 	"time"
     "regexp"
-	"hotel-backend/config"
 	"hotel-backend/models"
 	"hotel-backend/services"
 
@@ -139,13 +138,9 @@ func (c *GuestController) GetGuestsByBookingID(ctx *gin.Context) {
     }
     bookingID := uint(bookingID64)
 
-    var guests []models.Guest
-
-    // ✅ FILTER booking_id = bookingID เท่านั้น
-    if err := config.DB.
-        Where("booking_id = ?", bookingID).
-        Order("is_main_guest DESC, id ASC").
-        Find(&guests).Error; err != nil {
+    // ✅ FILTER booking_id = bookingID เท่านั้น (ผ่าน service เพื่อถอดรหัสข้อมูลส่วนบุคคล)
+    guests, err := c.GuestSvc.GetByBookingID(bookingID)
+    if err != nil {
 
         log.Printf("[GetGuestsByBookingID] booking_id=%d err=%v", bookingID, err)
         ctx.JSON(http.StatusInternalServerError, gin.H{
@@ -187,11 +182,8 @@ func (c *GuestController) GetGuests(ctx *gin.Context) {
 	}
 	bookingID := uint(bookingID64)
 
-	var guests []models.Guest
-	if err := config.DB.
-		Where("booking_id = ?", bookingID).
-		Order("is_main_guest DESC, id ASC").
-		Find(&guests).Error; err != nil {
+	guests, err := c.GuestSvc.GetByBookingID(bookingID)
+	if err != nil {
 
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -228,6 +220,35 @@ func (c *GuestController) GetAllGuests(ctx *gin.Context) {
 	})
 }
 
+// ----------------------------------------------------------------------
+// GET /api/guests/search?idNumber=...
+// ค้นหาแบบตรงตัวผ่าน blind index (เลขบัตรในฐานข้อมูลถูกเข้ารหัส)
+// ----------------------------------------------------------------------
+func (c *GuestController) SearchGuestsByIDNumber(ctx *gin.Context) {
+	idNumber := strings.TrimSpace(ctx.Query("idNumber"))
+	if idNumber == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "error.missingIdNumber",
+		})
+		return
+	}
+
+	guests, err := c.GuestSvc.FindByIDNumber(idNumber)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to search guests",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   guests,
+	})
+}
+
 func (c *GuestController) GetGuestByID(ctx *gin.Context) {
     log.Println("✅ HIT GetGuestByID id=", ctx.Param("id"))
 
@@ -288,7 +309,7 @@ func (c *GuestController) CreateGuest(ctx *gin.Context) {
 		return
 	}
 
-	log.Printf("➡️ RAW CreateGuest payload: %+v", payload)
+	log.Printf("➡️ CreateGuest payload received (%d fields)", len(payload))
 
 	// ---------------- helpers ----------------
 	getString := func(keys ...string) string {
@@ -450,7 +471,7 @@ func (c *GuestController) CreateGuest(ctx *gin.Context) {
 	// base64 images (React ส่ง faceImageBase64/documentImageBase64)
 	faceB64 := getString("faceImageBase64", "face_image_base64")
 	if faceB64 != "" {
-		path, err := services.SaveBase64Image(faceB64, "faces")
+		path, err := services.SaveGuestImage(faceB64, "faces")
 		if err != nil {
 			log.Println("❌ save face image failed:", err)
 		} else {
@@ -460,7 +481,7 @@ func (c *GuestController) CreateGuest(ctx *gin.Context) {
 
 	docB64 := getString("documentImageBase64", "document_image_base64")
 	if docB64 != "" {
-		path, err := services.SaveBase64Image(docB64, "documents")
+		path, err := services.SaveGuestImage(docB64, "documents")
 		if err != nil {
 			log.Println("❌ save document image failed:", err)
 		} else {
@@ -468,7 +489,7 @@ func (c *GuestController) CreateGuest(ctx *gin.Context) {
 		}
 	}
 
-	log.Printf("➡️ CreateGuest mapped model: %+v", g)
+	log.Printf("➡️ CreateGuest mapped model: booking_id=%v main=%v", g.BookingID, g.IsMainGuest)
 
 	// ---------------- save ----------------
 	if err := c.GuestSvc.Create(&g); err != nil {
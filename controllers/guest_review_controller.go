@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hotel-backend/models"
+	"hotel-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GuestReviewController: คิวตรวจเอกสารยืนยันตัวตน (reception)
+type GuestReviewController struct {
+	ReviewSvc   *services.GuestReviewService
+	DocumentSvc *services.DocumentAccessService
+}
+
+func NewGuestReviewController(svc *services.GuestReviewService, docSvc *services.DocumentAccessService) *GuestReviewController {
+	return &GuestReviewController{ReviewSvc: svc, DocumentSvc: docSvc}
+}
+
+type reviewDecisionPayload struct {
+	Reason          string `json:"reason"`
+	RequireReupload bool   `json:"requireReupload"`
+}
+
+type reuploadPayload struct {
+	Token               string `json:"token" binding:"required"`
+	FaceImageBase64     string `json:"faceImageBase64"`
+	DocumentImageBase64 string `json:"documentImageBase64"`
+}
+
+// reviewItem: ข้อมูลสำหรับหน้าจอตรวจ (รูปเอกสารกับรูปหน้าวางคู่กัน)
+// รูปเปิดผ่าน signed URL อายุสั้นที่ผูกกับ admin ผู้ตรวจ
+func (ctrl *GuestReviewController) reviewItem(g models.Guest, adminID uint) gin.H {
+	faceURL := ""
+	if strings.TrimSpace(g.FaceImagePath) != "" {
+		faceURL = ctrl.DocumentSvc.SignedURL(g.ID, services.DocumentKindFace, adminID).URL
+	}
+	documentURL := ""
+	if strings.TrimSpace(g.DocumentImagePath) != "" {
+		documentURL = ctrl.DocumentSvc.SignedURL(g.ID, services.DocumentKindDocument, adminID).URL
+	}
+
+	return gin.H{
+		"guestId":          g.ID,
+		"bookingId":        g.BookingID,
+		"bookingRef":       g.Booking.ReferenceCode,
+		"bookingStatus":    g.Booking.Status,
+		"customerName":     g.Booking.Customer.FullName,
+		"roomNumber":       g.RoomNumber,
+		"fullName":         g.FullName,
+		"isMainGuest":      g.IsMainGuest,
+		"nationality":      g.Nationality,
+		"idType":           g.IDType,
+		"idNumber":         g.IDNumber,
+		"faceImageUrl":     faceURL,
+		"documentImageUrl": documentURL,
+		"reviewStatus":     g.ReviewStatus,
+		"reviewedBy":       g.ReviewedBy,
+		"reviewedAt":       g.ReviewedAt,
+		"reviewReason":     g.ReviewReason,
+		"submittedAt":      g.CreatedAt,
+	}
+}
+
+func parseGuestIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidGuestId", "message": "guestId ไม่ถูกต้อง"}})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondReviewError(c *gin.Context, err error) {
+	switch {
+	case strings.Contains(err.Error(), "guest_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.guestNotFound", "message": "ไม่พบข้อมูลแขก"}})
+	case strings.Contains(err.Error(), "reviewer_required"), strings.Contains(err.Error(), "reviewer_not_found"):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": gin.H{"code": "error.unauthorized", "message": "กรุณาเข้าสู่ระบบ"}})
+	case strings.Contains(err.Error(), "reason_required"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.reasonRequired", "message": "ต้องระบุเหตุผลในการปฏิเสธ"}})
+	case strings.Contains(err.Error(), "invalid_review_status"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidReviewStatus", "message": "status ต้องเป็น pending, approved หรือ rejected"}})
+	case strings.Contains(err.Error(), "invalid_or_expired_token"):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": gin.H{"code": "error.invalidOrExpiredToken", "message": "ลิงก์ไม่ถูกต้องหรือหมดอายุ"}})
+	case strings.Contains(err.Error(), "reupload_not_requested"):
+		c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.reuploadNotRequested", "message": "เอกสารนี้ไม่ได้ถูกขอให้อัปโหลดใหม่"}})
+	case strings.Contains(err.Error(), "images_required"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.imagesRequired", "message": "ต้องแนบรูปเอกสารหรือรูปใบหน้าอย่างน้อยหนึ่งรูป"}})
+	default:
+		log.Printf("guest review error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ", "details": err.Error()}})
+	}
+}
+
+// GET /api/guest-reviews?status=pending
+func (ctrl *GuestReviewController) ListQueue(c *gin.Context) {
+	guests, err := ctrl.ReviewSvc.ListQueue(c.Query("status"))
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+
+	adminID := c.GetUint("adminId")
+	items := make([]gin.H, 0, len(guests))
+	for _, g := range guests {
+		items = append(items, ctrl.reviewItem(g, adminID))
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": items})
+}
+
+// GET /api/guest-reviews/:id
+func (ctrl *GuestReviewController) GetReview(c *gin.Context) {
+	id, ok := parseGuestIDParam(c)
+	if !ok {
+		return
+	}
+
+	g, err := ctrl.ReviewSvc.GetForReview(id)
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": ctrl.reviewItem(*g, c.GetUint("adminId"))})
+}
+
+// POST /api/guest-reviews/:id/approve
+// ผู้ตรวจ = admin ที่ login อยู่
+func (ctrl *GuestReviewController) Approve(c *gin.Context) {
+	id, ok := parseGuestIDParam(c)
+	if !ok {
+		return
+	}
+
+	guest, err := ctrl.ReviewSvc.Approve(id, c.GetUint("adminId"))
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": guest})
+}
+
+// POST /api/guest-reviews/:id/reject
+// Body: { "reason": "ภาพเบลอ", "requireReupload": true }
+func (ctrl *GuestReviewController) Reject(c *gin.Context) {
+	id, ok := parseGuestIDParam(c)
+	if !ok {
+		return
+	}
+
+	var payload reviewDecisionPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "payload ไม่ถูกต้อง", "details": err.Error()}})
+		return
+	}
+
+	guest, err := ctrl.ReviewSvc.Reject(id, c.GetUint("adminId"), payload.Reason, payload.RequireReupload)
+	if err != nil {
+		if strings.Contains(err.Error(), "email_send_failed") {
+			// ปฏิเสธสำเร็จแล้ว แต่ส่งอีเมลแจ้งแขกไม่สำเร็จ
+			c.JSON(http.StatusPartialContent, gin.H{
+				"status": "warning",
+				"data":   guest,
+				"error": gin.H{
+					"code":    "error.emailSendFailed",
+					"message": "บันทึกผลการตรวจแล้ว แต่ส่งอีเมลแจ้งแขกไม่สำเร็จ",
+					"details": err.Error(),
+				},
+			})
+			return
+		}
+		respondReviewError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": guest})
+}
+
+// POST /api/checkin/guests/:id/documents  (public — ใช้ token จากลิงก์ในอีเมล)
+func (ctrl *GuestReviewController) Reupload(c *gin.Context) {
+	id, ok := parseGuestIDParam(c)
+	if !ok {
+		return
+	}
+
+	var payload reuploadPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "payload ไม่ถูกต้อง", "details": err.Error()}})
+		return
+	}
+
+	guest, err := ctrl.ReviewSvc.Reupload(payload.Token, id, payload.FaceImageBase64, payload.DocumentImageBase64)
+	if err != nil {
+		respondReviewError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"guestId":      guest.ID,
+			"reviewStatus": guest.ReviewStatus,
+		},
+	})
+}
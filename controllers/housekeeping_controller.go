@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hotel-backend/config"
+	"hotel-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// -----------------------------
+// Housekeeping (งานทำความสะอาดห้อง)
+// -----------------------------
+
+// housekeepingError แปลง error ของ HousekeepingService เป็น HTTP response
+func housekeepingError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "housekeeping_task_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.housekeepingTaskNotFound", "message": "ไม่พบงานทำความสะอาด"}})
+	case strings.Contains(msg, "room_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.roomNotFound", "message": "ไม่พบห้องพัก"}})
+	case strings.Contains(msg, "invalid_housekeeping_kind"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidHousekeepingKind", "message": "kind ต้องเป็น checkout หรือ stayover"}})
+	case strings.Contains(msg, "inspection_note_required"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.inspectionNoteRequired", "message": "กรุณาระบุเหตุผลที่ตรวจไม่ผ่าน"}})
+	case strings.Contains(msg, "housekeeping_assignee_invalid"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.housekeepingAssigneeInvalid", "message": "ผู้รับงานต้องมีสิทธิ์ทำงาน housekeeping"}})
+	case strings.Contains(msg, "housekeeping_not_assignee"):
+		c.JSON(http.StatusForbidden, gin.H{"error": gin.H{"code": "error.housekeepingNotAssignee", "message": "งานนี้มอบหมายให้ผู้อื่นแล้ว"}})
+	case strings.Contains(msg, "housekeeping_invalid_transition"):
+		c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.housekeepingInvalidTransition", "message": "สถานะงานปัจจุบันไม่สามารถทำขั้นตอนนี้ได้", "details": msg}})
+	default:
+		log.Printf("housekeeping error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+	}
+}
+
+// GET /api/housekeeping/tasks?status=&kind=&roomId=&assignedTo=&mine=1&date=YYYY-MM-DD&open=1&page=&limit=
+func ListHousekeepingTasks(c *gin.Context) {
+	roomID, _ := strconv.ParseUint(c.Query("roomId"), 10, 64)
+	assignedTo, _ := strconv.ParseUint(c.Query("assignedTo"), 10, 64)
+	page, _ := strconv.Atoi(c.Query("page"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	f := services.HousekeepingFilter{
+		Status:     strings.TrimSpace(c.Query("status")),
+		Kind:       strings.TrimSpace(c.Query("kind")),
+		RoomID:     uint(roomID),
+		AssignedTo: uint(assignedTo),
+		OpenOnly:   c.Query("open") == "1" || c.Query("open") == "true",
+		Page:       page,
+		Limit:      limit,
+	}
+	if c.Query("mine") == "1" || c.Query("mine") == "true" {
+		f.AssignedTo = c.GetUint("adminId")
+	}
+	if v := strings.TrimSpace(c.Query("date")); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidDate", "message": "รูปแบบวันที่ต้องเป็น YYYY-MM-DD", "details": "date"}})
+			return
+		}
+		f.Date = &d
+	}
+	rows, total, err := services.NewHousekeepingService(config.DB).List(f)
+	if err != nil {
+		housekeepingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": rows, "total": total})
+}
+
+// GET /api/housekeeping/tasks/:id
+func GetHousekeepingTask(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	task, err := services.NewHousekeepingService(config.DB).Get(id)
+	if err != nil {
+		housekeepingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": task})
+}
+
+// GET /api/housekeeping/cleaners  (ผู้ที่รับงานได้ — สำหรับมอบหมายงาน)
+func ListHousekeepingCleaners(c *gin.Context) {
+	admins, err := services.NewHousekeepingService(config.DB).Cleaners()
+	if err != nil {
+		housekeepingError(c, err)
+		return
+	}
+	data := make([]gin.H, 0, len(admins))
+	for _, a := range admins {
+		data = append(data, gin.H{"id": a.ID, "fullName": a.FullName, "username": a.Username})
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": data})
+}
+
+// POST /api/housekeeping/tasks  { roomId, kind, assignedTo, notes }
+func CreateHousekeepingTask(c *gin.Context) {
+	var in services.HousekeepingTaskInput
+	if err := c.ShouldBindJSON(&in); err != nil || in.RoomID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "กรุณาระบุ roomId"}})
+		return
+	}
+	task, err := services.NewHousekeepingService(config.DB).Create(in, c.GetUint("adminId"))
+	if err != nil {
+		housekeepingError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": task})
+}
+
+// PUT /api/housekeeping/tasks/:id/assign  { assignedTo }  (null = คืนงานเข้ากอง)
+func AssignHousekeepingTask(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		AssignedTo *uint `json:"assignedTo"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "payload ไม่ถูกต้อง", "details": err.Error()}})
+		return
+	}
+	task, err := services.NewHousekeepingService(config.DB).Assign(id, req.AssignedTo, c.GetUint("adminId"))
+	if err != nil {
+		housekeepingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": task})
+}
+
+// POST /api/housekeeping/tasks/:id/start
+func StartHousekeepingTask(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	task, err := services.NewHousekeepingService(config.DB).Start(id, c.GetUint("adminId"))
+	if err != nil {
+		housekeepingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": task})
+}
+
+// POST /api/housekeeping/tasks/:id/finish  { notes }
+func FinishHousekeepingTask(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Notes string `json:"notes"`
+	}
+	_ = c.ShouldBindJSON(&req) // body ไม่บังคับ
+	task, err := services.NewHousekeepingService(config.DB).Finish(id, c.GetUint("adminId"), req.Notes)
+	if err != nil {
+		housekeepingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": task})
+}
+
+// POST /api/housekeeping/tasks/:id/inspect  { passed, note }
+// ผ่าน: งาน checkout สุดท้ายของห้อง -> ห้อง Available / ไม่ผ่าน: งานกลับเป็น dirty (ต้องมี note)
+func InspectHousekeepingTask(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Passed *bool  `json:"passed"`
+		Note   string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Passed == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "กรุณาระบุ passed"}})
+		return
+	}
+	task, err := services.NewHousekeepingService(config.DB).Inspect(id, c.GetUint("adminId"), *req.Passed, req.Note)
+	if err != nil {
+		housekeepingError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": task})
+}
@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hotel-backend/config"
+	"hotel-backend/middleware"
+	"hotel-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// -----------------------------
+// Real-time dashboard (Server-Sent Events)
+// -----------------------------
+
+const (
+	liveHeartbeatInterval = 25 * time.Second // comment line กัน proxy ตัดการเชื่อมต่อ
+	livePermissionRecheck = time.Minute      // ตรวจ session + โหลด permission ใหม่ (logout / role ถูกแก้ / admin ถูกลบ)
+	liveWriteTimeout      = 10 * time.Second
+)
+
+// POST /api/live/stream-url  → { url, expiresAt }
+// EventSource ส่ง Authorization header ไม่ได้ — หน้าเว็บขอ signed URL ก่อนแล้วเปิด new EventSource(url)
+func IssueLiveStreamURL(c *gin.Context) {
+	session, err := services.FindAdminSession(config.DB, middleware.BearerToken(c))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid_session") || strings.Contains(err.Error(), "missing_token") {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": gin.H{"code": "error.unauthorized", "message": "กรุณาเข้าสู่ระบบ"}})
+			return
+		}
+		log.Printf("IssueLiveStreamURL: load session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": services.LiveStreamURL(session.AdminID, session.ID)})
+}
+
+// liveStreamAuth: ตัวตนของการเชื่อมต่อ — Bearer (token) หรือ signed URL (SessionID ของ session ที่ขอ URL)
+type liveStreamAuth struct {
+	AdminID   uint
+	Bearer    string
+	SessionID uint
+}
+
+// liveStreamAdmin: admin ของการเชื่อมต่อ จาก Authorization header หรือ signed URL (aid/sid/exp/sig)
+// คืนข้อมูล session ด้วย เพื่อตรวจซ้ำระหว่างเปิด stream
+func liveStreamAdmin(c *gin.Context) (liveStreamAuth, bool) {
+	if h := strings.TrimSpace(c.GetHeader("Authorization")); h != "" {
+		parts := strings.SplitN(h, " ", 2)
+		if len(parts) == 2 && strings.EqualFold(parts[0], "Bearer") {
+			token := strings.TrimSpace(parts[1])
+			if admin, err := services.ResolveAdminSession(config.DB, token); err == nil {
+				return liveStreamAuth{AdminID: admin.ID, Bearer: token}, true
+			}
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": gin.H{"code": "error.unauthorized", "message": "กรุณาเข้าสู่ระบบ"}})
+		return liveStreamAuth{}, false
+	}
+
+	adminID, aErr := strconv.ParseUint(c.Query("aid"), 10, 64)
+	sessionID, sErr := strconv.ParseUint(c.Query("sid"), 10, 64)
+	exp, eErr := strconv.ParseInt(c.Query("exp"), 10, 64)
+	if aErr != nil || sErr != nil || eErr != nil || services.VerifyLiveStreamURL(uint(adminID), uint(sessionID), exp, c.Query("sig")) != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": gin.H{"code": "error.linkInvalidOrExpired", "message": "ลิงก์ไม่ถูกต้องหรือหมดอายุ"}})
+		return liveStreamAuth{}, false
+	}
+	auth := liveStreamAuth{AdminID: uint(adminID), SessionID: uint(sessionID)}
+	active, err := liveStreamSessionValid(auth)
+	if err != nil {
+		log.Printf("LiveStream: load session %d (admin=%d): %v", sessionID, adminID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+		return liveStreamAuth{}, false
+	}
+	if !active {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": gin.H{"code": "error.unauthorized", "message": "กรุณาเข้าสู่ระบบ"}})
+		return liveStreamAuth{}, false
+	}
+	return auth, true
+}
+
+// liveStreamSessionValid: session ยังไม่หมดอายุ/ไม่ถูก logout และเป็น admin คนเดิม
+// (Bearer ตรวจจาก token, signed URL ตรวจจาก session ที่ผูกไว้) — error = ตรวจไม่ได้ชั่วคราว (DB)
+func liveStreamSessionValid(auth liveStreamAuth) (bool, error) {
+	if auth.Bearer == "" {
+		return services.AdminSessionActive(config.DB, auth.SessionID, auth.AdminID)
+	}
+	admin, err := services.ResolveAdminSession(config.DB, auth.Bearer)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid_session") || strings.Contains(err.Error(), "missing_token") {
+			return false, nil
+		}
+		return false, err
+	}
+	return admin.ID == auth.AdminID, nil
+}
+
+// GET /api/live/stream  (Authorization: Bearer หรือ ?aid=&sid=&exp=&sig= จาก /api/live/stream-url)
+// event: booking.created | booking.deleted | checkin.completed | checkout.completed | guest.added | room.status_changed | housekeeping.updated | maintenance.updated
+// เฉพาะชนิดที่ role ของ admin มี permission — ต่อใหม่พร้อม Last-Event-ID จะได้ event ที่พลาดไป
+func LiveStream(c *gin.Context) {
+	auth, ok := liveStreamAdmin(c)
+	if !ok {
+		return
+	}
+	adminID := auth.AdminID
+	perms, err := services.AdminPermissions(config.DB, adminID)
+	if err != nil {
+		log.Printf("LiveStream: load permissions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+		return
+	}
+	if !services.LiveStreamAllowed(perms) {
+		c.JSON(http.StatusForbidden, gin.H{"error": gin.H{"code": "error.forbidden", "message": "ไม่มีสิทธิ์เข้าถึง"}})
+		return
+	}
+
+	lastID, _ := strconv.ParseUint(c.GetHeader("Last-Event-ID"), 10, 64)
+	hub := services.DefaultLiveHub()
+	sub, missed := hub.Subscribe(perms, lastID)
+	defer hub.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // nginx ไม่ buffer
+	c.Status(http.StatusOK)
+
+	// server มี WriteTimeout — ขยาย deadline ก่อนเขียนทุกครั้ง
+	rc := http.NewResponseController(c.Writer)
+	write := func(chunk string) bool {
+		_ = rc.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+		if _, err := c.Writer.WriteString(chunk); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+
+	if !write("retry: 5000\n\n") {
+		return
+	}
+	for _, m := range missed {
+		if !write(liveSSEFrame(m)) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(liveHeartbeatInterval)
+	defer heartbeat.Stop()
+	recheck := time.NewTicker(livePermissionRecheck)
+	defer recheck.Stop()
+	ctx := c.Request.Context()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m, open := <-sub.C:
+			if !open {
+				return
+			}
+			if !write(liveSSEFrame(m)) {
+				return
+			}
+		case <-heartbeat.C:
+			if !write(": ping\n\n") {
+				return
+			}
+		case <-recheck.C:
+			// logout / session หมดอายุ / admin ถูกลบ = ปิด stream
+			active, err := liveStreamSessionValid(auth)
+			if err != nil {
+				log.Printf("LiveStream: recheck session (admin=%d): %v", adminID, err)
+				continue
+			}
+			if !active {
+				return
+			}
+			perms, err := services.AdminPermissions(config.DB, adminID)
+			if err != nil {
+				log.Printf("LiveStream: reload permissions (admin=%d): %v", adminID, err)
+				continue
+			}
+			if !services.LiveStreamAllowed(perms) {
+				return
+			}
+			hub.SetPermissions(sub, perms)
+		}
+	}
+}
+
+func liveSSEFrame(m services.LiveMessage) string {
+	data, err := json.Marshal(m)
+	if err != nil {
+		data = []byte("{}")
+	}
+	return fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", m.ID, m.Type, data)
+}
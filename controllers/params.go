@@ -0,0 +1,18 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idParam อ่าน :id ของ route เป็นเลขบวก — ไม่ถูกต้องตอบ 400 แล้วคืน false
+func idParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id parameter"})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hotel-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrivacyController: คำขอของเจ้าของข้อมูลตาม PDPA (ถอนความยินยอม / ขอสำเนา / ขอลบ)
+type PrivacyController struct {
+	DataSubjectSvc *services.DataSubjectService
+}
+
+func NewPrivacyController(svc *services.DataSubjectService) *PrivacyController {
+	return &PrivacyController{DataSubjectSvc: svc}
+}
+
+// privacyError แปลง error ของ DataSubjectService เป็น HTTP response
+func privacyError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "invalid_request_type"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidRequestType", "message": "ประเภทคำขอไม่ถูกต้อง (withdraw_consent | access | erasure)"}})
+	case strings.Contains(msg, "invalid_email"), strings.Contains(msg, "subject_required"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.subjectRequired", "message": "กรุณาระบุอีเมลหรือลูกค้าที่เป็นเจ้าของข้อมูล"}})
+	case strings.Contains(msg, "invalid_consent"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidConsent", "message": "ไม่พบเงื่อนไขที่ระบุ"}})
+	case strings.Contains(msg, "customer_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.customerNotFound", "message": "ไม่พบลูกค้า"}})
+	case strings.Contains(msg, "request_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.requestNotFound", "message": "ไม่พบคำขอ"}})
+	case strings.Contains(msg, "request_not_open"):
+		c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.requestNotOpen", "message": "คำขอนี้ดำเนินการไปแล้วหรือยังไม่ได้ยืนยันตัวตน"}})
+	case strings.Contains(msg, "reason_required"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.reasonRequired", "message": "กรุณาระบุเหตุผล"}})
+	case strings.Contains(msg, "invalid_or_expired_token"):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": gin.H{"code": "error.invalidOrExpiredToken", "message": "ลิงก์ไม่ถูกต้องหรือหมดอายุ"}})
+	case strings.Contains(msg, "erasure_incomplete"):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.erasureIncomplete", "message": "ลบข้อมูลได้ไม่ครบ กรุณาลองใหม่", "details": msg}})
+	default:
+		log.Printf("privacy request error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+	}
+}
+
+func privacyRequestID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidId", "message": "id ไม่ถูกต้อง"}})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// ------------------------------------------------------------
+// Public (แขก)
+// ------------------------------------------------------------
+
+// POST /api/privacy/requests  { email, type, consentId?, note? }
+// ตอบ 202 เสมอเมื่อ payload ถูกต้อง (ไม่บอกว่าอีเมลมีข้อมูลในระบบหรือไม่)
+func (ctrl *PrivacyController) Submit(c *gin.Context) {
+	var in services.DataSubjectInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "payload ไม่ถูกต้อง", "details": err.Error()}})
+		return
+	}
+	in.CustomerID = nil
+
+	if err := ctrl.DataSubjectSvc.Submit(in); err != nil {
+		msg := err.Error()
+		if strings.Contains(msg, "invalid_") {
+			privacyError(c, err)
+			return
+		}
+		// ส่งอีเมลไม่สำเร็จ ฯลฯ — log ไว้ ไม่เปิดเผยรายละเอียด
+		log.Printf("privacy request submit error: %v", err)
+	}
+	c.JSON(http.StatusAccepted, gin.H{"status": "success", "message": "หากอีเมลนี้ถูกต้อง คุณจะได้รับลิงก์เพื่อยืนยันคำขอ"})
+}
+
+// POST /api/privacy/requests/verify  { token }
+func (ctrl *PrivacyController) Verify(c *gin.Context) {
+	var body struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.missingToken", "message": "ไม่พบ token"}})
+		return
+	}
+
+	req, err := ctrl.DataSubjectSvc.Verify(body.Token, time.Now())
+	if err != nil {
+		privacyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{
+		"id":     req.ID,
+		"type":   req.Type,
+		"status": req.Status,
+		"dueAt":  req.DueAt,
+	}})
+}
+
+// GET /api/privacy/exports?token=  (ลิงก์ดาวน์โหลดจากอีเมล)
+func (ctrl *PrivacyController) DownloadExport(c *gin.Context) {
+	data, filename, err := ctrl.DataSubjectSvc.OpenExport(c.Request.Context(), c.Query("token"), time.Now())
+	if err != nil {
+		privacyError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", data)
+}
+
+// ------------------------------------------------------------
+// Staff
+// ------------------------------------------------------------
+
+// GET /api/data-subject-requests?status=&overdue=1
+func (ctrl *PrivacyController) List(c *gin.Context) {
+	overdue := c.Query("overdue") == "1" || c.Query("overdue") == "true"
+	list, err := ctrl.DataSubjectSvc.List(strings.TrimSpace(c.Query("status")), overdue, time.Now())
+	if err != nil {
+		privacyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": list})
+}
+
+// GET /api/data-subject-requests/:id
+func (ctrl *PrivacyController) Get(c *gin.Context) {
+	id, ok := privacyRequestID(c)
+	if !ok {
+		return
+	}
+	req, err := ctrl.DataSubjectSvc.Get(id)
+	if err != nil {
+		privacyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": req})
+}
+
+// POST /api/data-subject-requests  { type, email?, customerId?, consentId?, note? }
+func (ctrl *PrivacyController) Create(c *gin.Context) {
+	var in services.DataSubjectInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "payload ไม่ถูกต้อง", "details": err.Error()}})
+		return
+	}
+	req, err := ctrl.DataSubjectSvc.CreateByStaff(in, c.GetUint("adminId"), time.Now())
+	if err != nil {
+		privacyError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": req})
+}
+
+// GET /api/data-subject-requests/:id/export?format=json|zip
+func (ctrl *PrivacyController) Export(c *gin.Context) {
+	id, ok := privacyRequestID(c)
+	if !ok {
+		return
+	}
+	req, err := ctrl.DataSubjectSvc.Get(id)
+	if err != nil {
+		privacyError(c, err)
+		return
+	}
+	format := "zip"
+	if c.Query("format") == "json" {
+		format = "json"
+	}
+
+	data, err := ctrl.DataSubjectSvc.BuildExport(c.Request.Context(), req, format, time.Now())
+	if err != nil {
+		privacyError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	if format == "json" {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="personal-data-`+strconv.FormatUint(uint64(id), 10)+`.zip"`)
+	c.Data(http.StatusOK, "application/zip", data)
+}
+
+// POST /api/data-subject-requests/:id/fulfil
+func (ctrl *PrivacyController) Fulfil(c *gin.Context) {
+	id, ok := privacyRequestID(c)
+	if !ok {
+		return
+	}
+	req, err := ctrl.DataSubjectSvc.Fulfil(c.Request.Context(), id, c.GetUint("adminId"), time.Now())
+	if err != nil {
+		privacyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": req})
+}
+
+// POST /api/data-subject-requests/:id/reject  { reason }
+func (ctrl *PrivacyController) Reject(c *gin.Context) {
+	id, ok := privacyRequestID(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	_ = c.ShouldBindJSON(&body)
+
+	req, err := ctrl.DataSubjectSvc.Reject(id, c.GetUint("adminId"), body.Reason, time.Now())
+	if err != nil {
+		privacyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": req})
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hotel-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RetentionController: retention policy ของข้อมูลแขก + ประวัติการลบ
+type RetentionController struct {
+	RetentionSvc *services.RetentionService
+}
+
+func NewRetentionController(svc *services.RetentionService) *RetentionController {
+	return &RetentionController{RetentionSvc: svc}
+}
+
+// GET /api/retention/policy
+func (ctrl *RetentionController) GetPolicy(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": services.LoadRetentionPolicy(ctrl.RetentionSvc.DB)})
+}
+
+// GET /api/retention/purge-logs?guestId=&dataClass=&limit=
+func (ctrl *RetentionController) ListPurgeLogs(c *gin.Context) {
+	guestID, _ := strconv.ParseUint(strings.TrimSpace(c.Query("guestId")), 10, 64)
+	limit, _ := strconv.Atoi(strings.TrimSpace(c.Query("limit")))
+
+	logs, err := ctrl.RetentionSvc.ListPurgeLogs(uint(guestID), strings.TrimSpace(c.Query("dataClass")), limit)
+	if err != nil {
+		log.Printf("ListPurgeLogs error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": logs})
+}
+
+// POST /api/retention/purge  (สั่งรันทันที นอกเหนือจากรอบอัตโนมัติ)
+func (ctrl *RetentionController) RunPurge(c *gin.Context) {
+	adminID := c.GetUint("adminId")
+	summary, err := ctrl.RetentionSvc.PurgeDue(c.Request.Context(), time.Now(), "manual", &adminID)
+	if err != nil {
+		log.Printf("manual purge error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": gin.H{"code": "error.purgeFailed", "message": "ลบข้อมูลไม่สำเร็จทั้งหมด", "details": err.Error()},
+			"data":  summary,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": summary})
+}
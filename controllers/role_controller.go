@@ -27,9 +27,18 @@ type roleMemberResponse struct {
 var defaultActionsByModule = map[string][]string{
 	"bookingManagement":   {"view", "create", "edit", "delete"},
 	"roomManagement":      {"view", "create", "edit", "delete", "editStatus"},
+	"housekeeping":        {"view", "work", "assign", "inspect"},
+	"maintenance":         {"view", "create", "assign", "work"},
 	"customerList":        {"view", "create", "edit", "delete", "export"},
 	"tm30Verification":    {"view", "submit", "verify", "export"},
 	"rolesAndPermissions": {"view", "create", "edit", "delete"},
+	"guestDocuments":      {"view", "review"},
+	"dataRetention":       {"view", "run"},
+	"consentManagement":   {"view", "edit", "publish"},
+	"privacyRequests":     {"view", "manage"},
+	"emailManagement":     {"view", "send", "config"},
+	"webhookManagement":   {"view", "config", "replay"},
+	"hotelSettings":       {"edit"},
 }
 
 func buildDefaultPermissions() map[string]map[string]bool {
@@ -10,6 +10,7 @@ import (
 
 	"hotel-backend/config"
 	"hotel-backend/models"
+	"hotel-backend/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -73,22 +74,24 @@ func CreateRoom(c *gin.Context) {
         return
     }
 
-    // If RoomTypeID pointer exists but is zero or invalid -> set to nil to avoid FK=0 insertion
-   if room.RoomTypeID != nil {
-    var rt models.RoomType
-    err := config.DB.
-        Where("id = ?", *room.RoomTypeID).
-        First(&rt).Error
-
-    if err != nil {
-        log.Printf("❌ Invalid RoomTypeID provided: %v", *room.RoomTypeID)
-        c.JSON(http.StatusBadRequest, gin.H{
-            "status": "error",
-            "message": "Invalid roomTypeID provided.",
-        })
-        return
-    }
-}
+	// ประเภทห้องจาก roomTypeID (หรือชื่อใน type) — type เก็บชื่อตาม FK เสมอ
+	rt, err := services.ResolveRoomType(config.DB, room.RoomTypeID, room.Type)
+	if err != nil {
+		log.Printf("❌ Invalid room type provided: %v / %q (%v)", room.RoomTypeID, room.Type, err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "Invalid roomTypeID provided.",
+		})
+		return
+	}
+	room.RoomTypeID = nil
+	if rt != nil {
+		room.RoomTypeID = &rt.ID
+		room.Type = rt.TypeName
+		if room.MaxOccupancy <= 0 {
+			room.MaxOccupancy = int(rt.MaxGuests)
+		}
+	}
 
 	if strings.TrimSpace(room.AccessCode) == "" {
 		code, err := generateUniqueAccessCode()
@@ -166,31 +169,102 @@ func UpdateRoom(c *gin.Context) {
 		return
 	}
 
-	if statusRaw, ok := updateData["status"]; ok {
-		statusStr := fmt.Sprintf("%v", statusRaw)
-		if strings.EqualFold(existing.Status, "Cleaning") && strings.EqualFold(statusStr, "Available") {
-			code, err := generateUniqueAccessCode()
-			if err != nil {
-				log.Printf("❌ Access code generation error: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"status":  "error",
-					"message": "Failed to generate access code",
-				})
-				return
+	// ประเภทห้อง: รับ roomTypeID หรือชื่อใน type แล้วตั้งทั้งคู่ให้ตรงกัน
+	var typeID *uint
+	typeName := ""
+	typeGiven := false
+	for _, k := range []string{"RoomTypeID", "roomTypeId", "roomTypeID", "room_type_id"} {
+		if v, ok := updateData[k]; ok {
+			typeGiven = true
+			if f, ok := v.(float64); ok && f > 0 {
+				id := uint(f)
+				typeID = &id
 			}
-			updateData["access_code"] = code
+			delete(updateData, k)
+		}
+	}
+	if v, ok := updateData["type"]; ok {
+		typeGiven = true
+		typeName = fmt.Sprintf("%v", v)
+		delete(updateData, "type")
+	}
+	if typeGiven {
+		rt, err := services.ResolveRoomType(config.DB, typeID, typeName)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "Invalid roomTypeID provided.",
+			})
+			return
+		}
+		if rt != nil {
+			updateData["room_type_id"] = rt.ID
+			updateData["type"] = rt.TypeName
+		} else {
+			updateData["room_type_id"] = nil
+			updateData["type"] = ""
+		}
+	}
+
+	// status แบบเดิมแปลงเป็น occupancy/condition — เปลี่ยนผ่าน RoomStatusService (มีประวัติ) ไม่เขียนคอลัมน์ตรง
+	statusRaw, hasStatus := updateData["status"]
+	for _, k := range []string{"status", "occupancy", "condition", "room_condition"} {
+		delete(updateData, k)
+	}
+	var statusChange services.RoomStatusChange
+	if hasStatus {
+		ch, err := services.LegacyRoomStatusChange(fmt.Sprintf("%v", statusRaw))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"code":    "error.invalidRoomStatus",
+				"message": "สถานะห้องไม่ถูกต้อง",
+			})
+			return
+		}
+		if adminID := c.GetUint("adminId"); adminID != 0 {
+			ch.ChangedBy = &adminID
 		}
+		statusChange = ch
 	}
 
 	// Update DB
-	if err := config.DB.Model(&models.Room{}).Where("id = ?", id).Updates(updateData).Error; err != nil {
-		log.Printf("❌ Update Error for Room %s: %v", id, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": "Update failed",
-			"details": err.Error(),
-		})
-		return
+	if len(updateData) > 0 {
+		if err := config.DB.Model(&models.Room{}).Where("id = ?", id).Updates(updateData).Error; err != nil {
+			log.Printf("❌ Update Error for Room %s: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "Update failed",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+	if statusChange.Occupancy != nil || statusChange.Condition != nil {
+		if _, err := services.NewRoomStatusService(config.DB).Update(existing.ID, statusChange); err != nil {
+			switch {
+			case strings.Contains(err.Error(), "housekeeping_pending"):
+				c.JSON(http.StatusConflict, gin.H{
+					"status":  "error",
+					"code":    "error.housekeepingPending",
+					"message": "ห้องยังมีงานทำความสะอาดที่ยังไม่ผ่านการตรวจ",
+				})
+			case strings.Contains(err.Error(), "room_blocked"):
+				c.JSON(http.StatusConflict, gin.H{
+					"status":  "error",
+					"code":    "error.roomBlocked",
+					"message": "ห้องอยู่ในช่วงซ่อม/งดขาย กรุณายกเลิก block ก่อน",
+					"details": err.Error(),
+				})
+			default:
+				log.Printf("❌ Status update error for Room %s: %v", id, err)
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"status":  "error",
+					"message": "Update failed",
+				})
+			}
+			return
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
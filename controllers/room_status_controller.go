@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hotel-backend/config"
+	"hotel-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// -----------------------------
+// Room status (occupancy/condition) + room blocks (ซ่อม/งดขาย)
+// -----------------------------
+
+// roomStatusError แปลง error ของ RoomStatusService / RoomBlockService เป็น HTTP response
+func roomStatusError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "room_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.roomNotFound", "message": "ไม่พบห้องพัก"}})
+	case strings.Contains(msg, "room_block_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.roomBlockNotFound", "message": "ไม่พบรายการปิดห้อง"}})
+	case strings.Contains(msg, "invalid_room_occupancy"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidRoomOccupancy", "message": "occupancy ต้องเป็น vacant หรือ occupied"}})
+	case strings.Contains(msg, "invalid_room_condition"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidRoomCondition", "message": "condition ต้องเป็น clean, dirty, inspected, out_of_order หรือ out_of_service"}})
+	case strings.Contains(msg, "invalid_block_kind"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidBlockKind", "message": "kind ต้องเป็น out_of_order หรือ out_of_service"}})
+	case strings.Contains(msg, "invalid_block_dates"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidBlockDates", "message": "startDate/endDate ต้องเป็น YYYY-MM-DD และ endDate ต้องหลัง startDate"}})
+	case strings.Contains(msg, "block_reason_required"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.blockReasonRequired", "message": "กรุณาระบุเหตุผล"}})
+	case strings.Contains(msg, "housekeeping_pending"):
+		c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.housekeepingPending", "message": "ห้องยังมีงานทำความสะอาดที่ยังไม่ผ่านการตรวจ"}})
+	case strings.Contains(msg, "room_blocked"):
+		c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.roomBlocked", "message": "ห้องอยู่ในช่วงซ่อม/งดขาย กรุณายกเลิก block ก่อน", "details": msg}})
+	case strings.Contains(msg, "room_block_already_ended"):
+		c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.roomBlockAlreadyEnded", "message": "รายการปิดห้องนี้สิ้นสุดแล้ว"}})
+	default:
+		log.Printf("room status error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+	}
+}
+
+// PUT /api/rooms/:id/status  { occupancy, condition, reason }
+func UpdateRoomStatus(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Occupancy *string `json:"occupancy"`
+		Condition *string `json:"condition"`
+		Reason    string  `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Occupancy == nil && req.Condition == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "กรุณาระบุ occupancy หรือ condition"}})
+		return
+	}
+	adminID := c.GetUint("adminId")
+	room, err := services.NewRoomStatusService(config.DB).Update(id, services.RoomStatusChange{
+		Occupancy: req.Occupancy,
+		Condition: req.Condition,
+		Reason:    req.Reason,
+		ChangedBy: &adminID,
+	})
+	if err != nil {
+		roomStatusError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": room})
+}
+
+// GET /api/rooms/:id/status-history?page=&limit=
+func GetRoomStatusHistory(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.Query("page"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	rows, total, err := services.NewRoomStatusService(config.DB).History(id, page, limit)
+	if err != nil {
+		roomStatusError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": rows, "total": total})
+}
+
+// GET /api/room-blocks?roomId=&active=1&from=YYYY-MM-DD&to=YYYY-MM-DD
+func ListRoomBlocks(c *gin.Context) {
+	roomID, _ := strconv.ParseUint(c.Query("roomId"), 10, 64)
+	f := services.RoomBlockFilter{
+		RoomID:     uint(roomID),
+		ActiveOnly: c.Query("active") == "1" || c.Query("active") == "true",
+	}
+	for key, dst := range map[string]**time.Time{"from": &f.From, "to": &f.To} {
+		v := strings.TrimSpace(c.Query(key))
+		if v == "" {
+			continue
+		}
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidDate", "message": "รูปแบบวันที่ต้องเป็น YYYY-MM-DD", "details": key}})
+			return
+		}
+		*dst = &d
+	}
+	rows, err := services.NewRoomBlockService(config.DB).List(f)
+	if err != nil {
+		roomStatusError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": rows})
+}
+
+// POST /api/room-blocks  { roomId, kind, startDate, endDate, reason }
+// booking ที่ทับช่วงนี้อยู่แล้วไม่ถูกยกเลิก — คืนใน conflicts ให้ย้ายห้องเอง
+func CreateRoomBlock(c *gin.Context) {
+	var in services.RoomBlockInput
+	if err := c.ShouldBindJSON(&in); err != nil || in.RoomID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "กรุณาระบุ roomId"}})
+		return
+	}
+	block, conflicts, err := services.NewRoomBlockService(config.DB).Create(in, c.GetUint("adminId"))
+	if err != nil {
+		roomStatusError(c, err)
+		return
+	}
+	if conflicts == nil {
+		conflicts = []uint{}
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": block, "conflicts": conflicts})
+}
+
+// POST /api/room-blocks/:id/release  (ยกเลิกก่อนกำหนด — ห้องเป็น dirty รอทำความสะอาด)
+func ReleaseRoomBlock(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	block, err := services.NewRoomBlockService(config.DB).Release(id, c.GetUint("adminId"))
+	if err != nil {
+		roomStatusError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": block})
+}
@@ -1,32 +1,212 @@
 package controllers
 
 import (
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+
 	"hotel-backend/config"
-	"hotel-backend/models"
+	"hotel-backend/services"
 
 	"github.com/gin-gonic/gin"
 )
 
+// roomTypeError แปลง error ของ RoomTypeService เป็น HTTP response
+func roomTypeError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "room_type_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.roomTypeNotFound", "message": "ไม่พบประเภทห้อง"}})
+	case strings.Contains(msg, "photo_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.photoNotFound", "message": "ไม่พบรูป"}})
+	case strings.Contains(msg, "room_type_name_required"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.roomTypeNameRequired", "message": "กรุณาระบุชื่อประเภทห้อง"}})
+	case strings.Contains(msg, "room_type_name_taken"):
+		c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.roomTypeNameTaken", "message": "มีประเภทห้องชื่อนี้แล้ว"}})
+	case strings.Contains(msg, "invalid_max_guests"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidMaxGuests", "message": "max_guests ต้องมากกว่า 0"}})
+	case strings.Contains(msg, "invalid_base_occupancy"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidBaseOccupancy", "message": "baseOccupancy ต้องอยู่ระหว่าง 1 ถึง max_guests"}})
+	case strings.Contains(msg, "invalid_amenity"), strings.Contains(msg, "too_many_amenities"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidAmenities", "message": "สิ่งอำนวยความสะดวกไม่เกิน 50 รายการ รายการละไม่เกิน 64 ตัวอักษร", "details": msg}})
+	case strings.Contains(msg, "invalid_bed_configuration"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidBedConfiguration", "message": "ชนิดเตียงต้องเป็น single, twin, double, queen, king, sofa_bed หรือ bunk และจำนวน 1-10", "details": msg}})
+	case strings.Contains(msg, "too_many_photos"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.tooManyPhotos", "message": "แกลเลอรีมีรูปได้ไม่เกิน 20 รูป"}})
+	case strings.Contains(msg, "invalid_photo_order"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPhotoOrder", "message": "photoIds ต้องเป็นรูปทั้งหมดของประเภทห้องนี้"}})
+	case strings.Contains(msg, "invalid_photo"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPhoto", "message": "ไฟล์รูปไม่ถูกต้อง", "details": msg}})
+	case strings.Contains(msg, "invalid_reassign_target"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidReassignTarget", "message": "reassignTo ต้องเป็นประเภทห้องอื่นที่มีอยู่"}})
+	case strings.Contains(msg, "room_type_in_use"):
+		c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.roomTypeInUse", "message": "ยังมีห้องใช้ประเภทนี้อยู่ กรุณาระบุ reassignTo เพื่อย้ายห้องก่อนลบ", "details": msg}})
+	default:
+		log.Printf("room type error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+	}
+}
+
 func GetRoomTypes(c *gin.Context) {
-	var types []models.RoomType
-	config.DB.Find(&types)
+	types, err := services.NewRoomTypeService(config.DB).List()
+	if err != nil {
+		roomTypeError(c, err)
+		return
+	}
 	c.JSON(http.StatusOK, types)
 }
 
+// GET /api/room-types/:id
+func GetRoomType(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	rt, err := services.NewRoomTypeService(config.DB).Get(id)
+	if err != nil {
+		roomTypeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rt)
+}
+
 func CreateRoomType(c *gin.Context) {
-	var rt models.RoomType
-	if err := c.ShouldBindJSON(&rt); err != nil {
+	var in services.RoomTypeInput
+	if err := c.ShouldBindJSON(&in); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	config.DB.Create(&rt)
+	rt, err := services.NewRoomTypeService(config.DB).Create(in)
+	if err != nil {
+		roomTypeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rt)
+}
+
+// PUT/PATCH /api/room-types/:id  { typeName, description, max_guests, baseOccupancy, amenities[], bedConfiguration[] }
+// ฟิลด์ที่ไม่ส่งคงเดิม — เปลี่ยนชื่อแล้ว type ของห้องประเภทนี้เปลี่ยนตาม
+func UpdateRoomType(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	var in services.RoomTypeInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "payload ไม่ถูกต้อง", "details": err.Error()}})
+		return
+	}
+	rt, err := services.NewRoomTypeService(config.DB).Update(id, in)
+	if err != nil {
+		roomTypeError(c, err)
+		return
+	}
 	c.JSON(http.StatusOK, rt)
 }
 
+// DELETE /api/room-types/:id?reassignTo=<id>
+// ยังมีห้องใช้อยู่: ไม่ระบุ reassignTo -> 409 / ระบุ -> ย้ายห้องไปประเภทนั้นแล้วลบ
 func DeleteRoomType(c *gin.Context) {
-	id := c.Param("id")
-	config.DB.Delete(&models.RoomType{}, id)
-	c.JSON(http.StatusOK, gin.H{"message": "Room type deleted"})
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	var reassignTo *uint
+	if v := strings.TrimSpace(c.Query("reassignTo")); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidReassignTarget", "message": "reassignTo ต้องเป็นประเภทห้องอื่นที่มีอยู่"}})
+			return
+		}
+		target := uint(n)
+		reassignTo = &target
+	}
+	moved, err := services.NewRoomTypeService(config.DB).Delete(id, reassignTo)
+	if err != nil {
+		roomTypeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Room type deleted", "roomsReassigned": moved})
+}
+
+// POST /api/room-types/:id/photos  { image (base64 / data URI), caption }
+func AddRoomTypePhoto(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Image   string `json:"image"`
+		Caption string `json:"caption"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Image) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "กรุณาแนบรูป"}})
+		return
+	}
+	rt, err := services.NewRoomTypeService(config.DB).AddPhoto(id, req.Image, req.Caption)
+	if err != nil {
+		roomTypeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, rt)
+}
+
+// PUT /api/room-types/:id/photos/order  { photoIds: [] }
+func ReorderRoomTypePhotos(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		PhotoIDs []uint `json:"photoIds"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "payload ไม่ถูกต้อง", "details": err.Error()}})
+		return
+	}
+	rt, err := services.NewRoomTypeService(config.DB).ReorderPhotos(id, req.PhotoIDs)
+	if err != nil {
+		roomTypeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rt)
+}
+
+// DELETE /api/room-types/:id/photos/:photoId
+func DeleteRoomTypePhoto(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	photoID, err := strconv.ParseUint(c.Param("photoId"), 10, 64)
+	if err != nil || photoID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidId", "message": "photoId ไม่ถูกต้อง"}})
+		return
+	}
+	rt, err := services.NewRoomTypeService(config.DB).DeletePhoto(id, uint(photoID))
+	if err != nil {
+		roomTypeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rt)
+}
+
+// GET /api/room-types/photos/:id  (รูปสาธารณะ — ใช้ในหน้าจองได้)
+func ServeRoomTypePhoto(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	body, info, err := services.NewRoomTypeService(config.DB).OpenPhoto(c.Request.Context(), id)
+	if err != nil {
+		roomTypeError(c, err)
+		return
+	}
+	defer body.Close()
+
+	c.DataFromReader(http.StatusOK, info.Size, info.ContentType, body, map[string]string{
+		"Cache-Control": "public, max-age=300",
+	})
 }
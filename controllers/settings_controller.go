@@ -2,10 +2,15 @@
 
 import (
 	"errors"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"hotel-backend/config"
 	"hotel-backend/models"
+	"hotel-backend/services"
+	"hotel-backend/utils"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -18,6 +23,141 @@ type hotelSettingsPayload struct {
 	Email   string `json:"email"`
 	Website string `json:"website"`
 	Logo    string `json:"logo"`
+
+	RequireIdentityApproval bool `json:"require_identity_approval"`
+	RequireSignature        bool `json:"require_signature"`
+
+	// retention (วัน, 0 = ไม่ลบ) — ไม่ส่งมา = คงค่าเดิม
+	RetentionFaceImageDays     *int `json:"retention_face_image_days"`
+	RetentionDocumentImageDays *int `json:"retention_document_image_days"`
+	RetentionGuestDataDays     *int `json:"retention_guest_data_days"`
+
+	// ลิงก์/รหัสเช็คอินออนไลน์ — ไม่ส่งมา = คงค่าเดิม
+	CheckinLinkOpenDaysBefore *int  `json:"checkin_link_open_days_before"`
+	CheckinLinkNeverExpire    *bool `json:"checkin_link_never_expire"`
+	CheckinCodeValidityHours  *int  `json:"checkin_code_validity_hours"`
+	CheckinCodeResendMinutes  *int  `json:"checkin_code_resend_minutes"`
+
+	// คำเชิญเช็คอินก่อนวันเข้าพัก — ไม่ส่งมา = คงค่าเดิม
+	CheckinInviteEnabled    *bool `json:"checkin_invite_enabled"`
+	CheckinInviteDaysBefore *int  `json:"checkin_invite_days_before"`
+	CheckinReminderHour     *int  `json:"checkin_reminder_hour"`
+
+	// ภาษาตั้งต้นของอีเมล (en | th) — ไม่ส่งมา = คงค่าเดิม
+	EmailLocale *string `json:"email_locale"`
+
+	// timezone ของโรงแรม (IANA เช่น Asia/Bangkok) — ไม่ส่งมา = คงค่าเดิม
+	Timezone *string `json:"timezone"`
+}
+
+func validRetention(payload hotelSettingsPayload) bool {
+	for _, days := range []*int{payload.RetentionFaceImageDays, payload.RetentionDocumentImageDays, payload.RetentionGuestDataDays} {
+		if days != nil && *days < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func validCheckinPolicy(payload hotelSettingsPayload) bool {
+	for _, v := range []*int{payload.CheckinLinkOpenDaysBefore, payload.CheckinCodeValidityHours} {
+		if v != nil && *v < 0 {
+			return false
+		}
+	}
+	if payload.CheckinInviteDaysBefore != nil && *payload.CheckinInviteDaysBefore < 0 {
+		return false
+	}
+	if payload.CheckinReminderHour != nil && (*payload.CheckinReminderHour < 0 || *payload.CheckinReminderHour > 23) {
+		return false
+	}
+	return payload.CheckinCodeResendMinutes == nil || *payload.CheckinCodeResendMinutes > 0
+}
+
+// applyCheckinPolicy คัดลอกเฉพาะค่าลิงก์/รหัสเช็คอินที่ส่งมา
+func applyCheckinPolicy(hotel *models.HotelSetting, payload hotelSettingsPayload) {
+	if payload.CheckinLinkOpenDaysBefore != nil {
+		hotel.CheckinLinkOpenDaysBefore = *payload.CheckinLinkOpenDaysBefore
+	}
+	if payload.CheckinLinkNeverExpire != nil {
+		hotel.CheckinLinkNeverExpire = *payload.CheckinLinkNeverExpire
+	}
+	if payload.CheckinCodeValidityHours != nil {
+		hotel.CheckinCodeValidityHours = *payload.CheckinCodeValidityHours
+	}
+	if payload.CheckinCodeResendMinutes != nil {
+		hotel.CheckinCodeResendMinutes = *payload.CheckinCodeResendMinutes
+	}
+	if payload.CheckinInviteEnabled != nil {
+		hotel.CheckinInviteEnabled = *payload.CheckinInviteEnabled
+	}
+	if payload.CheckinInviteDaysBefore != nil {
+		hotel.CheckinInviteDaysBefore = *payload.CheckinInviteDaysBefore
+	}
+	if payload.CheckinReminderHour != nil {
+		hotel.CheckinReminderHour = *payload.CheckinReminderHour
+	}
+}
+
+// applyEmailLocale ตั้งภาษาตั้งต้นของอีเมลถ้าส่งมา
+func applyEmailLocale(hotel *models.HotelSetting, payload hotelSettingsPayload) {
+	if payload.EmailLocale != nil {
+		hotel.EmailLocale = utils.NormalizeEmailLocale(*payload.EmailLocale)
+	}
+}
+
+// applyTimezone ตั้ง timezone ของโรงแรมถ้าส่งมา
+func applyTimezone(hotel *models.HotelSetting, payload hotelSettingsPayload) {
+	if payload.Timezone != nil {
+		hotel.Timezone = strings.TrimSpace(*payload.Timezone)
+	}
+}
+
+// applyRetention คัดลอกเฉพาะค่า retention ที่ส่งมา
+func applyRetention(hotel *models.HotelSetting, payload hotelSettingsPayload) {
+	if payload.RetentionFaceImageDays != nil {
+		hotel.RetentionFaceImageDays = *payload.RetentionFaceImageDays
+	}
+	if payload.RetentionDocumentImageDays != nil {
+		hotel.RetentionDocumentImageDays = *payload.RetentionDocumentImageDays
+	}
+	if payload.RetentionGuestDataDays != nil {
+		hotel.RetentionGuestDataDays = *payload.RetentionGuestDataDays
+	}
+}
+
+// โลโก้ที่อัปโหลด (data URI) ถูกเก็บใน BlobStore ใต้ "branding/" และเปิดผ่าน hotelLogoURL
+const (
+	hotelLogoDir = "branding"
+	hotelLogoURL = "/api/settings/hotel/logo"
+)
+
+func isStoredHotelLogo(logo string) bool {
+	return strings.HasPrefix(logo, hotelLogoDir+"/")
+}
+
+// presentHotel: แทน key ของโลโก้ด้วย URL สาธารณะ (?v= กัน cache เก่า)
+func presentHotel(hotel models.HotelSetting) models.HotelSetting {
+	if isStoredHotelLogo(hotel.Logo) {
+		hotel.Logo = hotelLogoURL + "?v=" + strconv.FormatInt(hotel.UpdatedAt.Unix(), 10)
+	}
+	return hotel
+}
+
+// resolveHotelLogo แปลงค่า logo จาก payload เป็นค่าที่เก็บใน DB
+// - data URI -> อัปโหลดเข้า BlobStore
+// - hotelLogoURL (ค่าที่ GET ส่งกลับไป) -> ใช้โลโก้เดิม
+// - อื่น ๆ (URL ภายนอก / ค่าว่าง) -> เก็บตามที่ส่งมา
+func resolveHotelLogo(payloadLogo, current string) (string, error) {
+	logo := strings.TrimSpace(payloadLogo)
+	switch {
+	case strings.HasPrefix(logo, "data:"):
+		return services.SaveBase64Image(logo, hotelLogoDir)
+	case strings.HasPrefix(logo, hotelLogoURL):
+		return current, nil
+	default:
+		return logo, nil
+	}
 }
 
 func GetHotelSettings(c *gin.Context) {
@@ -31,7 +171,35 @@ func GetHotelSettings(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"hotel": hotel})
+	c.JSON(http.StatusOK, gin.H{"hotel": presentHotel(hotel)})
+}
+
+// GET /api/settings/hotel/logo  (public — ใช้ใน <img src> และอีเมล)
+func GetHotelLogo(c *gin.Context) {
+	var hotel models.HotelSetting
+	if err := config.DB.First(&hotel).Error; err != nil || strings.TrimSpace(hotel.Logo) == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "logo not found"})
+		return
+	}
+	if !isStoredHotelLogo(hotel.Logo) {
+		c.Redirect(http.StatusFound, hotel.Logo)
+		return
+	}
+
+	body, info, err := services.DefaultBlobStore().Open(c.Request.Context(), hotel.Logo)
+	if err != nil {
+		if errors.Is(err, services.ErrBlobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "logo not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	c.DataFromReader(http.StatusOK, info.Size, info.ContentType, body, map[string]string{
+		"Cache-Control": "public, max-age=300",
+	})
 }
 
 func UpdateHotelSettings(c *gin.Context) {
@@ -43,24 +211,70 @@ func UpdateHotelSettings(c *gin.Context) {
 
 	var hotel models.HotelSetting
 	err := config.DB.First(&hotel).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			hotel = models.HotelSetting{
-				Name:    payload.Name,
-				Address: payload.Address,
-				Phone:   payload.Phone,
-				Email:   payload.Email,
-				Website: payload.Website,
-				Logo:    payload.Logo,
-			}
-			if err := config.DB.Create(&hotel).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
-			c.JSON(http.StatusOK, gin.H{"hotel": hotel})
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !validRetention(payload) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "retention days must be zero or positive"})
+		return
+	}
+	if payload.EmailLocale != nil && utils.NormalizeEmailLocale(*payload.EmailLocale) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email_locale must be one of: " + strings.Join(utils.EmailLocales, ", ")})
+		return
+	}
+	if payload.Timezone != nil && !services.ValidHotelTimezone(*payload.Timezone) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "timezone must be an IANA time zone name, e.g. " + services.DefaultHotelTimezone})
+		return
+	}
+	if !validCheckinPolicy(payload) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "check-in link/invite days and code hours must be zero or positive, resend minutes must be positive, reminder hour must be 0-23"})
+		return
+	}
+
+	previousLogo := hotel.Logo
+	logo, logoErr := resolveHotelLogo(payload.Logo, previousLogo)
+	if logoErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid logo image", "details": logoErr.Error()})
+		return
+	}
+	payload.Logo = logo
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		hotel = models.HotelSetting{
+			Name:    payload.Name,
+			Address: payload.Address,
+			Phone:   payload.Phone,
+			Email:   payload.Email,
+			Website: payload.Website,
+			Logo:    payload.Logo,
+
+			RequireIdentityApproval: payload.RequireIdentityApproval,
+			RequireSignature:        payload.RequireSignature,
+
+			RetentionFaceImageDays:     30,
+			RetentionDocumentImageDays: 90,
+
+			CheckinLinkOpenDaysBefore: 7,
+			CheckinCodeValidityHours:  168,
+			CheckinCodeResendMinutes:  15,
+			CheckinInviteDaysBefore:   3,
+			CheckinReminderHour:       8,
+
+			EmailLocale: "en",
+			Timezone:    services.DefaultHotelTimezone,
+		}
+		applyRetention(&hotel, payload)
+		applyCheckinPolicy(&hotel, payload)
+		applyEmailLocale(&hotel, payload)
+		applyTimezone(&hotel, payload)
+		// Select("*"): ค่า 0/false ที่ตั้งมา (เช่น open_days_before = 0) ต้องไม่ถูกแทนด้วย default ของคอลัมน์
+		if err := config.DB.Select("*").Create(&hotel).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusOK, gin.H{"hotel": presentHotel(hotel)})
 		return
 	}
 
@@ -70,11 +284,24 @@ func UpdateHotelSettings(c *gin.Context) {
 	hotel.Email = payload.Email
 	hotel.Website = payload.Website
 	hotel.Logo = payload.Logo
+	hotel.RequireIdentityApproval = payload.RequireIdentityApproval
+	hotel.RequireSignature = payload.RequireSignature
+	applyRetention(&hotel, payload)
+	applyCheckinPolicy(&hotel, payload)
+	applyEmailLocale(&hotel, payload)
+	applyTimezone(&hotel, payload)
 
 	if err := config.DB.Save(&hotel).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"hotel": hotel})
+	// ลบไฟล์โลโก้เดิมเมื่อถูกแทนที่
+	if isStoredHotelLogo(previousLogo) && previousLogo != hotel.Logo {
+		if err := services.DeleteStoredImage(previousLogo); err != nil {
+			log.Printf("warning: failed to delete previous hotel logo %s: %v", previousLogo, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"hotel": presentHotel(hotel)})
 }
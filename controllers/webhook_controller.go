@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hotel-backend/config"
+	"hotel-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// -----------------------------
+// Outgoing webhooks (ระบบภายนอกรับ event แทนการ poll /api/bookings)
+// -----------------------------
+
+// webhookError แปลง error ของ WebhookService เป็น HTTP response
+func webhookError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "webhook_endpoint_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.webhookEndpointNotFound", "message": "ไม่พบ webhook endpoint"}})
+	case strings.Contains(msg, "webhook_delivery_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.webhookDeliveryNotFound", "message": "ไม่พบประวัติการส่ง"}})
+	case strings.Contains(msg, "webhook_fields_required"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.webhookFieldsRequired", "message": "กรุณาระบุ url และ events"}})
+	case strings.Contains(msg, "invalid_webhook_url"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidWebhookUrl", "message": "URL ต้องขึ้นต้นด้วย http:// หรือ https://"}})
+	case strings.Contains(msg, "invalid_webhook_event"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidWebhookEvent", "message": "event ไม่ถูกต้อง", "details": msg, "events": services.WebhookEvents()}})
+	case strings.Contains(msg, "webhook_delivery_pending"):
+		c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.webhookDeliveryPending", "message": "รายการนี้ยังอยู่ในคิวรอส่ง"}})
+	case strings.Contains(msg, "webhook_endpoint_disabled"):
+		c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.webhookEndpointDisabled", "message": "endpoint ถูกปิดใช้งานอยู่"}})
+	default:
+		log.Printf("webhook error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+	}
+}
+
+func bindWebhookEndpoint(c *gin.Context) (services.WebhookEndpointInput, bool) {
+	var in services.WebhookEndpointInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "payload ไม่ถูกต้อง", "details": err.Error()}})
+		return in, false
+	}
+	return in, true
+}
+
+// GET /api/webhook-endpoints
+func ListWebhookEndpoints(c *gin.Context) {
+	rows, err := services.NewWebhookService(config.DB).ListEndpoints()
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": rows, "events": services.WebhookEvents()})
+}
+
+// GET /api/webhook-endpoints/:id
+func GetWebhookEndpoint(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	ep, err := services.NewWebhookService(config.DB).GetEndpoint(id)
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": ep})
+}
+
+// POST /api/webhook-endpoints  { name, url, description, events: ["booking.created", ...], active }
+// ตอบ secret ครั้งเดียว — ผู้รับใช้ตรวจ X-Webhook-Signature
+func CreateWebhookEndpoint(c *gin.Context) {
+	in, ok := bindWebhookEndpoint(c)
+	if !ok {
+		return
+	}
+	view, err := services.NewWebhookService(config.DB).CreateEndpoint(in, c.GetUint("adminId"))
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": view})
+}
+
+// PUT /api/webhook-endpoints/:id
+func UpdateWebhookEndpoint(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	in, ok := bindWebhookEndpoint(c)
+	if !ok {
+		return
+	}
+	ep, err := services.NewWebhookService(config.DB).UpdateEndpoint(id, in)
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": ep})
+}
+
+// POST /api/webhook-endpoints/:id/rotate-secret
+func RotateWebhookSecret(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	view, err := services.NewWebhookService(config.DB).RotateSecret(id)
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": view})
+}
+
+// DELETE /api/webhook-endpoints/:id
+func DeleteWebhookEndpoint(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	if err := services.NewWebhookService(config.DB).DeleteEndpoint(id); err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+// GET /api/webhook-deliveries?endpointId=&event=&eventId=&status=&page=&limit=
+func ListWebhookDeliveries(c *gin.Context) {
+	endpointID, _ := strconv.ParseUint(c.Query("endpointId"), 10, 64)
+	page, _ := strconv.Atoi(c.Query("page"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	rows, total, err := services.NewWebhookService(config.DB).ListDeliveries(services.WebhookDeliveryFilter{
+		EndpointID: uint(endpointID),
+		Event:      strings.TrimSpace(c.Query("event")),
+		EventID:    strings.TrimSpace(c.Query("eventId")),
+		Status:     strings.TrimSpace(c.Query("status")),
+		Page:       page,
+		Limit:      limit,
+	})
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": rows, "total": total})
+}
+
+// GET /api/webhook-deliveries/:id  (รวม payload และ response ล่าสุด)
+func GetWebhookDelivery(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	row, err := services.NewWebhookService(config.DB).GetDelivery(id)
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": row})
+}
+
+// POST /api/webhook-deliveries/:id/replay  (ส่ง event เดิมอีกครั้งเป็นแถวใหม่)
+func ReplayWebhookDelivery(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	row, err := services.NewWebhookService(config.DB).Replay(id, c.GetUint("adminId"))
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"status": "success", "data": row})
+}
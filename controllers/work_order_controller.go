@@ -0,0 +1,267 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hotel-backend/config"
+	"hotel-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// -----------------------------
+// Maintenance work orders (งานซ่อมบำรุง)
+// -----------------------------
+
+// workOrderError แปลง error ของ WorkOrderService เป็น HTTP response
+func workOrderError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "work_order_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.workOrderNotFound", "message": "ไม่พบงานซ่อม"}})
+	case strings.Contains(msg, "room_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.roomNotFound", "message": "ไม่พบห้องพัก"}})
+	case strings.Contains(msg, "file_not_found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"code": "error.fileNotFound", "message": "ไม่พบไฟล์"}})
+	case strings.Contains(msg, "link_invalid_or_expired"):
+		c.JSON(http.StatusForbidden, gin.H{"error": gin.H{"code": "error.linkInvalidOrExpired", "message": "ลิงก์ไม่ถูกต้องหรือหมดอายุ"}})
+	case strings.Contains(msg, "invalid_work_order_category"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidWorkOrderCategory", "message": "category ต้องเป็น hvac, plumbing, electrical, furniture, appliance หรือ other"}})
+	case strings.Contains(msg, "invalid_work_order_priority"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidWorkOrderPriority", "message": "priority ต้องเป็น low, normal, high หรือ urgent"}})
+	case strings.Contains(msg, "work_order_description_required"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.workOrderDescriptionRequired", "message": "กรุณาระบุหัวข้อหรือรายละเอียดปัญหา"}})
+	case strings.Contains(msg, "work_order_note_required"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.workOrderNoteRequired", "message": "กรุณาระบุหมายเหตุ"}})
+	case strings.Contains(msg, "too_many_photos"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.tooManyPhotos", "message": "แนบรูปได้ไม่เกิน 10 รูปต่องาน"}})
+	case strings.Contains(msg, "photo_required"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.photoRequired", "message": "กรุณาแนบรูป"}})
+	case strings.Contains(msg, "invalid_photo"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPhoto", "message": "ไฟล์รูปไม่ถูกต้อง", "details": msg}})
+	case strings.Contains(msg, "invalid_block_dates"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidBlockDates", "message": "blockStartDate/blockEndDate ต้องเป็น YYYY-MM-DD และวันสิ้นสุดต้องหลังวันเริ่ม"}})
+	case strings.Contains(msg, "work_order_assignee_invalid"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.workOrderAssigneeInvalid", "message": "ผู้รับงานต้องมีสิทธิ์ทำงานซ่อม"}})
+	case strings.Contains(msg, "work_order_not_assignee"):
+		c.JSON(http.StatusForbidden, gin.H{"error": gin.H{"code": "error.workOrderNotAssignee", "message": "งานนี้มอบหมายให้ผู้อื่นแล้ว"}})
+	case strings.Contains(msg, "work_order_already_blocked"):
+		c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.workOrderAlreadyBlocked", "message": "ห้องของงานนี้ถูกปิดซ่อมอยู่แล้ว"}})
+	case strings.Contains(msg, "work_order_invalid_transition"):
+		c.JSON(http.StatusConflict, gin.H{"error": gin.H{"code": "error.workOrderInvalidTransition", "message": "สถานะงานปัจจุบันไม่สามารถทำขั้นตอนนี้ได้", "details": msg}})
+	default:
+		log.Printf("work order error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"}})
+	}
+}
+
+// GET /api/work-orders?roomId=&floor=&status=open,in_progress&category=&priority=&assignedTo=&mine=1&open=1&page=&limit=
+func ListWorkOrders(c *gin.Context) {
+	roomID, _ := strconv.ParseUint(c.Query("roomId"), 10, 64)
+	assignedTo, _ := strconv.ParseUint(c.Query("assignedTo"), 10, 64)
+	page, _ := strconv.Atoi(c.Query("page"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	f := services.WorkOrderFilter{
+		RoomID:     uint(roomID),
+		Floor:      strings.TrimSpace(c.Query("floor")),
+		Status:     strings.TrimSpace(c.Query("status")),
+		Category:   strings.TrimSpace(c.Query("category")),
+		Priority:   strings.TrimSpace(c.Query("priority")),
+		AssignedTo: uint(assignedTo),
+		OpenOnly:   c.Query("open") == "1" || c.Query("open") == "true",
+		Page:       page,
+		Limit:      limit,
+	}
+	if c.Query("mine") == "1" || c.Query("mine") == "true" {
+		f.AssignedTo = c.GetUint("adminId")
+	}
+	rows, total, err := services.NewWorkOrderService(config.DB).List(f)
+	if err != nil {
+		workOrderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": rows, "total": total})
+}
+
+// GET /api/work-orders/:id  (รูปมี url แบบ signed อายุ 10 นาที)
+func GetWorkOrder(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	order, err := services.NewWorkOrderService(config.DB).Get(id, c.GetUint("adminId"))
+	if err != nil {
+		workOrderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": order})
+}
+
+// GET /api/work-orders/technicians  (ผู้ที่รับงานซ่อมได้ — สำหรับมอบหมายงาน)
+func ListWorkOrderTechnicians(c *gin.Context) {
+	admins, err := services.NewWorkOrderService(config.DB).Technicians()
+	if err != nil {
+		workOrderError(c, err)
+		return
+	}
+	data := make([]gin.H, 0, len(admins))
+	for _, a := range admins {
+		data = append(data, gin.H{"id": a.ID, "fullName": a.FullName, "username": a.Username})
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": data})
+}
+
+// POST /api/work-orders  { roomId, category, priority, title, description, assignedTo, photos[], outOfOrder, blockStartDate, blockEndDate }
+// outOfOrder: ห้องปิดซ่อมช่วง [blockStartDate, blockEndDate) — booking ที่ทับช่วงคืนใน conflicts
+func CreateWorkOrder(c *gin.Context) {
+	var in services.WorkOrderInput
+	if err := c.ShouldBindJSON(&in); err != nil || in.RoomID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "กรุณาระบุ roomId"}})
+		return
+	}
+	order, conflicts, err := services.NewWorkOrderService(config.DB).Create(in, c.GetUint("adminId"))
+	if err != nil {
+		workOrderError(c, err)
+		return
+	}
+	if conflicts == nil {
+		conflicts = []uint{}
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": order, "conflicts": conflicts})
+}
+
+// PUT /api/work-orders/:id/assign  { assignedTo }  (null = คืนงานเข้ากอง)
+func AssignWorkOrder(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		AssignedTo *uint `json:"assignedTo"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "payload ไม่ถูกต้อง", "details": err.Error()}})
+		return
+	}
+	order, err := services.NewWorkOrderService(config.DB).Assign(id, req.AssignedTo, c.GetUint("adminId"))
+	if err != nil {
+		workOrderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": order})
+}
+
+// POST /api/work-orders/:id/out-of-order  { startDate, endDate }
+func BlockWorkOrderRoom(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		StartDate string `json:"startDate"`
+		EndDate   string `json:"endDate"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "payload ไม่ถูกต้อง", "details": err.Error()}})
+		return
+	}
+	order, conflicts, err := services.NewWorkOrderService(config.DB).BlockRoom(id, c.GetUint("adminId"), req.StartDate, req.EndDate)
+	if err != nil {
+		workOrderError(c, err)
+		return
+	}
+	if conflicts == nil {
+		conflicts = []uint{}
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": order, "conflicts": conflicts})
+}
+
+// POST /api/work-orders/:id/photos  { photos[] }
+func AddWorkOrderPhotos(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Photos []string `json:"photos"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"code": "error.invalidPayload", "message": "payload ไม่ถูกต้อง", "details": err.Error()}})
+		return
+	}
+	order, err := services.NewWorkOrderService(config.DB).AddPhotos(id, c.GetUint("adminId"), req.Photos)
+	if err != nil {
+		workOrderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": order})
+}
+
+// GET /api/work-orders/photos/:id?aid=&exp=&sig=  (signed URL จาก GET /api/work-orders/:id)
+func ServeWorkOrderPhoto(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	adminID, _ := strconv.ParseUint(c.Query("aid"), 10, 64)
+	exp, _ := strconv.ParseInt(c.Query("exp"), 10, 64)
+	data, contentType, err := services.NewWorkOrderService(config.DB).OpenPhoto(c.Request.Context(), id, uint(adminID), exp, c.Query("sig"))
+	if err != nil {
+		workOrderError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// workOrderTransition: POST /api/work-orders/:id/{start|hold|resolve|close|reopen|cancel}  { note }
+// hold/resolve/reopen/cancel ต้องมี note — resolve/cancel เปิดห้องที่ปิดซ่อมคืน (ห้อง dirty รอทำความสะอาด)
+func workOrderTransition(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := idParam(c)
+		if !ok {
+			return
+		}
+		var req struct {
+			Note string `json:"note"`
+		}
+		_ = c.ShouldBindJSON(&req) // body ไม่บังคับสำหรับ start/close
+		svc := services.NewWorkOrderService(config.DB)
+		adminID := c.GetUint("adminId")
+		var (
+			order interface{}
+			err   error
+		)
+		switch action {
+		case "start":
+			order, err = svc.Start(id, adminID)
+		case "hold":
+			order, err = svc.Hold(id, adminID, req.Note)
+		case "resolve":
+			order, err = svc.Resolve(id, adminID, req.Note)
+		case "close":
+			order, err = svc.Close(id, adminID)
+		case "reopen":
+			order, err = svc.Reopen(id, adminID, req.Note)
+		case "cancel":
+			order, err = svc.Cancel(id, adminID, req.Note)
+		}
+		if err != nil {
+			workOrderError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "success", "data": order})
+	}
+}
+
+var (
+	StartWorkOrder   = workOrderTransition("start")
+	HoldWorkOrder    = workOrderTransition("hold")
+	ResolveWorkOrder = workOrderTransition("resolve")
+	CloseWorkOrder   = workOrderTransition("close")
+	ReopenWorkOrder  = workOrderTransition("reopen")
+	CancelWorkOrder  = workOrderTransition("cancel")
+)
@@ -6,15 +6,18 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
+	"gorm.io/gorm"
 
 	"hotel-backend/config"
 	"hotel-backend/controllers"
 	"hotel-backend/routes"
 	"hotel-backend/services"
+	"hotel-backend/utils"
 )
 
 func main() {
@@ -30,6 +33,14 @@ func main() {
 	}
 	log.Println("✅ AIGEN_API_KEY detected.")
 
+	// hash chain ของ consent log ต้องมี key ลับ — production (GIN_MODE=release) ไม่ยอมเริ่มถ้าไม่ได้ตั้ง
+	if !services.ConsentAuditKeyConfigured() {
+		if os.Getenv("GIN_MODE") == "release" {
+			log.Fatal("❌ ERROR: CONSENT_AUDIT_KEY is not set. The consent log hash chain would use plain sha256 and could be rewritten.")
+		}
+		log.Println("⚠️⚠️ CONSENT_AUDIT_KEY not set; consent log hash chain uses plain sha256 (NOT tamper-evident). Set it before going to production.")
+	}
+
 	// Connect database (config.ConnectDatabase should set config.DB)
 	if err := config.ConnectDatabase(); err != nil {
 		log.Fatalf("❌ Database connect failed: %v", err)
@@ -40,20 +51,66 @@ func main() {
 	}
 	log.Println("✅ Database connection established and migrations applied (if configured).")
 
+	// ห้องเดิมก่อนแยก occupancy/condition — ตั้งค่าจาก status เดิม (ทำครั้งเดียว ห้องที่มีค่าแล้วข้าม)
+	if err := services.BackfillRoomStatus(db); err != nil {
+		log.Printf("⚠️ Room status backfill failed: %v", err)
+	}
+	// วันเกิดในคอลัมน์ date_of_birth เดิม -> date_of_birth_enc (ทำเสมอ แม้ยังไม่ตั้ง key เข้ารหัส)
+	if n, err := services.BackfillGuestDOB(db); err != nil {
+		log.Printf("⚠️ Guest date-of-birth backfill failed: %v", err)
+	} else if n > 0 {
+		log.Printf("guest date-of-birth backfill: %d guests", n)
+	}
+	// Room.Type (ชื่อ) ตรงกับ RoomTypeID — ห้องเก่าที่มีแต่ชื่อได้ FK
+	if err := services.ReconcileRoomTypes(db); err != nil {
+		log.Printf("⚠️ Room type reconcile failed: %v", err)
+	}
+
+	// Storage for uploaded images (local UPLOADS_DIR or S3-compatible)
+	blobStore, err := services.NewBlobStoreFromEnv()
+	if err != nil {
+		log.Fatalf("❌ Blob store init failed: %v", err)
+	}
+	services.SetDefaultBlobStore(blobStore)
+
 	// Initialize services
 	guestService := services.NewGuestService(db)
 	customerService := services.NewCustomerService(db)
 	bookingService := services.NewBookingService(db)
 	bookingInfoService := services.NewBookingInfoService(db)
+	guestReviewService := services.NewGuestReviewService(db)
+	documentAccessService := services.NewDocumentAccessService(db, blobStore)
+	retentionService := services.NewRetentionService(db, blobStore)
+	dataSubjectService := services.NewDataSubjectService(db, blobStore)
+
+	// อีเมลทุกฉบับเข้าคิว outbox แล้ว worker ส่งพร้อม retry
+	emailOutboxService := services.NewEmailOutboxService(db, blobStore)
+	utils.SetEmailEnqueuer(emailOutboxService.Enqueue)
+	// template อีเมลที่ admin แก้ไข + branding จาก hotel settings
+	utils.SetEmailTemplateStore(services.NewEmailTemplateService(db))
+	// SMS / LINE ถึงแขก (ไม่ได้ตั้ง env ของช่องทางไหน = ไม่ส่งช่องทางนั้น)
+	if sms := utils.NewSMSNotifierFromEnv(); sms != nil {
+		utils.SetNotifier(sms)
+	}
+	if line := utils.NewLineNotifierFromEnv(); line != nil {
+		utils.SetNotifier(line)
+	}
+	// domain events: อีเมล/webhook/audit/housekeeping ทำงานหลัง service commit
+	services.RegisterEventHandlers(services.DefaultEventBus(), db)
 
 	// Initialize controllers
 	guestController := controllers.NewGuestController(guestService)
 	customerController := controllers.NewCustomerController(customerService)
 	bookingController := controllers.NewBookingController(bookingService)
 	bookingInfoController := controllers.NewBookingInfoController(bookingInfoService)
+	guestReviewController := controllers.NewGuestReviewController(guestReviewService, documentAccessService)
+	documentController := controllers.NewDocumentController(documentAccessService)
+	retentionController := controllers.NewRetentionController(retentionService)
+	privacyController := controllers.NewPrivacyController(dataSubjectService)
+	emailOutboxController := controllers.NewEmailOutboxController(emailOutboxService)
 
 	// Build router
-	router := routes.SetupRouter(guestController, bookingController, bookingInfoController, customerController, apiKey)
+	router := routes.SetupRouter(guestController, bookingController, bookingInfoController, customerController, guestReviewController, documentController, retentionController, privacyController, emailOutboxController, apiKey)
 
 	// Port from env (prefer), fallback to 8080
 	port := os.Getenv("PORT")
@@ -84,6 +141,24 @@ func main() {
 
 	go startAutoCheckout(autoCtx, bookingService)
 
+	go startRetentionPurge(autoCtx, retentionService)
+
+	go startCheckinCampaign(autoCtx, services.NewCheckinCampaignService(db))
+
+	go startHousekeepingStayover(autoCtx, services.NewHousekeepingService(db))
+
+	go startRoomBlockSync(autoCtx, services.NewRoomBlockService(db))
+
+	go emailOutboxService.Run(autoCtx)
+
+	// outgoing webhooks (booking / check-in / checkout / guest events)
+	go services.NewWebhookService(db).Run(autoCtx)
+
+	// เข้ารหัสข้อมูลแขกเก่า / re-wrap ด้วย key ใหม่หลังหมุน key
+	if services.PIIEncryptionEnabled() {
+		go rotateGuestPII(autoCtx, db)
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server with timeout
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
@@ -91,6 +166,8 @@ func main() {
 	log.Println("⚠️  Shutdown signal received, shutting down server...")
 
 	autoCancel()
+	// ปิด SSE stream ที่ค้างอยู่ ไม่ให้ Shutdown รอจนหมดเวลา
+	services.DefaultLiveHub().Close()
 
 	// Create context with timeout for shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
@@ -102,6 +179,142 @@ func main() {
 	log.Println("✅ Server stopped gracefully")
 }
 
+func rotateGuestPII(ctx context.Context, db *gorm.DB) {
+	n, err := services.RotateGuestPII(ctx, db)
+	if err != nil {
+		log.Printf("guest PII rotation stopped: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("🔒 guest PII rotation: %d guests re-encrypted", n)
+	}
+}
+
+// startRetentionPurge ลบรูป/ข้อมูลแขกที่เกินระยะเก็บ (RETENTION_PURGE_INTERVAL_HOURS, ค่าเริ่มต้น 24)
+func startRetentionPurge(ctx context.Context, svc *services.RetentionService) {
+	hours, err := strconv.Atoi(os.Getenv("RETENTION_PURGE_INTERVAL_HOURS"))
+	if err != nil || hours <= 0 {
+		hours = 24
+	}
+	ticker := time.NewTicker(time.Duration(hours) * time.Hour)
+	defer ticker.Stop()
+
+	run := func(now time.Time) {
+		summary, err := svc.PurgeDue(ctx, now, "scheduled", nil)
+		if err != nil {
+			log.Printf("retention purge job failed: %v", err)
+		}
+		if summary.FaceImagesDeleted+summary.DocumentImagesDeleted+summary.GuestsAnonymised+summary.Failures > 0 {
+			log.Printf("retention purge: faces=%d documents=%d anonymised=%d failures=%d",
+				summary.FaceImagesDeleted, summary.DocumentImagesDeleted, summary.GuestsAnonymised, summary.Failures)
+		}
+	}
+
+	// run immediately
+	run(time.Now())
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("retention purge job stopped")
+			return
+		case now := <-ticker.C:
+			run(now)
+		}
+	}
+}
+
+// startCheckinCampaign ส่งคำเชิญ/เตือนเช็คอินออนไลน์ก่อนวันเข้าพัก (เปิดใน hotel settings: checkin_invite_enabled)
+func startCheckinCampaign(ctx context.Context, svc *services.CheckinCampaignService) {
+	ticker := time.NewTicker(15 * time.Minute)
+	defer ticker.Stop()
+
+	run := func(now time.Time) {
+		summary, err := svc.RunDue(ctx, now)
+		if err != nil {
+			log.Printf("checkin campaign job failed: %v", err)
+		}
+		if summary.Invitations+summary.Reminders+summary.Failures > 0 {
+			log.Printf("checkin campaign: invitations=%d reminders=%d skipped=%d failures=%d",
+				summary.Invitations, summary.Reminders, summary.Skipped, summary.Failures)
+		}
+	}
+
+	// run immediately
+	run(time.Now())
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("checkin campaign job stopped")
+			return
+		case now := <-ticker.C:
+			run(now)
+		}
+	}
+}
+
+func startHousekeepingStayover(ctx context.Context, svc *services.HousekeepingService) {
+	ticker := time.NewTicker(30 * time.Minute)
+	defer ticker.Stop()
+
+	// งานรายวันของห้องที่พักต่อ สร้างตั้งแต่ HOUSEKEEPING_STAYOVER_HOUR (ค่าเริ่มต้น 8 โมง ตามเวลาท้องถิ่นของโรงแรม)
+	hour, err := strconv.Atoi(os.Getenv("HOUSEKEEPING_STAYOVER_HOUR"))
+	if err != nil || hour < 0 || hour > 23 {
+		hour = 8
+	}
+	run := func(now time.Time) {
+		if now.In(services.HotelLocation(svc.DB)).Hour() < hour {
+			return
+		}
+		n, err := svc.CreateStayoverTasks(ctx, now)
+		if err != nil {
+			log.Printf("housekeeping stay-over job failed: %v", err)
+		}
+		if n > 0 {
+			log.Printf("housekeeping: %d stay-over tasks created", n)
+		}
+	}
+
+	// run immediately
+	run(time.Now())
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("housekeeping stay-over job stopped")
+			return
+		case now := <-ticker.C:
+			run(now)
+		}
+	}
+}
+
+func startRoomBlockSync(ctx context.Context, svc *services.RoomBlockService) {
+	ticker := time.NewTicker(15 * time.Minute)
+	defer ticker.Stop()
+
+	// เริ่ม/จบ block ซ่อม-งดขายตามวันที่
+	run := func(now time.Time) {
+		if err := svc.Sync(ctx, now); err != nil {
+			log.Printf("room block sync failed: %v", err)
+		}
+	}
+
+	// run immediately
+	run(time.Now())
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("room block sync job stopped")
+			return
+		case now := <-ticker.C:
+			run(now)
+		}
+	}
+}
+
 func startAutoCheckout(ctx context.Context, svc *services.BookingService) {
 	ticker := time.NewTicker(30 * time.Minute)
 	defer ticker.Stop()
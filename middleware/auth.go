@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"hotel-backend/config"
+	"hotel-backend/services"
+)
+
+// BearerToken อ่าน token จาก header "Authorization: Bearer <token>"
+func BearerToken(c *gin.Context) string {
+	parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+	if len(parts) == 2 && strings.EqualFold(parts[0], "Bearer") {
+		return strings.TrimSpace(parts[1])
+	}
+	return ""
+}
+
+// RequireAdmin ต้องมี session ของ admin ที่ login แล้ว -> ตั้ง "adminId" ใน context
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		admin, err := services.ResolveAdminSession(config.DB, BearerToken(c))
+		if err != nil {
+			if !strings.Contains(err.Error(), "missing_token") && !strings.Contains(err.Error(), "invalid_session") {
+				log.Printf("RequireAdmin: session lookup failed: %v", err)
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": gin.H{"code": "error.unauthorized", "message": "กรุณาเข้าสู่ระบบ"},
+			})
+			return
+		}
+		c.Set("adminId", admin.ID)
+		c.Next()
+	}
+}
+
+// RequirePermission ใช้ต่อจาก RequireAdmin
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminID := c.GetUint("adminId")
+		ok, err := services.AdminHasPermission(config.DB, adminID, permission)
+		if err != nil {
+			log.Printf("RequirePermission(%s): %v", permission, err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": gin.H{"code": "error.internal", "message": "เกิดข้อผิดพลาดภายในระบบ"},
+			})
+			return
+		}
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": gin.H{"code": "error.forbidden", "message": "ไม่มีสิทธิ์เข้าถึง", "permission": permission},
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -13,6 +13,7 @@ type Admin struct {
 	Password  string         `gorm:"size:255" json:"-"` // store hashed password, never return in JSON
 	ResetToken        *string    `gorm:"size:128;index" json:"-"`
 	ResetTokenExpires *time.Time `json:"-"`
+	Locale    string         `gorm:"size:10" json:"locale"` // ภาษาอีเมลถึง admin (en | th, ว่าง = ภาษาตั้งต้นของโรงแรม)
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// AdminSession: token ที่ออกให้ตอน login (เก็บเฉพาะ hash ของ token)
+type AdminSession struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	AdminID   uint      `gorm:"index;not null" json:"admin_id"`
+	TokenHash string    `gorm:"size:64;uniqueIndex" json:"-"`
+	ExpiresAt time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
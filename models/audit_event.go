@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// AuditEvent: domain event ที่เกิดขึ้นในระบบ (บันทึกโดย handler "audit" ของ event bus — append-only)
+type AuditEvent struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Event     string         `gorm:"size:40;index" json:"event"` // BookingCreated, CheckInCompleted, ...
+	BookingID *uint          `gorm:"index" json:"bookingId"`
+	GuestID   *uint          `gorm:"index" json:"guestId"`
+	Data      datatypes.JSON `json:"data"`
+	CreatedAt time.Time      `gorm:"index" json:"createdAt"`
+}
@@ -10,14 +10,25 @@ type BookingInfo struct {
 	gorm.Model
 
 	BookingID     uint       `gorm:"index" json:"bookingId"`
-	Token         string     `gorm:"uniqueIndex;size:128" json:"token"`
-	CheckinCode   string     `gorm:"uniqueIndex;size:16" json:"checkinCode"`
+	// token ลิงก์เช็คอิน / รหัสเช็คอิน เป็นความลับของแขก — ไม่ออกไปกับ JSON ของ struct
+	// (flow ของแขกส่งค่าเองแบบ explicit)
+	Token         string     `gorm:"uniqueIndex;size:128" json:"-"`
+	CheckinCode   string     `gorm:"uniqueIndex;size:16" json:"-"`
 	Status        string     `gorm:"default:INITIATED" json:"status"`
 	EmailStatus   string     `gorm:"default:PENDING" json:"emailStatus"`
 	EmailError    string     `json:"emailError"`
 	ExpiresAt     *time.Time `json:"expiresAt"`
 	CodeExpiresAt *time.Time `json:"codeExpiresAt"`
+	OpensAt       *time.Time `json:"opensAt"` // ก่อนเวลานี้ยังเช็คอินออนไลน์ไม่ได้ (nil = ได้ทันที)
 
 	GuestEmail    string `json:"guestEmail"`
 	GuestLastName string `json:"guestLastName"`
+
+	// ป้องกันการเดารหัสเช็คอิน: นับครั้งที่กรอกผิด และล็อกรหัสชั่วคราวเมื่อผิดเกินกำหนด
+	FailedAttempts int        `gorm:"default:0" json:"failedAttempts"`
+	LastFailedAt   *time.Time `json:"lastFailedAt"`
+	LastFailedIP   string     `gorm:"size:64" json:"lastFailedIp"`
+	LockedAt       *time.Time `json:"lockedAt"`
+	LockedUntil    *time.Time `json:"lockedUntil"`
+	LockCount      int        `gorm:"default:0" json:"lockCount"`
 }
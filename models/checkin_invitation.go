@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// CheckinInvitation: อีเมลเชิญเช็คอินออนไลน์ที่ระบบส่งอัตโนมัติก่อนวันเข้าพัก (หนึ่งแถวต่อ booking ต่อ Kind)
+type CheckinInvitation struct {
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	BookingID     uint   `gorm:"uniqueIndex:idx_checkin_invitation_kind" json:"bookingId"`
+	Kind          string `gorm:"size:20;uniqueIndex:idx_checkin_invitation_kind" json:"kind"`
+	BookingInfoID uint   `gorm:"index" json:"bookingInfoId"`
+
+	Recipient string     `gorm:"size:255" json:"recipient"`
+	Status    string     `gorm:"size:10;index" json:"status"` // SENT | FAILED | SKIPPED
+	Attempts  int        `gorm:"default:0" json:"attempts"`
+	LastError string     `gorm:"type:text" json:"lastError,omitempty"`
+	SentAt    *time.Time `json:"sentAt"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Kind ของ CheckinInvitation
+const (
+	CheckinInviteKindInvitation        = "invitation"       // N วันก่อนเข้าพัก
+	CheckinInviteKindReminderDayBefore = "reminder_d1"      // D-1 ถ้ายังไม่เช็คอินออนไลน์
+	CheckinInviteKindReminderArrival   = "reminder_arrival" // เช้าวันเข้าพัก
+)
+
+// Status ของ CheckinInvitation
+const (
+	CheckinInviteSending = "SENDING" // job ตัวหนึ่งจองแถวไว้และกำลังส่ง (กันหลาย replica ส่งซ้ำ)
+	CheckinInviteSent    = "SENT"
+	CheckinInviteFailed  = "FAILED"
+	CheckinInviteSkipped = "SKIPPED"
+)
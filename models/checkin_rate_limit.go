@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// CheckinRateLimit: ตัวนับ fixed window ของหน้าเช็คอินสาธารณะ (ต่อ IP / ต่อการส่งรหัสซ้ำ)
+// เก็บใน DB เพื่อให้ทุก replica ใช้ตัวนับเดียวกัน
+type CheckinRateLimit struct {
+	Key     string    `gorm:"column:limit_key;primaryKey;size:128" json:"key"`
+	Count   int       `gorm:"not null;default:0" json:"count"`
+	ResetAt time.Time `gorm:"index" json:"resetAt"`
+}
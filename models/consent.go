@@ -9,10 +9,35 @@ import (
 type Consent struct {
 	// ใช้ ID ในโค้ด แต่แมปไปยัง column: consent_id ใน DB
 	ID            uint           `gorm:"primaryKey;autoIncrement;column:consent_id" json:"id"`
-	Slug          string         `json:"slug"`
+	Slug          string         `gorm:"size:100;index" json:"slug"`
 	Title         string         `json:"title"`
 	Description   string         `json:"description"`
 	EffectiveFrom *time.Time     `json:"effective_from"`
 	Version       string         `json:"version"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+
+	// lifecycle: draft -> published -> retired (เวอร์ชันที่ publish แล้วแก้ไขไม่ได้)
+	Status      string     `gorm:"size:20;index" json:"status"`
+	Required    bool       `gorm:"default:true" json:"required"`
+	PublishedAt *time.Time `json:"published_at"`
+	ContentHash string     `gorm:"size:64" json:"content_hash"` // sha256 ของเนื้อหาทุกภาษา ณ ตอน publish
+	RetiredAt   *time.Time `json:"retired_at"`
+
+	// ภาษาของ Title/Description หลัก; ภาษาอื่นอยู่ใน Translations
+	BaseLocale   string               `gorm:"size:10;default:th" json:"base_locale"`
+	Translations []ConsentTranslation `gorm:"foreignKey:ConsentID;references:ID" json:"translations,omitempty"`
+
+	// ภาษาที่แสดง + hash ของข้อความที่แสดง (ไม่เก็บใน DB — client ส่งกลับมาตอนยอมรับ)
+	Locale   string `gorm:"-" json:"locale,omitempty"`
+	TextHash string `gorm:"-" json:"text_hash,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// สถานะของ Consent
+const (
+	ConsentStatusDraft     = "draft"
+	ConsentStatusPublished = "published"
+	ConsentStatusRetired   = "retired"
+)
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// ConsentChainHead: entry ล่าสุดของแต่ละ hash chain ใน consent_logs (ใช้ตรวจว่ามีการลบแถวท้าย chain)
+type ConsentChainHead struct {
+	ChainKey  string    `gorm:"primaryKey;size:100" json:"chain_key"`
+	Seq       uint      `json:"seq"`
+	Hash      string    `gorm:"size:64" json:"hash"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -17,6 +17,22 @@ type ConsentLog struct {
     Status       string         `gorm:"index" json:"status"`
     Action       string         `gorm:"index" json:"action"`
 
+    // ภาษาและ sha256 ของข้อความ consent ที่แขกเห็นตอนยอมรับ
+    Locale       string         `gorm:"size:10" json:"locale"`
+    TextHash     string         `gorm:"size:64" json:"text_hash"`
+
+    // 🔒 append-only: ห้ามแก้/ลบแถว — การเปลี่ยนแปลงทุกอย่างเป็นแถวใหม่ (ดู services/consent_log_service.go)
+    IP          string `gorm:"size:64" json:"ip"`
+    UserAgent   string `gorm:"size:255" json:"user_agent"`
+    VersionHash string `gorm:"size:64" json:"version_hash"` // Consent.ContentHash ของเวอร์ชันที่ยอมรับ
+    RefID       *uint  `gorm:"index" json:"ref_id"`          // แถวที่ถูกอ้างถึง (attached / withdrawn)
+
+    // hash chain ต่อ booking (หรือ guest/token ก่อนผูก booking)
+    ChainKey  string `gorm:"size:100;index" json:"chain_key"`
+    Seq       uint   `json:"seq"`
+    PrevHash  string `gorm:"size:64" json:"prev_hash"`
+    EntryHash string `gorm:"size:64;index" json:"entry_hash"`
+
     CreatedAt    time.Time
     UpdatedAt    time.Time
     DeletedAt    gorm.DeletedAt `gorm:"index"`
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ConsentReceipt: ใบยืนยันการให้ความยินยอม (PDF) ที่ออกให้แขกหลังเช็คอิน — หนึ่งแถวต่อการออกหนึ่งครั้ง
+type ConsentReceipt struct {
+	ID        uint `gorm:"primaryKey" json:"id"`
+	BookingID uint `gorm:"index" json:"bookingId"`
+
+	// key ใน BlobStore (ไฟล์ PDF เข้ารหัสแบบเดียวกับรูปเอกสารแขก)
+	StorageKey string `gorm:"size:255" json:"-"`
+
+	// sha256 ของไฟล์ PDF และลายเซ็น (HMAC ด้วย CONSENT_AUDIT_KEY) ของข้อมูลในใบ — พิมพ์อยู่ท้ายใบด้วย
+	SHA256    string `gorm:"size:64" json:"sha256"`
+	Signature string `gorm:"size:64;index" json:"signature"`
+
+	// จำนวน entry ของ consent log ที่อยู่ในใบ
+	Entries int `json:"entries"`
+
+	EmailedTo  string     `gorm:"size:150" json:"emailedTo"`
+	EmailedAt  *time.Time `json:"emailedAt"`
+	EmailError string     `gorm:"type:text" json:"emailError,omitempty"`
+
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+}
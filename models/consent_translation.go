@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ConsentTranslation: ข้อความของ consent หนึ่งเวอร์ชันในภาษาอื่น (ภาษาหลักอยู่ใน Consent.Title/Description)
+type ConsentTranslation struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ConsentID   uint      `gorm:"not null;uniqueIndex:idx_consent_locale" json:"consent_id"`
+	Locale      string    `gorm:"size:10;not null;uniqueIndex:idx_consent_locale" json:"locale"`
+	Title       string    `gorm:"size:255" json:"title"`
+	Description string    `gorm:"type:text" json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
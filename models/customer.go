@@ -15,6 +15,13 @@ type Customer struct {
 	ID       uint   `gorm:"primaryKey"`
 	FullName string `json:"fullName"`
 	Email    string `json:"email"`
+
+	// ช่องทางแจ้งเตือนนอกจากอีเมล (SMS / LINE) — NotifyChannels เช่น "sms,line" (ว่าง = อีเมลอย่างเดียว)
+	Phone          string `gorm:"size:32" json:"phone"`
+	LineUserID     string `gorm:"size:64" json:"lineUserId"`
+	NotifyChannels string `gorm:"size:64" json:"notifyChannels"`
+	// ภาษาอีเมลที่ลูกค้าเลือก (en | th) — ว่าง = ภาษาที่ใช้ตอนให้ consent หรือภาษาตั้งต้นของโรงแรม
+	Locale string `gorm:"size:10" json:"locale"`
 	// ...
 }
 
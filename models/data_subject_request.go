@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// DataSubjectRequest: คำขอของเจ้าของข้อมูลตาม PDPA (ถอนความยินยอม / ขอสำเนาข้อมูล / ขอลบข้อมูล)
+type DataSubjectRequest struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	Type   string `gorm:"size:20;index" json:"type"`   // withdraw_consent | access | erasure
+	Status string `gorm:"size:30;index" json:"status"` // pending_verification | open | completed | rejected
+	Source string `gorm:"size:10" json:"source"`       // guest | staff
+
+	// ตัวตนของเจ้าของข้อมูล: อีเมลที่ยืนยันแล้ว หรือ customer ที่พนักงานระบุ
+	Email      string `gorm:"size:150;index" json:"email"`
+	CustomerID *uint  `gorm:"index" json:"customerId"`
+
+	// withdraw_consent: consent ที่ถอน (nil = ทุกฉบับ)
+	ConsentID *uint `json:"consentId"`
+
+	// token ยืนยันอีเมล (เก็บเฉพาะ sha256)
+	VerifyTokenHash string     `gorm:"size:64;index" json:"-"`
+	VerifyExpiresAt *time.Time `json:"-"`
+	VerifiedAt      *time.Time `json:"verifiedAt"`
+
+	// กำหนดเวลาตอบคำขอ (นับจากยืนยันตัวตน)
+	DueAt       *time.Time `gorm:"index" json:"dueAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+	HandledBy   *uint      `json:"handledBy"`
+	RequestedBy *uint      `json:"requestedBy"` // admin ที่สร้างคำขอแทนแขก
+
+	Note         string `gorm:"type:text" json:"note"`
+	RejectReason string `gorm:"type:text" json:"rejectReason,omitempty"`
+
+	// access: ไฟล์ export ใน BlobStore + token ดาวน์โหลดของแขก (sha256)
+	ExportKey          string     `gorm:"size:255" json:"-"`
+	ExportTokenHash    string     `gorm:"size:64;index" json:"-"`
+	ExportExpiresAt    *time.Time `json:"exportExpiresAt,omitempty"`
+	ExportDownloadedAt *time.Time `json:"exportDownloadedAt,omitempty"`
+	Result             string     `gorm:"type:text" json:"result,omitempty"` // สรุปผลการดำเนินการ
+
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ประเภทคำขอ
+const (
+	DSRTypeWithdrawConsent = "withdraw_consent"
+	DSRTypeAccess          = "access"
+	DSRTypeErasure         = "erasure"
+)
+
+// สถานะคำขอ
+const (
+	DSRStatusPendingVerification = "pending_verification"
+	DSRStatusOpen                = "open"
+	DSRStatusCompleted           = "completed"
+	DSRStatusRejected            = "rejected"
+)
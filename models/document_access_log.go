@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// DocumentAccessLog: บันทึกทุกครั้งที่มีการออกลิงก์หรือเปิดดูรูปเอกสาร/ใบหน้าของแขก
+type DocumentAccessLog struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	GuestID   uint      `gorm:"index" json:"guest_id"`
+	AdminID   uint      `gorm:"index" json:"admin_id"`
+	Kind      string    `gorm:"size:20" json:"kind"`   // face | document
+	Action    string    `gorm:"size:20" json:"action"` // issue_url | view | denied
+	IP        string    `gorm:"size:64" json:"ip"`
+	UserAgent string    `gorm:"size:255" json:"user_agent"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// EmailEvent: event จาก delivery webhook ของ SendGrid / Resend (หนึ่งแถวต่อ event — provider ส่งซ้ำได้ จึง unique ที่ event id)
+type EmailEvent struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	Provider          string     `gorm:"size:20;not null;uniqueIndex:idx_email_event_provider_id" json:"provider"`
+	ProviderEventID   string     `gorm:"size:255;not null;uniqueIndex:idx_email_event_provider_id" json:"providerEventId"`
+	ProviderMessageID string     `gorm:"size:255;index" json:"providerMessageId,omitempty"`
+	OutboxID          *uint      `gorm:"index" json:"outboxId,omitempty"`
+	Event             string     `gorm:"size:20;index" json:"event"` // delivered | bounced | dropped | deferred | complained | opened | clicked
+	Recipient         string     `gorm:"size:255" json:"recipient"`
+	Detail            string     `gorm:"type:text" json:"detail,omitempty"` // เหตุผล bounce / URL ที่คลิก
+	OccurredAt        *time.Time `json:"occurredAt"`
+	CreatedAt         time.Time  `json:"createdAt"`
+}
+
+// EmailSuppression: ที่อยู่อีเมลที่ห้ามส่ง (hard bounce / แจ้ง spam / admin เพิ่มเอง) — outbox ข้ามผู้รับเหล่านี้
+type EmailSuppression struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Email     string    `gorm:"size:255;not null;uniqueIndex" json:"email"` // ตัวพิมพ์เล็ก
+	Reason    string    `gorm:"size:20" json:"reason"`                      // hard_bounce | complaint | manual
+	Provider  string    `gorm:"size:20" json:"provider,omitempty"`
+	Detail    string    `gorm:"type:text" json:"detail,omitempty"`
+	OutboxID  *uint     `json:"outboxId,omitempty"`
+	CreatedBy *uint     `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// EmailSuppression.Reason
+const (
+	EmailSuppressHardBounce = "hard_bounce"
+	EmailSuppressComplaint  = "complaint"
+	EmailSuppressManual     = "manual"
+)
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// EmailOutbox: อีเมลทุกฉบับเข้าคิวที่นี่ก่อน แล้ว worker ส่งพร้อม retry/backoff และสลับ provider เมื่อส่งไม่สำเร็จ
+type EmailOutbox struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Kind string `gorm:"size:40;index" json:"kind"`
+
+	ToAddresses string `gorm:"type:text" json:"to"` // คั่นด้วย ","
+	Subject     string `gorm:"size:500" json:"subject"`
+	HTMLBody    string `gorm:"type:mediumtext" json:"html,omitempty"`
+	TextBody    string `gorm:"type:mediumtext" json:"text,omitempty"`
+	FromEmail   string `gorm:"size:255" json:"fromEmail"`
+	FromName    string `gorm:"size:255" json:"fromName"`
+
+	// ไฟล์แนบเก็บใน BlobStore (เข้ารหัส) — [{filename, contentType, size, key}]
+	Attachments datatypes.JSON `json:"-"`
+
+	Status        string     `gorm:"size:12;index" json:"status"` // QUEUED | SENDING | SENT | FAILED | SUPPRESSED
+	Attempts      int        `gorm:"default:0" json:"attempts"`
+	MaxAttempts   int        `gorm:"default:6" json:"maxAttempts"`
+	NextAttemptAt *time.Time `gorm:"index" json:"nextAttemptAt"`
+	LastError     string     `gorm:"type:text" json:"lastError,omitempty"`
+	Provider      string     `gorm:"size:20" json:"provider,omitempty"` // provider ที่ส่งสำเร็จ
+	SentAt        *time.Time `json:"sentAt"`
+
+	// สิ่งที่อีเมลเกี่ยวข้อง เช่น "booking_info:12" (utils.BookingInfoEmailRef)
+	Ref string `gorm:"size:60;index" json:"ref,omitempty"`
+
+	// ผลจาก delivery webhook ของ provider — ดู services/email_delivery_service.go
+	ProviderMessageID string     `gorm:"size:255;index" json:"providerMessageId,omitempty"`
+	DeliveryStatus    string     `gorm:"size:20" json:"deliveryStatus,omitempty"` // DELIVERED | OPENED | CLICKED | BOUNCED | DROPPED | COMPLAINED
+	LastEventAt       *time.Time `json:"lastEventAt,omitempty"`
+
+	// ส่งซ้ำจากหน้า admin — ชี้ไปแถวต้นฉบับ
+	ResendOf    *uint `gorm:"index" json:"resendOf,omitempty"`
+	RequestedBy *uint `json:"requestedBy,omitempty"`
+
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// body มีลิงก์/รหัสลับ (reset password, คำเชิญ, ลิงก์เช็คอิน ...) — ไม่แสดงในหน้า admin
+	BodyRedacted bool `gorm:"-" json:"bodyRedacted,omitempty"`
+}
+
+// Status ของ EmailOutbox
+const (
+	EmailStatusQueued  = "QUEUED"
+	EmailStatusSending = "SENDING"
+	EmailStatusSent    = "SENT"
+	EmailStatusFailed  = "FAILED"
+	// ผู้รับทุกคนอยู่ใน EmailSuppression — ไม่ส่งและไม่ retry
+	EmailStatusSuppressed = "SUPPRESSED"
+)
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// EmailTemplate: template อีเมลที่ admin แก้ไข (ทับ template ตั้งต้นใน utils/email_templates) หนึ่งแถวต่อ kind ต่อภาษา
+type EmailTemplate struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	Kind   string `gorm:"size:40;not null;uniqueIndex:idx_email_template_kind_locale" json:"kind"`
+	Locale string `gorm:"size:10;not null;uniqueIndex:idx_email_template_kind_locale" json:"locale"`
+
+	// text/template (subject, text) และ html/template (html)
+	Subject  string `gorm:"size:500" json:"subject"`
+	HTMLBody string `gorm:"type:mediumtext" json:"html"`
+	TextBody string `gorm:"type:mediumtext" json:"text"`
+
+	UpdatedBy *uint     `json:"updatedBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -24,7 +24,11 @@ type Guest struct {
     FullName string `json:"fullName"`
 
     IsMainGuest bool       `json:"isMainGuest"`
-    DateOfBirth *time.Time `json:"dateOfBirth"`
+    DateOfBirth *time.Time `gorm:"-" json:"dateOfBirth"`
+
+    // 🔒 วันเกิดแบบเข้ารหัส (GuestService แปลงเป็น DateOfBirth ให้)
+    // คอลัมน์ date_of_birth เดิมถูกย้ายมาที่นี่โดย RotateGuestPII
+    DateOfBirthEnc string `gorm:"column:date_of_birth_enc;type:text" json:"-"`
 
     Gender         string `json:"gender"`
     Nationality    string `json:"nationality"`
@@ -34,9 +38,33 @@ type Guest struct {
     IDNumber        string `json:"idNumber"`
     IDIssuedCountry string `json:"idIssuedCountry"`
 
+    // 🔒 blind index (HMAC) ของ IDNumber สำหรับค้นหา — IDNumber ในตารางถูกเข้ารหัส
+    IDNumberIndex string `gorm:"size:64;index" json:"-"`
+
+    // 🔒 id ของ master key ที่ใช้เข้ารหัสแถวนี้ล่าสุด (ใช้ตอนหมุน key)
+    PIIKeyID string `gorm:"column:pii_key_id;size:64" json:"-"`
+
+    // 🔒 IDNumber, CurrentAddress และ path รูปเก็บแบบเข้ารหัส (ดู services/pii_crypto.go)
     FaceImagePath     string `json:"faceImagePath"`
     DocumentImagePath string `json:"documentImagePath"`
 
     // เพิ่มฟิลด์นี้เพื่อเก็บอีเมล
     Email string `json:"email"`
+
+    // 🔹 ผลการตรวจเอกสารยืนยันตัวตนโดยพนักงาน (manual review)
+    ReviewStatus        string     `gorm:"size:20;index" json:"reviewStatus"`
+    ReviewedBy          *uint      `gorm:"index" json:"reviewedBy"`
+    ReviewedAt          *time.Time `json:"reviewedAt"`
+    ReviewReason        string     `gorm:"type:text" json:"reviewReason"`
+    ReuploadRequestedAt *time.Time `json:"reuploadRequestedAt"`
+
+    // 🔹 ถูกลบข้อมูลระบุตัวตนตาม retention policy แล้ว
+    AnonymisedAt *time.Time `gorm:"index" json:"anonymisedAt"`
 }
+
+// สถานะการตรวจเอกสารของ Guest (ค่าว่าง = ข้อมูลเก่าก่อนมีระบบตรวจ)
+const (
+    GuestReviewPending  = "pending"
+    GuestReviewApproved = "approved"
+    GuestReviewRejected = "rejected"
+)
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// GuestSignature: ลายเซ็นของแขกหลักตอนเช็คอินออนไลน์ (ใช้พิมพ์ใบลงทะเบียนผู้เข้าพัก)
+type GuestSignature struct {
+	ID        uint `gorm:"primaryKey" json:"id"`
+	GuestID   uint `gorm:"index" json:"guestId"`
+	BookingID uint `gorm:"index" json:"bookingId"`
+
+	// image = รูป PNG/JPEG จาก signature pad, strokes = เส้นแบบ vector (JSON)
+	Kind string `gorm:"size:10" json:"kind"`
+
+	// 🔒 key ใน BlobStore (เข้ารหัสแบบเดียวกับ FaceImagePath) — ไฟล์ก็เข้ารหัส
+	StorageKey string `gorm:"type:text" json:"-"`
+
+	// sha256 ของข้อมูลลายเซ็นก่อนเข้ารหัส
+	SHA256 string `gorm:"size:64" json:"sha256"`
+
+	SignedAt  time.Time `json:"signedAt"`
+	IP        string    `gorm:"size:64" json:"ip"`
+	UserAgent string    `gorm:"size:255" json:"userAgent"`
+
+	// ลบไฟล์ตาม retention / คำขอลบข้อมูลแล้ว
+	PurgedAt *time.Time `json:"purgedAt"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Kind ของ GuestSignature
+const (
+	SignatureKindImage   = "image"
+	SignatureKindStrokes = "strokes"
+)
@@ -10,6 +10,35 @@ type HotelSetting struct {
 	Email     string    `gorm:"size:150" json:"email"`
 	Website   string    `gorm:"size:255" json:"website"`
 	Logo      string    `gorm:"size:255" json:"logo"`
+
+	// RequireIdentityApproval: ต้องให้พนักงานอนุมัติเอกสารของแขกทุกคนก่อนจบการเช็คอิน
+	RequireIdentityApproval bool `gorm:"default:false" json:"require_identity_approval"`
+
+	// RequireSignature: แขกหลักต้องเซ็นชื่อในหน้าเช็คอินออนไลน์ (ใช้พิมพ์ใบลงทะเบียนผู้เข้าพัก)
+	RequireSignature bool `gorm:"default:false" json:"require_signature"`
+
+	// ลิงก์/รหัสเช็คอินออนไลน์ — ดู services/checkin_policy.go
+	CheckinLinkOpenDaysBefore int  `gorm:"default:7" json:"checkin_link_open_days_before"` // ลิงก์ใช้ได้ตั้งแต่ N วันก่อนเข้าพัก (0 = ใช้ได้ทันที)
+	CheckinLinkNeverExpire    bool `gorm:"default:false" json:"checkin_link_never_expire"` // false = ลิงก์หมดอายุเมื่อถึงเวลา check-out
+	CheckinCodeValidityHours  int  `gorm:"default:168" json:"checkin_code_validity_hours"` // อายุรหัส 8 หลัก (0 = เท่าอายุลิงก์)
+	CheckinCodeResendMinutes  int  `gorm:"default:15" json:"checkin_code_resend_minutes"`  // ขอส่งรหัสซ้ำ = ต่ออายุรหัสอีก N นาที
+
+	// ส่งคำเชิญเช็คอินออนไลน์อัตโนมัติ — ดู services/checkin_campaign.go
+	CheckinInviteEnabled    bool `gorm:"default:false" json:"checkin_invite_enabled"`
+	CheckinInviteDaysBefore int  `gorm:"default:3" json:"checkin_invite_days_before"` // ส่งคำเชิญ N วันก่อนเข้าพัก
+	CheckinReminderHour     int  `gorm:"default:8" json:"checkin_reminder_hour"`      // ส่งเตือน D-1 และเช้าวันเข้าพักหลังเวลานี้ (0-23)
+
+	// timezone ของโรงแรม (IANA) — งานตามวัน/ชั่วโมง (คำเชิญเช็คอิน, งานแม่บ้านรายวัน) ใช้เวลาท้องถิ่นนี้
+	Timezone string `gorm:"size:64;default:Asia/Bangkok" json:"timezone"`
+
+	// ภาษาตั้งต้นของอีเมลถึงแขก/พนักงาน (en | th) — ดู utils/email_template.go
+	EmailLocale string `gorm:"size:10;default:en" json:"email_locale"`
+
+	// Retention (วันหลัง check-out, 0 = เก็บไว้ไม่ลบ) — ดู services/retention_service.go
+	RetentionFaceImageDays     int `gorm:"default:30" json:"retention_face_image_days"`
+	RetentionDocumentImageDays int `gorm:"default:90" json:"retention_document_image_days"`
+	RetentionGuestDataDays     int `gorm:"default:0" json:"retention_guest_data_days"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
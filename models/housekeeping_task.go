@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// HousekeepingTask: งานทำความสะอาดห้องหนึ่งงาน (สร้างอัตโนมัติตอนเช็คเอาท์ / รายวันสำหรับห้องที่ยังพักต่อ หรือหัวหน้าสร้างเอง)
+type HousekeepingTask struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	RoomID    uint      `gorm:"index" json:"roomId"`
+	BookingID *uint     `gorm:"index" json:"bookingId"`
+	Kind      string    `gorm:"size:16;index" json:"kind"`   // checkout | stayover
+	Status    string    `gorm:"size:16;index" json:"status"` // dirty | in_progress | clean | inspected
+	TaskDate  time.Time `gorm:"type:date;index" json:"taskDate"`
+
+	// กันสร้างงานอัตโนมัติซ้ำ: "checkout:<booking>:<room>" / "stayover:<room>:<date>" (งานที่สร้างเอง = nil)
+	DedupKey *string `gorm:"size:64;uniqueIndex" json:"-"`
+
+	AssignedTo *uint      `gorm:"index" json:"assignedTo"`
+	AssignedBy *uint      `json:"assignedBy,omitempty"`
+	AssignedAt *time.Time `json:"assignedAt"`
+
+	StartedAt   *time.Time `json:"startedAt"`
+	CleanedAt   *time.Time `json:"cleanedAt"`
+	CleanedBy   *uint      `json:"cleanedBy,omitempty"`
+	InspectedAt *time.Time `json:"inspectedAt"`
+	InspectedBy *uint      `json:"inspectedBy,omitempty"`
+
+	Notes          string `gorm:"type:text" json:"notes"`
+	InspectionNote string `gorm:"type:text" json:"inspectionNote"` // เหตุผลที่ตรวจไม่ผ่าน (งานกลับเป็น dirty)
+
+	CreatedBy *uint     `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Room Room `gorm:"foreignKey:RoomID" json:"room,omitempty"`
+}
+
+// Kind ของ HousekeepingTask
+const (
+	HousekeepingCheckout = "checkout" // ห้องว่างหลังเช็คเอาท์ — ต้องตรวจผ่านก่อนห้องกลับเป็น Available
+	HousekeepingStayover = "stayover" // ห้องที่แขกยังพักต่อ
+)
+
+// Status ของ HousekeepingTask
+const (
+	HousekeepingDirty      = "dirty"
+	HousekeepingInProgress = "in_progress"
+	HousekeepingClean      = "clean"
+	HousekeepingInspected  = "inspected"
+)
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// PurgeLog: หลักฐานการลบข้อมูลตาม retention policy (หนึ่งแถวต่อหนึ่ง object/guest)
+type PurgeLog struct {
+	ID        uint  `gorm:"primaryKey" json:"id"`
+	GuestID   uint  `gorm:"index" json:"guestId"`
+	BookingID *uint `gorm:"index" json:"bookingId"`
+
+	DataClass string `gorm:"size:30;index" json:"dataClass"` // face_image | document_image | guest_record
+	Action    string `gorm:"size:20" json:"action"`          // deleted | missing | anonymised | failed
+
+	// sha256 ของ key ใน storage (พิสูจน์ได้ว่าลบ object ไหน โดยไม่เก็บ path จริง)
+	ObjectRef string `gorm:"size:64" json:"objectRef"`
+
+	RetentionDays int        `json:"retentionDays"`
+	CheckedOutAt  *time.Time `json:"checkedOutAt"`
+
+	Trigger     string `gorm:"size:20" json:"trigger"` // scheduled | manual
+	TriggeredBy *uint  `json:"triggeredBy"`
+	Error       string `gorm:"type:text" json:"error,omitempty"`
+
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+}
+
+// DataClass ของ PurgeLog
+const (
+	PurgeClassFaceImage     = "face_image"
+	PurgeClassDocumentImage = "document_image"
+	PurgeClassGuestRecord   = "guest_record"
+	PurgeClassSignature     = "signature"
+)
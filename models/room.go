@@ -1,6 +1,8 @@
 package models
 
 import (
+	"strings"
+
 	"gorm.io/gorm"
 )
 
@@ -15,7 +17,11 @@ type Room struct {
 	AccessCode string `json:"accessCode" gorm:"column:access_code;type:varchar(6)"`
 
 	Type         string  `json:"type"`
-	Status       string  `json:"status"`
+	Status       string  `json:"status"` // สถานะแสดงผล คำนวณจาก Occupancy + Condition (RoomDisplayStatus) — ห้ามตั้งตรง
+
+	// สถานะห้อง: การเข้าพัก แยกจากสภาพห้อง (เปลี่ยนผ่าน services.ApplyRoomStatus เท่านั้น — มีประวัติ)
+	Occupancy string `json:"occupancy" gorm:"column:occupancy;type:varchar(16)"`      // vacant | occupied
+	Condition string `json:"condition" gorm:"column:room_condition;type:varchar(16)"` // clean | dirty | inspected | out_of_order | out_of_service
 	Floor        string  `json:"floor" gorm:"type:varchar(10)"`
 	Price        float64 `json:"price"`
 	MaxOccupancy int     `json:"maxOccupancy" gorm:"column:max_occupancy"`
@@ -23,3 +29,87 @@ type Room struct {
 
 	RoomType RoomType `gorm:"foreignKey:RoomTypeID"`
 }
+
+// Occupancy ของห้อง
+const (
+	RoomVacant   = "vacant"
+	RoomOccupied = "occupied"
+)
+
+// Condition ของห้อง
+const (
+	RoomClean        = "clean"          // ทำความสะอาดแล้ว รอตรวจ
+	RoomDirty        = "dirty"          // ต้องทำความสะอาด
+	RoomInspected    = "inspected"      // ตรวจแล้ว พร้อมขาย
+	RoomOutOfOrder   = "out_of_order"   // ซ่อม — ขายไม่ได้
+	RoomOutOfService = "out_of_service" // งดขายชั่วคราว (เช่นปรับปรุงเล็กน้อย) — ขายไม่ได้
+)
+
+// Status แสดงผล (ค่าเดิมที่หน้าเว็บใช้)
+const (
+	RoomStatusAvailable    = "Available"
+	RoomStatusOccupied     = "Occupied"
+	RoomStatusCleaning     = "Cleaning"
+	RoomStatusOutOfOrder   = "Out of Order"
+	RoomStatusOutOfService = "Out of Service"
+)
+
+// BeforeCreate: ห้องใหม่ที่ไม่ได้ระบุ occupancy/condition ใช้ค่าจาก Status เดิม (ค่าเริ่มต้น ว่าง + พร้อมขาย)
+func (r *Room) BeforeCreate(tx *gorm.DB) error {
+	if r.Occupancy == "" || r.Condition == "" {
+		occupancy, condition := RoomStateFromLegacyStatus(r.Status)
+		if r.Occupancy == "" {
+			r.Occupancy = occupancy
+		}
+		if r.Condition == "" {
+			r.Condition = condition
+		}
+	}
+	r.Status = RoomDisplayStatus(r.Occupancy, r.Condition)
+	return nil
+}
+
+// RoomStateFromLegacyStatus occupancy/condition ที่ตรงกับ Status แบบเดิม
+func RoomStateFromLegacyStatus(status string) (occupancy, condition string) {
+	occupancy, condition = RoomVacant, RoomInspected
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "occupied":
+		occupancy = RoomOccupied
+	case "cleaning", "dirty":
+		condition = RoomDirty
+	case "maintenance", "out of order", "out_of_order":
+		condition = RoomOutOfOrder
+	case "out of service", "out_of_service":
+		condition = RoomOutOfService
+	}
+	return occupancy, condition
+}
+
+// ValidRoomOccupancy / ValidRoomCondition ตรวจค่าที่รับจาก API
+func ValidRoomOccupancy(v string) bool {
+	return v == RoomVacant || v == RoomOccupied
+}
+
+func ValidRoomCondition(v string) bool {
+	switch v {
+	case RoomClean, RoomDirty, RoomInspected, RoomOutOfOrder, RoomOutOfService:
+		return true
+	}
+	return false
+}
+
+// RoomDisplayStatus สถานะแสดงผลจาก occupancy + condition
+func RoomDisplayStatus(occupancy, condition string) string {
+	switch {
+	case condition == RoomOutOfOrder:
+		return RoomStatusOutOfOrder
+	case condition == RoomOutOfService:
+		return RoomStatusOutOfService
+	case occupancy == RoomOccupied:
+		return RoomStatusOccupied
+	case condition == RoomInspected:
+		return RoomStatusAvailable
+	default:
+		return RoomStatusCleaning
+	}
+}
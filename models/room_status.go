@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// RoomStatusHistory: การเปลี่ยนสถานะห้องทุกครั้ง (append-only)
+type RoomStatusHistory struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	RoomID        uint      `gorm:"index" json:"roomId"`
+	FromOccupancy string    `gorm:"size:16" json:"fromOccupancy"`
+	ToOccupancy   string    `gorm:"size:16" json:"toOccupancy"`
+	FromCondition string    `gorm:"size:16" json:"fromCondition"`
+	ToCondition   string    `gorm:"size:16" json:"toCondition"`
+	FromStatus    string    `gorm:"size:32" json:"fromStatus"`
+	ToStatus      string    `gorm:"size:32" json:"toStatus"`
+	Source        string    `gorm:"size:16;index" json:"source"` // manual | checkin | checkout | housekeeping | maintenance | migration
+	Reason        string    `gorm:"size:500" json:"reason"`
+	ChangedBy     *uint     `gorm:"index" json:"changedBy"` // admin (nil = ระบบ)
+	CreatedAt     time.Time `gorm:"index" json:"createdAt"`
+}
+
+// RoomBlock: ช่วงวันที่ห้องไม่พร้อมขาย (ซ่อม / งดขาย) — ห้องถูกตัดออกจากการจองในช่วงนี้
+// StartDate ถึงก่อน EndDate (EndDate = วันแรกที่ห้องกลับมาขายได้ เหมือน check-out)
+type RoomBlock struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	RoomID    uint      `gorm:"index" json:"roomId"`
+	Kind      string    `gorm:"size:16" json:"kind"` // out_of_order | out_of_service
+	StartDate time.Time `gorm:"type:date;index" json:"startDate"`
+	EndDate   time.Time `gorm:"type:date;index" json:"endDate"`
+	Reason    string    `gorm:"size:500" json:"reason"`
+
+	// ห้องถูกตั้งเป็น Kind แล้ว (block เริ่ม) / คืนสภาพห้องแล้ว (block จบหรือถูกยกเลิก)
+	AppliedAt *time.Time `json:"appliedAt"`
+	EndedAt   *time.Time `json:"endedAt"`
+
+	ReleasedAt *time.Time `json:"releasedAt"` // ยกเลิกก่อนกำหนด
+	ReleasedBy *uint      `json:"releasedBy,omitempty"`
+	CreatedBy  *uint      `json:"createdBy,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+
+	Room Room `gorm:"foreignKey:RoomID" json:"room,omitempty"`
+}
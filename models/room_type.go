@@ -1,8 +1,10 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -15,9 +17,53 @@ type RoomType struct {
 	Description string `json:"description"`
 	MaxGuests   uint   `json:"max_guests"`
 
+	// จำนวนผู้เข้าพักที่รวมในราคาห้อง (ไม่เกิน MaxGuests — ส่วนเกินคือ extra guest)
+	BaseOccupancy uint `json:"baseOccupancy" gorm:"column:base_occupancy"`
+
+	Amenities        datatypes.JSON `json:"amenities" gorm:"type:json"`        // ["wifi","bathtub",...]
+	BedConfiguration datatypes.JSON `json:"bedConfiguration" gorm:"type:json"` // [{"type":"king","count":1}]
+
 	CreatedAt time.Time
+	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 
+	Photos []RoomTypePhoto `gorm:"foreignKey:RoomTypeID" json:"photos,omitempty"`
+
+	RoomCount int64 `gorm:"-" json:"roomCount"` // จำนวนห้องที่ใช้ประเภทนี้ (เติมโดย RoomTypeService)
+
 	// One-To-Many Relation: RoomType -> Rooms
-	// Rooms       []Room         `gorm:"foreignKey:RoomTypeID"`
+	// Rooms       []Room         `gorm:"foreignKey:RoomTypeID"`
+}
+
+// RoomTypePhoto: รูปในแกลเลอรีของประเภทห้อง (รูปสาธารณะ — เก็บใน BlobStore แบบไม่เข้ารหัสเหมือนโลโก้)
+type RoomTypePhoto struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	RoomTypeID uint      `gorm:"index" json:"roomTypeId"`
+	StorageKey string    `gorm:"size:255" json:"-"`
+	Caption    string    `gorm:"size:200" json:"caption"`
+	SortOrder  int       `json:"sortOrder"`
+	CreatedAt  time.Time `json:"createdAt"`
+
+	URL string `gorm:"-" json:"url"`
+}
+
+// AfterFind: URL สาธารณะของรูป (GET /api/room-types/photos/:id)
+func (p *RoomTypePhoto) AfterFind(tx *gorm.DB) error {
+	p.URL = fmt.Sprintf("/api/room-types/photos/%d", p.ID)
+	return nil
+}
+
+// BedConfig: เตียงหนึ่งชนิดในห้อง
+type BedConfig struct {
+	Type  string `json:"type"` // single | twin | double | queen | king | sofa_bed | bunk
+	Count int    `json:"count"`
+}
+
+// ValidBedType ตรวจชนิดเตียง
+func ValidBedType(v string) bool {
+	switch v {
+	case "single", "twin", "double", "queen", "king", "sofa_bed", "bunk":
+		return true
+	}
+	return false
 }
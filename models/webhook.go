@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// WebhookEndpoint: ระบบภายนอก (ประตู/บัญชี/BI) ที่ลงทะเบียนรับ event ของโรงแรม
+type WebhookEndpoint struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Name        string         `gorm:"size:100" json:"name"`
+	URL         string         `gorm:"size:500" json:"url"`
+	Description string         `gorm:"size:500" json:"description"`
+	Events      datatypes.JSON `json:"events"` // ["booking.created", ...] หรือ ["*"]
+	Active      bool           `gorm:"not null" json:"active"`
+
+	// secret สำหรับ HMAC (เข้ารหัสแบบ PII) — แสดงให้ admin ครั้งเดียวตอนสร้าง/หมุน
+	Secret string `gorm:"type:text" json:"-"`
+
+	CreatedBy *uint          `json:"createdBy,omitempty"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// WebhookDelivery: การส่ง event หนึ่งครั้งไปยัง endpoint หนึ่ง (คิว + log ผลการส่ง)
+type WebhookDelivery struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	EndpointID uint   `gorm:"index" json:"endpointId"`
+	EventID    string `gorm:"size:40;index" json:"eventId"` // เหมือนกันทุก endpoint และทุกการ replay ของ event เดียวกัน
+	Event      string `gorm:"size:40;index" json:"event"`
+	Payload    string `gorm:"type:mediumtext" json:"payload,omitempty"`
+
+	Status        string     `gorm:"size:12;index" json:"status"` // PENDING | SENDING | SUCCEEDED | FAILED
+	Attempts      int        `gorm:"default:0" json:"attempts"`
+	MaxAttempts   int        `gorm:"default:8" json:"maxAttempts"`
+	NextAttemptAt *time.Time `gorm:"index" json:"nextAttemptAt"`
+
+	// ผลของความพยายามล่าสุด
+	ResponseCode int        `json:"responseCode"`
+	ResponseBody string     `gorm:"type:text" json:"responseBody,omitempty"` // ตัดเหลือ 2KB
+	DurationMs   int        `json:"durationMs"`
+	LastError    string     `gorm:"type:text" json:"lastError,omitempty"`
+	DeliveredAt  *time.Time `json:"deliveredAt"`
+
+	// replay จากหน้า admin — ชี้ไปแถวต้นฉบับ
+	ReplayOf    *uint `gorm:"index" json:"replayOf,omitempty"`
+	RequestedBy *uint `json:"requestedBy,omitempty"`
+
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Status ของ WebhookDelivery
+const (
+	WebhookDeliveryPending   = "PENDING"
+	WebhookDeliverySending   = "SENDING"
+	WebhookDeliverySucceeded = "SUCCEEDED"
+	WebhookDeliveryFailed    = "FAILED"
+)
@@ -0,0 +1,93 @@
+package models
+
+import "time"
+
+// WorkOrder: งานซ่อมบำรุงของห้อง (แอร์เสีย ก๊อกน้ำรั่ว ...) แทนใบงานกระดาษ
+type WorkOrder struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	RoomID      uint   `gorm:"index" json:"roomId"`
+	Category    string `gorm:"size:16;index" json:"category"` // hvac | plumbing | electrical | furniture | appliance | other
+	Priority    string `gorm:"size:16;index" json:"priority"` // low | normal | high | urgent
+	Title       string `gorm:"size:200" json:"title"`
+	Description string `gorm:"type:text" json:"description"`
+	Status      string `gorm:"size:16;index" json:"status"` // open | in_progress | on_hold | resolved | closed | cancelled
+
+	AssignedTo *uint      `gorm:"index" json:"assignedTo"`
+	AssignedBy *uint      `json:"assignedBy,omitempty"`
+	AssignedAt *time.Time `json:"assignedAt"`
+
+	// ห้องปิดซ่อม (out_of_order) ระหว่างซ่อม — block ถูกยกเลิกเมื่องาน resolved/cancelled
+	RoomBlockID *uint `gorm:"index" json:"roomBlockId"`
+
+	StartedAt      *time.Time `json:"startedAt"`
+	ResolvedAt     *time.Time `json:"resolvedAt"`
+	ResolvedBy     *uint      `json:"resolvedBy,omitempty"`
+	ResolutionNote string     `gorm:"type:text" json:"resolutionNote"`
+	ClosedAt       *time.Time `json:"closedAt"`
+	StatusNote     string     `gorm:"size:500" json:"statusNote"` // เหตุผลล่าสุด (รออะไหล่ / ยกเลิก / เปิดใหม่)
+
+	ReportedBy *uint     `json:"reportedBy,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+
+	Room      Room             `gorm:"foreignKey:RoomID" json:"room,omitempty"`
+	RoomBlock *RoomBlock       `gorm:"foreignKey:RoomBlockID" json:"roomBlock,omitempty"`
+	Photos    []WorkOrderPhoto `gorm:"foreignKey:WorkOrderID" json:"photos,omitempty"`
+}
+
+// WorkOrderPhoto: รูปประกอบงานซ่อม (เก็บแบบเข้ารหัสเหมือนรูปของแขก — เปิดผ่าน signed URL)
+type WorkOrderPhoto struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	WorkOrderID uint      `gorm:"index" json:"workOrderId"`
+	StorageKey  string    `gorm:"size:512" json:"-"` // key ใน BlobStore (เข้ารหัส)
+	UploadedBy  *uint     `json:"uploadedBy,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+
+	URL string `gorm:"-" json:"url,omitempty"`
+}
+
+// Category ของ WorkOrder
+const (
+	WorkOrderHVAC       = "hvac"
+	WorkOrderPlumbing   = "plumbing"
+	WorkOrderElectrical = "electrical"
+	WorkOrderFurniture  = "furniture"
+	WorkOrderAppliance  = "appliance"
+	WorkOrderOther      = "other"
+)
+
+// Priority ของ WorkOrder
+const (
+	WorkOrderLow    = "low"
+	WorkOrderNormal = "normal"
+	WorkOrderHigh   = "high"
+	WorkOrderUrgent = "urgent"
+)
+
+// Status ของ WorkOrder
+const (
+	WorkOrderOpen       = "open"
+	WorkOrderInProgress = "in_progress"
+	WorkOrderOnHold     = "on_hold"
+	WorkOrderResolved   = "resolved"
+	WorkOrderClosed     = "closed"
+	WorkOrderCancelled  = "cancelled"
+)
+
+// ValidWorkOrderCategory ตรวจ category
+func ValidWorkOrderCategory(v string) bool {
+	switch v {
+	case WorkOrderHVAC, WorkOrderPlumbing, WorkOrderElectrical, WorkOrderFurniture, WorkOrderAppliance, WorkOrderOther:
+		return true
+	}
+	return false
+}
+
+// ValidWorkOrderPriority ตรวจ priority
+func ValidWorkOrderPriority(v string) bool {
+	switch v {
+	case WorkOrderLow, WorkOrderNormal, WorkOrderHigh, WorkOrderUrgent:
+		return true
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 ﻿package routes
 
 import (
+	"log"
 	"net/http"
 	"os"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"hotel-backend/controllers"
+	"hotel-backend/middleware"
 )
 
 func parseCorsOrigins() []string {
@@ -32,12 +34,30 @@ func parseCorsOrigins() []string {
 	return origins
 }
 
-func resolveUploadsDir() string {
-	dir := strings.TrimSpace(os.Getenv("UPLOADS_DIR"))
-	if dir == "" {
-		return "./uploads"
+// configureClientIP: ClientIP() เชื่อ X-Forwarded-For เฉพาะจาก proxy ใน TRUSTED_PROXIES (คั่นด้วย ,)
+// ไม่ตั้ง = ไม่เชื่อ header ใด ๆ ใช้ IP ของ connection — ตัวจำกัดความถี่ต่อ IP จึงปลอม header หลบไม่ได้
+// TRUSTED_PLATFORM: cloudflare | google หรือชื่อ header ที่ load balancer ตั้งให้ (เช่น X-Real-IP)
+func configureClientIP(r *gin.Engine) error {
+	var proxies []string
+	for _, part := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p := strings.TrimSpace(part); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	if err := r.SetTrustedProxies(proxies); err != nil {
+		return err
+	}
+
+	switch platform := strings.TrimSpace(os.Getenv("TRUSTED_PLATFORM")); strings.ToLower(platform) {
+	case "":
+	case "cloudflare":
+		r.TrustedPlatform = gin.PlatformCloudflare
+	case "google":
+		r.TrustedPlatform = gin.PlatformGoogleAppEngine
+	default:
+		r.TrustedPlatform = platform
 	}
-	return dir
+	return nil
 }
 
 // SetupRouter รับ Controller Instances เข้ามาเพื่อกำหนด Route
@@ -46,10 +66,18 @@ func SetupRouter(
 	bc *controllers.BookingController,
 	bic *controllers.BookingInfoController,
 	ctc *controllers.CustomerController,
+	grc *controllers.GuestReviewController,
+	dc *controllers.DocumentController,
+	rc *controllers.RetentionController,
+	pc *controllers.PrivacyController,
+	eoc *controllers.EmailOutboxController,
 	apiKey string,
 ) *gin.Engine {
+	// ไม่เปิด /uploads เป็น static แล้ว — รูปเอกสาร/ใบหน้าเข้าถึงผ่าน signed URL เท่านั้น
 	r := gin.Default()
-	r.Static("/uploads", resolveUploadsDir())
+	if err := configureClientIP(r); err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	origins := parseCorsOrigins()
 	allowCredentials := true
@@ -77,22 +105,42 @@ func SetupRouter(
 	{
 		guests := api.Group("/guests")
 		{
-			guests.GET("", gc.GetGuests)
+			// ข้อมูลแขกถอดรหัสแล้ว — admin ที่มีสิทธิ์เท่านั้น
+			guests.GET("", middleware.RequireAdmin(), middleware.RequirePermission("customerList.view"), gc.GetGuests)
 
 			// ? ต้องอยู่ก่อน /:id
-			guests.GET("/all", gc.GetAllGuests)
+			guests.GET("/all", middleware.RequireAdmin(), middleware.RequirePermission("customerList.view"), gc.GetAllGuests)
+			guests.GET("/search", middleware.RequireAdmin(), middleware.RequirePermission("customerList.view"), gc.SearchGuestsByIDNumber)
 
 			// ? รับเฉพาะตัวเลข ป้องกัน all/xyz ไปชน handler นี้
-			guests.GET("/:id", gc.GetGuestByID)
+			guests.GET("/:id", middleware.RequireAdmin(), middleware.RequirePermission("customerList.view"), gc.GetGuestByID)
 			guests.POST("", gc.CreateGuest)
-			guests.PUT("/:id", gc.UpdateGuest)
-			guests.DELETE("/:id", gc.DeleteGuest)
+			guests.PUT("/:id", middleware.RequireAdmin(), middleware.RequirePermission("customerList.edit"), gc.UpdateGuest)
+			guests.DELETE("/:id", middleware.RequireAdmin(), middleware.RequirePermission("customerList.delete"), gc.DeleteGuest)
+
+			// รูปเอกสาร/ใบหน้า: ออก signed URL อายุสั้น + ประวัติการเข้าถึง
+			guests.POST("/:id/document-links", middleware.RequireAdmin(), middleware.RequirePermission("guestDocuments.view"), dc.IssueLinks)
+			guests.GET("/:id/document-access-logs", middleware.RequireAdmin(), middleware.RequirePermission("auditLogs.view"), dc.GetAccessLogs)
+		}
+
+		// เปิดไฟล์รูปจาก signed URL (ตรวจลายเซ็นแทน Authorization header)
+		api.GET("/documents/guests/:id/:kind", dc.Serve)
+
+		// คิวตรวจเอกสารยืนยันตัวตน (reception)
+		guestReviews := api.Group("/guest-reviews", middleware.RequireAdmin(), middleware.RequirePermission("guestDocuments.review"))
+		{
+			guestReviews.GET("", grc.ListQueue)
+			guestReviews.GET("/:id", grc.GetReview)
+			guestReviews.POST("/:id/approve", grc.Approve)
+			guestReviews.POST("/:id/reject", grc.Reject)
 		}
 
 		// Customers
 		customersRoutes := api.Group("/customers")
 		{
 			customersRoutes.POST("", ctc.CreateCustomer)
+			customersRoutes.GET("/:id/notifications", middleware.RequireAdmin(), middleware.RequirePermission("customerList.view"), ctc.GetNotifyPreferences)
+			customersRoutes.PUT("/:id/notifications", middleware.RequireAdmin(), middleware.RequirePermission("customerList.edit"), ctc.UpdateNotifyPreferences)
 		}
 
 		// Bookings
@@ -107,70 +155,221 @@ func SetupRouter(
 
 			bookings.DELETE("/:id", bc.DeleteBooking)
 			bookings.POST("/:id/checkout", bc.CheckoutBooking)
-			bookings.GET("/:id/guests", gc.GetGuestsByBookingID)
+			bookings.GET("/:id/guests", middleware.RequireAdmin(), middleware.RequirePermission("customerList.view"), gc.GetGuestsByBookingID)
+
+			// ใบลงทะเบียนผู้เข้าพัก (PDF) พร้อมลายเซ็นที่เก็บตอนเช็คอินออนไลน์
+			bookings.GET("/:id/registration-card", middleware.RequireAdmin(), middleware.RequirePermission("guestDocuments.view"), dc.RegistrationCard)
+
+			// ใบยืนยันการให้ความยินยอม (PDF) ที่ส่งให้แขกหลังเช็คอิน
+			bookings.GET("/:id/consent-receipts", middleware.RequireAdmin(), middleware.RequirePermission("bookingManagement.view"), controllers.ListConsentReceipts)
+			bookings.GET("/:id/consent-receipt", middleware.RequireAdmin(), middleware.RequirePermission("bookingManagement.view"), controllers.DownloadConsentReceipt)
+			bookings.POST("/:id/consent-receipt", middleware.RequireAdmin(), middleware.RequirePermission("bookingManagement.edit"), controllers.IssueConsentReceipt)
+			bookings.POST("/:id/consent-receipt/resend", middleware.RequireAdmin(), middleware.RequirePermission("bookingManagement.edit"), controllers.ResendConsentReceipt)
+			bookings.GET("/:id/checkin-invitations", middleware.RequireAdmin(), middleware.RequirePermission("bookingManagement.view"), controllers.ListCheckinInvitations)
 		}
 
-		infoRoutes := api.Group("/booking-info")
+		infoRoutes := api.Group("/booking-info", middleware.RequireAdmin())
 		{
-			infoRoutes.POST("", bic.SaveBookingInfo)
-			infoRoutes.GET("/:id", bic.GetBookingInfoByID)
+			infoRoutes.POST("", middleware.RequirePermission("bookingManagement.edit"), bic.SaveBookingInfo)
+			infoRoutes.GET("/:id", middleware.RequirePermission("bookingManagement.view"), bic.GetBookingInfoByID)
 			infoRoutes.DELETE("/:id", bic.DeleteBookingInfo)
 		}
 		consents := api.Group("/consents")
 		{
 			consents.GET("", controllers.GetConsents)
-			consents.POST("", controllers.CreateConsent)
+			consents.POST("", middleware.RequireAdmin(), middleware.RequirePermission("consentManagement.edit"), controllers.CreateConsent)
 			consents.POST("/accept", controllers.AcceptConsent) //  อันใหม่
-			consents.DELETE("/:id", controllers.DeleteConsent)
+			consents.DELETE("/:id", middleware.RequireAdmin(), middleware.RequirePermission("consentManagement.edit"), controllers.DeleteConsent)
+			// วงจรเวอร์ชัน: แก้ draft / publish / retire
+			consents.PUT("/:id", middleware.RequireAdmin(), middleware.RequirePermission("consentManagement.edit"), controllers.UpdateConsent)
+			consents.PUT("/:id/translations/:locale", middleware.RequireAdmin(), middleware.RequirePermission("consentManagement.edit"), controllers.SetConsentTranslation)
+			consents.DELETE("/:id/translations/:locale", middleware.RequireAdmin(), middleware.RequirePermission("consentManagement.edit"), controllers.DeleteConsentTranslation)
+			consents.POST("/:id/publish", middleware.RequireAdmin(), middleware.RequirePermission("consentManagement.publish"), controllers.PublishConsent)
+			consents.POST("/:id/retire", middleware.RequireAdmin(), middleware.RequirePermission("consentManagement.publish"), controllers.RetireConsent)
 		}
 		consentLogs := api.Group("/consent-logs")
 		{
-			consentLogs.GET("", controllers.GetConsentLogs)
+			consentLogs.GET("", middleware.RequireAdmin(), middleware.RequirePermission("consentManagement.view"), controllers.GetConsentLogs)
+			consentLogs.GET("/verify", middleware.RequireAdmin(), middleware.RequirePermission("auditLogs.view"), controllers.VerifyConsentLogs)
+			// แขกบันทึกได้เฉพาะผ่าน token ลิงก์เช็คอิน (admin ที่ login แล้วบันทึกแทนได้)
 			consentLogs.POST("", controllers.CreateConsentLog)
-			consentLogs.DELETE("/:id", controllers.DeleteConsentLog)
-			consentLogs.PATCH("/attach-booking", controllers.AttachBookingToPending)
+			consentLogs.DELETE("/:id", middleware.RequireAdmin(), middleware.RequirePermission("consentManagement.edit"), controllers.DeleteConsentLog)
+			consentLogs.PATCH("/attach-booking", middleware.RequireAdmin(), middleware.RequirePermission("consentManagement.edit"), controllers.AttachBookingToPending)
 		}
 
-		roles := api.Group("/roles")
+		roles := api.Group("/roles", middleware.RequireAdmin())
 		{
-			roles.GET("", controllers.GetRoles)
-			roles.PUT("/:id/permissions", controllers.UpdateRolePermissions)
+			roles.GET("", middleware.RequirePermission("rolesAndPermissions.view"), controllers.GetRoles)
+			roles.PUT("/:id/permissions", middleware.RequirePermission("rolesAndPermissions.edit"), controllers.UpdateRolePermissions)
 		}
 
 		settings := api.Group("/settings")
 		{
 			settings.GET("/hotel", controllers.GetHotelSettings)
-			settings.PUT("/hotel", controllers.UpdateHotelSettings)
+			settings.GET("/hotel/logo", controllers.GetHotelLogo)
+			settings.PUT("/hotel", middleware.RequireAdmin(), middleware.RequirePermission("hotelSettings.edit"), controllers.UpdateHotelSettings)
+		}
+
+		// Retention policy + ประวัติการลบข้อมูลแขก
+		retention := api.Group("/retention", middleware.RequireAdmin())
+		{
+			retention.GET("/policy", middleware.RequirePermission("dataRetention.view"), rc.GetPolicy)
+			retention.GET("/purge-logs", middleware.RequirePermission("dataRetention.view"), rc.ListPurgeLogs)
+			retention.POST("/purge", middleware.RequirePermission("dataRetention.run"), rc.RunPurge)
+		}
+
+		// คำขอของเจ้าของข้อมูล (PDPA): แขกยื่นผ่านอีเมล / พนักงานจัดการ
+		privacy := api.Group("/privacy")
+		{
+			privacy.POST("/requests", pc.Submit)
+			privacy.POST("/requests/verify", pc.Verify)
+			privacy.GET("/exports", pc.DownloadExport)
+		}
+		dsr := api.Group("/data-subject-requests", middleware.RequireAdmin())
+		{
+			dsr.GET("", middleware.RequirePermission("privacyRequests.view"), pc.List)
+			dsr.GET("/:id", middleware.RequirePermission("privacyRequests.view"), pc.Get)
+			dsr.POST("", middleware.RequirePermission("privacyRequests.manage"), pc.Create)
+			dsr.GET("/:id/export", middleware.RequirePermission("privacyRequests.manage"), pc.Export)
+			dsr.POST("/:id/fulfil", middleware.RequirePermission("privacyRequests.manage"), pc.Fulfil)
+			dsr.POST("/:id/reject", middleware.RequirePermission("privacyRequests.manage"), pc.Reject)
+		}
+
+		// คิวอีเมลขาออก: สถานะการส่ง / ส่งซ้ำ
+		emailOutbox := api.Group("/email-outbox", middleware.RequireAdmin())
+		{
+			emailOutbox.GET("", middleware.RequirePermission("emailManagement.view"), eoc.List)
+			emailOutbox.GET("/:id", middleware.RequirePermission("emailManagement.view"), eoc.Get)
+			emailOutbox.POST("/:id/resend", middleware.RequirePermission("emailManagement.send"), eoc.Resend)
+		}
+
+		// ที่อยู่ที่ห้ามส่ง (hard bounce / แจ้ง spam จาก webhook หรือเพิ่มเอง)
+		emailSuppressions := api.Group("/email-suppressions", middleware.RequireAdmin())
+		{
+			emailSuppressions.GET("", middleware.RequirePermission("emailManagement.view"), controllers.ListEmailSuppressions)
+			emailSuppressions.POST("", middleware.RequirePermission("emailManagement.config"), controllers.AddEmailSuppression)
+			emailSuppressions.DELETE("/:id", middleware.RequirePermission("emailManagement.config"), controllers.RemoveEmailSuppression)
+		}
+
+		// delivery webhooks ของ provider (public — ตรวจลายเซ็นใน handler)
+		emailWebhooks := api.Group("/webhooks/email")
+		{
+			emailWebhooks.POST("/sendgrid", controllers.SendGridEmailWebhook)
+			emailWebhooks.POST("/resend", controllers.ResendEmailWebhook)
+		}
+
+		// template อีเมล (แยกตามประเภทและภาษา) + preview
+		emailTemplates := api.Group("/email-templates", middleware.RequireAdmin())
+		{
+			emailTemplates.GET("", middleware.RequirePermission("emailManagement.view"), controllers.ListEmailTemplates)
+			emailTemplates.GET("/:kind/:locale", middleware.RequirePermission("emailManagement.view"), controllers.GetEmailTemplate)
+			emailTemplates.POST("/:kind/:locale/preview", middleware.RequirePermission("emailManagement.view"), controllers.PreviewEmailTemplate)
+			emailTemplates.PUT("/:kind/:locale", middleware.RequirePermission("emailManagement.config"), controllers.SaveEmailTemplate)
+			emailTemplates.DELETE("/:kind/:locale", middleware.RequirePermission("emailManagement.config"), controllers.ResetEmailTemplate)
+		}
+
+		// outgoing webhooks: endpoint ของระบบภายนอก + log การส่ง / replay
+		webhookEndpoints := api.Group("/webhook-endpoints", middleware.RequireAdmin())
+		{
+			webhookEndpoints.GET("", middleware.RequirePermission("webhookManagement.view"), controllers.ListWebhookEndpoints)
+			webhookEndpoints.GET("/:id", middleware.RequirePermission("webhookManagement.view"), controllers.GetWebhookEndpoint)
+			webhookEndpoints.POST("", middleware.RequirePermission("webhookManagement.config"), controllers.CreateWebhookEndpoint)
+			webhookEndpoints.PUT("/:id", middleware.RequirePermission("webhookManagement.config"), controllers.UpdateWebhookEndpoint)
+			webhookEndpoints.POST("/:id/rotate-secret", middleware.RequirePermission("webhookManagement.config"), controllers.RotateWebhookSecret)
+			webhookEndpoints.DELETE("/:id", middleware.RequirePermission("webhookManagement.config"), controllers.DeleteWebhookEndpoint)
+		}
+		webhookDeliveries := api.Group("/webhook-deliveries", middleware.RequireAdmin())
+		{
+			webhookDeliveries.GET("", middleware.RequirePermission("webhookManagement.view"), controllers.ListWebhookDeliveries)
+			webhookDeliveries.GET("/:id", middleware.RequirePermission("webhookManagement.view"), controllers.GetWebhookDelivery)
+			webhookDeliveries.POST("/:id/replay", middleware.RequirePermission("webhookManagement.replay"), controllers.ReplayWebhookDelivery)
+		}
+		api.GET("/audit-events", middleware.RequireAdmin(), middleware.RequirePermission("auditLogs.view"), controllers.ListAuditEvents)
+
+		housekeeping := api.Group("/housekeeping", middleware.RequireAdmin())
+		{
+			housekeeping.GET("/tasks", middleware.RequirePermission("housekeeping.view"), controllers.ListHousekeepingTasks)
+			housekeeping.GET("/tasks/:id", middleware.RequirePermission("housekeeping.view"), controllers.GetHousekeepingTask)
+			housekeeping.GET("/cleaners", middleware.RequirePermission("housekeeping.assign"), controllers.ListHousekeepingCleaners)
+			housekeeping.POST("/tasks", middleware.RequirePermission("housekeeping.assign"), controllers.CreateHousekeepingTask)
+			housekeeping.PUT("/tasks/:id/assign", middleware.RequirePermission("housekeeping.assign"), controllers.AssignHousekeepingTask)
+			housekeeping.POST("/tasks/:id/start", middleware.RequirePermission("housekeeping.work"), controllers.StartHousekeepingTask)
+			housekeeping.POST("/tasks/:id/finish", middleware.RequirePermission("housekeeping.work"), controllers.FinishHousekeepingTask)
+			housekeeping.POST("/tasks/:id/inspect", middleware.RequirePermission("housekeeping.inspect"), controllers.InspectHousekeepingTask)
+		}
+
+		workOrders := api.Group("/work-orders")
+		{
+			// รูปเปิดผ่าน signed URL (ใช้ใน <img> — ไม่มี Authorization header)
+			workOrders.GET("/photos/:id", controllers.ServeWorkOrderPhoto)
+
+			admin := workOrders.Group("", middleware.RequireAdmin())
+			admin.GET("", middleware.RequirePermission("maintenance.view"), controllers.ListWorkOrders)
+			admin.GET("/technicians", middleware.RequirePermission("maintenance.assign"), controllers.ListWorkOrderTechnicians)
+			admin.GET("/:id", middleware.RequirePermission("maintenance.view"), controllers.GetWorkOrder)
+			admin.POST("", middleware.RequirePermission("maintenance.create"), controllers.CreateWorkOrder)
+			admin.POST("/:id/photos", middleware.RequirePermission("maintenance.create"), controllers.AddWorkOrderPhotos)
+			admin.PUT("/:id/assign", middleware.RequirePermission("maintenance.assign"), controllers.AssignWorkOrder)
+			admin.POST("/:id/out-of-order", middleware.RequirePermission("maintenance.assign"), controllers.BlockWorkOrderRoom)
+			admin.POST("/:id/start", middleware.RequirePermission("maintenance.work"), controllers.StartWorkOrder)
+			admin.POST("/:id/hold", middleware.RequirePermission("maintenance.work"), controllers.HoldWorkOrder)
+			admin.POST("/:id/resolve", middleware.RequirePermission("maintenance.work"), controllers.ResolveWorkOrder)
+			admin.POST("/:id/close", middleware.RequirePermission("maintenance.assign"), controllers.CloseWorkOrder)
+			admin.POST("/:id/reopen", middleware.RequirePermission("maintenance.assign"), controllers.ReopenWorkOrder)
+			admin.POST("/:id/cancel", middleware.RequirePermission("maintenance.assign"), controllers.CancelWorkOrder)
 		}
 
+		// real-time dashboard (SSE) — stream ตรวจ session/signed URL และ permission เอง
+		api.POST("/live/stream-url", middleware.RequireAdmin(), controllers.IssueLiveStreamURL)
+		api.GET("/live/stream", controllers.LiveStream)
+
 		auth := api.Group("/auth")
 		{
 			auth.POST("/login", controllers.Login)
+			auth.POST("/logout", controllers.Logout)
 			auth.POST("/forgot", controllers.ForgotPassword)
 		}
 
 		admins := api.Group("/admins")
 		{
-			admins.GET("", controllers.GetAdmins)
-			admins.POST("", controllers.CreateAdmin)
-			admins.POST("/invite", controllers.InviteAdmin)
+			// ผู้ได้รับเชิญตั้งรหัสผ่านด้วย token ในอีเมล (ยังไม่มี session)
 			admins.POST("/activate", controllers.ActivateAdmin)
-			admins.PUT("/:id/role", controllers.UpdateAdminRole)
-			admins.DELETE("/:id", controllers.DeleteAdmin)
+
+			manage := admins.Group("", middleware.RequireAdmin())
+			manage.GET("", middleware.RequirePermission("rolesAndPermissions.view"), controllers.GetAdmins)
+			manage.POST("", middleware.RequirePermission("rolesAndPermissions.create"), controllers.CreateAdmin)
+			manage.POST("/invite", middleware.RequirePermission("rolesAndPermissions.create"), controllers.InviteAdmin)
+			manage.PUT("/:id/role", middleware.RequirePermission("rolesAndPermissions.edit"), controllers.UpdateAdminRole)
+			manage.DELETE("/:id", middleware.RequirePermission("rolesAndPermissions.delete"), controllers.DeleteAdmin)
 		}
 		rooms := api.Group("/rooms")
 		{
 			rooms.GET("", controllers.GetRooms)
 			rooms.POST("", controllers.CreateRoom)
-			rooms.PATCH("/:id", controllers.UpdateRoom)
-			rooms.PUT("/:id", controllers.UpdateRoom)
+			rooms.PATCH("/:id", middleware.RequireAdmin(), middleware.RequirePermission("roomManagement.edit"), controllers.UpdateRoom)
+			rooms.PUT("/:id", middleware.RequireAdmin(), middleware.RequirePermission("roomManagement.edit"), controllers.UpdateRoom)
 			rooms.DELETE("/:id", controllers.DeleteRoom)
+			rooms.PUT("/:id/status", middleware.RequireAdmin(), middleware.RequirePermission("roomManagement.editStatus"), controllers.UpdateRoomStatus)
+			rooms.GET("/:id/status-history", middleware.RequireAdmin(), middleware.RequirePermission("roomManagement.view"), controllers.GetRoomStatusHistory)
+		}
+		roomBlocks := api.Group("/room-blocks", middleware.RequireAdmin())
+		{
+			roomBlocks.GET("", middleware.RequirePermission("roomManagement.view"), controllers.ListRoomBlocks)
+			roomBlocks.POST("", middleware.RequirePermission("roomManagement.editStatus"), controllers.CreateRoomBlock)
+			roomBlocks.POST("/:id/release", middleware.RequirePermission("roomManagement.editStatus"), controllers.ReleaseRoomBlock)
 		}
 		roomTypes := api.Group("/room-types")
 		{
 			roomTypes.GET("", controllers.GetRoomTypes)
-			roomTypes.POST("", controllers.CreateRoomType)
-			roomTypes.DELETE("/:id", controllers.DeleteRoomType)
+			roomTypes.POST("", middleware.RequireAdmin(), middleware.RequirePermission("roomManagement.edit"), controllers.CreateRoomType)
+			roomTypes.DELETE("/:id", middleware.RequireAdmin(), middleware.RequirePermission("roomManagement.edit"), controllers.DeleteRoomType)
+			roomTypes.GET("/photos/:id", controllers.ServeRoomTypePhoto)
+			roomTypes.GET("/:id", controllers.GetRoomType)
+			roomTypes.PUT("/:id", middleware.RequireAdmin(), middleware.RequirePermission("roomManagement.edit"), controllers.UpdateRoomType)
+			roomTypes.PATCH("/:id", middleware.RequireAdmin(), middleware.RequirePermission("roomManagement.edit"), controllers.UpdateRoomType)
+			roomTypes.POST("/:id/photos", middleware.RequireAdmin(), middleware.RequirePermission("roomManagement.edit"), controllers.AddRoomTypePhoto)
+			roomTypes.PUT("/:id/photos/order", middleware.RequireAdmin(), middleware.RequirePermission("roomManagement.edit"), controllers.ReorderRoomTypePhotos)
+			roomTypes.DELETE("/:id/photos/:photoId", middleware.RequireAdmin(), middleware.RequirePermission("roomManagement.edit"), controllers.DeleteRoomTypePhoto)
 		}
 
 		checkin := api.Group("/checkin")
@@ -178,8 +377,11 @@ func SetupRouter(
 			checkin.POST("/initiate", bc.InitiateCheckIn)
 			checkin.POST("", bc.ConfirmCheckIn)
 			checkin.GET("/verify", bc.VerifyToken)
+			checkin.GET("/required-consents", bc.RequiredConsents)
 			checkin.POST("/validate", bic.ValidateCheckinCode)
 			checkin.POST("/resend", bic.ResendCheckinCode)
+			checkin.POST("/guests/:id/documents", grc.Reupload)
+			checkin.POST("/invitations/run", middleware.RequireAdmin(), middleware.RequirePermission("bookingManagement.edit"), controllers.RunCheckinCampaign)
 		}
 
 		api.POST("/verify/idcard", func(c *gin.Context) {
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"hotel-backend/models"
+	"hotel-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func adminSessionTTL() time.Duration {
+	hours, err := strconv.Atoi(utils.EnvOrDefault("ADMIN_SESSION_TTL_HOURS", "12"))
+	if err != nil || hours <= 0 {
+		hours = 12
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// CreateAdminSession บันทึก session ของ token ที่ออกตอน login
+func CreateAdminSession(db *gorm.DB, adminID uint, token string) (time.Time, error) {
+	expiresAt := time.Now().UTC().Add(adminSessionTTL())
+	session := models.AdminSession{
+		AdminID:   adminID,
+		TokenHash: hashSessionToken(token),
+		ExpiresAt: expiresAt,
+	}
+	if err := db.Create(&session).Error; err != nil {
+		return time.Time{}, err
+	}
+	return expiresAt, nil
+}
+
+// FindAdminSession คืน session ของ bearer token ที่ยังไม่หมดอายุ
+func FindAdminSession(db *gorm.DB, token string) (models.AdminSession, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return models.AdminSession{}, errors.New("missing_token")
+	}
+
+	var session models.AdminSession
+	if err := db.
+		Where("token_hash = ? AND expires_at > ?", hashSessionToken(token), time.Now().UTC()).
+		First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.AdminSession{}, errors.New("invalid_session")
+		}
+		return models.AdminSession{}, err
+	}
+	return session, nil
+}
+
+// AdminSessionActive: session (ตาม id) ของ admin คนนี้ยังไม่หมดอายุและไม่ถูก logout
+func AdminSessionActive(db *gorm.DB, sessionID, adminID uint) (bool, error) {
+	var n int64
+	err := db.Model(&models.AdminSession{}).
+		Where("id = ? AND admin_id = ? AND expires_at > ?", sessionID, adminID, time.Now().UTC()).
+		Count(&n).Error
+	return n > 0, err
+}
+
+// ResolveAdminSession คืน admin ของ bearer token ที่ยังไม่หมดอายุ
+func ResolveAdminSession(db *gorm.DB, token string) (models.Admin, error) {
+	session, err := FindAdminSession(db, token)
+	if err != nil {
+		return models.Admin{}, err
+	}
+
+	var admin models.Admin
+	if err := db.First(&admin, session.AdminID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Admin{}, errors.New("invalid_session")
+		}
+		return models.Admin{}, err
+	}
+	return admin, nil
+}
+
+// RevokeAdminSession ลบ session (logout)
+func RevokeAdminSession(db *gorm.DB, token string) error {
+	return db.Where("token_hash = ?", hashSessionToken(strings.TrimSpace(token))).
+		Delete(&models.AdminSession{}).Error
+}
+
+// AdminHasPermission ตรวจ permission (เช่น "guestDocuments.view") จาก role ของ admin
+func AdminHasPermission(db *gorm.DB, adminID uint, permission string) (bool, error) {
+	var count int64
+	err := db.Model(&models.RolePermission{}).
+		Joins("JOIN role_members ON role_members.role_id = role_permissions.role_id").
+		Where("role_members.admin_id = ? AND role_permissions.permission = ?", adminID, permission).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// AdminPermissions permission ทั้งหมดของ admin (รวมทุก role)
+func AdminPermissions(db *gorm.DB, adminID uint) ([]string, error) {
+	var perms []string
+	err := db.Model(&models.RolePermission{}).
+		Joins("JOIN role_members ON role_members.role_id = role_permissions.role_id").
+		Where("role_members.admin_id = ?", adminID).
+		Distinct().Pluck("role_permissions.permission", &perms).Error
+	return perms, err
+}
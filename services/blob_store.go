@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"hotel-backend/utils"
+)
+
+// ErrBlobNotFound: ไม่มี object ตาม key ที่ขอ
+var ErrBlobNotFound = errors.New("blob_not_found")
+
+// BlobInfo: metadata ของ object ที่เปิดอ่าน (Size = -1 ถ้าไม่ทราบ)
+type BlobInfo struct {
+	ContentType string
+	Size        int64
+}
+
+// BlobStore: ที่เก็บไฟล์ที่ผู้ใช้อัปโหลด (รูปใบหน้า, เอกสาร, โลโก้โรงแรม)
+// key เป็น path แบบ slash เช่น "faces/ab12....png" และเป็นค่าที่บันทึกลง DB
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, BlobInfo, error)
+	Delete(ctx context.Context, key string) error
+}
+
+var (
+	blobStoreMu      sync.RWMutex
+	defaultBlobStore BlobStore
+)
+
+// SetDefaultBlobStore ตั้ง store ที่ใช้ทั้งระบบ (เรียกครั้งเดียวตอน start ใน main.go)
+func SetDefaultBlobStore(store BlobStore) {
+	blobStoreMu.Lock()
+	defer blobStoreMu.Unlock()
+	defaultBlobStore = store
+}
+
+// DefaultBlobStore คืน store ที่ตั้งไว้ หรือ local store ใต้ UPLOADS_DIR ถ้ายังไม่ได้ตั้ง
+func DefaultBlobStore() BlobStore {
+	blobStoreMu.RLock()
+	store := defaultBlobStore
+	blobStoreMu.RUnlock()
+	if store != nil {
+		return store
+	}
+	return NewLocalBlobStore(resolveUploadsDir())
+}
+
+// NewBlobStoreFromEnv เลือก backend จาก BLOB_STORE ("local" ค่าเริ่มต้น หรือ "s3")
+//
+// s3 ใช้ได้กับ AWS S3 และ S3-compatible (MinIO, R2, Spaces ฯลฯ):
+// S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY_ID, S3_SECRET_ACCESS_KEY,
+// S3_PATH_STYLE (true สำหรับ MinIO), S3_PREFIX (ไม่บังคับ)
+func NewBlobStoreFromEnv() (BlobStore, error) {
+	backend := strings.ToLower(strings.TrimSpace(utils.EnvOrDefault("BLOB_STORE", "local")))
+	switch backend {
+	case "local":
+		dir := resolveUploadsDir()
+		log.Printf("blob store: local (%s)", dir)
+		return NewLocalBlobStore(dir), nil
+	case "s3":
+		store, err := NewS3BlobStore(S3Config{
+			Endpoint:        strings.TrimSpace(utils.EnvOrDefault("S3_ENDPOINT", "https://s3.amazonaws.com")),
+			Region:          strings.TrimSpace(utils.EnvOrDefault("S3_REGION", "us-east-1")),
+			Bucket:          strings.TrimSpace(utils.EnvOrDefault("S3_BUCKET", "")),
+			AccessKeyID:     strings.TrimSpace(utils.EnvOrDefault("S3_ACCESS_KEY_ID", "")),
+			SecretAccessKey: strings.TrimSpace(utils.EnvOrDefault("S3_SECRET_ACCESS_KEY", "")),
+			PathStyle:       strings.EqualFold(strings.TrimSpace(utils.EnvOrDefault("S3_PATH_STYLE", "false")), "true"),
+			Prefix:          strings.TrimSpace(utils.EnvOrDefault("S3_PREFIX", "")),
+		})
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("blob store: s3 (%s, bucket %s)", store.cfg.Endpoint, store.cfg.Bucket)
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown BLOB_STORE %q (expected local or s3)", backend)
+	}
+}
+
+// รูปที่รับอัปโหลดได้ -> นามสกุลไฟล์
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// DetectImageType ดู content-type จากเนื้อไฟล์จริง (ไม่เชื่อ data URI ที่ client ส่งมา)
+func DetectImageType(data []byte) (contentType string, ext string, err error) {
+	contentType = http.DetectContentType(data)
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = strings.TrimSpace(contentType[:i])
+	}
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return "", "", fmt.Errorf("unsupported_image_type: %s", contentType)
+	}
+	return contentType, ext, nil
+}
+
+// normalizeBlobKey แปลง path ที่เก็บใน DB เป็น key ของ BlobStore
+// รองรับข้อมูลเก่าที่มี prefix "./uploads/" หรือ "<UPLOADS_DIR>/" และกัน ".." หลุดออกนอก store
+func normalizeBlobKey(stored string) (string, error) {
+	key := strings.TrimSpace(strings.ReplaceAll(stored, "\\", "/"))
+	key = strings.TrimPrefix(key, "./")
+
+	base := strings.TrimSuffix(strings.TrimPrefix(strings.ReplaceAll(resolveUploadsDir(), "\\", "/"), "./"), "/")
+	for _, prefix := range []string{base + "/", "uploads/"} {
+		key = strings.TrimPrefix(key, prefix)
+	}
+	key = strings.TrimLeft(key, "/")
+
+	if key == "" {
+		return "", ErrBlobNotFound
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return "", ErrBlobNotFound
+		}
+	}
+	return key, nil
+}
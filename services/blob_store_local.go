@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// LocalBlobStore เก็บไฟล์ในดิสก์ใต้ Dir (ใช้ได้กับ instance เดียว หรือ volume ที่แชร์กัน)
+type LocalBlobStore struct {
+	Dir string
+}
+
+func NewLocalBlobStore(dir string) *LocalBlobStore {
+	return &LocalBlobStore{Dir: dir}
+}
+
+func (s *LocalBlobStore) path(key string) (string, error) {
+	key, err := normalizeBlobKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(key)), nil
+}
+
+func (s *LocalBlobStore) Put(_ context.Context, key string, data []byte, _ string) error {
+	full, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return fmt.Errorf("mkdir uploads dir: %w", err)
+	}
+	if err := os.WriteFile(full, data, 0644); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalBlobStore) Open(_ context.Context, key string) (io.ReadCloser, BlobInfo, error) {
+	full, err := s.path(key)
+	if err != nil {
+		return nil, BlobInfo{}, err
+	}
+
+	f, err := os.Open(full)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, BlobInfo{}, ErrBlobNotFound
+		}
+		return nil, BlobInfo{}, err
+	}
+	st, err := f.Stat()
+	if err != nil || st.IsDir() {
+		f.Close()
+		return nil, BlobInfo{}, ErrBlobNotFound
+	}
+
+	// ไฟล์เก่าบางไฟล์ไม่มีนามสกุล -> sniff จากเนื้อไฟล์
+	contentType := mime.TypeByExtension(filepath.Ext(full))
+	if contentType == "" {
+		head := make([]byte, 512)
+		n, _ := io.ReadFull(f, head)
+		contentType = http.DetectContentType(head[:n])
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			f.Close()
+			return nil, BlobInfo{}, err
+		}
+	}
+
+	return f, BlobInfo{ContentType: contentType, Size: st.Size()}, nil
+}
+
+func (s *LocalBlobStore) Delete(_ context.Context, key string) error {
+	full, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(full); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	testS3AccessKey = "AKIDEXAMPLE"
+	testS3Secret    = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	testS3Region    = "ap-southeast-1"
+)
+
+// s3Stub: S3 แบบ path-style ในหน่วยความจำ — ตรวจลายเซ็น SigV4 จากมุมของ server ทุก request
+type s3Stub struct {
+	t       *testing.T
+	mu      sync.Mutex
+	objects map[string]s3StubObject
+	paths   []string
+}
+
+type s3StubObject struct {
+	data        []byte
+	contentType string
+}
+
+func newS3Stub(t *testing.T) (*s3Stub, *httptest.Server) {
+	stub := &s3Stub{t: t, objects: map[string]s3StubObject{}}
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+	return stub, srv
+}
+
+func (s *s3Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if err := verifySigV4(r, body); err != "" {
+		s.t.Errorf("%s %s: %s", r.Method, r.URL.Path, err)
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paths = append(s.paths, r.URL.Path)
+	switch r.Method {
+	case http.MethodPut:
+		s.objects[r.URL.Path] = s3StubObject{data: body, contentType: r.Header.Get("Content-Type")}
+	case http.MethodGet:
+		obj, ok := s.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Write(obj.data)
+	case http.MethodDelete:
+		delete(s.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "MethodNotAllowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// verifySigV4 คำนวณลายเซ็นใหม่จาก request ที่ server ได้รับ — คืนข้อความ error ("" = ถูกต้อง)
+func verifySigV4(r *http.Request, body []byte) string {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 ") {
+		return "missing SigV4 Authorization header"
+	}
+	fields := map[string]string{}
+	for _, part := range strings.Split(strings.TrimPrefix(auth, "AWS4-HMAC-SHA256 "), ",") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(part), "="); ok {
+			fields[k] = v
+		}
+	}
+	cred := strings.Split(fields["Credential"], "/")
+	if len(cred) != 5 || cred[0] != testS3AccessKey || cred[2] != testS3Region || cred[3] != "s3" {
+		return "bad credential scope " + fields["Credential"]
+	}
+	if got := r.Header.Get("X-Amz-Content-Sha256"); got != sha256Hex(body) {
+		return "payload hash mismatch"
+	}
+
+	names := strings.Split(fields["SignedHeaders"], ";")
+	if !sort.StringsAreSorted(names) {
+		return "signed headers not sorted"
+	}
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		fields["SignedHeaders"],
+		sha256Hex(body),
+	}, "\n")
+	amzDate := r.Header.Get("X-Amz-Date")
+	scope := strings.Join(cred[1:], "/")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+testS3Secret), cred[1])
+	key = hmacSHA256(key, testS3Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	want := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	if !hmac.Equal([]byte(fields["Signature"]), []byte(want)) {
+		return "signature mismatch"
+	}
+	return ""
+}
+
+func newTestS3Store(t *testing.T, endpoint, prefix string) *S3BlobStore {
+	t.Helper()
+	store, err := NewS3BlobStore(S3Config{
+		Endpoint:        endpoint,
+		Region:          testS3Region,
+		Bucket:          "hotel-uploads",
+		AccessKeyID:     testS3AccessKey,
+		SecretAccessKey: testS3Secret,
+		PathStyle:       true,
+		Prefix:          prefix,
+	})
+	if err != nil {
+		t.Fatalf("NewS3BlobStore: %v", err)
+	}
+	return store
+}
+
+func TestS3BlobStoreRoundTrip(t *testing.T) {
+	stub, srv := newS3Stub(t)
+	store := newTestS3Store(t, srv.URL, "/prod/")
+
+	// ช่องว่างและ "+" ต้อง escape ตรงกันทั้งตอนเซ็นและตอนส่ง
+	blobStoreRoundTrip(t, store, "faces/guest 1+a.png")
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	if len(stub.paths) == 0 {
+		t.Fatal("stub received no requests")
+	}
+	for _, p := range stub.paths {
+		if p != "/hotel-uploads/prod/faces/guest 1+a.png" {
+			t.Errorf("request path = %q, want bucket/prefix/key", p)
+		}
+	}
+	if len(stub.objects) != 0 {
+		t.Errorf("objects left after delete: %d", len(stub.objects))
+	}
+}
+
+func TestS3BlobStoreServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "SlowDown", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+	store := newTestS3Store(t, srv.URL, "")
+
+	err := store.Put(t.Context(), "faces/x.png", []byte("x"), "image/png")
+	if err == nil || !strings.Contains(err.Error(), "status 503") || !strings.Contains(err.Error(), "SlowDown") {
+		t.Fatalf("Put error = %v, want status 503 with body", err)
+	}
+}
+
+func TestS3BlobStoreVirtualHostURL(t *testing.T) {
+	store, err := NewS3BlobStore(S3Config{
+		Endpoint:        "https://s3.ap-southeast-1.amazonaws.com/",
+		Bucket:          "hotel-uploads",
+		AccessKeyID:     testS3AccessKey,
+		SecretAccessKey: testS3Secret,
+	})
+	if err != nil {
+		t.Fatalf("NewS3BlobStore: %v", err)
+	}
+	u, err := store.objectURL("./uploads/documents/a.png")
+	if err != nil {
+		t.Fatalf("objectURL: %v", err)
+	}
+	if got, want := u.String(), "https://hotel-uploads.s3.ap-southeast-1.amazonaws.com/documents/a.png"; got != want {
+		t.Errorf("objectURL = %q, want %q", got, want)
+	}
+	if _, err := store.objectURL("../secret"); err == nil {
+		t.Error("objectURL(../secret) should fail")
+	}
+}
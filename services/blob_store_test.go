@@ -0,0 +1,64 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// blobStoreRoundTrip: Put -> Open -> Delete -> Open (ไม่พบ) -> Delete ซ้ำ (ไม่ error) ใช้ได้กับทุก backend
+func blobStoreRoundTrip(t *testing.T, store BlobStore, key string) {
+	t.Helper()
+	ctx := context.Background()
+	data := []byte("\x89PNG\r\n\x1a\nround-trip")
+
+	if err := store.Put(ctx, key, data, "image/png"); err != nil {
+		t.Fatalf("Put(%q): %v", key, err)
+	}
+
+	body, info, err := store.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("Open(%q): %v", key, err)
+	}
+	got, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		t.Fatalf("read %q: %v", key, err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("Open(%q) = %q, want %q", key, got, data)
+	}
+	if info.ContentType != "image/png" {
+		t.Errorf("Open(%q) content type = %q, want image/png", key, info.ContentType)
+	}
+	if info.Size != int64(len(data)) {
+		t.Errorf("Open(%q) size = %d, want %d", key, info.Size, len(data))
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete(%q): %v", key, err)
+	}
+	if _, _, err := store.Open(ctx, key); !errors.Is(err, ErrBlobNotFound) {
+		t.Fatalf("Open(%q) after delete: err = %v, want ErrBlobNotFound", key, err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete(%q) of missing key: %v", key, err)
+	}
+}
+
+func TestLocalBlobStoreRoundTrip(t *testing.T) {
+	blobStoreRoundTrip(t, NewLocalBlobStore(t.TempDir()), "faces/abc123.png")
+}
+
+func TestNormalizeBlobKeyRejectsTraversal(t *testing.T) {
+	for _, stored := range []string{"", "faces/../../etc/passwd", "./uploads/", "faces//x.png"} {
+		if key, err := normalizeBlobKey(stored); err == nil {
+			t.Errorf("normalizeBlobKey(%q) = %q, want error", stored, key)
+		}
+	}
+	if key, err := normalizeBlobKey("./uploads/faces/x.png"); err != nil || key != "faces/x.png" {
+		t.Errorf("normalizeBlobKey(legacy path) = %q, %v; want faces/x.png", key, err)
+	}
+}
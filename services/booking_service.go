@@ -118,6 +118,7 @@ func (s *BookingService) InitiateCheckInProcess(bookingID uint) (models.BookingI
 
 	// create booking_info with retries on unique collision
 	var bookingInfo models.BookingInfo
+	policy := LoadCheckinLinkPolicy(s.DB)
 	maxRetries := 5
 	var createErr error
 	for attempt := 0; attempt < maxRetries; attempt++ {
@@ -134,24 +135,16 @@ func (s *BookingService) InitiateCheckInProcess(bookingID uint) (models.BookingI
 			return models.BookingInfo{}, fmt.Errorf("failed to format checkin code: %w", fErr)
 		}
 
-		var expiresAt *time.Time
-		var codeExp *time.Time
-		if strings.ToLower(utils.EnvOrDefault("CHECKIN_CODE_NEVER_EXPIRE", "false")) != "true" {
-			t := time.Now().UTC().Add(7 * 24 * time.Hour)
-			codeExp = &t
-		}
-
 		bookingInfo = models.BookingInfo{
 			BookingID:     bookingID,
 			Token:         token,
 			CheckinCode:   formatted,
 			Status:        "INITIATED",
 			EmailStatus:   "PENDING",
-			ExpiresAt:     expiresAt,
-			CodeExpiresAt: codeExp,
 			GuestEmail:    booking.Customer.Email,
 			GuestLastName: booking.Customer.FullName,
 		}
+		policy.Apply(&bookingInfo, booking, time.Now())
 
 		createErr = s.DB.Create(&bookingInfo).Error
 		if createErr == nil {
@@ -170,42 +163,19 @@ func (s *BookingService) InitiateCheckInProcess(bookingID uint) (models.BookingI
 	}
 
 	// build rooms list for email (best-effort)
-	roomsForEmail := []utils.RoomInfo{}
-	if len(booking.Rooms) > 0 {
-		for _, br := range booking.Rooms {
-			num := ""
-			typ := ""
-			if br.Room.ID != 0 {
-				if strings.TrimSpace(br.Room.RoomCode) != "" {
-					num = strings.TrimSpace(br.Room.RoomCode)
-				} else {
-					num = strings.TrimSpace(br.Room.RoomNumber)
-				}
-				if strings.TrimSpace(br.Room.Type) != "" {
-					typ = strings.TrimSpace(br.Room.Type)
-				} else if br.Room.RoomType.ID != 0 {
-					typ = strings.TrimSpace(br.Room.RoomType.TypeName)
-				}
-			}
-			roomsForEmail = append(roomsForEmail, utils.RoomInfo{Number: num, Type: typ})
-		}
-	} else if booking.Room.ID != 0 {
-		num := strings.TrimSpace(booking.Room.RoomCode)
-		if num == "" {
-			num = strings.TrimSpace(booking.Room.RoomNumber)
-		}
-		typ := strings.TrimSpace(booking.Room.Type)
-		if typ == "" && booking.Room.RoomType.ID != 0 {
-			typ = strings.TrimSpace(booking.Room.RoomType.TypeName)
-		}
-		roomsForEmail = append(roomsForEmail, utils.RoomInfo{Number: num, Type: typ})
-	}
+	roomsForEmail := bookingRoomsForEmail(booking)
 
 	frontend := utils.EnvOrDefault("FRONTEND_URL", "http://localhost:3000")
 	checkinLink := fmt.Sprintf("%s/checkin?token=%s", strings.TrimRight(frontend, "/"), bookingInfo.Token)
 
+	// SMS / LINE ตามช่องทางที่ลูกค้าเลือก (ไม่ขึ้นกับผลการส่งอีเมล)
+	NotifyCustomerAsync(booking.Customer, fmt.Sprintf("checkin link booking_info=%d", bookingInfo.ID),
+		utils.CheckinLinkText(booking.ReferenceCode, checkinLink, bookingInfo.CheckinCode))
+
 	// send email (best-effort) and update email status
 	if mailErr := utils.SendCheckInLinkEmail(
+		bookingInfo.ID,
+		GuestEmailLocale(s.DB, booking.ID),
 		booking.Customer.Email,
 		booking.ReferenceCode,
 		checkinLink,
@@ -231,54 +201,85 @@ func (s *BookingService) InitiateCheckInProcess(bookingID uint) (models.BookingI
 	}
 
 	_ = s.DB.Model(&bookingInfo).Where("id = ?", bookingInfo.ID).
-		Updates(map[string]interface{}{"email_status": "SENT"}).Error
+		Updates(map[string]interface{}{"email_status": checkinEmailStatus()}).Error
 
 	return bookingInfo, nil
 }
 
-// ValidateCheckinCodeByBooking: ตรวจสอบ code + query (name or reference code) และยังไม่หมดอายุ
-func (s *BookingService) ValidateCheckinCodeByBooking(code string, query string) (models.BookingInfo, error) {
-	var bi models.BookingInfo
-	now := time.Now().UTC()
-
-	norm := utils.NormalizeCheckinCode(code)
-	if len(norm) != 8 {
-		return bi, errors.New("invalid_or_expired_code")
+// SendCheckinLinkEmail ส่งลิงก์/รหัสของ BookingInfo ที่มีอยู่แล้วอีกครั้ง และอัปเดต email_status
+func (s *BookingService) SendCheckinLinkEmail(bi models.BookingInfo) error {
+	var booking models.Booking
+	if err := s.DB.Preload("Rooms.Room.RoomType").Preload("Room.RoomType").Preload("Customer").First(&booking, bi.BookingID).Error; err != nil {
+		return err
 	}
-	formatted := strings.ToUpper(norm[:4] + "-" + norm[4:])
-
-	qLower := strings.ToLower(strings.TrimSpace(query))
-	if qLower == "" {
-		return bi, errors.New("invalid_query")
+	recipient := strings.TrimSpace(bi.GuestEmail)
+	if recipient == "" {
+		recipient = strings.TrimSpace(booking.Customer.Email)
+	}
+	if recipient == "" {
+		return errors.New("customer_email_missing")
 	}
 
-	err := s.DB.
-		Table("booking_infos").
-		Select("booking_infos.*").
-		Joins("JOIN bookings ON bookings.id = booking_infos.booking_id").
-		Joins("JOIN customers ON customers.id = bookings.customer_id").
-		Where("(booking_infos.checkin_code = ? OR booking_infos.checkin_code = ?)", formatted, strings.ToUpper(code)).
-		Where("( (LOWER(customers.full_name) LIKE ?) OR (LOWER(bookings.reference_code) = ?) )", "%"+qLower+"%", qLower).
-		Where("(booking_infos.code_expires_at IS NULL OR booking_infos.code_expires_at > ?)", now).
-		First(&bi).Error
+	link := utils.BuildCheckinLink(utils.EnvOrDefault("FRONTEND_URL", "http://localhost:3000"), bi.Token, true)
+	link = utils.AppendBookingIDParam(link, bi.BookingID)
+	arrival, departure := bookingStay(booking)
+	NotifyCustomerAsync(booking.Customer, fmt.Sprintf("checkin link booking_info=%d", bi.ID),
+		utils.CheckinLinkText(booking.ReferenceCode, link, bi.CheckinCode))
+	mailErr := utils.SendCheckInLinkEmail(
+		bi.ID,
+		GuestEmailLocale(s.DB, bi.BookingID),
+		recipient,
+		booking.ReferenceCode,
+		link,
+		booking.Customer.FullName,
+		bookingRoomsForEmail(booking),
+		receiptDate(arrival, nil),
+		receiptDate(departure, nil),
+		bi.CheckinCode,
+	)
+	if mailErr != nil {
+		_ = s.DB.Model(&models.BookingInfo{}).Where("id = ?", bi.ID).
+			Updates(map[string]interface{}{"email_status": "FAILED", "email_error": mailErr.Error()}).Error
+		return mailErr
+	}
+	return s.DB.Model(&models.BookingInfo{}).Where("id = ?", bi.ID).
+		Updates(map[string]interface{}{"email_status": checkinEmailStatus(), "email_error": ""}).Error
+}
 
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			var biAny models.BookingInfo
-			err2 := s.DB.
-				Table("booking_infos").
-				Select("booking_infos.*").
-				Where("booking_infos.checkin_code = ? OR booking_infos.checkin_code = ?", formatted, strings.ToUpper(code)).
-				First(&biAny).Error
-			if err2 == nil {
-				log.Printf("ValidateCheckinCodeByBooking: code exists but expired (booking_info_id=%d)", biAny.ID)
+// bookingRoomsForEmail รายการห้องสำหรับอีเมล (booking ต้อง Preload Rooms.Room.RoomType / Room)
+func bookingRoomsForEmail(booking models.Booking) []utils.RoomInfo {
+	roomsForEmail := []utils.RoomInfo{}
+	if len(booking.Rooms) > 0 {
+		for _, br := range booking.Rooms {
+			num := ""
+			typ := ""
+			if br.Room.ID != 0 {
+				if strings.TrimSpace(br.Room.RoomCode) != "" {
+					num = strings.TrimSpace(br.Room.RoomCode)
+				} else {
+					num = strings.TrimSpace(br.Room.RoomNumber)
+				}
+				if strings.TrimSpace(br.Room.Type) != "" {
+					typ = strings.TrimSpace(br.Room.Type)
+				} else if br.Room.RoomType.ID != 0 {
+					typ = strings.TrimSpace(br.Room.RoomType.TypeName)
+				}
 			}
-			return bi, errors.New("invalid_or_expired_code")
+			roomsForEmail = append(roomsForEmail, utils.RoomInfo{Number: num, Type: typ})
 		}
-		return bi, fmt.Errorf("failed to validate code: %w", err)
+	} else if booking.Room.ID != 0 {
+		num := strings.TrimSpace(booking.Room.RoomCode)
+		if num == "" {
+			num = strings.TrimSpace(booking.Room.RoomNumber)
+		}
+		typ := strings.TrimSpace(booking.Room.Type)
+		if typ == "" && booking.Room.RoomType.ID != 0 {
+			typ = strings.TrimSpace(booking.Room.RoomType.TypeName)
+		}
+		roomsForEmail = append(roomsForEmail, utils.RoomInfo{Number: num, Type: typ})
 	}
 
-	return bi, nil
+	return roomsForEmail
 }
 
 // FinalizeCheckInTransaction: ทำงานใน transaction — อัพเดต booking, insert guests, save consent logs, finalize booking_info
@@ -286,11 +287,21 @@ func (s *BookingService) FinalizeCheckInTransaction(
 	token string,
 	guests []models.Guest,
 	consents []models.Consent,
+	signature *SignatureInput,
+	audit ConsentAudit,
 ) error {
 
 	now := time.Now().UTC()
+	pendingReview := false
+	var events []Event
 
-	return s.DB.Transaction(func(tx *gorm.DB) error {
+	// ตรวจลายเซ็นก่อนเริ่ม transaction (ใช้พิมพ์ใบลงทะเบียนผู้เข้าพัก)
+	preparedSignature, err := PrepareSignature(signature)
+	if err != nil {
+		return err
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
 
 		var bookingInfo models.BookingInfo
 		if err := tx.
@@ -302,11 +313,18 @@ func (s *BookingService) FinalizeCheckInTransaction(
 			}
 			return err
 		}
+		if err := CheckinOpen(bookingInfo, now); err != nil {
+			return err
+		}
 
 		// idempotent
 		if bookingInfo.Status == "COMPLETED" {
 			return nil
 		}
+		if bookingInfo.Status == "PENDING_REVIEW" {
+			pendingReview = true
+			return nil
+		}
 
 		bookingID := bookingInfo.BookingID
 
@@ -321,30 +339,80 @@ func (s *BookingService) FinalizeCheckInTransaction(
 			return nil
 		}
 
-		// ✅ update booking + number_of_guests ตามของจริง
-		if err := tx.Model(&booking).Updates(map[string]interface{}{
+		// ✅ ตรวจ consent ฝั่ง server: ต้องเป็นเวอร์ชันที่มีผลอยู่ ครบทุกตัวที่บังคับ (log เก็บภาษา+hash ของข้อความที่แขกเห็น)
+		accepted, err := NewConsentService(tx).ResolveAccepted(consents, now)
+		if err != nil {
+			return err
+		}
+
+		// ✅ hotel บังคับลายเซ็น -> ต้องส่งมาพร้อมการเช็คอิน
+		if preparedSignature == nil && SignatureRequired(tx) {
+			return errors.New("signature_required")
+		}
+
+		// ✅ hotel เปิด policy ตรวจเอกสาร -> booking รอพนักงานอนุมัติก่อน (ยังไม่ Checked-In)
+		pendingReview = IdentityApprovalRequired(tx)
+
+		bookingUpdates := map[string]interface{}{
 			"status":            "Checked-In",
 			"check_in":          now,
 			"checked_in_at":     now,
 			"checkin_completed": true,
 			"number_of_guests":  len(guests),
-		}).Error; err != nil {
+		}
+		if pendingReview {
+			bookingUpdates = map[string]interface{}{
+				"status":           "Pending-Review",
+				"number_of_guests": len(guests),
+			}
+		}
+
+		// ✅ update booking + number_of_guests ตามของจริง
+		if err := tx.Model(&booking).Updates(bookingUpdates).Error; err != nil {
 			return err
 		}
 
 		// insert guests (ลูกค้ากรอกจริง)
 		insertedGuestIDs := make([]uint, 0, len(guests))
+		var mainGuestID uint
 		for i := range guests {
 			guests[i].BookingID = &bookingID
+			guests[i].ReviewStatus = models.GuestReviewPending
+			guests[i].ReviewedBy = nil
+			guests[i].ReviewedAt = nil
+			if err := SealGuestPII(&guests[i]); err != nil {
+				return err
+			}
 			if err := tx.Create(&guests[i]).Error; err != nil {
 				return err
 			}
+			events = append(events, GuestAdded{GuestID: guests[i].ID, BookingID: &bookingID, IsMainGuest: guests[i].IsMainGuest, ReviewStatus: guests[i].ReviewStatus, At: now})
 			insertedGuestIDs = append(insertedGuestIDs, guests[i].ID)
+			if guests[i].IsMainGuest && mainGuestID == 0 {
+				mainGuestID = guests[i].ID
+			}
+		}
+
+		// ลายเซ็นเก็บกับแขกหลัก (ไม่ระบุแขกหลัก = คนแรก)
+		if preparedSignature != nil {
+			if mainGuestID == 0 && len(insertedGuestIDs) > 0 {
+				mainGuestID = insertedGuestIDs[0]
+			}
+			if mainGuestID == 0 {
+				return errors.New("invalid_guest_signature: no guest to sign")
+			}
+			if _, err := SaveGuestSignature(tx, mainGuestID, bookingID, preparedSignature, audit, now); err != nil {
+				return err
+			}
 		}
 
 		// save consent logs
+		consentEvent := ConsentAccepted{BookingID: &bookingID, GuestIDs: insertedGuestIDs, Source: ConsentSourceCheckin, At: now}
+		for _, c := range accepted {
+			consentEvent.ConsentIDs = append(consentEvent.ConsentIDs, c.ID)
+		}
 		for _, gid := range insertedGuestIDs {
-			for _, c := range consents {
+			for _, c := range accepted {
 				gidLocal := gid
 				logEntry := models.ConsentLog{
 					BookingID:  &bookingID,
@@ -352,23 +420,69 @@ func (s *BookingService) FinalizeCheckInTransaction(
 					GuestID:    &gidLocal,
 					AcceptedAt: now,
 					Status:     "accepted",
+					Locale:     c.Locale,
+					TextHash:   c.TextHash,
 				}
-				if err := tx.Create(&logEntry).Error; err != nil {
+				if err := AppendConsentLog(tx, &logEntry, audit); err != nil {
 					return err
 				}
+				consentEvent.ConsentLogIDs = append(consentEvent.ConsentLogIDs, logEntry.ID)
 			}
 		}
+		// ✅ ใบยืนยัน consent (PDF) ออกโดย handler ของ ConsentAccepted หลัง commit
+		events = append(events, consentEvent)
 
 		// finalize booking_info
+		infoStatus := "COMPLETED"
+		if pendingReview {
+			infoStatus = "PENDING_REVIEW"
+		}
 		if err := tx.Model(&bookingInfo).
 			Updates(map[string]interface{}{
-				"status": "COMPLETED",
+				"status": infoStatus,
 			}).Error; err != nil {
 			return err
 		}
 
-		return nil
+		if !pendingReview {
+			roomIDs, err := bookingRoomIDs(tx, bookingID)
+			if err != nil {
+				return err
+			}
+			events = append(events, CheckInCompleted{BookingID: bookingID, RoomIDs: roomIDs, At: now})
+		}
+		// webhook เข้าคิวใน transaction เดียวกัน
+		return EnqueueWebhooks(tx, events...)
 	})
+	if err != nil {
+		return err
+	}
+	PublishEvents(events...)
+
+	// ข้อมูลบันทึกแล้ว แต่ต้องรอพนักงานอนุมัติเอกสาร (controller ตอบ 202)
+	if pendingReview {
+		return errors.New("checkin_pending_review")
+	}
+	return nil
+}
+
+// bookingRoomIDs ห้องทั้งหมดของ booking (booking_rooms หรือ room_id แบบเดิม)
+func bookingRoomIDs(db *gorm.DB, bookingID uint) ([]uint, error) {
+	var ids []uint
+	if err := db.Model(&models.BookingRoom{}).Where("booking_id = ?", bookingID).Pluck("room_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 {
+		return ids, nil
+	}
+	var booking models.Booking
+	if err := db.Unscoped().Select("id", "room_id").First(&booking, bookingID).Error; err != nil {
+		return nil, err
+	}
+	if booking.RoomID != nil {
+		ids = append(ids, *booking.RoomID)
+	}
+	return ids, nil
 }
 
 // CreateBooking: สร้าง booking แบบ single-room helper
@@ -438,32 +552,41 @@ func (s *BookingService) GetAllWithRelations() ([]models.Booking, error) {
 	return list, nil
 }
 
-// DeleteByStringID
+// DeleteByStringID ลบ booking ตาม id (ตัวเลข) หรือ reference_code
 func (s *BookingService) DeleteByStringID(referenceCode string) error {
 	ref := strings.TrimSpace(referenceCode)
 	if ref == "" {
 		return gorm.ErrRecordNotFound
 	}
 
-	// Try numeric id first if possible
-	if id, err := strconv.ParseUint(ref, 10, 64); err == nil && id != 0 {
-		res := s.DB.Delete(&models.Booking{}, id)
+	// Try numeric id first if possible, fallback to reference_code
+	var booking models.Booking
+	err := gorm.ErrRecordNotFound
+	if id, perr := strconv.ParseUint(ref, 10, 64); perr == nil && id != 0 {
+		err = s.DB.First(&booking, id).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = s.DB.Where("reference_code = ?", ref).First(&booking).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	event := BookingDeleted{BookingID: booking.ID, ReferenceCode: booking.ReferenceCode, At: time.Now().UTC()}
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Delete(&models.Booking{}, booking.ID)
 		if res.Error != nil {
 			return fmt.Errorf("failed to delete booking: %w", res.Error)
 		}
-		if res.RowsAffected > 0 {
-			return nil
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
 		}
+		return EnqueueWebhooks(tx, event)
+	})
+	if err != nil {
+		return err
 	}
-
-	// Fallback to reference_code
-	res := s.DB.Where("reference_code = ?", ref).Delete(&models.Booking{})
-	if res.Error != nil {
-		return fmt.Errorf("failed to delete booking: %w", res.Error)
-	}
-	if res.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
-	}
+	PublishEvents(event)
 	return nil
 }
 
@@ -543,10 +666,15 @@ func (s *BookingService) CreateBookingMultiple(
 		}
 	}
 
+	// ห้องที่ถูก block (ซ่อม/งดขาย) ในช่วงที่จองไม่ได้
+	if err := EnsureRoomsBookable(s.DB, roomIDs, checkInDate, checkOutDate); err != nil {
+		return resultBooking, err
+	}
+
 	var bookingID uint
-	var bookingRef string
+	var created BookingCreated
 
-	// transaction create booking + booking_room + update room status
+	// transaction create booking + booking_room
 	txErr := s.DB.Transaction(func(tx *gorm.DB) error {
 		var ciDate *time.Time
 		var coDate *time.Time
@@ -582,7 +710,6 @@ func (s *BookingService) CreateBookingMultiple(
 		}
 
 		bookingID = booking.ID
-		bookingRef = booking.ReferenceCode
 
 		nights := 0
 		if checkInDate != nil && checkOutDate != nil && checkOutDate.After(*checkInDate) {
@@ -603,93 +730,27 @@ func (s *BookingService) CreateBookingMultiple(
 			if err := tx.Create(&br).Error; err != nil {
 				return fmt.Errorf("failed to create booking_room for room %d: %w", rid, err)
 			}
-
-			if err := tx.Model(&models.Room{}).
-				Where("id = ?", rid).
-				Updates(map[string]interface{}{"status": "Reserved"}).Error; err != nil {
-				return fmt.Errorf("failed to update room %d status: %w", rid, err)
-			}
+			// ไม่เปลี่ยนสถานะห้อง — การจองเป็นช่วงวันที่ของ booking (ห้องเป็น occupied ตอนเช็คอิน)
 		}
 
 		// ❌ ไม่สร้าง records ใน guests ที่นี่แล้ว
 
-		return nil
+		created = BookingCreated{
+			BookingID:       bookingID,
+			CustomerID:      uint(customerID),
+			RoomIDs:         roomIDs,
+			SendCheckinLink: sendEmail,
+			At:              time.Now().UTC(),
+		}
+		return EnqueueWebhooks(tx, created)
 	})
 
 	if txErr != nil {
 		return resultBooking, txErr
 	}
 
-	// optional: send checkin link email for newly created booking
-	if sendEmail {
-		token, genErr := utils.GenerateSecureToken(32)
-		if genErr != nil {
-			return resultBooking, fmt.Errorf("email_send_failed: failed to generate token: %w", genErr)
-		}
-		raw, gErr := utils.GenerateCheckinCode(8)
-		if gErr != nil {
-			return resultBooking, fmt.Errorf("email_send_failed: failed to generate code: %w", gErr)
-		}
-		formatted, fErr := utils.GenerateFormattedCheckinCode(raw)
-		if fErr != nil {
-			return resultBooking, fmt.Errorf("email_send_failed: failed to format code: %w", fErr)
-		}
-
-		var expiresAt *time.Time
-		var codeExpires *time.Time
-		codeExpires = timePtr(time.Now().UTC().Add(7 * 24 * time.Hour))
-
-		bookingInfo := models.BookingInfo{
-			BookingID:     bookingID,
-			Token:         token,
-			CheckinCode:   formatted,
-			Status:        "INITIATED",
-			EmailStatus:   "PENDING",
-			ExpiresAt:     expiresAt,
-			CodeExpiresAt: codeExpires,
-			GuestEmail:    cust.Email,
-			GuestLastName: cust.FullName,
-		}
-
-		if err := s.DB.Create(&bookingInfo).Error; err != nil {
-			return resultBooking, fmt.Errorf("email_send_failed: failed to create booking_info: %w", err)
-		}
-
-		frontend := utils.EnvOrDefault("FRONTEND_URL", "http://localhost:3000")
-		checkinLink := fmt.Sprintf("%s/checkin?token=%s", strings.TrimRight(frontend, "/"), bookingInfo.Token)
-
-		// load room models for email content
-		var roomModels []models.Room
-		if err := s.DB.Where("id IN ?", roomIDs).Find(&roomModels).Error; err != nil {
-			log.Printf("warning: failed to load room models for email: %v", err)
-		}
-		roomsForEmail := make([]utils.RoomInfo, 0, len(roomModels))
-		for _, rm := range roomModels {
-			num := strings.TrimSpace(rm.RoomCode)
-			if num == "" {
-				num = strings.TrimSpace(rm.RoomNumber)
-			}
-			roomsForEmail = append(roomsForEmail, utils.RoomInfo{Number: num, Type: strings.TrimSpace(rm.Type)})
-		}
-
-		if mailErr := utils.SendCheckInLinkEmail(
-			cust.Email,
-			bookingRef,
-			// ถ้าต้องการให้ไม่ว่าง ต้องแน่ใจว่า booking มี reference_code ถูก generate แล้ว
-			checkinLink,
-			cust.FullName,
-			roomsForEmail,
-			checkIn,
-			checkOut,
-			bookingInfo.CheckinCode,
-		); mailErr != nil {
-			_ = s.DB.Model(&bookingInfo).Where("id = ?", bookingInfo.ID).
-				Updates(map[string]interface{}{"email_status": "FAILED", "email_error": mailErr.Error()}).Error
-			return resultBooking, fmt.Errorf("email_send_failed: %w", mailErr)
-		}
-		_ = s.DB.Model(&bookingInfo).Where("id = ?", bookingInfo.ID).
-			Updates(map[string]interface{}{"email_status": "SENT"}).Error
-	}
+	// ลิงก์เช็คอิน (sendEmail), audit ทำใน event handler หลัง commit (webhook เข้าคิวใน transaction แล้ว)
+	PublishEvents(created)
 
 	// reload booking with relations (สำคัญมาก)
 	if err := s.DB.
@@ -710,7 +771,14 @@ func (s *BookingService) CreateBookingMultiple(
 
 // ✅ CheckoutBooking: แก้ให้เป็น Checked-Out (ของเดิมผิด)
 func (s *BookingService) CheckoutBooking(bookingID uint) error {
-	return s.DB.Transaction(func(tx *gorm.DB) error {
+	return s.checkout(bookingID, false)
+}
+
+// checkout เปลี่ยนสถานะเป็น Checked-Out แล้ว publish CheckedOut (ห้องเข้าคิวทำความสะอาดโดย handler)
+func (s *BookingService) checkout(bookingID uint, auto bool) error {
+	now := time.Now().UTC()
+	var roomIDs []uint
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
 
 		var booking models.Booking
 		if err := tx.Preload("Rooms").First(&booking, bookingID).Error; err != nil {
@@ -724,8 +792,6 @@ func (s *BookingService) CheckoutBooking(bookingID uint) error {
 			return fmt.Errorf("not_checked_in")
 		}
 
-		now := time.Now().UTC()
-
 		if err := tx.Model(&booking).Updates(map[string]interface{}{
 			"status":    "Checked-Out",
 			"check_out": now,
@@ -744,15 +810,15 @@ func (s *BookingService) CheckoutBooking(bookingID uint) error {
 		}
 
 		for _, br := range booking.Rooms {
-			if err := tx.Model(&models.Room{}).
-				Where("id = ?", br.RoomID).
-				Updates(map[string]interface{}{"status": "Cleaning"}).Error; err != nil {
-				return err
-			}
+			roomIDs = append(roomIDs, br.RoomID)
 		}
-
-		return nil
+		return EnqueueWebhooks(tx, CheckedOut{BookingID: bookingID, RoomIDs: roomIDs, Auto: auto, At: now})
 	})
+	if err != nil {
+		return err
+	}
+	PublishEvents(CheckedOut{BookingID: bookingID, RoomIDs: roomIDs, Auto: auto, At: now})
+	return nil
 }
 
 func (s *BookingService) AutoCheckoutDue(ctx context.Context, now time.Time) error {
@@ -773,7 +839,7 @@ func (s *BookingService) AutoCheckoutDue(ctx context.Context, now time.Time) err
 	}
 
 	for _, b := range dueBookings {
-		if err := s.CheckoutBooking(b.ID); err != nil {
+		if err := s.checkout(b.ID, true); err != nil {
 			log.Printf("auto checkout failed for booking %d: %v", b.ID, err)
 		}
 	}
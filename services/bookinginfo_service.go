@@ -2,6 +2,7 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -21,9 +22,33 @@ func NewBookingInfoService(db *gorm.DB) *BookingInfoService {
 	return &BookingInfoService{DB: db}
 }
 
-// SaveBookingInfo saves or updates a BookingInfo
-func (s *BookingInfoService) SaveBookingInfo(info models.BookingInfo) error {
-	return s.DB.Save(&info).Error
+// SaveBookingInfo แก้ข้อมูล BookingInfo ที่มีอยู่แล้ว (สร้างใหม่ผ่าน InitiateCheckIn เท่านั้น)
+// แก้ได้เฉพาะข้อมูลติดต่อแขกและช่วงเวลาลิงก์ — token/รหัส/ตัวนับการล็อกไม่รับจาก payload
+func (s *BookingInfoService) SaveBookingInfo(info *models.BookingInfo) error {
+	if info.ID == 0 {
+		return errors.New("missing_booking_info")
+	}
+	res := s.DB.Model(&models.BookingInfo{}).Where("id = ?", info.ID).
+		Select("guest_email", "guest_last_name", "expires_at", "opens_at").
+		Updates(map[string]interface{}{
+			"guest_email":     strings.TrimSpace(info.GuestEmail),
+			"guest_last_name": strings.TrimSpace(info.GuestLastName),
+			"expires_at":      info.ExpiresAt,
+			"opens_at":        info.OpensAt,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		var n int64
+		if err := s.DB.Model(&models.BookingInfo{}).Where("id = ?", info.ID).Count(&n).Error; err != nil {
+			return err
+		}
+		if n == 0 {
+			return gorm.ErrRecordNotFound
+		}
+	}
+	return s.DB.First(info, info.ID).Error
 }
 
 // GetByID returns a BookingInfo by id
@@ -93,18 +118,20 @@ func (s *BookingInfoService) FindByCodeWithExpiry(code string, codeNoDash string
 	return models.BookingInfo{}, false, err2
 }
 
-// ExtendExpiry extends CodeExpiresAt by minutes and returns new expiry time.
-func (s *BookingInfoService) ExtendExpiry(bookingInfoId uint, minutes int) (*time.Time, error) {
+// ExtendExpiry ต่ออายุ CodeExpiresAt ตาม CheckinLinkPolicy (ไม่เกินอายุลิงก์) และคืนเวลาหมดอายุใหม่ (nil = ไม่หมดอายุ)
+func (s *BookingInfoService) ExtendExpiry(bookingInfoId uint) (*time.Time, error) {
 	var bi models.BookingInfo
 	if err := s.DB.First(&bi, bookingInfoId).Error; err != nil {
 		return nil, err
 	}
-	newExpiry := time.Now().UTC().Add(time.Duration(minutes) * time.Minute)
-	bi.CodeExpiresAt = &newExpiry
-	if err := s.DB.Save(&bi).Error; err != nil {
+	newExpiry, err := LoadCheckinLinkPolicy(s.DB).Extend(bi, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.DB.Model(&bi).Update("code_expires_at", newExpiry).Error; err != nil {
 		return nil, err
 	}
-	return &newExpiry, nil
+	return newExpiry, nil
 }
 
 // InitiateCheckIn creates a BookingInfo record and sends a check-in email.
@@ -157,6 +184,7 @@ func (s *BookingInfoService) InitiateCheckIn(bookingID uint) (models.BookingInfo
 		GuestEmail:    booking.Customer.Email,
 		GuestLastName: booking.Customer.FullName,
 	}
+	LoadCheckinLinkPolicy(s.DB).Apply(&bookingInfo, booking, now)
 
 	if err := s.DB.Create(&bookingInfo).Error; err != nil {
 		return models.BookingInfo{}, err
@@ -172,7 +200,11 @@ func (s *BookingInfoService) InitiateCheckIn(bookingID uint) (models.BookingInfo
 	checkinLink := utils.BuildCheckinLink(frontendURL, token, true)
 	checkinLink = utils.AppendBookingIDParam(checkinLink, bookingID)
 
+	NotifyCustomerAsync(booking.Customer, fmt.Sprintf("checkin link booking_info=%d", bookingInfo.ID),
+		utils.CheckinLinkText(bookingRef, checkinLink, bookingInfo.CheckinCode))
 	err = utils.SendCheckInLinkEmail(
+		bookingInfo.ID,
+		GuestEmailLocale(s.DB, bookingID),
 		guestEmail,
 		bookingRef,
 		checkinLink,
@@ -188,6 +220,6 @@ func (s *BookingInfoService) InitiateCheckIn(bookingID uint) (models.BookingInfo
 		return bookingInfo, errors.New("email_send_failed")
 	}
 
-	_ = s.DB.Model(&bookingInfo).Update("email_status", "SENT").Error
+	_ = s.DB.Model(&bookingInfo).Update("email_status", checkinEmailStatus()).Error
 	return bookingInfo, nil
 }
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// CaptchaVerifier ตรวจ CAPTCHA token จากหน้าเช็คอินสาธารณะ (validate / resend code)
+type CaptchaVerifier interface {
+	// Enabled: false = ไม่บังคับ CAPTCHA (ไม่ต้องส่ง token)
+	Enabled() bool
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+// NoopCaptcha ไม่ตรวจอะไร (ค่าเริ่มต้นเมื่อไม่ได้ตั้ง CAPTCHA_SECRET)
+type NoopCaptcha struct{}
+
+func (NoopCaptcha) Enabled() bool                                { return false }
+func (NoopCaptcha) Verify(context.Context, string, string) error { return nil }
+
+// SiteVerifyCaptcha ใช้ได้กับ reCAPTCHA, hCaptcha และ Cloudflare Turnstile
+// (ทั้งสามรับ POST form secret/response/remoteip และตอบ JSON {"success": bool})
+type SiteVerifyCaptcha struct {
+	VerifyURL string
+	Secret    string
+	Client    *http.Client
+}
+
+var captchaVerifyURLs = map[string]string{
+	"recaptcha": "https://www.google.com/recaptcha/api/siteverify",
+	"hcaptcha":  "https://api.hcaptcha.com/siteverify",
+	"turnstile": "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+}
+
+// NewCaptchaVerifierFromEnv: CAPTCHA_SECRET (ว่าง = ปิด), CAPTCHA_PROVIDER (recaptcha|hcaptcha|turnstile, ค่าเริ่มต้น turnstile)
+// หรือ CAPTCHA_VERIFY_URL สำหรับ endpoint อื่น
+func NewCaptchaVerifierFromEnv() CaptchaVerifier {
+	secret := strings.TrimSpace(os.Getenv("CAPTCHA_SECRET"))
+	if secret == "" {
+		return NoopCaptcha{}
+	}
+	verifyURL := strings.TrimSpace(os.Getenv("CAPTCHA_VERIFY_URL"))
+	if verifyURL == "" {
+		provider := strings.ToLower(strings.TrimSpace(os.Getenv("CAPTCHA_PROVIDER")))
+		if provider == "" {
+			provider = "turnstile"
+		}
+		verifyURL = captchaVerifyURLs[provider]
+		if verifyURL == "" {
+			log.Printf("captcha: unknown CAPTCHA_PROVIDER %q, captcha disabled", provider)
+			return NoopCaptcha{}
+		}
+	}
+	return &SiteVerifyCaptcha{
+		VerifyURL: verifyURL,
+		Secret:    secret,
+		Client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *SiteVerifyCaptcha) Enabled() bool { return true }
+
+// Verify คืน "captcha_required" ถ้าไม่ส่ง token และ "captcha_failed" ถ้า provider ปฏิเสธ
+func (s *SiteVerifyCaptcha) Verify(ctx context.Context, token, remoteIP string) error {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return errors.New("captcha_required")
+	}
+	form := url.Values{"secret": {s.Secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.VerifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Success    bool     `json:"success"`
+		ErrorCodes []string `json:"error-codes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return err
+	}
+	if !out.Success {
+		return errors.New("captcha_failed: " + strings.Join(out.ErrorCodes, ","))
+	}
+	return nil
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"hotel-backend/models"
+	"hotel-backend/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CheckinCampaignPolicy: ส่งคำเชิญเช็คอินออนไลน์อัตโนมัติก่อนวันเข้าพัก (อ่านจาก hotel settings)
+type CheckinCampaignPolicy struct {
+	Enabled          bool `json:"enabled"`
+	InviteDaysBefore int  `json:"inviteDaysBefore"`
+	ReminderHour     int  `json:"reminderHour"`
+}
+
+// LoadCheckinCampaignPolicy อ่าน policy จาก hotel settings (ไม่มีแถว = ปิด)
+func LoadCheckinCampaignPolicy(db *gorm.DB) CheckinCampaignPolicy {
+	var hotel models.HotelSetting
+	if err := db.Select("checkin_invite_enabled", "checkin_invite_days_before", "checkin_reminder_hour").
+		Order("id ASC").First(&hotel).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("LoadCheckinCampaignPolicy: %v (campaign disabled)", err)
+		}
+		return CheckinCampaignPolicy{}
+	}
+	policy := CheckinCampaignPolicy{
+		Enabled:          hotel.CheckinInviteEnabled,
+		InviteDaysBefore: hotel.CheckinInviteDaysBefore,
+		ReminderHour:     hotel.CheckinReminderHour,
+	}
+	if policy.ReminderHour < 0 || policy.ReminderHour > 23 {
+		policy.ReminderHour = 8
+	}
+	return policy
+}
+
+// CheckinCampaignSummary: ผลการรันหนึ่งรอบ
+type CheckinCampaignSummary struct {
+	Invitations int `json:"invitations"`
+	Reminders   int `json:"reminders"`
+	Skipped     int `json:"skipped"`
+	Failures    int `json:"failures"`
+}
+
+const (
+	checkinInviteMaxAttempts = 3
+	// เว้นระยะระหว่างอีเมลสองฉบับของ booking เดียวกัน (เช่นจองกระชั้นชิด ได้คำเชิญแล้วไม่ต้องเตือน D-1 ทันที)
+	checkinInviteMinGap = 12 * time.Hour
+	// แถว SENDING ที่ค้างนานกว่านี้ (process ตายระหว่างส่ง) ให้รอบถัดไปจองใหม่ได้
+	checkinInviteClaimTTL = 30 * time.Minute
+)
+
+// CheckinCampaignService: คำเชิญ N วันก่อนเข้าพัก, เตือน D-1 และเช้าวันเข้าพักถ้ายังไม่เช็คอินออนไลน์
+// การส่งแต่ละครั้งบันทึกใน CheckinInvitation (หนึ่งแถวต่อ booking ต่อ kind)
+type CheckinCampaignService struct {
+	DB       *gorm.DB
+	Bookings *BookingService
+}
+
+func NewCheckinCampaignService(db *gorm.DB) *CheckinCampaignService {
+	return &CheckinCampaignService{DB: db, Bookings: NewBookingService(db)}
+}
+
+// civilDays จำนวนวันตามปฏิทินจาก from ถึง to ตามวันที่ใน timezone ของ from (ส่งเวลาท้องถิ่นของโรงแรมมา)
+// to ที่เป็นวันที่ล้วน (00:00) ใช้วันที่ตามที่เก็บ — ค่าที่มีเวลาแปลงเข้า timezone ของ from ก่อน
+func civilDays(from, to time.Time) int {
+	if h, m, s := to.Clock(); h != 0 || m != 0 || s != 0 {
+		to = to.In(from.Location())
+	}
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+func invitationDone(row *models.CheckinInvitation) bool {
+	return row != nil && (row.Status == models.CheckinInviteSent || row.Status == models.CheckinInviteSkipped || row.Attempts >= checkinInviteMaxAttempts)
+}
+
+// RunDue ส่งอีเมลที่ถึงกำหนด — เรียกจาก job ทุก ~15 นาที (ส่งซ้ำไม่ได้เพราะมีแถวต่อ kind)
+func (s *CheckinCampaignService) RunDue(ctx context.Context, now time.Time) (CheckinCampaignSummary, error) {
+	var summary CheckinCampaignSummary
+	policy := LoadCheckinCampaignPolicy(s.DB)
+	if !policy.Enabled {
+		return summary, nil
+	}
+
+	// วัน/ชั่วโมงนับตามเวลาท้องถิ่นของโรงแรม (server เป็น UTC)
+	local := now.In(HotelLocation(s.DB))
+
+	// ดึงกว้างกว่าช่วงจริงหนึ่งวันทั้งสองด้าน แล้วคัดด้วย civilDays (วันที่เก็บเป็น 00:00 ของ timezone ที่ parse)
+	from := now.AddDate(0, 0, -1)
+	to := now.AddDate(0, 0, policy.InviteDaysBefore+2)
+	var bookings []models.Booking
+	if err := s.DB.
+		Where("deleted_at IS NULL AND checked_in_at IS NULL").
+		Where("LOWER(status) NOT IN ?", []string{"checked-in", "checked in", "checked-out", "cancelled", "canceled"}).
+		Where("COALESCE(check_in_date, check_in) >= ? AND COALESCE(check_in_date, check_in) < ?", from, to).
+		Find(&bookings).Error; err != nil {
+		return summary, err
+	}
+
+	for _, b := range bookings {
+		if ctx.Err() != nil {
+			return summary, ctx.Err()
+		}
+		arrival, _ := bookingStay(b)
+		if arrival == nil {
+			continue
+		}
+		days := civilDays(local, *arrival)
+		if days < 0 || days > policy.InviteDaysBefore {
+			continue
+		}
+		if err := s.processBooking(b, days, local.Hour(), policy, now, &summary); err != nil {
+			summary.Failures++
+			log.Printf("checkin campaign: booking %d: %v", b.ID, err)
+		}
+	}
+	return summary, nil
+}
+
+// localHour: ชั่วโมงปัจจุบันตามเวลาท้องถิ่นของโรงแรม (เทียบกับ ReminderHour)
+func (s *CheckinCampaignService) processBooking(b models.Booking, days, localHour int, policy CheckinCampaignPolicy, now time.Time, summary *CheckinCampaignSummary) error {
+	var rows []models.CheckinInvitation
+	if err := s.DB.Where("booking_id = ?", b.ID).Find(&rows).Error; err != nil {
+		return err
+	}
+	byKind := map[string]*models.CheckinInvitation{}
+	var lastSent *time.Time
+	for i := range rows {
+		byKind[rows[i].Kind] = &rows[i]
+		if rows[i].SentAt != nil && (lastSent == nil || rows[i].SentAt.After(*lastSent)) {
+			lastSent = rows[i].SentAt
+		}
+	}
+
+	var bi *models.BookingInfo
+	var latest models.BookingInfo
+	err := s.DB.Where("booking_id = ? AND deleted_at IS NULL", b.ID).Order("id DESC").First(&latest).Error
+	switch {
+	case err == nil:
+		bi = &latest
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+	// เช็คอินออนไลน์เสร็จแล้ว — หยุดส่ง
+	if bi != nil && (bi.Status == "COMPLETED" || bi.Status == "PENDING_REVIEW") {
+		return nil
+	}
+
+	if !invitationDone(byKind[models.CheckinInviteKindInvitation]) {
+		row, claimed, err := s.claim(byKind[models.CheckinInviteKindInvitation], b.ID, models.CheckinInviteKindInvitation, now)
+		if err != nil || !claimed {
+			return err
+		}
+		return s.sendInvitation(b, bi, row, now, summary)
+	}
+
+	var kind string
+	switch {
+	case days == 0 && localHour >= policy.ReminderHour:
+		kind = models.CheckinInviteKindReminderArrival
+	case days == 1 && localHour >= policy.ReminderHour:
+		kind = models.CheckinInviteKindReminderDayBefore
+	default:
+		return nil
+	}
+	if invitationDone(byKind[kind]) || (lastSent != nil && now.Sub(*lastSent) < checkinInviteMinGap) {
+		return nil
+	}
+	// ต้องมีลิงก์ที่ยังใช้ได้และยังไม่เริ่มเช็คอิน
+	if bi == nil || bi.Status != "INITIATED" || (bi.ExpiresAt != nil && !now.Before(*bi.ExpiresAt)) {
+		return nil
+	}
+	row, claimed, err := s.claim(byKind[kind], b.ID, kind, now)
+	if err != nil || !claimed {
+		return err
+	}
+	return s.sendReminder(b, *bi, kind, days == 0, row, now, summary)
+}
+
+// claim จองแถว (booking_id, kind) ก่อนส่ง — ส่งเฉพาะเมื่อจองได้ (หลาย replica รัน job พร้อมกันได้)
+// แถวใหม่ใช้ unique index กันซ้ำ, แถวเดิมอัปเดตแบบมีเงื่อนไขตามสถานะที่อ่านมา
+func (s *CheckinCampaignService) claim(row *models.CheckinInvitation, bookingID uint, kind string, now time.Time) (*models.CheckinInvitation, bool, error) {
+	if row == nil {
+		row = &models.CheckinInvitation{BookingID: bookingID, Kind: kind, Status: models.CheckinInviteSending}
+		res := s.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(row)
+		if res.Error != nil {
+			return nil, false, res.Error
+		}
+		return row, res.RowsAffected == 1, nil
+	}
+	if row.Status == models.CheckinInviteSending && now.Sub(row.UpdatedAt) < checkinInviteClaimTTL {
+		return row, false, nil
+	}
+	res := s.DB.Model(&models.CheckinInvitation{}).
+		Where("id = ? AND status = ? AND attempts = ?", row.ID, row.Status, row.Attempts).
+		Updates(map[string]interface{}{"status": models.CheckinInviteSending, "updated_at": now.UTC()})
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return row, false, nil
+	}
+	row.Status = models.CheckinInviteSending
+	return row, true, nil
+}
+
+// sendInvitation สร้าง BookingInfo + ส่งลิงก์ (InitiateCheckInProcess) — ถ้าพนักงานส่งไปแล้วถือว่า SKIPPED
+func (s *CheckinCampaignService) sendInvitation(b models.Booking, bi *models.BookingInfo, row *models.CheckinInvitation, now time.Time, summary *CheckinCampaignSummary) error {
+	if bi != nil && (bi.ExpiresAt == nil || now.Before(*bi.ExpiresAt)) {
+		// รอคิว outbox, SENT หรือมีผลจาก delivery webhook แล้ว (DELIVERED / OPENED / BOUNCED ...) = ส่งไปแล้ว
+		if bi.EmailStatus == models.EmailStatusQueued || emailEventRank[bi.EmailStatus] > 0 {
+			summary.Skipped++
+			return s.record(row, b.ID, models.CheckinInviteKindInvitation, bi.ID, bi.GuestEmail, models.CheckinInviteSkipped, "check-in link already sent", now)
+		}
+		// มีลิงก์อยู่แล้วแต่ส่งอีเมลไม่สำเร็จ — ส่งลิงก์เดิมอีกครั้ง
+		if err := s.Bookings.SendCheckinLinkEmail(*bi); err != nil {
+			summary.Failures++
+			return s.record(row, b.ID, models.CheckinInviteKindInvitation, bi.ID, bi.GuestEmail, models.CheckinInviteFailed, err.Error(), now)
+		}
+		summary.Invitations++
+		return s.record(row, b.ID, models.CheckinInviteKindInvitation, bi.ID, bi.GuestEmail, models.CheckinInviteSent, "", now)
+	}
+
+	info, err := s.Bookings.InitiateCheckInProcess(b.ID)
+	if err != nil {
+		if strings.Contains(err.Error(), "checkin_already_initiated") {
+			summary.Skipped++
+			return s.record(row, b.ID, models.CheckinInviteKindInvitation, 0, "", models.CheckinInviteSkipped, "check-in already initiated", now)
+		}
+		summary.Failures++
+		return s.record(row, b.ID, models.CheckinInviteKindInvitation, info.ID, info.GuestEmail, models.CheckinInviteFailed, err.Error(), now)
+	}
+	summary.Invitations++
+	return s.record(row, b.ID, models.CheckinInviteKindInvitation, info.ID, info.GuestEmail, models.CheckinInviteSent, "", now)
+}
+
+func (s *CheckinCampaignService) sendReminder(b models.Booking, bi models.BookingInfo, kind string, arrivalToday bool, row *models.CheckinInvitation, now time.Time, summary *CheckinCampaignSummary) error {
+	recipient := strings.TrimSpace(bi.GuestEmail)
+	if recipient == "" {
+		summary.Skipped++
+		return s.record(row, b.ID, kind, bi.ID, "", models.CheckinInviteSkipped, "no guest email", now)
+	}
+
+	link := utils.BuildCheckinLink(utils.EnvOrDefault("FRONTEND_URL", "http://localhost:3000"), bi.Token, true)
+	link = utils.AppendBookingIDParam(link, b.ID)
+	arrival, _ := bookingStay(b)
+	var customer models.Customer
+	if err := s.DB.First(&customer, b.CustomerID).Error; err == nil {
+		NotifyCustomerAsync(customer, fmt.Sprintf("checkin reminder booking_info=%d", bi.ID),
+			utils.CheckinReminderText(b.ReferenceCode, link, receiptDate(arrival, nil), arrivalToday))
+	} else {
+		log.Printf("checkin campaign: booking %d customer: %v", b.ID, err)
+	}
+	if err := utils.SendCheckInReminderEmail(bi.ID, GuestEmailLocale(s.DB, b.ID), recipient, bi.GuestLastName, b.ReferenceCode, link, receiptDate(arrival, nil), bi.CheckinCode, arrivalToday); err != nil {
+		summary.Failures++
+		return s.record(row, b.ID, kind, bi.ID, recipient, models.CheckinInviteFailed, err.Error(), now)
+	}
+	summary.Reminders++
+	return s.record(row, b.ID, kind, bi.ID, recipient, models.CheckinInviteSent, "", now)
+}
+
+// record บันทึก/อัปเดตแถวของ kind นั้น (นับ Attempts ทุกครั้ง)
+func (s *CheckinCampaignService) record(row *models.CheckinInvitation, bookingID uint, kind string, bookingInfoID uint, recipient, status, lastError string, now time.Time) error {
+	if row == nil {
+		row = &models.CheckinInvitation{BookingID: bookingID, Kind: kind}
+	}
+	row.Attempts++
+	row.Status = status
+	row.LastError = lastError
+	if bookingInfoID != 0 {
+		row.BookingInfoID = bookingInfoID
+	}
+	if recipient != "" {
+		row.Recipient = recipient
+	}
+	if status == models.CheckinInviteSent {
+		t := now.UTC()
+		row.SentAt = &t
+	}
+	return s.DB.Save(row).Error
+}
+
+// ListForBooking ประวัติคำเชิญ/การเตือนของ booking
+func (s *CheckinCampaignService) ListForBooking(bookingID uint) ([]models.CheckinInvitation, error) {
+	var rows []models.CheckinInvitation
+	err := s.DB.Where("booking_id = ?", bookingID).Order("id ASC").Find(&rows).Error
+	return rows, err
+}
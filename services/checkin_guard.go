@@ -0,0 +1,353 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"hotel-backend/models"
+	"hotel-backend/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CheckinGuardPolicy: เพดานการลองรหัสเช็คอินจากหน้าสาธารณะ (ตั้งผ่าน env)
+type CheckinGuardPolicy struct {
+	IPMaxAttempts   int           // CHECKIN_IP_MAX_ATTEMPTS ต่อ IP ต่อ IPWindow (ค่าเริ่มต้น 20)
+	IPWindow        time.Duration // CHECKIN_IP_WINDOW_MINUTES (15)
+	CodeMaxFailures int           // CHECKIN_CODE_MAX_FAILURES กรอกผิดติดกันกี่ครั้งจึงล็อกรหัส (5)
+	LockoutDuration time.Duration // CHECKIN_LOCKOUT_MINUTES (30)
+	ResendCooldown  time.Duration // CHECKIN_RESEND_COOLDOWN_SECONDS ส่งรหัสซ้ำได้ครั้งละ (60)
+}
+
+func envPositiveInt(name string, def int) int {
+	n, err := strconv.Atoi(strings.TrimSpace(os.Getenv(name)))
+	if err != nil || n <= 0 {
+		return def
+	}
+	return n
+}
+
+func LoadCheckinGuardPolicy() CheckinGuardPolicy {
+	return CheckinGuardPolicy{
+		IPMaxAttempts:   envPositiveInt("CHECKIN_IP_MAX_ATTEMPTS", 20),
+		IPWindow:        time.Duration(envPositiveInt("CHECKIN_IP_WINDOW_MINUTES", 15)) * time.Minute,
+		CodeMaxFailures: envPositiveInt("CHECKIN_CODE_MAX_FAILURES", 5),
+		LockoutDuration: time.Duration(envPositiveInt("CHECKIN_LOCKOUT_MINUTES", 30)) * time.Minute,
+		ResendCooldown:  time.Duration(envPositiveInt("CHECKIN_RESEND_COOLDOWN_SECONDS", 60)) * time.Second,
+	}
+}
+
+// CheckinThrottleError: ถูกจำกัดความถี่หรือรหัสถูกล็อก — Error() คืน code ("too_many_attempts" / "code_locked")
+type CheckinThrottleError struct {
+	Code       string
+	RetryAfter time.Duration
+}
+
+func (e *CheckinThrottleError) Error() string { return e.Code }
+
+// attemptLimiter นับครั้งต่อ key แบบ fixed window ในตาราง checkin_rate_limits (ใช้ร่วมกันทุก replica)
+type attemptLimiter struct {
+	db *gorm.DB
+}
+
+// แถวที่หมด window นานกว่านี้ถูกลบตอนเปิด window ใหม่ กันตารางโตไม่จำกัด
+const attemptLimiterRetention = 24 * time.Hour
+
+// hit นับ 1 ครั้ง — คืน false พร้อมเวลาที่ต้องรอถ้าเกิน max ใน window
+func (l *attemptLimiter) hit(key string, max int, window time.Duration, now time.Time) (bool, time.Duration, error) {
+	now = now.UTC()
+	allowed, wait := false, time.Duration(0)
+	err := l.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.CheckinRateLimit{Key: key, ResetAt: now.Add(window)}).Error; err != nil {
+			return err
+		}
+		var row models.CheckinRateLimit
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("limit_key = ?", key).First(&row).Error; err != nil {
+			return err
+		}
+		if !now.Before(row.ResetAt) {
+			row.Count, row.ResetAt = 0, now.Add(window)
+			if err := tx.Where("reset_at < ?", now.Add(-attemptLimiterRetention)).Delete(&models.CheckinRateLimit{}).Error; err != nil {
+				return err
+			}
+		}
+		if row.Count >= max {
+			wait = row.ResetAt.Sub(now)
+			return nil
+		}
+		allowed = true
+		return tx.Model(&models.CheckinRateLimit{}).Where("limit_key = ?", key).
+			Updates(map[string]interface{}{"count": row.Count + 1, "reset_at": row.ResetAt}).Error
+	})
+	return allowed, wait, err
+}
+
+// CheckinGuard: ตรวจรหัสเช็คอิน + นามสกุลจากหน้าสาธารณะ พร้อม throttle ต่อ IP, ล็อกรหัสเมื่อผิดเกินกำหนด และ CAPTCHA
+type CheckinGuard struct {
+	DB      *gorm.DB
+	Policy  CheckinGuardPolicy
+	Captcha CaptchaVerifier
+	limiter *attemptLimiter
+}
+
+func NewCheckinGuard(db *gorm.DB, captcha CaptchaVerifier) *CheckinGuard {
+	if captcha == nil {
+		captcha = NoopCaptcha{}
+	}
+	return &CheckinGuard{DB: db, Policy: LoadCheckinGuardPolicy(), Captcha: captcha, limiter: &attemptLimiter{db: db}}
+}
+
+// CheckinAttempt: ข้อมูลที่หน้าเช็คอินส่งมา
+type CheckinAttempt struct {
+	BookingInfoID uint
+	Code          string
+	Query         string // นามสกุล (ตรงทั้งคำ ไม่สนตัวพิมพ์) หรือ booking reference
+	CaptchaToken  string
+	Audit         ConsentAudit
+}
+
+// precheck: CAPTCHA + เพดานต่อ IP — นับทุกครั้งไม่ว่าผลจะเป็นอย่างไร
+func (g *CheckinGuard) precheck(ctx context.Context, a CheckinAttempt, now time.Time) error {
+	if g.Captcha.Enabled() {
+		if err := g.Captcha.Verify(ctx, a.CaptchaToken, a.Audit.IP); err != nil {
+			msg := err.Error()
+			if strings.Contains(msg, "captcha_required") || strings.Contains(msg, "captcha_failed") {
+				return err
+			}
+			return fmt.Errorf("captcha_unavailable: %w", err)
+		}
+	}
+	ok, wait, err := g.limiter.hit("ip:"+a.Audit.IP, g.Policy.IPMaxAttempts, g.Policy.IPWindow, now)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		log.Printf("checkin guard: ip %s throttled", a.Audit.IP)
+		return &CheckinThrottleError{Code: "too_many_attempts", RetryAfter: wait}
+	}
+	return nil
+}
+
+// findByCode หา BookingInfo จากรหัส (รวมที่หมดอายุแล้ว) — error "invalid_code_format" / "invalid_or_expired_code"
+func (g *CheckinGuard) findByCode(code string) (models.BookingInfo, error) {
+	var bi models.BookingInfo
+	norm := utils.NormalizeCheckinCode(code)
+	if len(norm) != 8 {
+		return bi, errors.New("invalid_code_format")
+	}
+	norm = strings.ToUpper(norm)
+	err := g.DB.Where("checkin_code IN ?", []string{norm[:4] + "-" + norm[4:], norm}).
+		Order("id DESC").First(&bi).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return bi, errors.New("invalid_or_expired_code")
+	}
+	return bi, err
+}
+
+func checkinLocked(bi models.BookingInfo, now time.Time) error {
+	if bi.LockedUntil != nil && now.Before(*bi.LockedUntil) {
+		return &CheckinThrottleError{Code: "code_locked", RetryAfter: bi.LockedUntil.Sub(now)}
+	}
+	return nil
+}
+
+func normalizeCheckinName(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// checkinQueryMatches: query ต้องตรงกับนามสกุล (คำสุดท้ายของชื่อ), ชื่อเต็ม หรือ booking reference แบบตรงทั้งคำ
+func checkinQueryMatches(query string, names []string, reference string) bool {
+	q := normalizeCheckinName(query)
+	if q == "" {
+		return false
+	}
+	if ref := normalizeCheckinName(reference); ref != "" && q == ref {
+		return true
+	}
+	for _, name := range names {
+		full := normalizeCheckinName(name)
+		if full == "" {
+			continue
+		}
+		if q == full {
+			return true
+		}
+		if parts := strings.Fields(full); q == parts[len(parts)-1] {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *CheckinGuard) queryMatches(bi models.BookingInfo, query string) (bool, error) {
+	var booking models.Booking
+	if err := g.DB.Preload("Customer").Select("id", "customer_id", "reference_code").First(&booking, bi.BookingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return checkinQueryMatches(query, []string{bi.GuestLastName, booking.Customer.FullName}, booking.ReferenceCode), nil
+}
+
+// recordFailure นับครั้งที่ผิดบน BookingInfo และล็อกรหัสเมื่อครบ CodeMaxFailures
+// เพิ่มค่าใน DB ภายใต้ row lock แล้วตัดสินจากค่าที่อ่านกลับ — request ที่ผิดพร้อมกันจึงนับครบทุกครั้ง
+func (g *CheckinGuard) recordFailure(bi models.BookingInfo, ip string, now time.Time) error {
+	var failed int
+	locked := false
+	retryAfter := g.Policy.LockoutDuration
+	err := g.DB.Transaction(func(tx *gorm.DB) error {
+		var row models.BookingInfo
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "failed_attempts", "locked_until").
+			First(&row, bi.ID).Error; err != nil {
+			return err
+		}
+		// request อื่นล็อกไปแล้วระหว่างที่รอ lock
+		if row.LockedUntil != nil && now.Before(*row.LockedUntil) {
+			locked = true
+			retryAfter = row.LockedUntil.Sub(now)
+			return nil
+		}
+		if err := tx.Model(&models.BookingInfo{}).Where("id = ?", bi.ID).Updates(map[string]interface{}{
+			"failed_attempts": gorm.Expr("failed_attempts + 1"),
+			"last_failed_at":  now,
+			"last_failed_ip":  ip,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.BookingInfo{}).Where("id = ?", bi.ID).
+			Select("failed_attempts").Scan(&failed).Error; err != nil {
+			return err
+		}
+		if failed < g.Policy.CodeMaxFailures {
+			return nil
+		}
+		locked = true
+		return tx.Model(&models.BookingInfo{}).Where("id = ?", bi.ID).Updates(map[string]interface{}{
+			"failed_attempts": 0,
+			"locked_at":       now,
+			"locked_until":    now.Add(g.Policy.LockoutDuration),
+			"lock_count":      gorm.Expr("lock_count + 1"),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	if locked {
+		if failed > 0 {
+			log.Printf("checkin guard: booking_info %d locked until %s after %d failures (last ip %s)",
+				bi.ID, now.Add(g.Policy.LockoutDuration).Format(time.RFC3339), failed, ip)
+		}
+		return &CheckinThrottleError{Code: "code_locked", RetryAfter: retryAfter}
+	}
+	return errors.New("invalid_or_expired_code")
+}
+
+// Validate ตรวจรหัส + นามสกุลจากหน้าเช็คอิน
+// errors: captcha_required, captcha_failed, too_many_attempts, code_locked, invalid_code_format, query_required,
+// invalid_or_expired_code (ไม่พบ/ชื่อไม่ตรง — ไม่แยกกันเพื่อไม่ให้เดาได้), code_expired, checkin_link_expired,
+// checkin_not_open, booking_checked_out
+func (g *CheckinGuard) Validate(ctx context.Context, a CheckinAttempt, now time.Time) (models.BookingInfo, error) {
+	now = now.UTC()
+	if err := g.precheck(ctx, a, now); err != nil {
+		return models.BookingInfo{}, err
+	}
+	if strings.TrimSpace(a.Query) == "" {
+		return models.BookingInfo{}, errors.New("query_required")
+	}
+	bi, err := g.findByCode(a.Code)
+	if err != nil {
+		return bi, err
+	}
+	if err := checkinLocked(bi, now); err != nil {
+		return bi, err
+	}
+	ok, err := g.queryMatches(bi, a.Query)
+	if err != nil {
+		return bi, err
+	}
+	if !ok {
+		return models.BookingInfo{}, g.recordFailure(bi, a.Audit.IP, now)
+	}
+
+	if bi.FailedAttempts > 0 {
+		_ = g.DB.Model(&models.BookingInfo{}).Where("id = ?", bi.ID).Update("failed_attempts", 0).Error
+		bi.FailedAttempts = 0
+	}
+	if bi.ExpiresAt != nil && !now.Before(*bi.ExpiresAt) {
+		return bi, errors.New("checkin_link_expired")
+	}
+	if bi.CodeExpiresAt != nil && !now.Before(*bi.CodeExpiresAt) {
+		return bi, errors.New("code_expired")
+	}
+	if err := CheckinOpen(bi, now); err != nil {
+		return bi, err
+	}
+	var booking models.Booking
+	if err := g.DB.Select("status").First(&booking, bi.BookingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return bi, errors.New("booking_not_found")
+		}
+		return bi, err
+	}
+	if strings.EqualFold(strings.TrimSpace(booking.Status), "Checked-Out") {
+		return bi, errors.New("booking_checked_out")
+	}
+	return bi, nil
+}
+
+// AuthorizeResend ตรวจสิทธิ์ขอส่งรหัสซ้ำ (ต้องรู้ bookingInfoId หรือรหัส + นามสกุล) และ cooldown ต่อ BookingInfo
+// ผ่านแล้ว caller จึงต่ออายุรหัสและส่งอีเมล
+func (g *CheckinGuard) AuthorizeResend(ctx context.Context, a CheckinAttempt, now time.Time) (models.BookingInfo, error) {
+	now = now.UTC()
+	if err := g.precheck(ctx, a, now); err != nil {
+		return models.BookingInfo{}, err
+	}
+	if strings.TrimSpace(a.Query) == "" {
+		return models.BookingInfo{}, errors.New("query_required")
+	}
+
+	var bi models.BookingInfo
+	var err error
+	switch {
+	case a.BookingInfoID > 0:
+		err = g.DB.First(&bi, a.BookingInfoID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = errors.New("invalid_or_expired_code")
+		}
+	case strings.TrimSpace(a.Code) != "":
+		bi, err = g.findByCode(a.Code)
+	default:
+		return bi, errors.New("missing_booking_info")
+	}
+	if err != nil {
+		return models.BookingInfo{}, err
+	}
+	if err := checkinLocked(bi, now); err != nil {
+		return models.BookingInfo{}, err
+	}
+	ok, err := g.queryMatches(bi, a.Query)
+	if err != nil {
+		return models.BookingInfo{}, err
+	}
+	if !ok {
+		return models.BookingInfo{}, g.recordFailure(bi, a.Audit.IP, now)
+	}
+	allowed, wait, err := g.limiter.hit(fmt.Sprintf("resend:%d", bi.ID), 1, g.Policy.ResendCooldown, now)
+	if err != nil {
+		return models.BookingInfo{}, err
+	}
+	if !allowed {
+		return models.BookingInfo{}, &CheckinThrottleError{Code: "too_many_attempts", RetryAfter: wait}
+	}
+	return bi, nil
+}
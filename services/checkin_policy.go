@@ -0,0 +1,137 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"hotel-backend/models"
+	"hotel-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// CheckinLinkPolicy: อายุของลิงก์ (token) และรหัส 8 หลักของเช็คอินออนไลน์ อ่านจาก hotel settings
+// ทุกจุดที่สร้างหรือต่ออายุ BookingInfo ต้องผ่าน Apply / Extend
+type CheckinLinkPolicy struct {
+	OpenDaysBefore     int  `json:"openDaysBefore"`
+	LinkNeverExpire    bool `json:"linkNeverExpire"`
+	CodeValidityHours  int  `json:"codeValidityHours"`
+	CodeResendMinutes  int  `json:"codeResendMinutes"`
+	CodeNeverExpireEnv bool `json:"codeNeverExpireEnv"` // CHECKIN_CODE_NEVER_EXPIRE=true (ทางหนีไฟเดิม) — รหัสไม่หมดอายุ
+
+	// Location timezone ของโรงแรม — ขอบวันของ OpensAt / ExpiresAt (nil = UTC)
+	Location *time.Location `json:"-"`
+}
+
+// LoadCheckinLinkPolicy อ่าน policy จาก hotel settings (ไม่มีแถว = ค่าเริ่มต้นของ model)
+func LoadCheckinLinkPolicy(db *gorm.DB) CheckinLinkPolicy {
+	policy := CheckinLinkPolicy{OpenDaysBefore: 7, CodeValidityHours: 168, CodeResendMinutes: 15}
+	var hotel models.HotelSetting
+	if err := db.Select("checkin_link_open_days_before", "checkin_link_never_expire", "checkin_code_validity_hours", "checkin_code_resend_minutes").
+		Order("id ASC").First(&hotel).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("LoadCheckinLinkPolicy: %v (using defaults)", err)
+		}
+	} else {
+		policy = CheckinLinkPolicy{
+			OpenDaysBefore:    hotel.CheckinLinkOpenDaysBefore,
+			LinkNeverExpire:   hotel.CheckinLinkNeverExpire,
+			CodeValidityHours: hotel.CheckinCodeValidityHours,
+			CodeResendMinutes: hotel.CheckinCodeResendMinutes,
+		}
+	}
+	if policy.CodeResendMinutes <= 0 {
+		policy.CodeResendMinutes = 15
+	}
+	policy.CodeNeverExpireEnv = strings.EqualFold(utils.EnvOrDefault("CHECKIN_CODE_NEVER_EXPIRE", "false"), "true")
+	policy.Location = HotelLocation(db)
+	return policy
+}
+
+// bookingStay วันเข้าพัก/check-out ของ booking (ใช้ check_in_date/check_out_date ก่อน แล้วจึง check_in/check_out)
+func bookingStay(b models.Booking) (arrival, departure *time.Time) {
+	arrival, departure = b.CheckInDate, b.CheckOutDate
+	if arrival == nil {
+		arrival = b.CheckIn
+	}
+	if departure == nil {
+		departure = b.CheckOut
+	}
+	return arrival, departure
+}
+
+// linkWindow ช่วงเวลาที่ลิงก์ใช้ได้ตามวันของโรงแรม (Location) — เปิดตอนต้นวัน N วันก่อนเข้าพัก
+// วันที่ไม่มีเวลา (00:00) ถือว่าหมดอายุตอนสิ้นวัน check-out
+func (p CheckinLinkPolicy) linkWindow(b models.Booking) (opensAt, expiresAt *time.Time) {
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	arrival, departure := bookingStay(b)
+	if arrival != nil && p.OpenDaysBefore > 0 {
+		y, m, d := hotelCivilDate(*arrival, loc)
+		t := time.Date(y, m, d-p.OpenDaysBefore, 0, 0, 0, 0, loc).UTC()
+		opensAt = &t
+	}
+	if departure != nil && !p.LinkNeverExpire {
+		t := departure.UTC()
+		if h, m, s := departure.Clock(); h == 0 && m == 0 && s == 0 {
+			y, mo, d := hotelCivilDate(*departure, loc)
+			t = time.Date(y, mo, d+1, 0, 0, 0, 0, loc).Add(-time.Second).UTC()
+		}
+		expiresAt = &t
+	}
+	return opensAt, expiresAt
+}
+
+// hotelCivilDate วันที่ตามปฏิทินของโรงแรม — ค่าที่เป็นวันที่ล้วน (00:00) ใช้วันที่ตามที่เก็บ (เหมือน civilDays)
+func hotelCivilDate(t time.Time, loc *time.Location) (int, time.Month, int) {
+	if h, m, s := t.Clock(); h != 0 || m != 0 || s != 0 {
+		t = t.In(loc)
+	}
+	return t.Date()
+}
+
+// codeExpiry: now + CodeValidityHours แต่ไม่เกินอายุลิงก์
+func (p CheckinLinkPolicy) codeExpiry(validity time.Duration, linkExpires *time.Time, now time.Time) *time.Time {
+	if p.CodeNeverExpireEnv {
+		return nil
+	}
+	if validity <= 0 {
+		return linkExpires
+	}
+	t := now.UTC().Add(validity)
+	if linkExpires != nil && linkExpires.Before(t) {
+		t = *linkExpires
+	}
+	return &t
+}
+
+// Apply ตั้ง OpensAt / ExpiresAt / CodeExpiresAt ของ BookingInfo ที่กำลังจะสร้าง
+func (p CheckinLinkPolicy) Apply(bi *models.BookingInfo, b models.Booking, now time.Time) {
+	bi.OpensAt, bi.ExpiresAt = p.linkWindow(b)
+	bi.CodeExpiresAt = p.codeExpiry(time.Duration(p.CodeValidityHours)*time.Hour, bi.ExpiresAt, now)
+}
+
+// Extend ต่ออายุรหัสตอนขอส่งซ้ำ (CodeResendMinutes) — error "checkin_link_expired" ถ้าลิงก์หมดอายุแล้ว
+func (p CheckinLinkPolicy) Extend(bi models.BookingInfo, now time.Time) (*time.Time, error) {
+	if bi.ExpiresAt != nil && !now.Before(*bi.ExpiresAt) {
+		return nil, errors.New("checkin_link_expired")
+	}
+	next := p.codeExpiry(time.Duration(p.CodeResendMinutes)*time.Minute, bi.ExpiresAt, now)
+	// ไม่ย่นอายุรหัสที่ยังเหลือมากกว่า
+	if next != nil && bi.CodeExpiresAt != nil && bi.CodeExpiresAt.After(*next) {
+		return bi.CodeExpiresAt, nil
+	}
+	return next, nil
+}
+
+// CheckinOpen: error "checkin_not_open" ถ้ายังไม่ถึงเวลาเปิดลิงก์
+func CheckinOpen(bi models.BookingInfo, now time.Time) error {
+	if bi.OpensAt != nil && now.Before(*bi.OpensAt) {
+		return errors.New("checkin_not_open")
+	}
+	return nil
+}
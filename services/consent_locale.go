@@ -0,0 +1,132 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+
+	"hotel-backend/models"
+)
+
+// ภาษาที่รองรับสำหรับข้อความ consent
+var ConsentLocales = []string{"th", "en", "zh", "ja"}
+
+const defaultConsentLocale = "th"
+
+// NormalizeConsentLocale: "en-US" -> "en", "zh-Hant-TW" -> "zh" (ไม่รองรับ = "")
+func NormalizeConsentLocale(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	for _, l := range ConsentLocales {
+		if tag == l {
+			return l
+		}
+	}
+	return ""
+}
+
+// NegotiateConsentLocale เลือกภาษา: ?locale= ก่อน แล้วค่อย Accept-Language (ตาม q) ไม่ตรงเลย = th
+func NegotiateConsentLocale(requested, acceptLanguage string) string {
+	if l := NormalizeConsentLocale(requested); l != "" {
+		return l
+	}
+
+	type candidate struct {
+		locale string
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		locale := NormalizeConsentLocale(fields[0])
+		if locale == "" {
+			continue
+		}
+		q := 1.0
+		for _, f := range fields[1:] {
+			f = strings.TrimSpace(f)
+			if strings.HasPrefix(f, "q=") {
+				if v, err := strconv.ParseFloat(f[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{locale, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return defaultConsentLocale
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].locale
+}
+
+func consentBaseLocale(c models.Consent) string {
+	if l := NormalizeConsentLocale(c.BaseLocale); l != "" {
+		return l
+	}
+	return defaultConsentLocale
+}
+
+// ConsentTextHash: sha256 ของข้อความที่แสดงจริง (slug/version/locale/title/description)
+func ConsentTextHash(c models.Consent, locale, title, description string) string {
+	h := sha256.New()
+	for _, part := range []string{c.Slug, c.Version, locale, title, description} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// LocalizeConsent คืน consent ที่ Title/Description เป็นภาษาที่ขอ (ไม่มีคำแปล = ภาษาหลัก)
+// พร้อม Locale และ TextHash ของข้อความนั้น — ต้อง preload Translations ก่อน
+func LocalizeConsent(c models.Consent, locale string) models.Consent {
+	out := c
+	out.Locale = consentBaseLocale(c)
+	if locale = NormalizeConsentLocale(locale); locale != "" && locale != out.Locale {
+		for _, t := range c.Translations {
+			if NormalizeConsentLocale(t.Locale) == locale && strings.TrimSpace(t.Title) != "" {
+				out.Title = t.Title
+				out.Description = t.Description
+				out.Locale = locale
+				break
+			}
+		}
+	}
+	out.TextHash = ConsentTextHash(c, out.Locale, out.Title, out.Description)
+	out.Translations = nil
+	return out
+}
+
+func localizeConsents(list []models.Consent, locale string) []models.Consent {
+	out := make([]models.Consent, len(list))
+	for i, c := range list {
+		out[i] = LocalizeConsent(c, locale)
+	}
+	return out
+}
+
+// ConsentVersionHash: sha256 ของเนื้อหาทุกภาษาของ consent หนึ่งเวอร์ชัน — ต้อง preload Translations ก่อน
+func ConsentVersionHash(c models.Consent) string {
+	translations := make([]models.ConsentTranslation, len(c.Translations))
+	copy(translations, c.Translations)
+	sort.Slice(translations, func(i, j int) bool { return translations[i].Locale < translations[j].Locale })
+
+	h := sha256.New()
+	for _, part := range []string{c.Slug, c.Version, consentBaseLocale(c), c.Title, c.Description} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	for _, t := range translations {
+		for _, part := range []string{NormalizeConsentLocale(t.Locale), t.Title, t.Description} {
+			h.Write([]byte(part))
+			h.Write([]byte{0})
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -1,14 +1,29 @@
 package services
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"hotel-backend/config"
 	"hotel-backend/models"
+	"hotel-backend/utils"
 )
 
 // ConsentLogService manages consent logs (accepted consents).
+//
+// consent_logs เป็น append-only: ไม่มีการ UPDATE/DELETE — การผูก booking และการถอนความยินยอมเป็นแถวใหม่ที่อ้างถึงแถวเดิม (RefID)
+// แต่ละแถวอยู่ใน hash chain (ChainKey) ของ booking / guest / token: EntryHash = H(เนื้อหาแถว + PrevHash)
+// และ ConsentChainHead เก็บ entry ล่าสุดของ chain เพื่อจับการลบแถวท้าย chain
+// ถ้าตั้ง CONSENT_AUDIT_KEY จะใช้ HMAC-SHA256 (ปลอม hash ใหม่ไม่ได้ถ้าไม่มี key)
 type ConsentLogService struct {
 	DB *gorm.DB
 }
@@ -20,12 +35,165 @@ func NewConsentLogService(db *gorm.DB) *ConsentLogService {
 	return &ConsentLogService{DB: db}
 }
 
-func (s *ConsentLogService) Log(cl *models.ConsentLog) error {
+// ConsentAudit: ข้อมูลของ request ที่ทำให้เกิด log (เก็บเป็นหลักฐาน)
+type ConsentAudit struct {
+	IP        string
+	UserAgent string
+}
+
+const (
+	ConsentActionAccepted  = "accepted"
+	ConsentActionAttached  = "attached"
+	ConsentActionWithdrawn = "withdrawn"
+)
+
+// consentChainKey: chain ของแถว — booking ก่อน, ไม่งั้น guest, ไม่งั้น token
+func consentChainKey(cl *models.ConsentLog) string {
+	switch {
+	case cl.BookingID != nil:
+		return fmt.Sprintf("booking:%d", *cl.BookingID)
+	case cl.GuestID != nil:
+		return fmt.Sprintf("guest:%d", *cl.GuestID)
+	case cl.BookingToken != nil && *cl.BookingToken != "":
+		return "token:" + *cl.BookingToken
+	}
+	return "global"
+}
+
+// consentEntryDigest: ฟิลด์ทั้งหมดที่อยู่ใน hash (ลำดับคงที่)
+type consentEntryDigest struct {
+	ChainKey     string `json:"chain"`
+	Seq          uint   `json:"seq"`
+	PrevHash     string `json:"prev"`
+	BookingID    *uint  `json:"booking"`
+	BookingToken string `json:"token"`
+	ConsentID    uint   `json:"consent"`
+	GuestID      *uint  `json:"guest"`
+	AcceptedAt   string `json:"at"`
+	AcceptedBy   string `json:"by"`
+	Status       string `json:"status"`
+	Action       string `json:"action"`
+	Locale       string `json:"locale"`
+	TextHash     string `json:"text"`
+	VersionHash  string `json:"version"`
+	IP           string `json:"ip"`
+	UserAgent    string `json:"ua"`
+	RefID        *uint  `json:"ref"`
+}
+
+func consentEntryHash(cl models.ConsentLog) string {
+	d := consentEntryDigest{
+		ChainKey:    cl.ChainKey,
+		Seq:         cl.Seq,
+		PrevHash:    cl.PrevHash,
+		BookingID:   cl.BookingID,
+		ConsentID:   cl.ConsentID,
+		GuestID:     cl.GuestID,
+		AcceptedAt:  cl.AcceptedAt.UTC().Format("2006-01-02T15:04:05.000Z"),
+		AcceptedBy:  cl.AcceptedBy,
+		Status:      cl.Status,
+		Action:      cl.Action,
+		Locale:      cl.Locale,
+		TextHash:    cl.TextHash,
+		VersionHash: cl.VersionHash,
+		IP:          cl.IP,
+		UserAgent:   cl.UserAgent,
+		RefID:       cl.RefID,
+	}
+	if cl.BookingToken != nil {
+		d.BookingToken = *cl.BookingToken
+	}
+	payload, _ := json.Marshal(d)
+	return consentAuditMAC(payload)
+}
+
+// ConsentAuditKeyConfigured: ตั้ง CONSENT_AUDIT_KEY แล้วหรือยัง (ไม่ตั้ง = ใครแก้ DB ก็คำนวณ chain ใหม่ได้)
+func ConsentAuditKeyConfigured() bool {
+	return strings.TrimSpace(utils.EnvOrDefault("CONSENT_AUDIT_KEY", "")) != ""
+}
+
+// consentAuditMAC: HMAC-SHA256 ด้วย CONSENT_AUDIT_KEY (ไม่ตั้ง key = sha256 ธรรมดา)
+func consentAuditMAC(payload []byte) string {
+	if key := strings.TrimSpace(utils.EnvOrDefault("CONSENT_AUDIT_KEY", "")); key != "" {
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write(payload)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// consentVersionHashFor: ContentHash ที่บันทึกตอน publish (consent เก่าที่ไม่มีค่าจะคำนวณจากเนื้อหาปัจจุบัน)
+func consentVersionHashFor(tx *gorm.DB, consentID uint) (string, error) {
+	var consent models.Consent
+	if err := tx.Unscoped().Preload("Translations").First(&consent, "consent_id = ?", consentID).Error; err != nil {
+		return "", err
+	}
+	if consent.ContentHash != "" {
+		return consent.ContentHash, nil
+	}
+	return ConsentVersionHash(consent), nil
+}
+
+// AppendConsentLog เพิ่มแถวต่อท้าย hash chain (เรียกใน transaction ของผู้เรียก หรือ db ปกติก็ได้)
+// ช่องทางเดียวที่ควรใช้เขียน consent_logs
+func AppendConsentLog(db *gorm.DB, cl *models.ConsentLog, audit ConsentAudit) error {
 	if cl == nil {
 		return gorm.ErrInvalidData
 	}
-	if cl.AcceptedAt.IsZero() {
-		cl.AcceptedAt = time.Now().UTC()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if cl.AcceptedAt.IsZero() {
+			cl.AcceptedAt = time.Now().UTC()
+		}
+		// MySQL datetime(3) เก็บถึง millisecond — ตัดก่อนคำนวณ hash ให้ตรงกับค่าที่อ่านกลับมา
+		cl.AcceptedAt = cl.AcceptedAt.UTC().Truncate(time.Millisecond)
+		if cl.Action == "" {
+			cl.Action = ConsentActionAccepted
+		}
+		if cl.IP == "" {
+			cl.IP = audit.IP
+		}
+		if cl.UserAgent == "" {
+			cl.UserAgent = audit.UserAgent
+		}
+		if len(cl.UserAgent) > 255 {
+			cl.UserAgent = cl.UserAgent[:255]
+		}
+		if cl.VersionHash == "" {
+			hash, err := consentVersionHashFor(tx, cl.ConsentID)
+			if err != nil {
+				return fmt.Errorf("consent version hash: %w", err)
+			}
+			cl.VersionHash = hash
+		}
+
+		cl.ChainKey = consentChainKey(cl)
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.ConsentChainHead{ChainKey: cl.ChainKey}).Error; err != nil {
+			return err
+		}
+		var head models.ConsentChainHead
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&head, "chain_key = ?", cl.ChainKey).Error; err != nil {
+			return err
+		}
+
+		cl.Seq = head.Seq + 1
+		cl.PrevHash = head.Hash
+		cl.EntryHash = consentEntryHash(*cl)
+		if err := tx.Create(cl).Error; err != nil {
+			return err
+		}
+		return tx.Model(&head).Updates(map[string]interface{}{
+			"seq":  cl.Seq,
+			"hash": cl.EntryHash,
+		}).Error
+	})
+}
+
+func (s *ConsentLogService) Log(cl *models.ConsentLog, audit ConsentAudit) error {
+	if cl == nil {
+		return gorm.ErrInvalidData
 	}
 	if cl.Status == "" {
 		if cl.BookingID != nil {
@@ -34,35 +202,258 @@ func (s *ConsentLogService) Log(cl *models.ConsentLog) error {
 			cl.Status = "pending"
 		}
 	}
-	return s.DB.Create(cl).Error
+	return AppendConsentLog(s.DB, cl, audit)
 }
 
-func (s *ConsentLogService) LinkPendingByGuestIDs(bookingID uint, guestIDs []uint) (int64, error) {
+// unattachedPending: แถว pending ที่ยังไม่มีแถว "attached" อ้างถึง
+func (s *ConsentLogService) unattachedPending(where string, args ...interface{}) ([]models.ConsentLog, error) {
+	var rows []models.ConsentLog
+	err := s.DB.Where("booking_id IS NULL").
+		Where(where, args...).
+		Where("NOT EXISTS (SELECT 1 FROM consent_logs a WHERE a.ref_id = consent_logs.id AND a.action = ?)", ConsentActionAttached).
+		Order("id ASC").
+		Find(&rows).Error
+	return rows, err
+}
+
+// attach: เพิ่มแถว "attached" ใน chain ของ booking สำหรับทุกแถว pending (แถวเดิมไม่ถูกแก้)
+func (s *ConsentLogService) attach(pending []models.ConsentLog, bookingID *uint, bookingToken *string, audit ConsentAudit) (int64, error) {
+	var n int64
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		for _, p := range pending {
+			ref := p.ID
+			entry := models.ConsentLog{
+				BookingID:    bookingID,
+				BookingToken: p.BookingToken,
+				ConsentID:    p.ConsentID,
+				GuestID:      p.GuestID,
+				AcceptedAt:   time.Now().UTC(),
+				AcceptedBy:   p.AcceptedBy,
+				Status:       "sent",
+				Action:       ConsentActionAttached,
+				Locale:       p.Locale,
+				TextHash:     p.TextHash,
+				VersionHash:  p.VersionHash,
+				RefID:        &ref,
+			}
+			if bookingToken != nil {
+				entry.BookingToken = bookingToken
+			}
+			if err := AppendConsentLog(tx, &entry, audit); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// LinkPendingByGuestIDs ผูกแถว pending ของแขกเข้ากับ booking (หรือ token ถ้ายังไม่รู้ booking id)
+func (s *ConsentLogService) LinkPendingByGuestIDs(bookingID *uint, bookingToken *string, guestIDs []uint, audit ConsentAudit) (int64, error) {
 	if len(guestIDs) == 0 {
 		return 0, nil
 	}
-	now := time.Now().UTC()
-	result := s.DB.Model(&models.ConsentLog{}).
-		Where("booking_id IS NULL AND guest_id IN ?", guestIDs).
-		Updates(map[string]interface{}{
-			"booking_id": bookingID,
-			"status":     "sent",
-			"updated_at": now,
-		})
-	return result.RowsAffected, result.Error
+	pending, err := s.unattachedPending("guest_id IN ?", guestIDs)
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+	return s.attach(pending, bookingID, bookingToken, audit)
 }
 
-func (s *ConsentLogService) LinkPendingByToken(token string, bookingID uint) (int64, error) {
+func (s *ConsentLogService) LinkPendingByToken(token string, bookingID uint, audit ConsentAudit) (int64, error) {
 	if token == "" {
 		return 0, nil
 	}
-	now := time.Now().UTC()
-	result := s.DB.Model(&models.ConsentLog{}).
-		Where("booking_token = ? AND booking_id IS NULL", token).
-		Updates(map[string]interface{}{
-			"booking_id": bookingID,
-			"status":     "sent",
-			"updated_at": now,
-		})
-	return result.RowsAffected, result.Error
+	pending, err := s.unattachedPending("booking_token = ?", token)
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+	return s.attach(pending, &bookingID, nil, audit)
+}
+
+// Withdraw: แทนการลบ — เพิ่มแถว "withdrawn" ที่อ้างถึงแถวเดิม
+func (s *ConsentLogService) Withdraw(id uint, by string, audit ConsentAudit) (models.ConsentLog, error) {
+	var original models.ConsentLog
+	if err := s.DB.First(&original, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return original, errors.New("consent_log_not_found")
+		}
+		return original, err
+	}
+	if original.Action == ConsentActionWithdrawn {
+		return original, errors.New("already_withdrawn")
+	}
+
+	var existing models.ConsentLog
+	err := s.DB.Where("ref_id = ? AND action = ?", id, ConsentActionWithdrawn).First(&existing).Error
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return existing, err
+	}
+
+	ref := original.ID
+	entry := models.ConsentLog{
+		BookingID:    original.BookingID,
+		BookingToken: original.BookingToken,
+		ConsentID:    original.ConsentID,
+		GuestID:      original.GuestID,
+		AcceptedBy:   by,
+		Status:       "withdrawn",
+		Action:       ConsentActionWithdrawn,
+		Locale:       original.Locale,
+		TextHash:     original.TextHash,
+		VersionHash:  original.VersionHash,
+		RefID:        &ref,
+	}
+	err = AppendConsentLog(s.DB, &entry, audit)
+	return entry, err
+}
+
+// ------------------------------------------------------------
+// Verification
+// ------------------------------------------------------------
+
+// ConsentChainProblem: จุดที่ตรวจพบว่าถูกแก้ไข
+type ConsentChainProblem struct {
+	LogID   uint   `json:"logId,omitempty"`
+	Seq     uint   `json:"seq,omitempty"`
+	Problem string `json:"problem"` // hash_mismatch | broken_link | sequence_gap | sequence_duplicate | soft_deleted | head_mismatch | head_missing
+}
+
+type ConsentChainReport struct {
+	ChainKey string                `json:"chainKey"`
+	Entries  int                   `json:"entries"`
+	Valid    bool                  `json:"valid"`
+	Problems []ConsentChainProblem `json:"problems"`
+}
+
+type ConsentAuditReport struct {
+	BookingID uint                 `json:"bookingId"`
+	Valid     bool                 `json:"valid"`
+	Chains    []ConsentChainReport `json:"chains"`
+	// แถวที่สร้างก่อนมี hash chain (ตรวจไม่ได้ แต่ไม่ถือว่าผิด)
+	Unchained int `json:"unchained"`
+}
+
+// VerifyChain ตรวจทั้ง chain: hash ของทุกแถว, การเชื่อมกับแถวก่อนหน้า, ลำดับ seq และ head
+func (s *ConsentLogService) VerifyChain(chainKey string) (ConsentChainReport, error) {
+	report := ConsentChainReport{ChainKey: chainKey, Problems: []ConsentChainProblem{}}
+
+	var rows []models.ConsentLog
+	if err := s.DB.Unscoped().
+		Where("chain_key = ?", chainKey).
+		Order("seq ASC, id ASC").
+		Find(&rows).Error; err != nil {
+		return report, err
+	}
+	report.Entries = len(rows)
+
+	expectedSeq := uint(1)
+	prev := ""
+	for _, row := range rows {
+		if row.DeletedAt.Valid {
+			report.Problems = append(report.Problems, ConsentChainProblem{LogID: row.ID, Seq: row.Seq, Problem: "soft_deleted"})
+		}
+		switch {
+		case row.Seq > expectedSeq:
+			report.Problems = append(report.Problems, ConsentChainProblem{LogID: row.ID, Seq: row.Seq, Problem: "sequence_gap"})
+		case row.Seq < expectedSeq:
+			report.Problems = append(report.Problems, ConsentChainProblem{LogID: row.ID, Seq: row.Seq, Problem: "sequence_duplicate"})
+		}
+		if row.PrevHash != prev {
+			report.Problems = append(report.Problems, ConsentChainProblem{LogID: row.ID, Seq: row.Seq, Problem: "broken_link"})
+		}
+		if consentEntryHash(row) != row.EntryHash {
+			report.Problems = append(report.Problems, ConsentChainProblem{LogID: row.ID, Seq: row.Seq, Problem: "hash_mismatch"})
+		}
+		prev = row.EntryHash
+		expectedSeq = row.Seq + 1
+	}
+
+	var head models.ConsentChainHead
+	err := s.DB.First(&head, "chain_key = ?", chainKey).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if len(rows) > 0 {
+			report.Problems = append(report.Problems, ConsentChainProblem{Problem: "head_missing"})
+		}
+	case err != nil:
+		return report, err
+	default:
+		if len(rows) == 0 || head.Seq != rows[len(rows)-1].Seq || head.Hash != rows[len(rows)-1].EntryHash {
+			report.Problems = append(report.Problems, ConsentChainProblem{Seq: head.Seq, Problem: "head_mismatch"})
+		}
+	}
+
+	report.Valid = len(report.Problems) == 0
+	return report, nil
+}
+
+// VerifyBooking ตรวจประวัติ consent ทั้งหมดของ booking: chain ของ booking, chain ของแขกใน booking
+// และ chain ของ token ที่เคยใช้กับ booking นี้
+func (s *ConsentLogService) VerifyBooking(bookingID uint) (ConsentAuditReport, error) {
+	report := ConsentAuditReport{BookingID: bookingID, Chains: []ConsentChainReport{}}
+
+	keys := map[string]bool{fmt.Sprintf("booking:%d", bookingID): true}
+
+	var guestIDs []uint
+	if err := s.DB.Unscoped().Model(&models.Guest{}).Where("booking_id = ?", bookingID).Pluck("id", &guestIDs).Error; err != nil {
+		return report, err
+	}
+	var tokens []string
+	if err := s.DB.Unscoped().Model(&models.BookingInfo{}).Where("booking_id = ?", bookingID).Pluck("token", &tokens).Error; err != nil {
+		return report, err
+	}
+
+	q := s.DB.Unscoped().Model(&models.ConsentLog{}).Where("booking_id = ?", bookingID)
+	if len(guestIDs) > 0 {
+		q = q.Or("guest_id IN ?", guestIDs)
+	}
+	if len(tokens) > 0 {
+		q = q.Or("booking_token IN ?", tokens)
+	}
+	var related []models.ConsentLog
+	if err := q.Select("id", "chain_key").Find(&related).Error; err != nil {
+		return report, err
+	}
+	for _, r := range related {
+		if r.ChainKey == "" {
+			report.Unchained++
+			continue
+		}
+		keys[r.ChainKey] = true
+	}
+	for _, gid := range guestIDs {
+		keys[fmt.Sprintf("guest:%d", gid)] = true
+	}
+	for _, t := range tokens {
+		if t != "" {
+			keys["token:"+t] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	report.Valid = true
+	for _, key := range sorted {
+		chain, err := s.VerifyChain(key)
+		if err != nil {
+			return report, err
+		}
+		if chain.Entries == 0 && chain.Valid {
+			continue
+		}
+		report.Chains = append(report.Chains, chain)
+		if !chain.Valid {
+			report.Valid = false
+		}
+	}
+	return report, nil
 }
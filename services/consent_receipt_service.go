@@ -0,0 +1,534 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"hotel-backend/models"
+	"hotel-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// ใบยืนยัน consent เก็บใน BlobStore ใต้ receipts/ (เข้ารหัสเหมือนรูปเอกสารแขก)
+const consentReceiptDir = "receipts"
+
+// ConsentReceiptService: ออกใบยืนยันการให้ความยินยอม (PDF) ต่อ booking แล้วส่งอีเมลให้แขก
+type ConsentReceiptService struct {
+	DB    *gorm.DB
+	Blobs BlobStore
+}
+
+func NewConsentReceiptService(db *gorm.DB, blobs BlobStore) *ConsentReceiptService {
+	return &ConsentReceiptService{DB: db, Blobs: blobs}
+}
+
+// ConsentReceiptLine: หนึ่งบรรทัดในใบ = แขกหนึ่งคนยอมรับ consent หนึ่งเวอร์ชัน
+type ConsentReceiptLine struct {
+	LogID      uint      `json:"logId"`
+	Guest      string    `json:"guest"`
+	ConsentID  uint      `json:"consentId"`
+	Title      string    `json:"title"`
+	Version    string    `json:"version"`
+	Locale     string    `json:"locale"`
+	AcceptedAt time.Time `json:"acceptedAt"`
+	EntryHash  string    `json:"entryHash"`
+	Withdrawn  bool      `json:"withdrawn"`
+}
+
+// ConsentReceiptData: ข้อมูลทั้งหมดที่พิมพ์ในใบ (Signature คำนวณจาก JSON ของ struct นี้)
+type ConsentReceiptData struct {
+	BookingID     uint                 `json:"bookingId"`
+	ReferenceCode string               `json:"referenceCode"`
+	CheckIn       string               `json:"checkIn"`
+	CheckOut      string               `json:"checkOut"`
+	HotelName     string               `json:"hotelName"`
+	HotelAddress  string               `json:"hotelAddress"`
+	HotelPhone    string               `json:"hotelPhone"`
+	HotelEmail    string               `json:"hotelEmail"`
+	HotelWebsite  string               `json:"hotelWebsite"`
+	Guests        []string             `json:"guests"`
+	Lines         []ConsentReceiptLine `json:"lines"`
+	IssuedAt      time.Time            `json:"issuedAt"`
+}
+
+// BuildData รวบรวมข้อมูลของ booking จาก consent log (เฉพาะแถว accepted/attached ที่ผูกกับ booking)
+func (s *ConsentReceiptService) BuildData(bookingID uint, now time.Time) (ConsentReceiptData, error) {
+	data := ConsentReceiptData{BookingID: bookingID, IssuedAt: now.UTC().Truncate(time.Second)}
+
+	var booking models.Booking
+	if err := s.DB.First(&booking, bookingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return data, errors.New("booking_not_found")
+		}
+		return data, err
+	}
+	data.ReferenceCode = booking.ReferenceCode
+	data.CheckIn = receiptDate(booking.CheckInDate, booking.CheckIn)
+	data.CheckOut = receiptDate(booking.CheckOutDate, booking.CheckOut)
+
+	var hotel models.HotelSetting
+	if err := s.DB.Order("id ASC").First(&hotel).Error; err == nil {
+		data.HotelName = hotel.Name
+		data.HotelAddress = hotel.Address
+		data.HotelPhone = hotel.Phone
+		data.HotelEmail = hotel.Email
+		data.HotelWebsite = hotel.Website
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return data, err
+	}
+
+	var guests []models.Guest
+	if err := s.DB.Select("id", "full_name", "is_main_guest", "anonymised_at").
+		Where("booking_id = ?", bookingID).
+		Order("is_main_guest DESC, id ASC").
+		Find(&guests).Error; err != nil {
+		return data, err
+	}
+	names := map[uint]string{}
+	for _, g := range guests {
+		name := strings.TrimSpace(g.FullName)
+		if g.AnonymisedAt != nil || name == "" {
+			name = fmt.Sprintf("Guest #%d", g.ID)
+		}
+		names[g.ID] = name
+		data.Guests = append(data.Guests, name)
+	}
+
+	var logs []models.ConsentLog
+	if err := s.DB.
+		Where("booking_id = ? AND action IN ?", bookingID, []string{ConsentActionAccepted, ConsentActionAttached}).
+		Order("accepted_at ASC, id ASC").
+		Find(&logs).Error; err != nil {
+		return data, err
+	}
+	if len(logs) == 0 {
+		return data, errors.New("no_consents")
+	}
+
+	// แถว attached อ้างถึงแถว pending เดิม — ใช้เวลายอมรับจริงของแถวนั้น
+	refIDs := []uint{}
+	logIDs := []uint{}
+	consentIDs := []uint{}
+	for _, l := range logs {
+		logIDs = append(logIDs, l.ID)
+		consentIDs = append(consentIDs, l.ConsentID)
+		if l.Action == ConsentActionAttached && l.RefID != nil {
+			refIDs = append(refIDs, *l.RefID)
+		}
+	}
+	originals := map[uint]models.ConsentLog{}
+	if len(refIDs) > 0 {
+		var rows []models.ConsentLog
+		if err := s.DB.Where("id IN ?", refIDs).Find(&rows).Error; err != nil {
+			return data, err
+		}
+		for _, r := range rows {
+			originals[r.ID] = r
+		}
+	}
+
+	var withdrawals []models.ConsentLog
+	if err := s.DB.Select("ref_id").
+		Where("action = ? AND ref_id IN ?", ConsentActionWithdrawn, append(append([]uint{}, logIDs...), refIDs...)).
+		Find(&withdrawals).Error; err != nil {
+		return data, err
+	}
+	withdrawn := map[uint]bool{}
+	for _, w := range withdrawals {
+		if w.RefID != nil {
+			withdrawn[*w.RefID] = true
+		}
+	}
+
+	var consents []models.Consent
+	if err := s.DB.Unscoped().Preload("Translations").Where("consent_id IN ?", consentIDs).Find(&consents).Error; err != nil {
+		return data, err
+	}
+	byID := map[uint]models.Consent{}
+	for _, c := range consents {
+		byID[c.ID] = c
+	}
+
+	for _, l := range logs {
+		acceptedAt := l.AcceptedAt
+		isWithdrawn := withdrawn[l.ID]
+		if l.Action == ConsentActionAttached && l.RefID != nil {
+			if orig, ok := originals[*l.RefID]; ok {
+				acceptedAt = orig.AcceptedAt
+			}
+			isWithdrawn = isWithdrawn || withdrawn[*l.RefID]
+		}
+
+		line := ConsentReceiptLine{
+			LogID:      l.ID,
+			ConsentID:  l.ConsentID,
+			Locale:     l.Locale,
+			AcceptedAt: acceptedAt.UTC(),
+			EntryHash:  l.EntryHash,
+			Withdrawn:  isWithdrawn,
+			Guest:      "-",
+		}
+		if l.GuestID != nil {
+			if name, ok := names[*l.GuestID]; ok {
+				line.Guest = name
+			}
+		}
+		if c, ok := byID[l.ConsentID]; ok {
+			// ข้อความตามภาษาที่แขกเห็นตอนยอมรับ
+			localized := LocalizeConsent(c, l.Locale)
+			line.Title = localized.Title
+			line.Version = c.Version
+		} else {
+			line.Title = fmt.Sprintf("Consent #%d", l.ConsentID)
+		}
+		data.Lines = append(data.Lines, line)
+	}
+	return data, nil
+}
+
+func receiptDate(preferred, fallback *time.Time) string {
+	if preferred != nil {
+		return preferred.Format("2006-01-02")
+	}
+	if fallback != nil {
+		return fallback.Format("2006-01-02")
+	}
+	return "-"
+}
+
+// ConsentReceiptSignature: ลายเซ็นของข้อมูลในใบ (HMAC ด้วย CONSENT_AUDIT_KEY ถ้ามี)
+func ConsentReceiptSignature(data ConsentReceiptData) string {
+	payload, _ := json.Marshal(data)
+	return consentAuditMAC(payload)
+}
+
+// Issue สร้าง PDF เก็บลง BlobStore บันทึก ConsentReceipt และส่งอีเมล (ถ้า sendEmail)
+// ส่งอีเมลไม่สำเร็จไม่ถือเป็น error — เก็บไว้ใน EmailError เพื่อให้พนักงานส่งซ้ำได้
+func (s *ConsentReceiptService) Issue(ctx context.Context, bookingID uint, sendEmail bool, now time.Time) (models.ConsentReceipt, error) {
+	var receipt models.ConsentReceipt
+
+	data, err := s.BuildData(bookingID, now)
+	if err != nil {
+		return receipt, err
+	}
+	signature := ConsentReceiptSignature(data)
+
+	pdf, err := RenderConsentReceipt(data, signature)
+	if err != nil {
+		return receipt, fmt.Errorf("render receipt: %w", err)
+	}
+	sum := sha256.Sum256(pdf)
+
+	sealed, err := EncryptPII(pdf)
+	if err != nil {
+		return receipt, err
+	}
+	name, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		return receipt, err
+	}
+	key := fmt.Sprintf("%s/booking-%d-%s.pdf", consentReceiptDir, bookingID, name)
+	if err := s.Blobs.Put(ctx, key, sealed, "application/octet-stream"); err != nil {
+		return receipt, fmt.Errorf("store receipt: %w", err)
+	}
+
+	receipt = models.ConsentReceipt{
+		BookingID:  bookingID,
+		StorageKey: key,
+		SHA256:     hex.EncodeToString(sum[:]),
+		Signature:  signature,
+		Entries:    len(data.Lines),
+		CreatedAt:  data.IssuedAt,
+	}
+	if err := s.DB.Create(&receipt).Error; err != nil {
+		_ = s.Blobs.Delete(ctx, key)
+		return receipt, err
+	}
+
+	if sendEmail {
+		return s.email(receipt, data, pdf, now)
+	}
+	return receipt, nil
+}
+
+// Resend ส่งใบล่าสุดของ booking ซ้ำ (ไม่สร้างใบใหม่)
+func (s *ConsentReceiptService) Resend(ctx context.Context, bookingID uint, now time.Time) (models.ConsentReceipt, error) {
+	receipt, err := s.Latest(bookingID)
+	if err != nil {
+		return receipt, err
+	}
+	pdf, err := s.Open(ctx, receipt)
+	if err != nil {
+		return receipt, err
+	}
+	data, err := s.BuildData(bookingID, now)
+	if err != nil {
+		return receipt, err
+	}
+	return s.email(receipt, data, pdf, now)
+}
+
+func (s *ConsentReceiptService) email(receipt models.ConsentReceipt, data ConsentReceiptData, pdf []byte, now time.Time) (models.ConsentReceipt, error) {
+	recipient := s.recipientEmail(receipt.BookingID)
+	if recipient == "" {
+		receipt.EmailError = "no_recipient"
+	} else {
+		receipt.EmailedTo = recipient
+		guestName := ""
+		if len(data.Guests) > 0 {
+			guestName = data.Guests[0]
+		}
+		ref := data.ReferenceCode
+		if ref == "" {
+			ref = fmt.Sprintf("#%d", data.BookingID)
+		}
+		if err := utils.SendConsentReceiptEmail(recipient, guestName, ref, data.HotelName, ConsentReceiptFilename(receipt), pdf); err != nil {
+			receipt.EmailError = err.Error()
+		} else {
+			t := now.UTC()
+			receipt.EmailedAt = &t
+			receipt.EmailError = ""
+		}
+	}
+
+	if err := s.DB.Model(&receipt).Updates(map[string]interface{}{
+		"emailed_to":  receipt.EmailedTo,
+		"emailed_at":  receipt.EmailedAt,
+		"email_error": receipt.EmailError,
+	}).Error; err != nil {
+		return receipt, err
+	}
+	return receipt, nil
+}
+
+// recipientEmail: อีเมลที่ได้รับลิงก์เช็คอิน > อีเมลแขกหลัก > อีเมลลูกค้าเจ้าของ booking
+func (s *ConsentReceiptService) recipientEmail(bookingID uint) string {
+	var info models.BookingInfo
+	if err := s.DB.Where("booking_id = ? AND guest_email <> ''", bookingID).Order("id DESC").First(&info).Error; err == nil {
+		return strings.TrimSpace(info.GuestEmail)
+	}
+	var guest models.Guest
+	if err := s.DB.Select("id", "email").
+		Where("booking_id = ? AND email <> '' AND anonymised_at IS NULL", bookingID).
+		Order("is_main_guest DESC, id ASC").
+		First(&guest).Error; err == nil {
+		return strings.TrimSpace(guest.Email)
+	}
+	var booking models.Booking
+	if err := s.DB.Preload("Customer").First(&booking, bookingID).Error; err == nil {
+		return strings.TrimSpace(booking.Customer.Email)
+	}
+	return ""
+}
+
+// Latest ใบล่าสุดของ booking
+func (s *ConsentReceiptService) Latest(bookingID uint) (models.ConsentReceipt, error) {
+	var receipt models.ConsentReceipt
+	if err := s.DB.Where("booking_id = ?", bookingID).Order("id DESC").First(&receipt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return receipt, errors.New("receipt_not_found")
+		}
+		return receipt, err
+	}
+	return receipt, nil
+}
+
+// List ประวัติการออกใบของ booking
+func (s *ConsentReceiptService) List(bookingID uint) ([]models.ConsentReceipt, error) {
+	var list []models.ConsentReceipt
+	err := s.DB.Where("booking_id = ?", bookingID).Order("id DESC").Find(&list).Error
+	return list, err
+}
+
+// Open อ่านไฟล์ PDF (ถอดรหัสแล้ว) และตรวจ sha256 กับที่บันทึกไว้
+func (s *ConsentReceiptService) Open(ctx context.Context, receipt models.ConsentReceipt) ([]byte, error) {
+	body, _, err := s.Blobs.Open(ctx, receipt.StorageKey)
+	if err != nil {
+		if errors.Is(err, ErrBlobNotFound) {
+			return nil, errors.New("receipt_not_found")
+		}
+		return nil, err
+	}
+	defer body.Close()
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	pdf, err := DecryptPII(raw)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(pdf)
+	if hex.EncodeToString(sum[:]) != receipt.SHA256 {
+		return nil, errors.New("receipt_tampered")
+	}
+	return pdf, nil
+}
+
+// DeleteForBookings ลบไฟล์ใบยืนยันของ booking (ใช้ตอนลบข้อมูลตามคำขอ) — แถว ConsentReceipt เก็บไว้เป็นหลักฐาน
+func (s *ConsentReceiptService) DeleteForBookings(ctx context.Context, bookingIDs []uint) error {
+	if len(bookingIDs) == 0 {
+		return nil
+	}
+	var list []models.ConsentReceipt
+	if err := s.DB.Where("booking_id IN ? AND storage_key <> ''", bookingIDs).Find(&list).Error; err != nil {
+		return err
+	}
+	for _, r := range list {
+		if err := s.Blobs.Delete(ctx, r.StorageKey); err != nil && !errors.Is(err, ErrBlobNotFound) {
+			return err
+		}
+		if err := s.DB.Model(&r).Update("storage_key", "").Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConsentReceiptFilename ชื่อไฟล์สำหรับดาวน์โหลด/แนบอีเมล
+func ConsentReceiptFilename(r models.ConsentReceipt) string {
+	return fmt.Sprintf("consent-receipt-%d.pdf", r.BookingID)
+}
+
+// IssueConsentReceiptAsync: ออกใบหลังเช็คอินเสร็จ (ไม่ให้ request รอการสร้าง PDF/ส่งอีเมล)
+func IssueConsentReceiptAsync(db *gorm.DB, bookingID uint) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("consent receipt panic (booking=%d): %v", bookingID, r)
+			}
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+		receipt, err := NewConsentReceiptService(db, DefaultBlobStore()).Issue(ctx, bookingID, true, time.Now())
+		if err != nil {
+			log.Printf("consent receipt (booking=%d): %v", bookingID, err)
+			return
+		}
+		if receipt.EmailError != "" {
+			log.Printf("consent receipt email (booking=%d): %s", bookingID, receipt.EmailError)
+		}
+	}()
+}
+
+// ------------------------------------------------------------
+// PDF layout
+// ------------------------------------------------------------
+
+// RenderConsentReceipt วาดใบยืนยันเป็น PDF (A4) — ใช้ font จาก PDF_FONT_PATH เพื่อแสดงภาษาไทย (ไม่มี font = ไม่ออกใบ)
+func RenderConsentReceipt(data ConsentReceiptData, signature string) ([]byte, error) {
+	doc, err := utils.NewPDFDocumentFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	const (
+		left   = 50.0
+		right  = utils.PDFPageWidth - 50
+		bottom = utils.PDFPageHeight - 60
+	)
+	width := right - left
+	y := 60.0
+
+	newPage := func() {
+		doc.AddPage()
+		y = 60
+	}
+	ensure := func(h float64) {
+		if y+h > bottom {
+			newPage()
+		}
+	}
+	text := func(x, size float64, s string, maxWidth float64) {
+		for _, line := range doc.WrapText(s, size, maxWidth) {
+			ensure(size + 4)
+			doc.Text(x, y, size, line)
+			y += size + 4
+		}
+	}
+
+	newPage()
+	hotel := data.HotelName
+	if hotel == "" {
+		hotel = "Hotel"
+	}
+	text(left, 16, hotel, width)
+	for _, s := range []string{data.HotelAddress, strings.Join(nonEmpty(data.HotelPhone, data.HotelEmail, data.HotelWebsite), "  |  ")} {
+		if strings.TrimSpace(s) != "" {
+			text(left, 9, s, width)
+		}
+	}
+	y += 6
+	doc.Line(left, y, right, y, 1)
+	y += 22
+
+	text(left, 14, "Consent Receipt / ใบยืนยันการให้ความยินยอม", width)
+	y += 4
+	ref := data.ReferenceCode
+	if ref == "" {
+		ref = "-"
+	}
+	text(left, 10, fmt.Sprintf("Booking: #%d   Reference: %s", data.BookingID, ref), width)
+	text(left, 10, fmt.Sprintf("Stay: %s  to  %s", data.CheckIn, data.CheckOut), width)
+	text(left, 10, "Issued: "+data.IssuedAt.Format("2006-01-02 15:04:05 UTC"), width)
+	y += 8
+
+	text(left, 11, "Guests / ผู้เข้าพัก", width)
+	for i, g := range data.Guests {
+		text(left+12, 10, fmt.Sprintf("%d. %s", i+1, g), width-12)
+	}
+	y += 8
+
+	text(left, 11, "Consents given / ความยินยอมที่ให้ไว้", width)
+	y += 2
+	for _, l := range data.Lines {
+		ensure(60)
+		doc.Line(left, y, right, y, 0.3)
+		y += 14
+		title := l.Title
+		if l.Version != "" {
+			title += "  (v" + strings.TrimPrefix(l.Version, "v") + ")"
+		}
+		if l.Withdrawn {
+			title += "  [WITHDRAWN]"
+		}
+		text(left, 10, title, width)
+		locale := l.Locale
+		if locale == "" {
+			locale = "-"
+		}
+		text(left+12, 9, fmt.Sprintf("Guest: %s   Accepted: %s   Language: %s",
+			l.Guest, l.AcceptedAt.Format("2006-01-02 15:04:05 UTC"), locale), width-12)
+		if l.EntryHash != "" {
+			text(left+12, 7, "Log entry: "+l.EntryHash, width-12)
+		}
+	}
+	y += 16
+
+	ensure(60)
+	doc.Line(left, y, right, y, 0.5)
+	y += 14
+	text(left, 8, "This receipt records the consents given at check-in. Each entry is part of the hotel's tamper-evident consent log.", width)
+	text(left, 8, "ใบนี้ออกโดยระบบอัตโนมัติ ใช้เป็นหลักฐานการให้ความยินยอม ณ เวลาที่ระบุ", width)
+	text(left, 7, "Signature: "+signature, width)
+
+	return doc.Bytes()
+}
+
+func nonEmpty(values ...string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -1,12 +1,21 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
 	"hotel-backend/config"
 	"hotel-backend/models"
 )
 
 // ConsentService manages consent templates.
+//
+// แต่ละ slug มีได้หลายเวอร์ชัน: draft (แก้ไขได้) -> published (ล็อกเนื้อหา) -> retired
+// เวอร์ชันที่ "ต้องยอมรับ" ตอนเช็คอิน = published + required ที่ effective_from ล่าสุดที่ไม่เกินเวลาปัจจุบันของแต่ละ slug
 type ConsentService struct {
 	DB *gorm.DB
 }
@@ -18,33 +27,464 @@ func NewConsentService(db *gorm.DB) *ConsentService {
 	return &ConsentService{DB: db}
 }
 
-func (s *ConsentService) Create(consent *models.Consent) error {
-	if consent == nil {
-		return gorm.ErrInvalidData
+// ConsentDraft: ข้อมูลที่แก้ได้ของ consent ที่ยังเป็น draft
+type ConsentDraft struct {
+	Slug          string     `json:"slug"`
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	Version       string     `json:"version"`
+	Required      *bool      `json:"required"`
+	EffectiveFrom *time.Time `json:"effective_from"`
+	BaseLocale    string     `json:"base_locale"`
+
+	// คำแปล (แทนที่ข้อความของ locale ที่ส่งมา)
+	Translations []ConsentTranslationInput `json:"translations"`
+}
+
+type ConsentTranslationInput struct {
+	Locale      string `json:"locale"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// Create สร้าง draft ใหม่ (ถ้า slug+version มีอยู่แล้วคืนแถวเดิม, created = false)
+// ไม่ระบุ version = เลขถัดไปของ slug นั้น ("1.0", "2.0", ...)
+func (s *ConsentService) Create(d ConsentDraft) (models.Consent, bool, error) {
+	slug := strings.TrimSpace(d.Slug)
+	title := strings.TrimSpace(d.Title)
+	if slug == "" || title == "" {
+		return models.Consent{}, false, errors.New("slug_and_title_required")
+	}
+
+	baseLocale := defaultConsentLocale
+	if strings.TrimSpace(d.BaseLocale) != "" {
+		if baseLocale = NormalizeConsentLocale(d.BaseLocale); baseLocale == "" {
+			return models.Consent{}, false, errors.New("unsupported_locale")
+		}
+	}
+
+	version := strings.TrimSpace(d.Version)
+	if version == "" {
+		var count int64
+		if err := s.DB.Unscoped().Model(&models.Consent{}).Where("slug = ?", slug).Count(&count).Error; err != nil {
+			return models.Consent{}, false, err
+		}
+		version = fmt.Sprintf("%d.0", count+1)
+	}
+
+	var existing models.Consent
+	err := s.DB.Where("slug = ? AND version = ?", slug, version).First(&existing).Error
+	if err == nil {
+		return existing, false, nil
 	}
-	if consent.Version == "" {
-		consent.Version = "1.0"
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Consent{}, false, err
 	}
-	return s.DB.Create(consent).Error
+
+	consent := models.Consent{
+		Slug:          slug,
+		Title:         title,
+		Description:   d.Description,
+		Version:       version,
+		EffectiveFrom: d.EffectiveFrom,
+		Status:        models.ConsentStatusDraft,
+		Required:      true,
+		BaseLocale:    baseLocale,
+	}
+	if d.Required != nil {
+		consent.Required = *d.Required
+	}
+	if err := s.DB.Omit("Translations").Create(&consent).Error; err != nil {
+		return models.Consent{}, false, err
+	}
+	// gorm ข้าม false ตอน create เพราะมี default:true
+	if !consent.Required {
+		if err := s.DB.Model(&consent).Update("required", false).Error; err != nil {
+			return models.Consent{}, false, err
+		}
+	}
+	for _, t := range d.Translations {
+		if err := s.upsertTranslation(consent, t); err != nil {
+			return models.Consent{}, false, err
+		}
+	}
+	consent, err = s.GetByID(consent.ID)
+	return consent, true, err
 }
 
-func (s *ConsentService) List() ([]models.Consent, error) {
+// List: status = "" -> ทุกสถานะ
+func (s *ConsentService) List(status string) ([]models.Consent, error) {
 	var out []models.Consent
 	// model uses consent_id as PK; order by consent_id desc
-	err := s.DB.Order("consent_id desc").Find(&out).Error
+	q := s.DB.Preload("Translations").Order("consent_id desc")
+	if status = strings.TrimSpace(status); status != "" {
+		q = q.Where("status = ?", status)
+	}
+	err := q.Find(&out).Error
 	return out, err
 }
 
 func (s *ConsentService) GetByID(id uint) (models.Consent, error) {
 	var c models.Consent
-	err := s.DB.First(&c, "consent_id = ?", id).Error
+	err := s.DB.Preload("Translations").First(&c, "consent_id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c, errors.New("consent_not_found")
+	}
 	return c, err
 }
 
-func (s *ConsentService) Update(consent models.Consent) error {
-	return s.DB.Model(&models.Consent{}).Where("consent_id = ?", consent.ID).Updates(consent).Error
+// UpdateDraft แก้ไขได้เฉพาะ draft — เวอร์ชันที่ publish แล้วต้องสร้างเวอร์ชันใหม่
+func (s *ConsentService) UpdateDraft(id uint, d ConsentDraft) (models.Consent, error) {
+	consent, err := s.GetByID(id)
+	if err != nil {
+		return consent, err
+	}
+	if consent.Status != models.ConsentStatusDraft {
+		return consent, errors.New("consent_immutable")
+	}
+
+	updates := map[string]interface{}{}
+	if v := strings.TrimSpace(d.Title); v != "" {
+		updates["title"] = v
+	}
+	if d.Description != "" {
+		updates["description"] = d.Description
+	}
+	if v := strings.TrimSpace(d.Version); v != "" && v != consent.Version {
+		var count int64
+		if err := s.DB.Model(&models.Consent{}).
+			Where("slug = ? AND version = ? AND consent_id <> ?", consent.Slug, v, consent.ID).
+			Count(&count).Error; err != nil {
+			return consent, err
+		}
+		if count > 0 {
+			return consent, errors.New("consent_version_exists")
+		}
+		updates["version"] = v
+	}
+	if d.Required != nil {
+		updates["required"] = *d.Required
+	}
+	if d.EffectiveFrom != nil {
+		updates["effective_from"] = d.EffectiveFrom
+	}
+	if strings.TrimSpace(d.BaseLocale) != "" {
+		locale := NormalizeConsentLocale(d.BaseLocale)
+		if locale == "" {
+			return consent, errors.New("unsupported_locale")
+		}
+		updates["base_locale"] = locale
+	}
+	if len(updates) > 0 {
+		if err := s.DB.Model(&consent).Omit("Translations").Updates(updates).Error; err != nil {
+			return consent, err
+		}
+	}
+	for _, t := range d.Translations {
+		if err := s.upsertTranslation(consent, t); err != nil {
+			return consent, err
+		}
+	}
+	return s.GetByID(id)
+}
+
+func (s *ConsentService) upsertTranslation(consent models.Consent, in ConsentTranslationInput) error {
+	locale := NormalizeConsentLocale(in.Locale)
+	if locale == "" {
+		return errors.New("unsupported_locale")
+	}
+	if strings.TrimSpace(in.Title) == "" {
+		return errors.New("slug_and_title_required")
+	}
+
+	var t models.ConsentTranslation
+	err := s.DB.Where("consent_id = ? AND locale = ?", consent.ID, locale).First(&t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		t = models.ConsentTranslation{ConsentID: consent.ID, Locale: locale}
+	} else if err != nil {
+		return err
+	}
+	t.Title = strings.TrimSpace(in.Title)
+	t.Description = in.Description
+	return s.DB.Save(&t).Error
+}
+
+// SetTranslation เพิ่ม/แก้คำแปลของ draft
+func (s *ConsentService) SetTranslation(id uint, in ConsentTranslationInput) (models.Consent, error) {
+	consent, err := s.GetByID(id)
+	if err != nil {
+		return consent, err
+	}
+	if consent.Status != models.ConsentStatusDraft {
+		return consent, errors.New("consent_immutable")
+	}
+	if err := s.upsertTranslation(consent, in); err != nil {
+		return consent, err
+	}
+	return s.GetByID(id)
+}
+
+// DeleteTranslation ลบคำแปลของ draft
+func (s *ConsentService) DeleteTranslation(id uint, locale string) (models.Consent, error) {
+	consent, err := s.GetByID(id)
+	if err != nil {
+		return consent, err
+	}
+	if consent.Status != models.ConsentStatusDraft {
+		return consent, errors.New("consent_immutable")
+	}
+	if err := s.DB.Where("consent_id = ? AND locale = ?", id, NormalizeConsentLocale(locale)).
+		Delete(&models.ConsentTranslation{}).Error; err != nil {
+		return consent, err
+	}
+	return s.GetByID(id)
+}
+
+// Publish ล็อกเนื้อหา draft และเริ่มใช้ตาม effective_from (ไม่ระบุ = ทันที)
+// ถ้ามีผลทันที เวอร์ชันเก่าของ slug เดียวกันที่ published อยู่จะถูก retire
+func (s *ConsentService) Publish(id uint, now time.Time) (models.Consent, error) {
+	now = now.UTC()
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var consent models.Consent
+		if err := tx.Preload("Translations").First(&consent, "consent_id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("consent_not_found")
+			}
+			return err
+		}
+		if consent.Status != models.ConsentStatusDraft {
+			return errors.New("consent_not_draft")
+		}
+
+		effective := now
+		if consent.EffectiveFrom != nil {
+			effective = consent.EffectiveFrom.UTC()
+		}
+		if err := tx.Model(&consent).Omit("Translations").Updates(map[string]interface{}{
+			"status":         models.ConsentStatusPublished,
+			"published_at":   now,
+			"effective_from": effective,
+			"content_hash":   ConsentVersionHash(consent),
+		}).Error; err != nil {
+			return err
+		}
+
+		if effective.After(now) {
+			return nil
+		}
+		return tx.Model(&models.Consent{}).
+			Where("slug = ? AND status = ? AND consent_id <> ? AND (effective_from IS NULL OR effective_from <= ?)",
+				consent.Slug, models.ConsentStatusPublished, consent.ID, effective).
+			Updates(map[string]interface{}{
+				"status":     models.ConsentStatusRetired,
+				"retired_at": now,
+			}).Error
+	})
+	if err != nil {
+		return models.Consent{}, err
+	}
+	return s.GetByID(id)
+}
+
+// Retire เลิกใช้เวอร์ชันที่ publish แล้ว (log เดิมยังอ้างถึงได้)
+func (s *ConsentService) Retire(id uint, now time.Time) (models.Consent, error) {
+	consent, err := s.GetByID(id)
+	if err != nil {
+		return consent, err
+	}
+	if consent.Status != models.ConsentStatusPublished {
+		return consent, errors.New("consent_not_published")
+	}
+	if err := s.DB.Model(&consent).Omit("Translations").Updates(map[string]interface{}{
+		"status":     models.ConsentStatusRetired,
+		"retired_at": now.UTC(),
+	}).Error; err != nil {
+		return consent, err
+	}
+	return s.GetByID(id)
 }
 
+// Delete ลบได้เฉพาะ draft
 func (s *ConsentService) Delete(id uint) error {
+	consent, err := s.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if consent.Status != models.ConsentStatusDraft {
+		return errors.New("consent_immutable")
+	}
 	return s.DB.Delete(&models.Consent{}, id).Error
 }
+
+// Current: consent ที่มีผลอยู่ ณ now — เวอร์ชันล่าสุดที่ published แล้วของแต่ละ slug
+func (s *ConsentService) Current(now time.Time) ([]models.Consent, error) {
+	var published []models.Consent
+	if err := s.DB.Preload("Translations").
+		Where("status = ? AND (effective_from IS NULL OR effective_from <= ?)", models.ConsentStatusPublished, now.UTC()).
+		Order("effective_from desc, consent_id desc").
+		Find(&published).Error; err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	out := make([]models.Consent, 0, len(published))
+	for _, c := range published {
+		// consent เก่าที่ไม่มี slug ถือเป็นเอกสารแยกกัน
+		key := c.Slug
+		if key == "" {
+			key = fmt.Sprintf("#%d", c.ID)
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+// Required: consent ที่แขกต้องยอมรับตอนเช็คอิน
+func (s *ConsentService) Required(now time.Time) ([]models.Consent, error) {
+	current, err := s.Current(now)
+	if err != nil {
+		return nil, err
+	}
+	out := current[:0]
+	for _, c := range current {
+		if c.Required {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// MissingConsentsError: check-in ไม่ได้ยอมรับ consent ที่บังคับ
+type MissingConsentsError struct {
+	Missing []models.Consent
+}
+
+func (e *MissingConsentsError) Error() string {
+	names := make([]string, 0, len(e.Missing))
+	for _, c := range e.Missing {
+		names = append(names, c.Slug+"@"+c.Version)
+	}
+	return "consent_required: " + strings.Join(names, ", ")
+}
+
+// ResolveAccepted ตรวจรายการ consent ที่ client ส่งมาตอนเช็คอินกับฝั่ง server
+// - ทุก id ต้องเป็นเวอร์ชันที่มีผลอยู่ (ไม่ใช่ draft/retired/เวอร์ชันเก่า) -> ไม่งั้น invalid_consent
+// - ถ้า client ส่ง text_hash มา ต้องตรงกับข้อความของ locale นั้น -> ไม่งั้น consent_text_mismatch
+// - ต้องครอบคลุม consent ที่บังคับทั้งหมด -> ไม่งั้น *MissingConsentsError
+// คืน consent (จาก DB, แปลตาม locale ที่ client แสดง) ที่ควรบันทึก log พร้อม Locale/TextHash
+func (s *ConsentService) ResolveAccepted(submitted []models.Consent, now time.Time) ([]models.Consent, error) {
+	current, err := s.Current(now)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Consent, len(current))
+	for _, c := range current {
+		byID[c.ID] = c
+	}
+
+	accepted := make([]models.Consent, 0, len(submitted))
+	seen := map[uint]bool{}
+	for _, sub := range submitted {
+		if seen[sub.ID] {
+			continue
+		}
+		seen[sub.ID] = true
+		c, ok := byID[sub.ID]
+		if !ok {
+			return nil, fmt.Errorf("invalid_consent: %d", sub.ID)
+		}
+		shown := LocalizeConsent(c, sub.Locale)
+		if sub.TextHash != "" && !strings.EqualFold(sub.TextHash, shown.TextHash) {
+			return nil, fmt.Errorf("consent_text_mismatch: %d", sub.ID)
+		}
+		accepted = append(accepted, shown)
+	}
+
+	var missing []models.Consent
+	for _, c := range current {
+		if c.Required && !seen[c.ID] {
+			missing = append(missing, LocalizeConsent(c, ""))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, &MissingConsentsError{Missing: missing}
+	}
+	return accepted, nil
+}
+
+// ConsentRequirement: สถานะ consent หนึ่งรายการของลูกค้า (ใช้ตรวจว่าต้องยอมรับใหม่หรือไม่)
+type ConsentRequirement struct {
+	Consent                   models.Consent `json:"consent"`
+	PreviouslyAcceptedVersion string         `json:"previouslyAcceptedVersion,omitempty"`
+	PreviouslyAcceptedAt      *time.Time     `json:"previouslyAcceptedAt,omitempty"`
+	// Reconsent = เคยยอมรับเวอร์ชันเก่าของ slug นี้ แต่ยังไม่ได้ยอมรับเวอร์ชันปัจจุบัน
+	Reconsent bool `json:"reconsent"`
+	// AlreadyAccepted = เคยยอมรับเวอร์ชันปัจจุบันแล้ว (booking ก่อนหน้า)
+	AlreadyAccepted bool `json:"alreadyAccepted"`
+}
+
+// RequirementsForCustomer: consent ที่ต้องยอมรับ พร้อมประวัติการยอมรับจาก booking ก่อน ๆ ของลูกค้าคนนี้
+// locale = ภาษาที่จะแสดงข้อความ
+func (s *ConsentService) RequirementsForCustomer(customerID uint, locale string, now time.Time) ([]ConsentRequirement, error) {
+	required, err := s.Required(now)
+	if err != nil {
+		return nil, err
+	}
+
+	type acceptedRow struct {
+		Slug       string
+		ConsentID  uint
+		Version    string
+		Status     string
+		AcceptedAt time.Time
+	}
+	var rows []acceptedRow
+	if customerID != 0 {
+		if err := s.DB.Table("consent_logs").
+			Select("consents.slug, consents.consent_id, consents.version, consent_logs.status, consent_logs.accepted_at").
+			Joins("JOIN consents ON consents.consent_id = consent_logs.consent_id").
+			Joins("JOIN bookings ON bookings.id = consent_logs.booking_id").
+			Where("bookings.customer_id = ? AND consent_logs.status IN ? AND consent_logs.deleted_at IS NULL",
+				customerID, []string{"accepted", "sent", "withdrawn"}).
+			Order("consent_logs.accepted_at desc, consent_logs.id desc").
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// แถวล่าสุดของแต่ละ consent เป็นตัวตัดสิน (ถอนความยินยอมแล้ว = ต้องยอมรับใหม่)
+	latestBySlug := map[string]acceptedRow{}
+	acceptedIDs := map[uint]bool{}
+	decided := map[uint]bool{}
+	for _, r := range rows {
+		if decided[r.ConsentID] {
+			continue
+		}
+		decided[r.ConsentID] = true
+		if r.Status == "withdrawn" {
+			continue
+		}
+		acceptedIDs[r.ConsentID] = true
+		if _, ok := latestBySlug[r.Slug]; !ok && r.Slug != "" {
+			latestBySlug[r.Slug] = r
+		}
+	}
+
+	out := make([]ConsentRequirement, 0, len(required))
+	for _, c := range required {
+		req := ConsentRequirement{Consent: LocalizeConsent(c, locale), AlreadyAccepted: acceptedIDs[c.ID]}
+		if prev, ok := latestBySlug[c.Slug]; ok {
+			at := prev.AcceptedAt
+			req.PreviouslyAcceptedVersion = prev.Version
+			req.PreviouslyAcceptedAt = &at
+			req.Reconsent = !req.AlreadyAccepted
+		}
+		out = append(out, req)
+	}
+	return out, nil
+}
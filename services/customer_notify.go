@@ -0,0 +1,114 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"hotel-backend/models"
+	"hotel-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// CustomerNotifyPreferences: เบอร์โทร / LINE userId / ช่องทางที่ลูกค้าเลือก (nil = ไม่เปลี่ยน)
+type CustomerNotifyPreferences struct {
+	Phone      *string   `json:"phone"`
+	LineUserID *string   `json:"lineUserId"`
+	Channels   *[]string `json:"channels"`
+	Locale     *string   `json:"locale"` // ภาษาอีเมล (en | th, "" = ไม่ระบุ)
+}
+
+// normalizeCustomerContact ตรวจ/จัดรูปแบบข้อมูลติดต่อก่อนบันทึก — ช่องทางที่เลือกต้องมีข้อมูลติดต่อของช่องทางนั้น
+func normalizeCustomerContact(c *models.Customer) error {
+	if phone := strings.TrimSpace(c.Phone); phone != "" {
+		if c.Phone = utils.NormalizePhone(phone); c.Phone == "" {
+			return errors.New("invalid_phone")
+		}
+	}
+	c.LineUserID = strings.TrimSpace(c.LineUserID)
+	if c.LineUserID != "" && !utils.ValidLineUserID(c.LineUserID) {
+		return errors.New("invalid_line_user_id")
+	}
+	channels, err := utils.ParseNotifyChannels(c.NotifyChannels)
+	if err != nil {
+		return err
+	}
+	for _, ch := range channels {
+		if (ch == utils.NotifyChannelSMS && c.Phone == "") || (ch == utils.NotifyChannelLine && c.LineUserID == "") {
+			return errors.New("notify_contact_missing: " + ch)
+		}
+	}
+	c.NotifyChannels = strings.Join(channels, ",")
+	if locale := strings.TrimSpace(c.Locale); locale != "" {
+		if c.Locale = utils.NormalizeEmailLocale(locale); c.Locale == "" {
+			return errors.New("invalid_locale")
+		}
+	}
+	return nil
+}
+
+// GuestEmailLocale ภาษาอีเมลถึงแขกของ booking: ภาษาที่ลูกค้าเลือก > ภาษาที่แขกใช้ตอนให้ consent ล่าสุด
+// คืน "" = ให้ RenderEmail ใช้ภาษาตั้งต้นของโรงแรม
+func GuestEmailLocale(db *gorm.DB, bookingID uint) string {
+	if bookingID == 0 {
+		return ""
+	}
+	var booking models.Booking
+	if err := db.Select("id", "customer_id").First(&booking, bookingID).Error; err == nil && booking.CustomerID != 0 {
+		var customer models.Customer
+		if err := db.Select("id", "locale").First(&customer, booking.CustomerID).Error; err == nil {
+			if locale := utils.NormalizeEmailLocale(customer.Locale); locale != "" {
+				return locale
+			}
+		}
+	}
+	var locales []string
+	if err := db.Model(&models.ConsentLog{}).
+		Where("booking_id = ? AND locale <> ''", bookingID).
+		Order("id DESC").Limit(1).Pluck("locale", &locales).Error; err == nil && len(locales) > 0 {
+		return utils.NormalizeEmailLocale(locales[0])
+	}
+	return ""
+}
+
+// Get ลูกค้าตาม id
+func (s *CustomerService) Get(id uint) (models.Customer, error) {
+	var customer models.Customer
+	if err := s.DB.First(&customer, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return customer, errors.New("customer_not_found")
+		}
+		return customer, err
+	}
+	return customer, nil
+}
+
+// UpdateNotifyPreferences แก้ช่องทางแจ้งเตือนของลูกค้า (พนักงานกรอกตามที่แขกแจ้ง)
+func (s *CustomerService) UpdateNotifyPreferences(id uint, p CustomerNotifyPreferences) (models.Customer, error) {
+	customer, err := s.Get(id)
+	if err != nil {
+		return customer, err
+	}
+	if p.Phone != nil {
+		customer.Phone = *p.Phone
+	}
+	if p.LineUserID != nil {
+		customer.LineUserID = *p.LineUserID
+	}
+	if p.Channels != nil {
+		customer.NotifyChannels = strings.Join(*p.Channels, ",")
+	}
+	if p.Locale != nil {
+		customer.Locale = *p.Locale
+	}
+	if err := normalizeCustomerContact(&customer); err != nil {
+		return customer, err
+	}
+	err = s.DB.Model(&customer).Updates(map[string]interface{}{
+		"phone":           customer.Phone,
+		"line_user_id":    customer.LineUserID,
+		"notify_channels": customer.NotifyChannels,
+		"locale":          customer.Locale,
+	}).Error
+	return customer, err
+}
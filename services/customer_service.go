@@ -17,6 +17,9 @@ func NewCustomerService(db *gorm.DB) *CustomerService {
 // Create Customer Record (T0.1)
 // รับ Pointer เพื่อให้ GORM อัปเดต Customer.ID กลับมา
 func (s *CustomerService) Create(customer *models.Customer) error {
+    if err := normalizeCustomerContact(customer); err != nil {
+        return err
+    }
     return s.DB.Create(customer).Error 
 }
 
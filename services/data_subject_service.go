@@ -0,0 +1,686 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"hotel-backend/models"
+	"hotel-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// DataSubjectService: คำขอของเจ้าของข้อมูล (PDPA) — ถอนความยินยอม, ขอสำเนาข้อมูล, ขอลบข้อมูล
+//
+// แขกยื่นคำขอด้วยอีเมล -> ยืนยันผ่านลิงก์ในอีเมล -> คำขอ open พร้อมกำหนดเวลา (DSR_DEADLINE_DAYS, ค่าเริ่มต้น 30 วัน)
+// การถอนความยินยอมมีผลทันทีเมื่อยืนยัน ส่วนคำขอสำเนา/ลบข้อมูลให้พนักงานเป็นผู้ดำเนินการ (fulfil)
+type DataSubjectService struct {
+	DB        *gorm.DB
+	Blobs     BlobStore
+	Retention *RetentionService
+}
+
+func NewDataSubjectService(db *gorm.DB, blobs BlobStore) *DataSubjectService {
+	return &DataSubjectService{DB: db, Blobs: blobs, Retention: NewRetentionService(db, blobs)}
+}
+
+const (
+	dsrVerifyTTL = 24 * time.Hour
+	dsrExportDir = "exports"
+)
+
+func dsrDeadline() time.Duration {
+	days, err := strconv.Atoi(utils.EnvOrDefault("DSR_DEADLINE_DAYS", "30"))
+	if err != nil || days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func dsrExportTTL() time.Duration {
+	hours, err := strconv.Atoi(utils.EnvOrDefault("DSR_EXPORT_TTL_HOURS", "168"))
+	if err != nil || hours <= 0 {
+		hours = 168
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+func hashDSRToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])
+}
+
+func validDSRType(t string) bool {
+	switch t {
+	case models.DSRTypeWithdrawConsent, models.DSRTypeAccess, models.DSRTypeErasure:
+		return true
+	}
+	return false
+}
+
+// DataSubjectInput: ข้อมูลคำขอจากแขกหรือพนักงาน
+type DataSubjectInput struct {
+	Type       string `json:"type"`
+	Email      string `json:"email"`
+	CustomerID *uint  `json:"customerId"`
+	ConsentID  *uint  `json:"consentId"`
+	Note       string `json:"note"`
+}
+
+func (s *DataSubjectService) validateInput(in *DataSubjectInput) error {
+	in.Type = strings.TrimSpace(in.Type)
+	in.Email = strings.ToLower(strings.TrimSpace(in.Email))
+	if !validDSRType(in.Type) {
+		return errors.New("invalid_request_type")
+	}
+	if in.ConsentID != nil {
+		if in.Type != models.DSRTypeWithdrawConsent {
+			in.ConsentID = nil
+		} else if err := s.DB.Select("consent_id").First(&models.Consent{}, *in.ConsentID).Error; err != nil {
+			return errors.New("invalid_consent")
+		}
+	}
+	return nil
+}
+
+// Submit: แขกยื่นคำขอด้วยอีเมล — ส่งลิงก์ยืนยันไปที่อีเมลนั้นเสมอ (ไม่บอกว่ามีข้อมูลในระบบหรือไม่)
+func (s *DataSubjectService) Submit(in DataSubjectInput) error {
+	if err := s.validateInput(&in); err != nil {
+		return err
+	}
+	if in.Email == "" || !strings.Contains(in.Email, "@") {
+		return errors.New("invalid_email")
+	}
+
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return err
+	}
+	expires := time.Now().UTC().Add(dsrVerifyTTL)
+	req := models.DataSubjectRequest{
+		Type:            in.Type,
+		Status:          models.DSRStatusPendingVerification,
+		Source:          "guest",
+		Email:           in.Email,
+		ConsentID:       in.ConsentID,
+		Note:            in.Note,
+		VerifyTokenHash: hashDSRToken(token),
+		VerifyExpiresAt: &expires,
+	}
+	if err := s.DB.Create(&req).Error; err != nil {
+		return err
+	}
+
+	frontend := strings.TrimRight(utils.EnvOrDefault("FRONTEND_URL", "http://localhost:3000"), "/")
+	link := fmt.Sprintf("%s/privacy/verify?token=%s", frontend, token)
+	return utils.SendPrivacyVerificationEmail(in.Email, in.Type, link, expires)
+}
+
+// Verify: แขกกดลิงก์ยืนยัน -> คำขอ open (ถอนความยินยอมดำเนินการทันที)
+func (s *DataSubjectService) Verify(token string, now time.Time) (models.DataSubjectRequest, error) {
+	now = now.UTC()
+	var req models.DataSubjectRequest
+	if strings.TrimSpace(token) == "" {
+		return req, errors.New("invalid_or_expired_token")
+	}
+	if err := s.DB.
+		Where("verify_token_hash = ? AND status = ? AND verify_expires_at > ?", hashDSRToken(token), models.DSRStatusPendingVerification, now).
+		First(&req).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return req, errors.New("invalid_or_expired_token")
+		}
+		return req, err
+	}
+
+	due := now.Add(dsrDeadline())
+	if err := s.DB.Model(&req).Updates(map[string]interface{}{
+		"status":            models.DSRStatusOpen,
+		"verified_at":       now,
+		"due_at":            due,
+		"verify_token_hash": "",
+	}).Error; err != nil {
+		return req, err
+	}
+
+	if req.Type == models.DSRTypeWithdrawConsent {
+		return s.completeWithdrawal(req.ID, nil, now)
+	}
+	return s.Get(req.ID)
+}
+
+// CreateByStaff: พนักงานบันทึกคำขอแทนแขก (ยืนยันตัวตนที่เคาน์เตอร์แล้ว)
+func (s *DataSubjectService) CreateByStaff(in DataSubjectInput, adminID uint, now time.Time) (models.DataSubjectRequest, error) {
+	if err := s.validateInput(&in); err != nil {
+		return models.DataSubjectRequest{}, err
+	}
+	if in.Email == "" && in.CustomerID == nil {
+		return models.DataSubjectRequest{}, errors.New("subject_required")
+	}
+	if in.CustomerID != nil {
+		var customer models.Customer
+		if err := s.DB.Select("id", "email").First(&customer, *in.CustomerID).Error; err != nil {
+			return models.DataSubjectRequest{}, errors.New("customer_not_found")
+		}
+	}
+
+	now = now.UTC()
+	due := now.Add(dsrDeadline())
+	req := models.DataSubjectRequest{
+		Type:        in.Type,
+		Status:      models.DSRStatusOpen,
+		Source:      "staff",
+		Email:       in.Email,
+		CustomerID:  in.CustomerID,
+		ConsentID:   in.ConsentID,
+		Note:        in.Note,
+		VerifiedAt:  &now,
+		DueAt:       &due,
+		RequestedBy: &adminID,
+	}
+	if err := s.DB.Create(&req).Error; err != nil {
+		return req, err
+	}
+	if req.Type == models.DSRTypeWithdrawConsent {
+		return s.completeWithdrawal(req.ID, &adminID, now)
+	}
+	return req, nil
+}
+
+func (s *DataSubjectService) Get(id uint) (models.DataSubjectRequest, error) {
+	var req models.DataSubjectRequest
+	err := s.DB.First(&req, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return req, errors.New("request_not_found")
+	}
+	return req, err
+}
+
+// List: status = "" ทุกสถานะ, overdue = เฉพาะคำขอ open ที่เลยกำหนด
+func (s *DataSubjectService) List(status string, overdue bool, now time.Time) ([]models.DataSubjectRequest, error) {
+	q := s.DB.Model(&models.DataSubjectRequest{})
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if overdue {
+		q = q.Where("status = ? AND due_at < ?", models.DSRStatusOpen, now.UTC())
+	}
+	var out []models.DataSubjectRequest
+	err := q.Order("id DESC").Limit(500).Find(&out).Error
+	return out, err
+}
+
+// ------------------------------------------------------------
+// เจ้าของข้อมูล -> customer / guest / booking ที่เกี่ยวข้อง
+// ------------------------------------------------------------
+
+type dataSubject struct {
+	CustomerIDs []uint
+	GuestIDs    []uint
+	BookingIDs  []uint
+}
+
+func (s *DataSubjectService) resolveSubject(req models.DataSubjectRequest) (dataSubject, error) {
+	var subj dataSubject
+
+	if req.CustomerID != nil {
+		subj.CustomerIDs = append(subj.CustomerIDs, *req.CustomerID)
+	}
+	if req.Email != "" {
+		var ids []uint
+		if err := s.DB.Model(&models.Customer{}).Where("LOWER(email) = ?", req.Email).Pluck("id", &ids).Error; err != nil {
+			return subj, err
+		}
+		subj.CustomerIDs = append(subj.CustomerIDs, ids...)
+	}
+
+	if len(subj.CustomerIDs) > 0 {
+		if err := s.DB.Model(&models.Booking{}).Where("customer_id IN ?", subj.CustomerIDs).Pluck("id", &subj.BookingIDs).Error; err != nil {
+			return subj, err
+		}
+	}
+
+	q := s.DB.Model(&models.Guest{})
+	switch {
+	case req.Email != "" && len(subj.BookingIDs) > 0:
+		q = q.Where("LOWER(email) = ? OR booking_id IN ?", req.Email, subj.BookingIDs)
+	case req.Email != "":
+		q = q.Where("LOWER(email) = ?", req.Email)
+	case len(subj.BookingIDs) > 0:
+		q = q.Where("booking_id IN ?", subj.BookingIDs)
+	default:
+		return subj, nil
+	}
+	var guests []models.Guest
+	if err := q.Select("id", "booking_id").Find(&guests).Error; err != nil {
+		return subj, err
+	}
+
+	seen := map[uint]bool{}
+	for _, id := range subj.BookingIDs {
+		seen[id] = true
+	}
+	for _, g := range guests {
+		subj.GuestIDs = append(subj.GuestIDs, g.ID)
+		if g.BookingID != nil && !seen[*g.BookingID] {
+			seen[*g.BookingID] = true
+			subj.BookingIDs = append(subj.BookingIDs, *g.BookingID)
+		}
+	}
+	return subj, nil
+}
+
+// ------------------------------------------------------------
+// ถอนความยินยอม
+// ------------------------------------------------------------
+
+// withdrawConsents: เพิ่ม log "withdrawn" ให้ทุก consent ที่แขกเคยยอมรับ (หรือเฉพาะ consentID) — log เดิมไม่ถูกลบ
+func (s *DataSubjectService) withdrawConsents(tx *gorm.DB, req models.DataSubjectRequest, guestIDs []uint, now time.Time) (int, error) {
+	if len(guestIDs) == 0 {
+		return 0, nil
+	}
+	q := tx.Model(&models.ConsentLog{}).
+		Where("guest_id IN ? AND status IN ?", guestIDs, []string{"accepted", "sent", "pending"})
+	if req.ConsentID != nil {
+		q = q.Where("consent_id = ?", *req.ConsentID)
+	}
+	var logs []models.ConsentLog
+	if err := q.Order("id ASC").Find(&logs).Error; err != nil {
+		return 0, err
+	}
+
+	type key struct{ guest, consent uint }
+	done := map[key]bool{}
+	by := fmt.Sprintf("dsr:%d", req.ID)
+	count := 0
+	for _, l := range logs {
+		k := key{*l.GuestID, l.ConsentID}
+		if done[k] {
+			continue
+		}
+		done[k] = true
+
+		var withdrawn int64
+		if err := tx.Model(&models.ConsentLog{}).
+			Where("guest_id = ? AND consent_id = ? AND status = ? AND id > ?", *l.GuestID, l.ConsentID, "withdrawn", l.ID).
+			Count(&withdrawn).Error; err != nil {
+			return count, err
+		}
+		if withdrawn > 0 {
+			continue
+		}
+
+		ref := l.ID
+		entry := models.ConsentLog{
+			BookingID:  l.BookingID,
+			ConsentID:  l.ConsentID,
+			GuestID:    l.GuestID,
+			AcceptedAt: now,
+			AcceptedBy: by,
+			Status:     "withdrawn",
+			Action:     "withdrawn",
+			Locale:     l.Locale,
+			TextHash:   l.TextHash,
+			RefID:      &ref,
+		}
+		if err := AppendConsentLog(tx, &entry, ConsentAudit{}); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (s *DataSubjectService) completeWithdrawal(id uint, adminID *uint, now time.Time) (models.DataSubjectRequest, error) {
+	req, err := s.Get(id)
+	if err != nil {
+		return req, err
+	}
+	subj, err := s.resolveSubject(req)
+	if err != nil {
+		return req, err
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		n, err := s.withdrawConsents(tx, req, subj.GuestIDs, now)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&req).Updates(map[string]interface{}{
+			"status":       models.DSRStatusCompleted,
+			"completed_at": now,
+			"handled_by":   adminID,
+			"result":       fmt.Sprintf("withdrew %d consent record(s) for %d guest(s)", n, len(subj.GuestIDs)),
+		}).Error
+	})
+	if err != nil {
+		return req, err
+	}
+	return s.Get(id)
+}
+
+// ------------------------------------------------------------
+// Export (สำเนาข้อมูล)
+// ------------------------------------------------------------
+
+// DataSubjectExport: ข้อมูลทั้งหมดของเจ้าของข้อมูล (ไฟล์ data.json ใน ZIP)
+type DataSubjectExport struct {
+	GeneratedAt time.Time           `json:"generatedAt"`
+	RequestID   uint                `json:"requestId"`
+	Customers   []models.Customer   `json:"customers"`
+	Bookings    []models.Booking    `json:"bookings"`
+	Guests      []models.Guest      `json:"guests"`
+	ConsentLogs []models.ConsentLog `json:"consentLogs"`
+	Consents    []models.Consent    `json:"consents"`
+}
+
+func (s *DataSubjectService) collectExport(req models.DataSubjectRequest, subj dataSubject, now time.Time) (DataSubjectExport, error) {
+	out := DataSubjectExport{GeneratedAt: now.UTC(), RequestID: req.ID}
+
+	if len(subj.CustomerIDs) > 0 {
+		if err := s.DB.Where("id IN ?", subj.CustomerIDs).Find(&out.Customers).Error; err != nil {
+			return out, err
+		}
+	}
+	if len(subj.BookingIDs) > 0 {
+		if err := s.DB.Preload("Rooms").Where("id IN ?", subj.BookingIDs).Find(&out.Bookings).Error; err != nil {
+			return out, err
+		}
+	}
+	if len(subj.GuestIDs) > 0 {
+		if err := s.DB.Where("id IN ?", subj.GuestIDs).Find(&out.Guests).Error; err != nil {
+			return out, err
+		}
+		if err := openGuests(out.Guests); err != nil {
+			return out, err
+		}
+		if err := s.DB.Where("guest_id IN ?", subj.GuestIDs).Order("id ASC").Find(&out.ConsentLogs).Error; err != nil {
+			return out, err
+		}
+	}
+
+	consentIDs := map[uint]bool{}
+	var ids []uint
+	for _, l := range out.ConsentLogs {
+		if !consentIDs[l.ConsentID] {
+			consentIDs[l.ConsentID] = true
+			ids = append(ids, l.ConsentID)
+		}
+	}
+	if len(ids) > 0 {
+		if err := s.DB.Unscoped().Preload("Translations").Where("consent_id IN ?", ids).Find(&out.Consents).Error; err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// BuildExport สร้างไฟล์ export — format "json" (ข้อมูลอย่างเดียว) หรือ "zip" (data.json + รูป)
+func (s *DataSubjectService) BuildExport(ctx context.Context, req models.DataSubjectRequest, format string, now time.Time) ([]byte, error) {
+	subj, err := s.resolveSubject(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.collectExport(req, subj, now)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if format == "json" {
+		return doc, nil
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("data.json")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(doc); err != nil {
+		return nil, err
+	}
+
+	for _, g := range data.Guests {
+		for kind, path := range map[string]string{"face": g.FaceImagePath, "document": g.DocumentImagePath} {
+			if path == "" {
+				continue
+			}
+			key, err := normalizeBlobKey(path)
+			if err != nil {
+				continue
+			}
+			img, _, err := OpenGuestImage(ctx, s.Blobs, key)
+			if err != nil {
+				if !errors.Is(err, ErrBlobNotFound) {
+					log.Printf("DataSubjectService: export image for guest %d: %v", g.ID, err)
+				}
+				continue
+			}
+			_, ext, err := DetectImageType(img)
+			if err != nil {
+				ext = ".bin"
+			}
+			w, err := zw.Create(fmt.Sprintf("images/guest-%d-%s%s", g.ID, kind, ext))
+			if err != nil {
+				return nil, err
+			}
+			if _, err := w.Write(img); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ------------------------------------------------------------
+// Fulfil / Reject
+// ------------------------------------------------------------
+
+// Fulfil ดำเนินการตามคำขอที่ open อยู่
+// - access: สร้าง ZIP (เข้ารหัสใน BlobStore) แล้วส่งลิงก์ดาวน์โหลดทางอีเมล
+// - erasure: anonymise แขกและลูกค้า (booking และ consent log คงไว้เป็นหลักฐาน)
+// - withdraw_consent: บันทึกการถอนความยินยอม
+func (s *DataSubjectService) Fulfil(ctx context.Context, id uint, adminID uint, now time.Time) (models.DataSubjectRequest, error) {
+	now = now.UTC()
+	req, err := s.Get(id)
+	if err != nil {
+		return req, err
+	}
+	if req.Status != models.DSRStatusOpen {
+		return req, errors.New("request_not_open")
+	}
+
+	switch req.Type {
+	case models.DSRTypeWithdrawConsent:
+		return s.completeWithdrawal(id, &adminID, now)
+	case models.DSRTypeAccess:
+		return s.fulfilAccess(ctx, req, adminID, now)
+	case models.DSRTypeErasure:
+		return s.fulfilErasure(ctx, req, adminID, now)
+	}
+	return req, errors.New("invalid_request_type")
+}
+
+func (s *DataSubjectService) fulfilAccess(ctx context.Context, req models.DataSubjectRequest, adminID uint, now time.Time) (models.DataSubjectRequest, error) {
+	archive, err := s.BuildExport(ctx, req, "zip", now)
+	if err != nil {
+		return req, err
+	}
+	sealed, err := EncryptPII(archive)
+	if err != nil {
+		return req, err
+	}
+	name, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		return req, err
+	}
+	key := fmt.Sprintf("%s/dsr-%d-%s.zip", dsrExportDir, req.ID, name)
+	if err := s.Blobs.Put(ctx, key, sealed, "application/octet-stream"); err != nil {
+		return req, fmt.Errorf("store export: %w", err)
+	}
+
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return req, err
+	}
+	expires := now.Add(dsrExportTTL())
+
+	previousKey := req.ExportKey
+	if err := s.DB.Model(&req).Updates(map[string]interface{}{
+		"status":            models.DSRStatusCompleted,
+		"completed_at":      now,
+		"handled_by":        adminID,
+		"export_key":        key,
+		"export_token_hash": hashDSRToken(token),
+		"export_expires_at": expires,
+		"result":            fmt.Sprintf("export generated (%d bytes)", len(archive)),
+	}).Error; err != nil {
+		return req, err
+	}
+	if previousKey != "" && previousKey != key {
+		_ = s.Blobs.Delete(ctx, previousKey)
+	}
+
+	if recipient := s.recipientEmail(req); recipient != "" {
+		frontend := strings.TrimRight(utils.EnvOrDefault("FRONTEND_URL", "http://localhost:3000"), "/")
+		link := fmt.Sprintf("%s/privacy/download?token=%s", frontend, token)
+		if err := utils.SendPrivacyExportEmail(recipient, link, expires); err != nil {
+			log.Printf("DataSubjectService: export email for request %d failed: %v", req.ID, err)
+		}
+	}
+	return s.Get(req.ID)
+}
+
+func (s *DataSubjectService) recipientEmail(req models.DataSubjectRequest) string {
+	if req.Email != "" {
+		return req.Email
+	}
+	if req.CustomerID != nil {
+		var customer models.Customer
+		if err := s.DB.Select("id", "email").First(&customer, *req.CustomerID).Error; err == nil {
+			return strings.TrimSpace(customer.Email)
+		}
+	}
+	return ""
+}
+
+func (s *DataSubjectService) fulfilErasure(ctx context.Context, req models.DataSubjectRequest, adminID uint, now time.Time) (models.DataSubjectRequest, error) {
+	subj, err := s.resolveSubject(req)
+	if err != nil {
+		return req, err
+	}
+
+	// ถอนความยินยอมทั้งหมดก่อน (หลักฐานการถอนยังอยู่หลัง anonymise)
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := s.withdrawConsents(tx, req, subj.GuestIDs, now)
+		return err
+	}); err != nil {
+		return req, err
+	}
+
+	anonymised, failed, err := s.Retention.AnonymiseGuests(ctx, subj.GuestIDs, "erasure_request", &adminID)
+	if err != nil {
+		return req, err
+	}
+	if failed > 0 {
+		return req, fmt.Errorf("erasure_incomplete: %d guest(s) failed", failed)
+	}
+
+	// ใบยืนยัน consent มีชื่อแขก — ลบไฟล์ (แถว ConsentReceipt เก็บ hash ไว้เป็นหลักฐาน)
+	if err := NewConsentReceiptService(s.DB, s.Blobs).DeleteForBookings(ctx, subj.BookingIDs); err != nil {
+		return req, fmt.Errorf("erasure_incomplete: consent receipts: %w", err)
+	}
+
+	if len(subj.CustomerIDs) > 0 {
+		if err := s.DB.Model(&models.Customer{}).Where("id IN ?", subj.CustomerIDs).
+			Updates(map[string]interface{}{"full_name": "ANONYMISED", "email": ""}).Error; err != nil {
+			return req, err
+		}
+	}
+
+	if err := s.DB.Model(&req).Updates(map[string]interface{}{
+		"status":       models.DSRStatusCompleted,
+		"completed_at": now,
+		"handled_by":   adminID,
+		"email":        "",
+		"result":       fmt.Sprintf("anonymised %d guest(s) and %d customer(s)", anonymised, len(subj.CustomerIDs)),
+	}).Error; err != nil {
+		return req, err
+	}
+	return s.Get(req.ID)
+}
+
+// Reject ปฏิเสธคำขอ (เช่น ยืนยันตัวตนไม่ได้ หรือมีหน้าที่ตามกฎหมายต้องเก็บข้อมูล)
+func (s *DataSubjectService) Reject(id uint, adminID uint, reason string, now time.Time) (models.DataSubjectRequest, error) {
+	req, err := s.Get(id)
+	if err != nil {
+		return req, err
+	}
+	if req.Status == models.DSRStatusCompleted || req.Status == models.DSRStatusRejected {
+		return req, errors.New("request_not_open")
+	}
+	if strings.TrimSpace(reason) == "" {
+		return req, errors.New("reason_required")
+	}
+	if err := s.DB.Model(&req).Updates(map[string]interface{}{
+		"status":            models.DSRStatusRejected,
+		"completed_at":      now.UTC(),
+		"handled_by":        adminID,
+		"reject_reason":     strings.TrimSpace(reason),
+		"verify_token_hash": "",
+	}).Error; err != nil {
+		return req, err
+	}
+	return s.Get(id)
+}
+
+// OpenExport: แขกดาวน์โหลดไฟล์ export ด้วย token จากอีเมล
+func (s *DataSubjectService) OpenExport(ctx context.Context, token string, now time.Time) ([]byte, string, error) {
+	if strings.TrimSpace(token) == "" {
+		return nil, "", errors.New("invalid_or_expired_token")
+	}
+	var req models.DataSubjectRequest
+	if err := s.DB.
+		Where("export_token_hash = ? AND export_expires_at > ? AND export_key <> ''", hashDSRToken(token), now.UTC()).
+		First(&req).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", errors.New("invalid_or_expired_token")
+		}
+		return nil, "", err
+	}
+
+	body, _, err := s.Blobs.Open(ctx, req.ExportKey)
+	if err != nil {
+		return nil, "", err
+	}
+	defer body.Close()
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return nil, "", err
+	}
+	archive, err := DecryptPII(raw)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.DB.Model(&req).Update("export_downloaded_at", now.UTC()).Error; err != nil {
+		log.Printf("DataSubjectService: mark export %d downloaded: %v", req.ID, err)
+	}
+	return archive, fmt.Sprintf("personal-data-%d.zip", req.ID), nil
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hotel-backend/models"
+	"hotel-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// ชนิดรูปของแขกที่เปิดผ่าน signed URL ได้
+const (
+	DocumentKindFace     = "face"
+	DocumentKindDocument = "document"
+)
+
+var (
+	documentSecretOnce sync.Once
+	documentSecret     []byte
+)
+
+// documentURLSecret: key สำหรับเซ็น URL (DOCUMENT_URL_SECRET)
+// ถ้าไม่ตั้งค่า จะสุ่ม key ต่อ process (ลิงก์ใช้ไม่ได้หลัง restart / ข้าม replica)
+func documentURLSecret() []byte {
+	documentSecretOnce.Do(func() {
+		if v := strings.TrimSpace(os.Getenv("DOCUMENT_URL_SECRET")); v != "" {
+			documentSecret = []byte(v)
+			return
+		}
+		log.Println("⚠️ DOCUMENT_URL_SECRET not set; using a random per-process key for document links")
+		documentSecret = make([]byte, 32)
+		if _, err := rand.Read(documentSecret); err != nil {
+			log.Fatalf("failed to generate document url secret: %v", err)
+		}
+	})
+	return documentSecret
+}
+
+func documentURLTTL() time.Duration {
+	secs, err := strconv.Atoi(utils.EnvOrDefault("DOCUMENT_URL_TTL_SECONDS", "300"))
+	if err != nil || secs <= 0 {
+		secs = 300
+	}
+	return time.Duration(secs) * time.Second
+}
+
+func signDocument(guestID uint, kind string, adminID uint, exp int64) string {
+	mac := hmac.New(sha256.New, documentURLSecret())
+	fmt.Fprintf(mac, "%d|%s|%d|%d", guestID, kind, adminID, exp)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// DocumentAccessService: ออก signed URL อายุสั้นและเปิดไฟล์รูปของแขกพร้อม log ทุกครั้ง
+type DocumentAccessService struct {
+	DB    *gorm.DB
+	Blobs BlobStore
+}
+
+func NewDocumentAccessService(db *gorm.DB, blobs BlobStore) *DocumentAccessService {
+	return &DocumentAccessService{DB: db, Blobs: blobs}
+}
+
+// DocumentLink: signed URL ของรูปหนึ่งชนิด
+type DocumentLink struct {
+	Kind      string    `json:"kind"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (s *DocumentAccessService) logAccess(guestID, adminID uint, kind, action, ip, userAgent string) {
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	entry := models.DocumentAccessLog{
+		GuestID:   guestID,
+		AdminID:   adminID,
+		Kind:      kind,
+		Action:    action,
+		IP:        ip,
+		UserAgent: userAgent,
+	}
+	if err := s.DB.Create(&entry).Error; err != nil {
+		log.Printf("DocumentAccessService: failed to write access log: %v", err)
+	}
+}
+
+// SignedURL สร้าง URL ของรูปชนิด kind (ไม่ log — ใช้ใน list ที่ log แยกเอง)
+func (s *DocumentAccessService) SignedURL(guestID uint, kind string, adminID uint) DocumentLink {
+	expiresAt := time.Now().UTC().Add(documentURLTTL())
+	exp := expiresAt.Unix()
+	q := url.Values{}
+	q.Set("aid", strconv.FormatUint(uint64(adminID), 10))
+	q.Set("exp", strconv.FormatInt(exp, 10))
+	q.Set("sig", signDocument(guestID, kind, adminID, exp))
+	return DocumentLink{
+		Kind:      kind,
+		URL:       fmt.Sprintf("/api/documents/guests/%d/%s?%s", guestID, kind, q.Encode()),
+		ExpiresAt: expiresAt,
+	}
+}
+
+// IssueLinks ออก signed URL ของรูปที่ guest มีอยู่จริง และ log การออกลิงก์
+func (s *DocumentAccessService) IssueLinks(guestID, adminID uint, ip, userAgent string) ([]DocumentLink, error) {
+	var guest models.Guest
+	if err := s.DB.Select("id", "face_image_path", "document_image_path").First(&guest, guestID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("guest_not_found")
+		}
+		return nil, err
+	}
+
+	links := make([]DocumentLink, 0, 2)
+	if strings.TrimSpace(guest.FaceImagePath) != "" {
+		links = append(links, s.SignedURL(guest.ID, DocumentKindFace, adminID))
+		s.logAccess(guest.ID, adminID, DocumentKindFace, "issue_url", ip, userAgent)
+	}
+	if strings.TrimSpace(guest.DocumentImagePath) != "" {
+		links = append(links, s.SignedURL(guest.ID, DocumentKindDocument, adminID))
+		s.logAccess(guest.ID, adminID, DocumentKindDocument, "issue_url", ip, userAgent)
+	}
+	return links, nil
+}
+
+// Open ตรวจลายเซ็น/อายุของ URL แล้วอ่านรูปจาก BlobStore (ถอดรหัสแล้ว) พร้อม content-type
+func (s *DocumentAccessService) Open(ctx context.Context, guestID uint, kind string, adminID uint, exp int64, sig, ip, userAgent string) ([]byte, string, error) {
+	if kind != DocumentKindFace && kind != DocumentKindDocument {
+		return nil, "", errors.New("invalid_document_kind")
+	}
+
+	expected := signDocument(guestID, kind, adminID, exp)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(strings.TrimSpace(sig)))) {
+		s.logAccess(guestID, adminID, kind, "denied", ip, userAgent)
+		return nil, "", errors.New("invalid_signature")
+	}
+	if time.Now().UTC().Unix() > exp {
+		s.logAccess(guestID, adminID, kind, "denied", ip, userAgent)
+		return nil, "", errors.New("link_expired")
+	}
+
+	var guest models.Guest
+	if err := s.DB.Select("id", "face_image_path", "document_image_path").First(&guest, guestID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", errors.New("guest_not_found")
+		}
+		return nil, "", err
+	}
+
+	stored := guest.FaceImagePath
+	if kind == DocumentKindDocument {
+		stored = guest.DocumentImagePath
+	}
+	stored, err := DecryptPIIString(stored)
+	if err != nil {
+		return nil, "", err
+	}
+	key, err := normalizeBlobKey(stored)
+	if err != nil {
+		return nil, "", errors.New("file_not_found")
+	}
+	data, contentType, err := OpenGuestImage(ctx, s.Blobs, key)
+	if err != nil {
+		if errors.Is(err, ErrBlobNotFound) {
+			return nil, "", errors.New("file_not_found")
+		}
+		return nil, "", err
+	}
+
+	s.logAccess(guestID, adminID, kind, "view", ip, userAgent)
+	return data, contentType, nil
+}
+
+// ListAccessLogs: ประวัติการเข้าถึงรูปของแขกหนึ่งคน (ล่าสุดก่อน)
+func (s *DocumentAccessService) ListAccessLogs(guestID uint) ([]models.DocumentAccessLog, error) {
+	var out []models.DocumentAccessLog
+	err := s.DB.Where("guest_id = ?", guestID).Order("id DESC").Find(&out).Error
+	return out, err
+}
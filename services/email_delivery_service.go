@@ -0,0 +1,504 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"hotel-backend/models"
+	"hotel-backend/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// webhook ที่ timestamp ห่างจากเวลาปัจจุบันเกินนี้ถือว่าถูกส่งซ้ำ (replay)
+const emailWebhookTolerance = 5 * time.Minute
+
+// Event ที่รองรับ (ชื่อกลางของทั้ง SendGrid และ Resend)
+const (
+	EmailEventDelivered  = "delivered"
+	EmailEventBounced    = "bounced"
+	EmailEventDropped    = "dropped"
+	EmailEventDeferred   = "deferred"
+	EmailEventComplained = "complained"
+	EmailEventOpened     = "opened"
+	EmailEventClicked    = "clicked"
+)
+
+// emailEventRank: ลำดับของสถานะ — อัปเดตเฉพาะเมื่อสถานะใหม่ "ไปไกลกว่า" (event มาไม่เรียงลำดับได้)
+// ค่าที่ไม่อยู่ใน map (PENDING / SENT / FAILED / deferred) = 0
+var emailEventRank = map[string]int{
+	"SENT":       1,
+	"DELIVERED":  2,
+	"OPENED":     3,
+	"CLICKED":    4,
+	"BOUNCED":    5,
+	"DROPPED":    5,
+	"COMPLAINED": 6,
+}
+
+// EmailDeliveryEvent: event หนึ่งรายการหลังแปลงจาก payload ของ provider
+type EmailDeliveryEvent struct {
+	Provider   string
+	EventID    string
+	MessageID  string
+	OutboxID   uint
+	Event      string
+	Recipient  string
+	Detail     string
+	HardBounce bool // ที่อยู่ใช้ไม่ได้ถาวร -> เข้า suppression list
+	OccurredAt time.Time
+}
+
+// EmailDeliveryService: รับ delivery webhook, อัปเดต outbox / BookingInfo.EmailStatus และดูแล suppression list
+type EmailDeliveryService struct {
+	DB *gorm.DB
+}
+
+func NewEmailDeliveryService(db *gorm.DB) *EmailDeliveryService {
+	return &EmailDeliveryService{DB: db}
+}
+
+func webhookTimestampFresh(timestamp string, now time.Time) error {
+	sec, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+	if err != nil {
+		return errors.New("invalid_signature: bad timestamp")
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > emailWebhookTolerance || d < -emailWebhookTolerance {
+		return errors.New("invalid_signature: stale timestamp")
+	}
+	return nil
+}
+
+// VerifySendGridSignature ตรวจ Signed Event Webhook ของ SendGrid (ECDSA P-256 ของ timestamp+body)
+// publicKey = "Verification Key" (base64) จากหน้า Mail Settings -> SENDGRID_WEBHOOK_PUBLIC_KEY
+func VerifySendGridSignature(publicKey, signature, timestamp string, body []byte, now time.Time) error {
+	publicKey = strings.TrimSpace(publicKey)
+	if publicKey == "" {
+		return errors.New("webhook_not_configured")
+	}
+	der, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return fmt.Errorf("webhook_not_configured: %w", err)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return fmt.Errorf("webhook_not_configured: %w", err)
+	}
+	pub, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.New("webhook_not_configured: not an ECDSA key")
+	}
+	if err := webhookTimestampFresh(timestamp, now); err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil || len(sig) == 0 {
+		return errors.New("invalid_signature")
+	}
+	digest := sha256.Sum256(append([]byte(timestamp), body...))
+	if !ecdsa.VerifyASN1(pub, digest[:], sig) {
+		return errors.New("invalid_signature")
+	}
+	return nil
+}
+
+// VerifyResendSignature ตรวจ webhook ของ Resend (Svix: HMAC-SHA256 ของ "id.timestamp.body")
+// secret = "whsec_..." จากหน้า Webhooks -> RESEND_WEBHOOK_SECRET
+func VerifyResendSignature(secret, msgID, timestamp, signatures string, body []byte, now time.Time) error {
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return errors.New("webhook_not_configured")
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, "whsec_"))
+	if err != nil {
+		return fmt.Errorf("webhook_not_configured: %w", err)
+	}
+	if strings.TrimSpace(msgID) == "" {
+		return errors.New("invalid_signature")
+	}
+	if err := webhookTimestampFresh(timestamp, now); err != nil {
+		return err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(msgID + "." + timestamp + "."))
+	mac.Write(body)
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	// header มีได้หลายลายเซ็น (ช่วงหมุน secret): "v1,<sig> v1,<sig>"
+	for _, part := range strings.Fields(signatures) {
+		version, sig, ok := strings.Cut(part, ",")
+		if ok && version == "v1" && hmac.Equal([]byte(sig), []byte(expected)) {
+			return nil
+		}
+	}
+	return errors.New("invalid_signature")
+}
+
+type sendGridEvent struct {
+	Email       string `json:"email"`
+	Timestamp   int64  `json:"timestamp"`
+	Event       string `json:"event"`
+	SgEventID   string `json:"sg_event_id"`
+	SgMessageID string `json:"sg_message_id"`
+	Reason      string `json:"reason"`
+	Type        string `json:"type"` // bounce: "bounce" (ถาวร) | "blocked" (ชั่วคราว)
+	URL         string `json:"url"`
+	OutboxID    string `json:"outbox_id"` // custom arg ที่ส่งไปตอนส่งอีเมล
+}
+
+// ParseSendGridEvents แปลง payload (array) ของ SendGrid Event Webhook — event ที่ไม่ได้ใช้ (processed ฯลฯ) ถูกข้าม
+func ParseSendGridEvents(body []byte) ([]EmailDeliveryEvent, error) {
+	var raw []sendGridEvent
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("invalid_payload: %w", err)
+	}
+	out := make([]EmailDeliveryEvent, 0, len(raw))
+	for _, e := range raw {
+		ev := EmailDeliveryEvent{
+			Provider:   utils.EmailProviderSendGrid,
+			EventID:    e.SgEventID,
+			Recipient:  e.Email,
+			OccurredAt: time.Unix(e.Timestamp, 0).UTC(),
+		}
+		// sg_message_id = "<X-Message-Id>.filterxxxx..."
+		ev.MessageID, _, _ = strings.Cut(e.SgMessageID, ".")
+		if id, err := strconv.ParseUint(e.OutboxID, 10, 64); err == nil {
+			ev.OutboxID = uint(id)
+		}
+		switch e.Event {
+		case "delivered":
+			ev.Event = EmailEventDelivered
+		case "bounce":
+			ev.Event, ev.Detail = EmailEventBounced, e.Reason
+			ev.HardBounce = e.Type != "blocked"
+		case "dropped":
+			ev.Event, ev.Detail = EmailEventDropped, e.Reason
+			ev.HardBounce = strings.Contains(strings.ToLower(e.Reason), "bounce")
+		case "deferred":
+			ev.Event, ev.Detail = EmailEventDeferred, e.Reason
+		case "spamreport":
+			ev.Event = EmailEventComplained
+		case "open":
+			ev.Event = EmailEventOpened
+		case "click":
+			ev.Event, ev.Detail = EmailEventClicked, e.URL
+		default:
+			continue
+		}
+		if ev.EventID == "" {
+			ev.EventID = fmt.Sprintf("%s:%s:%s:%d", e.SgMessageID, e.Event, e.Email, e.Timestamp)
+		}
+		out = append(out, ev)
+	}
+	return out, nil
+}
+
+type resendWebhook struct {
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      struct {
+		EmailID string          `json:"email_id"`
+		To      []string        `json:"to"`
+		Tags    json.RawMessage `json:"tags"`
+		Bounce  *struct {
+			Message string `json:"message"`
+			Type    string `json:"type"` // Permanent | Transient | Undetermined
+		} `json:"bounce"`
+		Click *struct {
+			Link string `json:"link"`
+		} `json:"click"`
+	} `json:"data"`
+}
+
+// resendTagValue อ่าน tag จาก data.tags (object {"name": "value"} หรือ array [{name, value}])
+func resendTagValue(raw json.RawMessage, name string) string {
+	var asMap map[string]string
+	if err := json.Unmarshal(raw, &asMap); err == nil {
+		return asMap[name]
+	}
+	var asList []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &asList); err == nil {
+		for _, t := range asList {
+			if t.Name == name {
+				return t.Value
+			}
+		}
+	}
+	return ""
+}
+
+// ParseResendEvent แปลง payload ของ Resend webhook (หนึ่ง event ต่อ request, eventID = svix-id)
+func ParseResendEvent(body []byte, eventID string) ([]EmailDeliveryEvent, error) {
+	var w resendWebhook
+	if err := json.Unmarshal(body, &w); err != nil {
+		return nil, fmt.Errorf("invalid_payload: %w", err)
+	}
+	base := EmailDeliveryEvent{
+		Provider:   utils.EmailProviderResend,
+		EventID:    eventID,
+		MessageID:  w.Data.EmailID,
+		OccurredAt: w.CreatedAt.UTC(),
+	}
+	if id, err := strconv.ParseUint(resendTagValue(w.Data.Tags, utils.EmailOutboxIDArg), 10, 64); err == nil {
+		base.OutboxID = uint(id)
+	}
+	switch w.Type {
+	case "email.delivered":
+		base.Event = EmailEventDelivered
+	case "email.bounced":
+		base.Event, base.HardBounce = EmailEventBounced, true
+		if w.Data.Bounce != nil {
+			base.Detail = w.Data.Bounce.Message
+			base.HardBounce = w.Data.Bounce.Type == "" || w.Data.Bounce.Type == "Permanent"
+		}
+	case "email.delivery_delayed":
+		base.Event = EmailEventDeferred
+	case "email.complained":
+		base.Event = EmailEventComplained
+	case "email.opened":
+		base.Event = EmailEventOpened
+	case "email.clicked":
+		base.Event = EmailEventClicked
+		if w.Data.Click != nil {
+			base.Detail = w.Data.Click.Link
+		}
+	default:
+		return nil, nil
+	}
+
+	if len(w.Data.To) <= 1 {
+		if len(w.Data.To) == 1 {
+			base.Recipient = w.Data.To[0]
+		}
+		return []EmailDeliveryEvent{base}, nil
+	}
+	out := make([]EmailDeliveryEvent, 0, len(w.Data.To))
+	for i, to := range w.Data.To {
+		ev := base
+		ev.Recipient = to
+		ev.EventID = fmt.Sprintf("%s:%d", eventID, i)
+		out = append(out, ev)
+	}
+	return out, nil
+}
+
+// Record บันทึก event และสะท้อนไปที่ outbox / BookingInfo / suppression list
+// คืน false เมื่อเป็น event ซ้ำ (provider retry) — ไม่ทำอะไรเพิ่ม
+func (s *EmailDeliveryService) Record(ev EmailDeliveryEvent) (bool, error) {
+	recorded := false
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var outbox *models.EmailOutbox
+		var row models.EmailOutbox
+		q := tx.Select("id", "ref", "delivery_status", "provider_message_id")
+		var err error
+		switch {
+		case ev.OutboxID != 0:
+			err = q.First(&row, ev.OutboxID).Error
+		case ev.MessageID != "":
+			err = q.Where("provider_message_id = ?", ev.MessageID).Order("id DESC").First(&row).Error
+		default:
+			err = gorm.ErrRecordNotFound
+		}
+		switch {
+		case err == nil:
+			outbox = &row
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		occurred := ev.OccurredAt
+		if occurred.IsZero() {
+			occurred = time.Now().UTC()
+		}
+		event := models.EmailEvent{
+			Provider:          ev.Provider,
+			ProviderEventID:   ev.EventID,
+			ProviderMessageID: ev.MessageID,
+			Event:             ev.Event,
+			Recipient:         strings.ToLower(strings.TrimSpace(ev.Recipient)),
+			Detail:            ev.Detail,
+			OccurredAt:        &occurred,
+		}
+		if outbox != nil {
+			event.OutboxID = &outbox.ID
+		}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&event)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		recorded = true
+
+		status := strings.ToUpper(ev.Event)
+		if outbox != nil {
+			updates := map[string]interface{}{"last_event_at": occurred}
+			if emailEventRank[status] > emailEventRank[outbox.DeliveryStatus] {
+				updates["delivery_status"] = status
+			}
+			if outbox.ProviderMessageID == "" && ev.MessageID != "" {
+				updates["provider_message_id"] = ev.MessageID
+			}
+			if err := tx.Model(&models.EmailOutbox{}).Where("id = ?", outbox.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+			if err := s.reflectOnBookingInfo(tx, outbox.Ref, status, ev.Detail); err != nil {
+				return err
+			}
+		}
+
+		reason := ""
+		switch {
+		case ev.Event == EmailEventComplained:
+			reason = models.EmailSuppressComplaint
+		case ev.HardBounce:
+			reason = models.EmailSuppressHardBounce
+		}
+		if reason != "" && event.Recipient != "" {
+			return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.EmailSuppression{
+				Email:    event.Recipient,
+				Reason:   reason,
+				Provider: ev.Provider,
+				Detail:   ev.Detail,
+				OutboxID: event.OutboxID,
+			}).Error
+		}
+		return nil
+	})
+	return recorded, err
+}
+
+// reflectOnBookingInfo อัปเดต BookingInfo.EmailStatus ของอีเมลลิงก์/เตือนเช็คอิน
+func (s *EmailDeliveryService) reflectOnBookingInfo(tx *gorm.DB, ref, status, detail string) error {
+	id, ok := bookingInfoIDFromRef(ref)
+	if !ok || emailEventRank[status] == 0 {
+		return nil
+	}
+	var bi models.BookingInfo
+	if err := tx.Select("id", "email_status").First(&bi, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if emailEventRank[status] <= emailEventRank[bi.EmailStatus] {
+		return nil
+	}
+	updates := map[string]interface{}{"email_status": status}
+	if status == "BOUNCED" || status == "DROPPED" || status == "COMPLAINED" {
+		msg := strings.ToLower(status)
+		if detail != "" {
+			msg += ": " + detail
+		}
+		updates["email_error"] = msg
+	}
+	return tx.Model(&models.BookingInfo{}).Where("id = ?", bi.ID).Updates(updates).Error
+}
+
+// RecordAll บันทึกหลาย event (SendGrid ส่งเป็น batch) — event ที่ผิดพลาดถูก log แล้วไปต่อ
+func (s *EmailDeliveryService) RecordAll(events []EmailDeliveryEvent) (int, error) {
+	recorded := 0
+	for _, ev := range events {
+		ok, err := s.Record(ev)
+		if err != nil {
+			log.Printf("email delivery webhook: %s %s: %v", ev.Provider, ev.EventID, err)
+			return recorded, err
+		}
+		if ok {
+			recorded++
+		}
+	}
+	return recorded, nil
+}
+
+// EventsForOutbox ประวัติ event ของอีเมลหนึ่งฉบับ
+func (s *EmailDeliveryService) EventsForOutbox(outboxID uint) ([]models.EmailEvent, error) {
+	var rows []models.EmailEvent
+	err := s.DB.Where("outbox_id = ?", outboxID).Order("occurred_at ASC, id ASC").Find(&rows).Error
+	return rows, err
+}
+
+// filterSuppressed แยกผู้รับที่อยู่ใน suppression list ออก — คืน (ส่งได้, ถูกตัด)
+func filterSuppressed(db *gorm.DB, to []string) ([]string, []string, error) {
+	if len(to) == 0 {
+		return to, nil, nil
+	}
+	lower := make([]string, 0, len(to))
+	for _, addr := range to {
+		lower = append(lower, strings.ToLower(strings.TrimSpace(addr)))
+	}
+	var blocked []string
+	if err := db.Model(&models.EmailSuppression{}).Where("email IN ?", lower).Pluck("email", &blocked).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(blocked) == 0 {
+		return to, nil, nil
+	}
+	isBlocked := make(map[string]bool, len(blocked))
+	for _, b := range blocked {
+		isBlocked[b] = true
+	}
+	var allowed, suppressed []string
+	for i, addr := range to {
+		if isBlocked[lower[i]] {
+			suppressed = append(suppressed, addr)
+		} else {
+			allowed = append(allowed, addr)
+		}
+	}
+	return allowed, suppressed, nil
+}
+
+// ListSuppressions รายการที่อยู่ที่ห้ามส่ง (q = ค้นหาบางส่วนของอีเมล)
+func (s *EmailDeliveryService) ListSuppressions(q string) ([]models.EmailSuppression, error) {
+	var rows []models.EmailSuppression
+	query := s.DB.Order("id DESC").Limit(500)
+	if q = strings.ToLower(strings.TrimSpace(q)); q != "" {
+		query = query.Where("email LIKE ?", "%"+q+"%")
+	}
+	err := query.Find(&rows).Error
+	return rows, err
+}
+
+// AddSuppression admin เพิ่มที่อยู่เอง (มีอยู่แล้ว = คืนแถวเดิม)
+func (s *EmailDeliveryService) AddSuppression(email, detail string, adminID uint) (models.EmailSuppression, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil {
+		return models.EmailSuppression{}, errors.New("invalid_email")
+	}
+	row := models.EmailSuppression{Email: strings.ToLower(addr.Address), Reason: models.EmailSuppressManual, Detail: strings.TrimSpace(detail)}
+	if adminID != 0 {
+		row.CreatedBy = &adminID
+	}
+	if err := s.DB.Where("email = ?", row.Email).FirstOrCreate(&row).Error; err != nil {
+		return row, err
+	}
+	return row, nil
+}
+
+// RemoveSuppression เอาที่อยู่ออกจาก suppression list (เช่นแขกแก้ไขกล่องอีเมลแล้ว)
+func (s *EmailDeliveryService) RemoveSuppression(id uint) error {
+	res := s.DB.Delete(&models.EmailSuppression{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("suppression_not_found")
+	}
+	return nil
+}
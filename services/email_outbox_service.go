@@ -0,0 +1,558 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"hotel-backend/models"
+	"hotel-backend/utils"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	outboxDir          = "outbox"
+	outboxBatchSize    = 20
+	outboxPollInterval = 30 * time.Second
+	outboxBaseBackoff  = time.Minute
+	outboxMaxBackoff   = 6 * time.Hour
+	// แถว SENDING ที่ค้างนานกว่านี้ (process ตายระหว่างส่ง) กลับเข้าคิว
+	outboxStuckAfter = 10 * time.Minute
+)
+
+// outboxAttachment: metadata ของไฟล์แนบใน EmailOutbox.Attachments
+type outboxAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Size        int    `json:"size"`
+	Key         string `json:"key"` // key ใน BlobStore (เข้ารหัส)
+}
+
+// emailCarriesSecret: อีเมลชนิดที่ body มี token/ลิงก์ลับของผู้รับ
+// ไม่คืน body ให้หน้า admin, ลบ body ทิ้งเมื่อส่งเสร็จ (หรือเลิก retry) และส่งซ้ำจาก outbox ไม่ได้ — ต้องออกลิงก์ใหม่จาก flow ของมันเอง
+func emailCarriesSecret(kind string) bool {
+	switch kind {
+	case "password_reset", utils.EmailKindAdminInvite, "identity_reupload":
+		return true
+	}
+	return strings.HasPrefix(kind, "privacy_") || strings.HasPrefix(kind, "checkin_")
+}
+
+// EmailAttachmentInfo: ไฟล์แนบสำหรับแสดงในหน้า admin (ไม่เปิดเผย key)
+type EmailAttachmentInfo struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Size        int    `json:"size"`
+}
+
+// EmailOutboxService: คิวอีเมล + worker (retry แบบ exponential backoff และ failover ระหว่าง provider)
+type EmailOutboxService struct {
+	DB    *gorm.DB
+	Blobs BlobStore
+	wake  chan struct{}
+}
+
+func NewEmailOutboxService(db *gorm.DB, blobs BlobStore) *EmailOutboxService {
+	return &EmailOutboxService{DB: db, Blobs: blobs, wake: make(chan struct{}, 1)}
+}
+
+// outboxBackoff: 1, 2, 4, 8 ... นาที ไม่เกิน 6 ชั่วโมง
+func outboxBackoff(attempt int) time.Duration {
+	d := outboxBaseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= outboxMaxBackoff {
+			return outboxMaxBackoff
+		}
+	}
+	return d
+}
+
+// Enqueue บันทึกอีเมลลงคิว (ใช้เป็น utils.EmailEnqueuer)
+func (s *EmailOutboxService) Enqueue(msg utils.OutboundEmail) error {
+	_, err := s.enqueue(msg, nil, nil)
+	return err
+}
+
+func (s *EmailOutboxService) enqueue(msg utils.OutboundEmail, resendOf, requestedBy *uint) (models.EmailOutbox, error) {
+	to := make([]string, 0, len(msg.To))
+	for _, addr := range msg.To {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	if len(to) == 0 {
+		return models.EmailOutbox{}, errors.New("recipient list is empty")
+	}
+	// ตัดผู้รับที่อยู่ใน suppression list (hard bounce / spam) — ไม่เหลือใครเลย = บันทึกเป็น SUPPRESSED แล้วคืน error
+	to, suppressed, err := filterSuppressed(s.DB, to)
+	if err != nil {
+		return models.EmailOutbox{}, err
+	}
+
+	var attachments []outboxAttachment
+	for _, a := range msg.Attachments {
+		key, err := saveGuestBlob(a.Content, outboxDir, filepath.Ext(a.Filename))
+		if err != nil {
+			return models.EmailOutbox{}, err
+		}
+		attachments = append(attachments, outboxAttachment{Filename: a.Filename, ContentType: a.ContentType, Size: len(a.Content), Key: key})
+	}
+	attachmentsJSON, err := json.Marshal(attachments)
+	if err != nil {
+		return models.EmailOutbox{}, err
+	}
+
+	subject := msg.Subject
+	if len(subject) > 500 {
+		subject = subject[:500]
+	}
+	now := time.Now().UTC()
+	toAddresses := to
+	if len(to) == 0 {
+		toAddresses = suppressed
+	}
+	row := models.EmailOutbox{
+		Kind:          msg.Kind,
+		Ref:           msg.Ref,
+		ToAddresses:   strings.Join(toAddresses, ","),
+		Subject:       subject,
+		HTMLBody:      msg.HTML,
+		TextBody:      msg.Text,
+		FromEmail:     msg.FromEmail,
+		FromName:      msg.FromName,
+		Attachments:   datatypes.JSON(attachmentsJSON),
+		Status:        models.EmailStatusQueued,
+		MaxAttempts:   6,
+		NextAttemptAt: &now,
+		ResendOf:      resendOf,
+		RequestedBy:   requestedBy,
+	}
+	if len(to) == 0 {
+		row.Status = models.EmailStatusSuppressed
+		row.NextAttemptAt = nil
+		row.LastError = "all recipients are on the suppression list"
+		if emailCarriesSecret(row.Kind) {
+			row.HTMLBody, row.TextBody = "", ""
+		}
+	}
+	if err := s.DB.Create(&row).Error; err != nil {
+		return row, err
+	}
+	if row.Status == models.EmailStatusSuppressed {
+		return row, fmt.Errorf("recipient_suppressed: %s", strings.Join(suppressed, ", "))
+	}
+	s.Wake()
+	return row, nil
+}
+
+// Wake ปลุก worker ให้ส่งทันทีไม่ต้องรอรอบถัดไป
+func (s *EmailOutboxService) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run: worker หลัก — ส่งรอบละ outboxBatchSize ทุก 30 วินาที หรือทันทีเมื่อมีอีเมลเข้าคิว
+func (s *EmailOutboxService) Run(ctx context.Context) {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	run := func() {
+		for {
+			processed, err := s.ProcessDue(ctx, time.Now())
+			if err != nil {
+				log.Printf("email outbox: %v", err)
+				return
+			}
+			if processed < outboxBatchSize || ctx.Err() != nil {
+				return
+			}
+		}
+	}
+
+	if n, err := s.PurgeSecretBodies(); err != nil {
+		log.Printf("email outbox: purge secret bodies: %v", err)
+	} else if n > 0 {
+		log.Printf("email outbox: cleared %d sent secret-bearing bodies", n)
+	}
+
+	run()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("email outbox worker stopped")
+			return
+		case <-ticker.C:
+			run()
+		case <-s.wake:
+			run()
+		}
+	}
+}
+
+// PurgeSecretBodies ลบ body ของอีเมลที่มีลิงก์/รหัสลับซึ่งจบแล้ว (แถวก่อนมีการลบตอนส่ง)
+func (s *EmailOutboxService) PurgeSecretBodies() (int64, error) {
+	var kinds []string
+	if err := s.DB.Model(&models.EmailOutbox{}).Distinct().Pluck("kind", &kinds).Error; err != nil {
+		return 0, err
+	}
+	var secret []string
+	for _, k := range kinds {
+		if emailCarriesSecret(k) {
+			secret = append(secret, k)
+		}
+	}
+	if len(secret) == 0 {
+		return 0, nil
+	}
+	res := s.DB.Model(&models.EmailOutbox{}).
+		Where("kind IN ? AND status IN ?", secret, []string{models.EmailStatusSent, models.EmailStatusFailed, models.EmailStatusSuppressed}).
+		Where("html_body <> '' OR text_body <> ''").
+		Updates(map[string]interface{}{"html_body": "", "text_body": ""})
+	return res.RowsAffected, res.Error
+}
+
+// ProcessDue ส่งอีเมลที่ถึงกำหนด — คืนจำนวนแถวที่หยิบมาทำ
+func (s *EmailOutboxService) ProcessDue(ctx context.Context, now time.Time) (int, error) {
+	now = now.UTC()
+	if err := s.DB.Model(&models.EmailOutbox{}).
+		Where("status = ? AND updated_at < ?", models.EmailStatusSending, now.Add(-outboxStuckAfter)).
+		Updates(map[string]interface{}{"status": models.EmailStatusQueued, "next_attempt_at": now}).Error; err != nil {
+		return 0, err
+	}
+
+	var due []models.EmailOutbox
+	if err := s.DB.Where("status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)", models.EmailStatusQueued, now).
+		Order("next_attempt_at ASC, id ASC").Limit(outboxBatchSize).Find(&due).Error; err != nil {
+		return 0, err
+	}
+
+	for _, row := range due {
+		if ctx.Err() != nil {
+			return len(due), ctx.Err()
+		}
+		// claim แถว — กัน worker หลายตัวส่งซ้ำ
+		res := s.DB.Model(&models.EmailOutbox{}).
+			Where("id = ? AND status = ?", row.ID, models.EmailStatusQueued).
+			Updates(map[string]interface{}{"status": models.EmailStatusSending, "updated_at": now})
+		if res.Error != nil {
+			return len(due), res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+		s.deliver(ctx, row, now)
+	}
+	return len(due), nil
+}
+
+// deliver ส่งหนึ่งฉบับผ่าน provider ทุกตัวตามลำดับ แล้วบันทึกผล (SENT / QUEUED รอ backoff / FAILED)
+func (s *EmailOutboxService) deliver(ctx context.Context, row models.EmailOutbox, now time.Time) {
+	msg, err := s.message(ctx, row)
+	provider, messageID := "", ""
+	var suppressed []string
+	if err == nil {
+		// suppression list อาจเพิ่มระหว่างรอคิว
+		msg.To, suppressed, err = filterSuppressed(s.DB, msg.To)
+	}
+	if err == nil && len(msg.To) == 0 {
+		updates := map[string]interface{}{
+			"status":          models.EmailStatusSuppressed,
+			"last_error":      "all recipients are on the suppression list: " + strings.Join(suppressed, ", "),
+			"next_attempt_at": nil,
+		}
+		if emailCarriesSecret(row.Kind) {
+			updates["html_body"] = ""
+			updates["text_body"] = ""
+		}
+		if err := s.DB.Model(&models.EmailOutbox{}).Where("id = ?", row.ID).Updates(updates).Error; err != nil {
+			log.Printf("email outbox: update #%d: %v", row.ID, err)
+		}
+		return
+	}
+	if err == nil {
+		provider, messageID, err = utils.SendEmailWithFailover(msg)
+	}
+
+	attempts := row.Attempts + 1
+	updates := map[string]interface{}{"attempts": attempts}
+	// ส่งเสร็จ/เลิก retry แล้วไม่ต้องเก็บลิงก์ลับไว้อีก
+	final := err == nil || attempts >= row.MaxAttempts
+	if final && emailCarriesSecret(row.Kind) {
+		updates["html_body"] = ""
+		updates["text_body"] = ""
+	}
+	switch {
+	case err == nil:
+		updates["status"] = models.EmailStatusSent
+		updates["provider"] = provider
+		updates["provider_message_id"] = messageID
+		updates["sent_at"] = time.Now().UTC()
+		updates["last_error"] = ""
+		updates["next_attempt_at"] = nil
+	case attempts >= row.MaxAttempts:
+		updates["status"] = models.EmailStatusFailed
+		updates["last_error"] = err.Error()
+		updates["next_attempt_at"] = nil
+		log.Printf("email outbox: #%d (%s) failed permanently after %d attempts: %v", row.ID, row.Kind, attempts, err)
+	default:
+		next := now.Add(outboxBackoff(attempts))
+		updates["status"] = models.EmailStatusQueued
+		updates["last_error"] = err.Error()
+		updates["next_attempt_at"] = next
+	}
+	if err := s.DB.Model(&models.EmailOutbox{}).Where("id = ?", row.ID).Updates(updates).Error; err != nil {
+		log.Printf("email outbox: update #%d: %v", row.ID, err)
+	}
+	if status, _ := updates["status"].(string); status != models.EmailStatusQueued {
+		lastError, _ := updates["last_error"].(string)
+		s.reflectOnBookingInfo(row, status, lastError)
+	}
+}
+
+// checkinEmailStatus ค่า BookingInfo.EmailStatus หลังเรียก SendXxxEmail สำเร็จ
+// มี outbox = QUEUED (worker อัปเดตเป็น SENT / FAILED ตอนส่งจริง) ส่งตรง = SENT
+func checkinEmailStatus() string {
+	if utils.EmailQueueEnabled() {
+		return models.EmailStatusQueued
+	}
+	return models.EmailStatusSent
+}
+
+// bookingInfoIDFromRef แยก id จาก Ref แบบ "booking_info:12" (utils.BookingInfoEmailRef)
+func bookingInfoIDFromRef(ref string) (uint, bool) {
+	kind, rawID, ok := strings.Cut(ref, ":")
+	if !ok || kind != utils.EmailRefBookingInfo {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// reflectOnBookingInfo ส่งผลการส่งจริงกลับไปที่ BookingInfo.EmailStatus ของอีเมลลิงก์/เตือนเช็คอิน
+// SENT ไม่ทับสถานะจาก delivery webhook (DELIVERED / OPENED ...), FAILED ทับเฉพาะแถวที่ยังรอคิว
+func (s *EmailOutboxService) reflectOnBookingInfo(row models.EmailOutbox, status, lastError string) {
+	id, ok := bookingInfoIDFromRef(row.Ref)
+	if !ok {
+		return
+	}
+	q := s.DB.Model(&models.BookingInfo{}).Where("id = ?", id)
+	var err error
+	switch status {
+	case models.EmailStatusSent:
+		err = q.Where("email_status IN ?", []string{"PENDING", models.EmailStatusQueued, models.EmailStatusFailed}).
+			Updates(map[string]interface{}{"email_status": models.EmailStatusSent, "email_error": ""}).Error
+	case models.EmailStatusFailed, models.EmailStatusSuppressed:
+		err = q.Where("email_status = ?", models.EmailStatusQueued).
+			Updates(map[string]interface{}{"email_status": models.EmailStatusFailed, "email_error": lastError}).Error
+	default:
+		return
+	}
+	if err != nil {
+		log.Printf("email outbox: #%d booking_info %d status: %v", row.ID, id, err)
+	}
+}
+
+// message สร้าง utils.OutboundEmail จากแถว (อ่านไฟล์แนบจาก BlobStore)
+func (s *EmailOutboxService) message(ctx context.Context, row models.EmailOutbox) (utils.OutboundEmail, error) {
+	msg := utils.OutboundEmail{
+		OutboxID:  row.ID,
+		Ref:       row.Ref,
+		Kind:      row.Kind,
+		To:        strings.Split(row.ToAddresses, ","),
+		Subject:   row.Subject,
+		HTML:      row.HTMLBody,
+		Text:      row.TextBody,
+		FromEmail: row.FromEmail,
+		FromName:  row.FromName,
+	}
+	attachments, err := outboxAttachments(row)
+	if err != nil {
+		return msg, err
+	}
+	for _, a := range attachments {
+		data, err := s.readAttachment(ctx, a.Key)
+		if err != nil {
+			return msg, err
+		}
+		msg.Attachments = append(msg.Attachments, utils.EmailAttachment{Filename: a.Filename, ContentType: a.ContentType, Content: data})
+	}
+	return msg, nil
+}
+
+func outboxAttachments(row models.EmailOutbox) ([]outboxAttachment, error) {
+	var attachments []outboxAttachment
+	if len(row.Attachments) == 0 {
+		return nil, nil
+	}
+	if err := json.Unmarshal(row.Attachments, &attachments); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// EmailOutboxAttachments รายการไฟล์แนบของแถว
+func EmailOutboxAttachments(row models.EmailOutbox) ([]EmailAttachmentInfo, error) {
+	attachments, err := outboxAttachments(row)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]EmailAttachmentInfo, 0, len(attachments))
+	for _, a := range attachments {
+		out = append(out, EmailAttachmentInfo{Filename: a.Filename, ContentType: a.ContentType, Size: a.Size})
+	}
+	return out, nil
+}
+
+func (s *EmailOutboxService) readAttachment(ctx context.Context, stored string) ([]byte, error) {
+	plain, err := DecryptPIIString(stored)
+	if err != nil {
+		return nil, err
+	}
+	key, err := normalizeBlobKey(plain)
+	if err != nil {
+		return nil, err
+	}
+	body, _, err := s.Blobs.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	return DecryptPII(raw)
+}
+
+// EmailOutboxFilter: ตัวกรองหน้า admin
+type EmailOutboxFilter struct {
+	Status string
+	Kind   string
+	To     string
+	Page   int
+	Limit  int
+}
+
+// List รายการอีเมลในคิว (ไม่รวม body) ใหม่สุดก่อน
+func (s *EmailOutboxService) List(f EmailOutboxFilter) ([]models.EmailOutbox, int64, error) {
+	if f.Limit <= 0 || f.Limit > 200 {
+		f.Limit = 50
+	}
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	q := s.DB.Model(&models.EmailOutbox{})
+	if f.Status != "" {
+		q = q.Where("status = ?", strings.ToUpper(f.Status))
+	}
+	if f.Kind != "" {
+		q = q.Where("kind = ?", f.Kind)
+	}
+	if f.To != "" {
+		q = q.Where("to_addresses LIKE ?", "%"+f.To+"%")
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []models.EmailOutbox
+	err := q.Omit("html_body", "text_body").Order("id DESC").
+		Limit(f.Limit).Offset((f.Page - 1) * f.Limit).Find(&rows).Error
+	return rows, total, err
+}
+
+func (s *EmailOutboxService) find(id uint) (models.EmailOutbox, error) {
+	var row models.EmailOutbox
+	err := s.DB.First(&row, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return row, errors.New("email_not_found")
+	}
+	return row, err
+}
+
+// Get อีเมลหนึ่งฉบับพร้อม body (ชนิดที่มีลิงก์/รหัสลับไม่คืน body — BodyRedacted)
+func (s *EmailOutboxService) Get(id uint) (models.EmailOutbox, error) {
+	row, err := s.find(id)
+	if err == nil && emailCarriesSecret(row.Kind) {
+		row.HTMLBody, row.TextBody = "", ""
+		row.BodyRedacted = true
+	}
+	return row, err
+}
+
+// Resend เข้าคิวใหม่เป็นอีกแถว (อ้างอิงต้นฉบับใน ResendOf) — แถวเดิมคงไว้เป็นประวัติ
+// อีเมลที่มีลิงก์/รหัสลับส่งซ้ำไม่ได้ (error "email_contains_secret")
+// ยกเว้นลิงก์/เตือนเช็คอินของ BookingInfo ที่ยังใช้ได้ — สร้างอีเมลลิงก์ฉบับใหม่แทน (reissueCheckinLink)
+func (s *EmailOutboxService) Resend(ctx context.Context, id, adminID uint) (models.EmailOutbox, error) {
+	orig, err := s.find(id)
+	if err != nil {
+		return orig, err
+	}
+	if orig.Status == models.EmailStatusQueued || orig.Status == models.EmailStatusSending {
+		return orig, errors.New("email_still_queued")
+	}
+	var requestedBy *uint
+	if adminID != 0 {
+		requestedBy = &adminID
+	}
+	if orig.Kind == utils.EmailKindCheckinLink || orig.Kind == utils.EmailKindCheckinReminder {
+		if biID, ok := bookingInfoIDFromRef(orig.Ref); ok {
+			return s.reissueCheckinLink(orig, biID, requestedBy)
+		}
+	}
+	if emailCarriesSecret(orig.Kind) {
+		return orig, errors.New("email_contains_secret")
+	}
+	msg, err := s.message(ctx, orig)
+	if err != nil {
+		return orig, err
+	}
+	return s.enqueue(msg, &orig.ID, requestedBy)
+}
+
+// reissueCheckinLink ส่งลิงก์เช็คอินของ BookingInfo อีกครั้งผ่าน SendCheckinLinkEmail (render body ใหม่
+// เพราะ body เดิมถูกลบแล้ว) แล้วผูกแถวใหม่กับต้นฉบับ — ลิงก์ที่หมดอายุ/เช็คอินแล้วต้องออกใหม่จากหน้าการจอง
+func (s *EmailOutboxService) reissueCheckinLink(orig models.EmailOutbox, bookingInfoID uint, requestedBy *uint) (models.EmailOutbox, error) {
+	var bi models.BookingInfo
+	if err := s.DB.First(&bi, bookingInfoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return orig, errors.New("checkin_link_expired")
+		}
+		return orig, err
+	}
+	if bi.Status != "INITIATED" || (bi.ExpiresAt != nil && !time.Now().Before(*bi.ExpiresAt)) {
+		return orig, errors.New("checkin_link_expired")
+	}
+	if err := NewBookingService(s.DB).SendCheckinLinkEmail(bi); err != nil {
+		return orig, err
+	}
+
+	var row models.EmailOutbox
+	if err := s.DB.Where("ref = ? AND kind = ? AND id > ?", orig.Ref, utils.EmailKindCheckinLink, orig.ID).
+		Order("id DESC").First(&row).Error; err != nil {
+		return orig, err
+	}
+	if err := s.DB.Model(&row).Updates(map[string]interface{}{"resend_of": orig.ID, "requested_by": requestedBy}).Error; err != nil {
+		return orig, err
+	}
+	row.ResendOf = &orig.ID
+	row.RequestedBy = requestedBy
+	row.HTMLBody, row.TextBody = "", ""
+	row.BodyRedacted = true
+	return row, nil
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"hotel-backend/models"
+	"hotel-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// EmailTemplateService: template อีเมลที่ admin แก้ไขได้ (ทับ template ตั้งต้นใน utils/email_templates)
+// และ branding จาก hotel settings — ใช้เป็น utils.EmailTemplateStore
+type EmailTemplateService struct {
+	DB *gorm.DB
+}
+
+func NewEmailTemplateService(db *gorm.DB) *EmailTemplateService {
+	return &EmailTemplateService{DB: db}
+}
+
+// EmailTemplate (utils.EmailTemplateStore) template ที่ admin บันทึกไว้ของ kind/ภาษานั้น
+func (s *EmailTemplateService) EmailTemplate(kind, locale string) (utils.EmailTemplateSource, bool) {
+	var row models.EmailTemplate
+	if err := s.DB.Where("kind = ? AND locale = ?", kind, locale).First(&row).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("EmailTemplate %s/%s: %v (using built-in template)", kind, locale, err)
+		}
+		return utils.EmailTemplateSource{}, false
+	}
+	return utils.EmailTemplateSource{Subject: row.Subject, HTML: row.HTMLBody, Text: row.TextBody}, true
+}
+
+// EmailBranding (utils.EmailTemplateStore) ชื่อ/โลโก้/ติดต่อ + ภาษาตั้งต้นจาก hotel settings
+func (s *EmailTemplateService) EmailBranding() (utils.EmailBranding, string) {
+	var hotel models.HotelSetting
+	if err := s.DB.Order("id ASC").First(&hotel).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("EmailBranding: %v", err)
+		}
+		return utils.EmailBranding{}, ""
+	}
+	return utils.EmailBranding{
+		Name:    strings.TrimSpace(hotel.Name),
+		LogoURL: emailLogoURL(hotel.Logo),
+		Phone:   strings.TrimSpace(hotel.Phone),
+		Address: strings.TrimSpace(hotel.Address),
+		Email:   strings.TrimSpace(hotel.Email),
+		Website: strings.TrimSpace(hotel.Website),
+	}, hotel.EmailLocale
+}
+
+// emailLogoURL URL โลโก้ที่เปิดจากอีเมลได้: โลโก้ที่อัปโหลด (branding/...) ต้องตั้ง PUBLIC_API_URL, URL ภายนอกใช้ตามเดิม
+func emailLogoURL(logo string) string {
+	logo = strings.TrimSpace(logo)
+	switch {
+	case strings.HasPrefix(logo, "http://"), strings.HasPrefix(logo, "https://"):
+		return logo
+	case strings.HasPrefix(logo, "branding/"):
+		base := strings.TrimRight(utils.EnvOrDefault("PUBLIC_API_URL", ""), "/")
+		if base == "" {
+			return ""
+		}
+		return base + "/api/settings/hotel/logo"
+	}
+	return ""
+}
+
+// EmailTemplateView: template หนึ่ง kind/ภาษาสำหรับหน้า admin (Custom = admin แก้ไขแล้ว)
+type EmailTemplateView struct {
+	Kind        string     `json:"kind"`
+	Locale      string     `json:"locale"`
+	Description string     `json:"description"`
+	Variables   []string   `json:"variables"`
+	Custom      bool       `json:"custom"`
+	Subject     string     `json:"subject"`
+	HTML        string     `json:"html,omitempty"`
+	Text        string     `json:"text,omitempty"`
+	UpdatedBy   *uint      `json:"updatedBy,omitempty"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
+}
+
+func emailTemplateKind(kind string) (utils.EmailTemplateKind, error) {
+	for _, k := range utils.EmailTemplateKinds() {
+		if k.Kind == kind {
+			return k, nil
+		}
+	}
+	return utils.EmailTemplateKind{}, errors.New("template_kind_unknown")
+}
+
+func emailTemplateLocale(locale string) (string, error) {
+	l := utils.NormalizeEmailLocale(locale)
+	if l == "" || l != strings.ToLower(strings.TrimSpace(locale)) {
+		return "", errors.New("locale_unsupported")
+	}
+	return l, nil
+}
+
+// List ทุก kind x ทุกภาษา (ไม่รวม body)
+func (s *EmailTemplateService) List() ([]EmailTemplateView, error) {
+	var rows []models.EmailTemplate
+	if err := s.DB.Select("id", "kind", "locale", "subject", "updated_by", "updated_at").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	custom := map[string]models.EmailTemplate{}
+	for _, r := range rows {
+		custom[r.Kind+"/"+r.Locale] = r
+	}
+
+	var out []EmailTemplateView
+	for _, k := range utils.EmailTemplateKinds() {
+		for _, locale := range utils.EmailLocales {
+			v := EmailTemplateView{Kind: k.Kind, Locale: locale, Description: k.Description, Variables: k.Variables}
+			if r, ok := custom[k.Kind+"/"+locale]; ok {
+				updatedAt := r.UpdatedAt
+				v.Custom, v.Subject, v.UpdatedBy, v.UpdatedAt = true, r.Subject, r.UpdatedBy, &updatedAt
+			} else if src, err := utils.BuiltinEmailTemplate(k.Kind, locale); err == nil {
+				v.Subject = src.Subject
+			}
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+// Get template ที่ใช้จริงของ kind/ภาษา (ของ admin หรือตั้งต้น)
+func (s *EmailTemplateService) Get(kind, locale string) (EmailTemplateView, error) {
+	k, err := emailTemplateKind(kind)
+	if err != nil {
+		return EmailTemplateView{}, err
+	}
+	if locale, err = emailTemplateLocale(locale); err != nil {
+		return EmailTemplateView{}, err
+	}
+	v := EmailTemplateView{Kind: k.Kind, Locale: locale, Description: k.Description, Variables: k.Variables}
+
+	var row models.EmailTemplate
+	err = s.DB.Where("kind = ? AND locale = ?", kind, locale).First(&row).Error
+	switch {
+	case err == nil:
+		updatedAt := row.UpdatedAt
+		v.Custom, v.UpdatedBy, v.UpdatedAt = true, row.UpdatedBy, &updatedAt
+		v.Subject, v.HTML, v.Text = row.Subject, row.HTMLBody, row.TextBody
+		return v, nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return v, err
+	}
+	src, err := utils.BuiltinEmailTemplate(kind, locale)
+	if err != nil {
+		return v, err
+	}
+	v.Subject, v.HTML, v.Text = src.Subject, src.HTML, src.Text
+	return v, nil
+}
+
+// Save บันทึก template ของ admin — ต้อง render กับข้อมูลตัวอย่างได้ก่อน (กันอีเมลจริงส่งไม่ออก)
+func (s *EmailTemplateService) Save(kind, locale string, src utils.EmailTemplateSource, adminID uint) (EmailTemplateView, error) {
+	if _, err := emailTemplateKind(kind); err != nil {
+		return EmailTemplateView{}, err
+	}
+	locale, err := emailTemplateLocale(locale)
+	if err != nil {
+		return EmailTemplateView{}, err
+	}
+	src.Subject = strings.TrimSpace(src.Subject)
+	if src.Subject == "" || strings.TrimSpace(src.HTML) == "" || strings.TrimSpace(src.Text) == "" {
+		return EmailTemplateView{}, errors.New("template_fields_required")
+	}
+	if len(src.Subject) > 500 {
+		return EmailTemplateView{}, errors.New("template_invalid: subject too long")
+	}
+	brand, _ := utils.CurrentEmailBranding()
+	if _, err := utils.RenderEmailTemplate(src, utils.EmailTemplateSample(kind), brand); err != nil {
+		return EmailTemplateView{}, err
+	}
+
+	var updatedBy *uint
+	if adminID != 0 {
+		updatedBy = &adminID
+	}
+	var row models.EmailTemplate
+	err = s.DB.Where("kind = ? AND locale = ?", kind, locale).First(&row).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return EmailTemplateView{}, err
+	}
+	row.Kind, row.Locale = kind, locale
+	row.Subject, row.HTMLBody, row.TextBody = src.Subject, src.HTML, src.Text
+	row.UpdatedBy = updatedBy
+	if err := s.DB.Save(&row).Error; err != nil {
+		return EmailTemplateView{}, err
+	}
+	return s.Get(kind, locale)
+}
+
+// Reset ลบ template ของ admin — กลับไปใช้ template ตั้งต้น
+func (s *EmailTemplateService) Reset(kind, locale string) (EmailTemplateView, error) {
+	if _, err := emailTemplateKind(kind); err != nil {
+		return EmailTemplateView{}, err
+	}
+	locale, err := emailTemplateLocale(locale)
+	if err != nil {
+		return EmailTemplateView{}, err
+	}
+	if err := s.DB.Where("kind = ? AND locale = ?", kind, locale).Delete(&models.EmailTemplate{}).Error; err != nil {
+		return EmailTemplateView{}, err
+	}
+	return s.Get(kind, locale)
+}
+
+// Preview render template (ที่ส่งมา หรือที่ใช้อยู่) กับข้อมูลตัวอย่าง
+// bookingID != 0 = ใช้ชื่อแขก/ห้อง/วันที่ของ booking นั้นแทนข้อมูลตัวอย่าง (ลิงก์และรหัสยังเป็นตัวอย่าง)
+func (s *EmailTemplateService) Preview(kind, locale string, src *utils.EmailTemplateSource, bookingID uint) (utils.RenderedEmail, error) {
+	if _, err := emailTemplateKind(kind); err != nil {
+		return utils.RenderedEmail{}, err
+	}
+	locale, err := emailTemplateLocale(locale)
+	if err != nil {
+		return utils.RenderedEmail{}, err
+	}
+
+	data := utils.EmailTemplateSample(kind)
+	if bookingID != 0 && (kind == utils.EmailKindCheckinLink || kind == utils.EmailKindCheckinReminder) {
+		var booking models.Booking
+		if err := s.DB.Preload("Rooms.Room.RoomType").Preload("Room.RoomType").Preload("Customer").First(&booking, bookingID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return utils.RenderedEmail{}, errors.New("booking_not_found")
+			}
+			return utils.RenderedEmail{}, err
+		}
+		arrival, departure := bookingStay(booking)
+		data["GuestName"] = booking.Customer.FullName
+		data["BookingRef"] = booking.ReferenceCode
+		data["CheckInDate"] = receiptDate(arrival, nil)
+		if kind == utils.EmailKindCheckinLink {
+			data["Rooms"] = bookingRoomsForEmail(booking)
+			data["CheckOutDate"] = receiptDate(departure, nil)
+		}
+	}
+
+	if src == nil {
+		current, _, err := utils.ResolveEmailTemplate(kind, locale)
+		if err != nil {
+			return utils.RenderedEmail{}, err
+		}
+		src = &current
+	}
+	brand, _ := utils.CurrentEmailBranding()
+	out, err := utils.RenderEmailTemplate(*src, data, brand)
+	out.Locale = locale
+	return out, err
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Domain events: service publish หลัง transaction commit แล้ว handler (อีเมล, webhook, audit, housekeeping ...)
+// subscribe แยกกันเอง แทนการเรียกต่อกันใน service method
+//
+// handler ทำงานแบบ synchronous ตามลำดับที่ subscribe — งานหนัก (PDF, SMS) ให้ handler แยก goroutine เอง
+// error / panic ของ handler แค่ log ไม่ย้อนไปกระทบผู้ publish (ข้อมูล commit ไปแล้ว)
+
+// Event: domain event หนึ่งชนิด (EventName ใช้เป็น key ของ subscriber)
+type Event interface {
+	EventName() string
+}
+
+const (
+	EventBookingCreated          = "BookingCreated"
+	EventBookingDeleted          = "BookingDeleted"
+	EventCheckInCompleted        = "CheckInCompleted"
+	EventCheckedOut              = "CheckedOut"
+	EventGuestAdded              = "GuestAdded"
+	EventConsentAccepted         = "ConsentAccepted"
+	EventRoomStatusChanged       = "RoomStatusChanged"
+	EventHousekeepingTaskChanged = "HousekeepingTaskChanged"
+	EventWorkOrderChanged        = "WorkOrderChanged"
+)
+
+// BookingCreated: สร้าง booking ใหม่ (SendCheckinLink = ผู้สร้างขอให้ส่งลิงก์เช็คอินทันที)
+type BookingCreated struct {
+	BookingID       uint      `json:"bookingId"`
+	CustomerID      uint      `json:"customerId"`
+	RoomIDs         []uint    `json:"roomIds"`
+	SendCheckinLink bool      `json:"sendCheckinLink"`
+	At              time.Time `json:"at"`
+}
+
+// BookingDeleted: ลบ booking (soft delete — ยังอ่านแบบ Unscoped ได้)
+type BookingDeleted struct {
+	BookingID     uint      `json:"bookingId"`
+	ReferenceCode string    `json:"referenceCode"`
+	At            time.Time `json:"at"`
+}
+
+// CheckInCompleted: เช็คอินเสร็จสมบูรณ์ (ออนไลน์ทันที หรือหลังพนักงานอนุมัติเอกสารครบ — Reviewed)
+type CheckInCompleted struct {
+	BookingID uint      `json:"bookingId"`
+	RoomIDs   []uint    `json:"roomIds"`
+	Reviewed  bool      `json:"reviewed"`
+	At        time.Time `json:"at"`
+}
+
+// CheckedOut: เช็คเอาท์ (Auto = job เช็คเอาท์อัตโนมัติ)
+type CheckedOut struct {
+	BookingID uint      `json:"bookingId"`
+	RoomIDs   []uint    `json:"roomIds"`
+	Auto      bool      `json:"auto"`
+	At        time.Time `json:"at"`
+}
+
+// GuestAdded: เพิ่มแขกเข้า booking (ไม่มีข้อมูลส่วนบุคคลใน event)
+type GuestAdded struct {
+	GuestID      uint      `json:"guestId"`
+	BookingID    *uint     `json:"bookingId"`
+	IsMainGuest  bool      `json:"isMainGuest"`
+	ReviewStatus string    `json:"reviewStatus"`
+	At           time.Time `json:"at"`
+}
+
+// ที่มาของ ConsentAccepted
+const (
+	ConsentSourceCheckin = "checkin" // ยอมรับตอนยืนยันเช็คอิน (ออกใบยืนยัน consent)
+	ConsentSourceAPI     = "api"     // POST /api/consents/accept, /api/consent-logs
+)
+
+// ConsentAccepted: บันทึกการยอมรับ consent (แถวใน hash chain แล้ว)
+type ConsentAccepted struct {
+	BookingID     *uint     `json:"bookingId"`
+	GuestIDs      []uint    `json:"guestIds"`
+	ConsentIDs    []uint    `json:"consentIds"`
+	ConsentLogIDs []uint    `json:"consentLogIds"`
+	Source        string    `json:"source"`
+	At            time.Time `json:"at"`
+}
+
+// RoomStatusChanged: สถานะห้องเปลี่ยน (ChangedBy = admin ที่แก้ / nil = ระบบ เช่นหลังเช็คเอาท์)
+type RoomStatusChanged struct {
+	RoomIDs   []uint    `json:"roomIds"`
+	Status    string    `json:"status"`
+	Occupancy string    `json:"occupancy"`
+	Condition string    `json:"condition"`
+	Source    string    `json:"source"`
+	ChangedBy *uint     `json:"changedBy,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// HousekeepingTaskChanged: งานทำความสะอาดถูกสร้าง/มอบหมาย/เปลี่ยนสถานะ
+type HousekeepingTaskChanged struct {
+	TaskID     uint      `json:"taskId"`
+	RoomID     uint      `json:"roomId"`
+	Kind       string    `json:"kind"`
+	Status     string    `json:"status"`
+	AssignedTo *uint     `json:"assignedTo"`
+	At         time.Time `json:"at"`
+}
+
+// WorkOrderChanged: งานซ่อมถูกสร้าง/มอบหมาย/เปลี่ยนสถานะ
+type WorkOrderChanged struct {
+	WorkOrderID uint      `json:"workOrderId"`
+	RoomID      uint      `json:"roomId"`
+	Category    string    `json:"category"`
+	Priority    string    `json:"priority"`
+	Status      string    `json:"status"`
+	AssignedTo  *uint     `json:"assignedTo"`
+	RoomBlockID *uint     `json:"roomBlockId"`
+	At          time.Time `json:"at"`
+}
+
+func (BookingCreated) EventName() string          { return EventBookingCreated }
+func (BookingDeleted) EventName() string          { return EventBookingDeleted }
+func (CheckInCompleted) EventName() string        { return EventCheckInCompleted }
+func (CheckedOut) EventName() string              { return EventCheckedOut }
+func (GuestAdded) EventName() string              { return EventGuestAdded }
+func (ConsentAccepted) EventName() string         { return EventConsentAccepted }
+func (RoomStatusChanged) EventName() string       { return EventRoomStatusChanged }
+func (HousekeepingTaskChanged) EventName() string { return EventHousekeepingTaskChanged }
+func (WorkOrderChanged) EventName() string        { return EventWorkOrderChanged }
+
+// EventHandler รับ event ที่ subscribe ไว้
+type EventHandler func(ctx context.Context, e Event) error
+
+type eventSubscriber struct {
+	name string
+	fn   EventHandler
+}
+
+// EventBus: in-process pub/sub
+type EventBus struct {
+	mu   sync.RWMutex
+	subs map[string][]eventSubscriber
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{subs: map[string][]eventSubscriber{}}
+}
+
+// Subscribe ลงทะเบียน handler (name ใช้ใน log) — eventName "*" = ทุก event
+func (b *EventBus) Subscribe(eventName, name string, fn EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[eventName] = append(b.subs[eventName], eventSubscriber{name: name, fn: fn})
+}
+
+// Publish ส่ง event ให้ handler ทุกตัว — เรียกหลัง commit เท่านั้น
+func (b *EventBus) Publish(ctx context.Context, events ...Event) {
+	for _, e := range events {
+		b.mu.RLock()
+		subs := append(append([]eventSubscriber(nil), b.subs[e.EventName()]...), b.subs["*"]...)
+		b.mu.RUnlock()
+		for _, sub := range subs {
+			if err := runEventHandler(ctx, sub, e); err != nil {
+				log.Printf("event %s: handler %s: %v", e.EventName(), sub.name, err)
+			}
+		}
+	}
+}
+
+func runEventHandler(ctx context.Context, sub eventSubscriber, e Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return sub.fn(ctx, e)
+}
+
+var defaultEventBus = NewEventBus()
+
+// DefaultEventBus bus ของทั้ง process (handler ลงทะเบียนตอนเริ่ม server — RegisterEventHandlers)
+func DefaultEventBus() *EventBus {
+	return defaultEventBus
+}
+
+// PublishEvents publish บน DefaultEventBus (service เก็บ event ระหว่าง transaction แล้วเรียกหลัง commit)
+func PublishEvents(events ...Event) {
+	if len(events) == 0 {
+		return
+	}
+	defaultEventBus.Publish(context.Background(), events...)
+}
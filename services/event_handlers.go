@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"hotel-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// RegisterEventHandlers ลงทะเบียน handler ของ domain event ทั้งหมด (เรียกครั้งเดียวตอนเริ่ม server)
+// แต่ละ handler ทำงานของตัวเอง — handler หนึ่งล้มไม่กระทบ handler อื่น
+func RegisterEventHandlers(bus *EventBus, db *gorm.DB) {
+	bus.Subscribe("*", "audit", auditEventHandler(db))
+
+	// webhook delivery เข้าคิวใน transaction ของงานแล้ว (EnqueueWebhooks) — หลัง commit แค่ปลุก worker
+	for _, name := range []string{EventBookingCreated, EventBookingDeleted, EventCheckInCompleted, EventCheckedOut, EventGuestAdded} {
+		bus.Subscribe(name, "webhooks", func(ctx context.Context, e Event) error {
+			wakeWebhookWorker()
+			return nil
+		})
+	}
+
+	// ส่งลิงก์เช็คอินทันทีเมื่อผู้สร้าง booking ขอ (sendEmail)
+	bus.Subscribe(EventBookingCreated, "checkin-link", func(ctx context.Context, e Event) error {
+		ev := e.(BookingCreated)
+		if !ev.SendCheckinLink {
+			return nil
+		}
+		_, err := NewBookingService(db).InitiateCheckInProcess(ev.BookingID)
+		return err
+	})
+
+	// ใบยืนยัน consent (PDF) หลังเช็คอินออนไลน์
+	bus.Subscribe(EventConsentAccepted, "consent-receipt", func(ctx context.Context, e Event) error {
+		ev := e.(ConsentAccepted)
+		if ev.Source == ConsentSourceCheckin && ev.BookingID != nil {
+			IssueConsentReceiptAsync(db, *ev.BookingID)
+		}
+		return nil
+	})
+
+	// เช็คอินเสร็จ — ส่งรหัสเข้าห้องทาง SMS / LINE
+	bus.Subscribe(EventCheckInCompleted, "room-access", func(ctx context.Context, e Event) error {
+		NotifyRoomAccess(db, e.(CheckInCompleted).BookingID)
+		return nil
+	})
+
+	// เช็คอินเสร็จ — ห้องเป็น occupied
+	bus.Subscribe(EventCheckInCompleted, "room-status", func(ctx context.Context, e Event) error {
+		ev := e.(CheckInCompleted)
+		var events []Event
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			events, err = applyRoomStatuses(tx, ev.RoomIDs, RoomStatusChange{
+				Occupancy: strPtr(models.RoomOccupied),
+				Source:    RoomSourceCheckin,
+				Reason:    fmt.Sprintf("booking #%d checked in", ev.BookingID),
+			}, ev.At)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		bus.Publish(ctx, events...)
+		return nil
+	})
+
+	// เช็คเอาท์แล้วห้องเป็น vacant + dirty และมีงาน housekeeping (ห้องกลับเป็น Available หลังตรวจผ่าน)
+	bus.Subscribe(EventCheckedOut, "housekeeping", func(ctx context.Context, e Event) error {
+		ev := e.(CheckedOut)
+		events, err := NewHousekeepingService(db).OnCheckedOut(ev.BookingID, ev.RoomIDs, ev.At)
+		if err != nil {
+			return err
+		}
+		bus.Publish(ctx, events...)
+		return nil
+	})
+
+	// dashboard แบบ real-time (SSE)
+	bus.Subscribe("*", "live-stream", liveEventHandler(DefaultLiveHub()))
+}
+
+// auditEventHandler บันทึกทุก event ลง audit_events
+func auditEventHandler(db *gorm.DB) EventHandler {
+	return func(ctx context.Context, e Event) error {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		row := models.AuditEvent{Event: e.EventName(), Data: data}
+		switch ev := e.(type) {
+		case BookingCreated:
+			row.BookingID = &ev.BookingID
+		case BookingDeleted:
+			row.BookingID = &ev.BookingID
+		case CheckInCompleted:
+			row.BookingID = &ev.BookingID
+		case CheckedOut:
+			row.BookingID = &ev.BookingID
+		case GuestAdded:
+			row.BookingID = ev.BookingID
+			row.GuestID = &ev.GuestID
+		case HousekeepingTaskChanged:
+			row.BookingID = nil
+		case ConsentAccepted:
+			row.BookingID = ev.BookingID
+			if len(ev.GuestIDs) == 1 {
+				row.GuestID = &ev.GuestIDs[0]
+			}
+		}
+		return db.WithContext(ctx).Create(&row).Error
+	}
+}
+
+// AuditEventFilter: ตัวกรองหน้า admin
+type AuditEventFilter struct {
+	Event     string
+	BookingID uint
+	GuestID   uint
+	From      *time.Time
+	To        *time.Time
+	Page      int
+	Limit     int
+}
+
+// ListAuditEvents ประวัติ domain event ใหม่สุดก่อน
+func ListAuditEvents(db *gorm.DB, f AuditEventFilter) ([]models.AuditEvent, int64, error) {
+	if f.Limit <= 0 || f.Limit > 200 {
+		f.Limit = 50
+	}
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	q := db.Model(&models.AuditEvent{})
+	if f.Event != "" {
+		q = q.Where("event = ?", f.Event)
+	}
+	if f.BookingID != 0 {
+		q = q.Where("booking_id = ?", f.BookingID)
+	}
+	if f.GuestID != 0 {
+		q = q.Where("guest_id = ?", f.GuestID)
+	}
+	if f.From != nil {
+		q = q.Where("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		q = q.Where("created_at < ?", *f.To)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []models.AuditEvent
+	err := q.Order("id DESC").Offset((f.Page - 1) * f.Limit).Limit(f.Limit).Find(&rows).Error
+	return rows, total, err
+}
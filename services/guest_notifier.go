@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"hotel-backend/models"
+	"hotel-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// SMS / LINE ถึงแขกตามช่องทางที่ลูกค้าเลือก (Customer.NotifyChannels) — ส่งเพิ่มจากอีเมล ไม่ได้แทนที่
+// เพราะอีเมลยังเป็นช่องทางหลักของใบยืนยัน/เอกสารแนบ
+
+type notifyTarget struct {
+	Channel string
+	To      string
+}
+
+// customerNotifyTargets ช่องทางนอกจากอีเมลที่ลูกค้าเลือกและมีข้อมูลติดต่อครบ
+func customerNotifyTargets(c models.Customer) []notifyTarget {
+	channels, err := utils.ParseNotifyChannels(c.NotifyChannels)
+	if err != nil {
+		log.Printf("customer %d notify channels %q: %v", c.ID, c.NotifyChannels, err)
+	}
+	var out []notifyTarget
+	for _, ch := range channels {
+		switch ch {
+		case utils.NotifyChannelSMS:
+			if phone := utils.NormalizePhone(c.Phone); phone != "" {
+				out = append(out, notifyTarget{Channel: ch, To: phone})
+			}
+		case utils.NotifyChannelLine:
+			if id := strings.TrimSpace(c.LineUserID); id != "" {
+				out = append(out, notifyTarget{Channel: ch, To: id})
+			}
+		}
+	}
+	return out
+}
+
+// NotifyCustomer ส่งข้อความไปทุกช่องทางที่ลูกค้าเลือก (ช่องทางที่ไม่ได้ตั้งค่า provider = ข้าม)
+func NotifyCustomer(ctx context.Context, c models.Customer, text string) error {
+	var errs []error
+	for _, t := range customerNotifyTargets(c) {
+		n, ok := utils.NotifierFor(t.Channel)
+		if !ok {
+			continue
+		}
+		if err := n.Notify(ctx, t.To, text); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", t.Channel, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NotifyCustomerAsync: เรียกจาก request / การส่งอีเมล — ไม่ให้ผู้เรียกรอ SMS / LINE (label ใช้ใน log)
+func NotifyCustomerAsync(c models.Customer, label, text string) {
+	if len(customerNotifyTargets(c)) == 0 {
+		return
+	}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("notify %s panic (customer=%d): %v", label, c.ID, r)
+			}
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := NotifyCustomer(ctx, c, text); err != nil {
+			log.Printf("notify %s (customer=%d): %v", label, c.ID, err)
+		}
+	}()
+}
+
+// NotifyCheckinCode ส่งรหัสเช็คอินที่แขกขอใหม่ (ResendCheckinCode)
+func NotifyCheckinCode(db *gorm.DB, bi models.BookingInfo) {
+	var booking models.Booking
+	if err := db.Preload("Customer").First(&booking, bi.BookingID).Error; err != nil {
+		log.Printf("notify checkin code (booking_info=%d): %v", bi.ID, err)
+		return
+	}
+	NotifyCustomerAsync(booking.Customer, fmt.Sprintf("checkin code booking_info=%d", bi.ID),
+		utils.CheckinCodeText(booking.ReferenceCode, bi.CheckinCode))
+}
+
+// NotifyRoomAccess ส่งรหัสเข้าห้องหลังเช็คอินเสร็จ (ห้องที่ยังไม่มีรหัสจะไม่อยู่ในข้อความ)
+func NotifyRoomAccess(db *gorm.DB, bookingID uint) {
+	var booking models.Booking
+	if err := db.Preload("Rooms.Room").Preload("Room").Preload("Customer").First(&booking, bookingID).Error; err != nil {
+		log.Printf("notify room access (booking=%d): %v", bookingID, err)
+		return
+	}
+	rooms := make([]models.Room, 0, len(booking.Rooms)+1)
+	for _, br := range booking.Rooms {
+		rooms = append(rooms, br.Room)
+	}
+	if len(rooms) == 0 {
+		rooms = append(rooms, booking.Room)
+	}
+
+	var access []utils.RoomAccess
+	for _, r := range rooms {
+		code := strings.TrimSpace(r.AccessCode)
+		if r.ID == 0 || code == "" {
+			continue
+		}
+		num := strings.TrimSpace(r.RoomCode)
+		if num == "" {
+			num = strings.TrimSpace(r.RoomNumber)
+		}
+		access = append(access, utils.RoomAccess{Number: num, AccessCode: code})
+	}
+	if len(access) == 0 {
+		return
+	}
+	NotifyCustomerAsync(booking.Customer, fmt.Sprintf("room access booking=%d", bookingID),
+		utils.RoomAccessText(booking.ReferenceCode, access))
+}
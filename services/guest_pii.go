@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"hotel-backend/models"
+	"hotel-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+const guestDOBLayout = "2006-01-02"
+
+// SealGuestPII เข้ารหัสฟิลด์ส่วนบุคคลของ guest ในตัว struct ก่อนบันทึก และเติม blind index
+// (ฟิลด์ที่เข้ารหัสแล้วจะไม่ถูกเข้ารหัสซ้ำ)
+func SealGuestPII(g *models.Guest) error {
+	if g.IDNumber != "" && !strings.HasPrefix(g.IDNumber, piiFieldPrefix) {
+		g.IDNumberIndex = IDNumberBlindIndex(g.IDNumber)
+	}
+	if g.DateOfBirth != nil {
+		g.DateOfBirthEnc = g.DateOfBirth.UTC().Format(guestDOBLayout)
+	}
+
+	for _, field := range []*string{&g.IDNumber, &g.CurrentAddress, &g.DateOfBirthEnc, &g.FaceImagePath, &g.DocumentImagePath} {
+		enc, err := EncryptPIIString(*field)
+		if err != nil {
+			return fmt.Errorf("encrypt guest pii: %w", err)
+		}
+		*field = enc
+	}
+	g.PIIKeyID = piiActiveKeyID()
+	return nil
+}
+
+// OpenGuestPII ถอดรหัสฟิลด์ส่วนบุคคลของ guest ที่โหลดจาก DB (ค่า plaintext เก่าใช้ได้ตามเดิม)
+func OpenGuestPII(g *models.Guest) error {
+	for _, field := range []*string{&g.IDNumber, &g.CurrentAddress, &g.DateOfBirthEnc, &g.FaceImagePath, &g.DocumentImagePath} {
+		plain, err := DecryptPIIString(*field)
+		if err != nil {
+			return fmt.Errorf("decrypt guest %d pii: %w", g.ID, err)
+		}
+		*field = plain
+	}
+
+	if g.DateOfBirthEnc != "" {
+		if dob, err := time.Parse(guestDOBLayout, g.DateOfBirthEnc); err == nil {
+			g.DateOfBirth = &dob
+		}
+	}
+	g.DateOfBirthEnc = ""
+	return nil
+}
+
+func openGuests(guests []models.Guest) error {
+	for i := range guests {
+		if err := OpenGuestPII(&guests[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveGuestImage บันทึกรูปใบหน้า/เอกสารของแขกแบบเข้ารหัส และคืน key (เข้ารหัสแล้ว) สำหรับเก็บลง DB
+func SaveGuestImage(b64 string, subdir string) (string, error) {
+	data, err := utils.DecodeBase64Image(b64)
+	if err != nil {
+		return "", err
+	}
+	_, ext, err := DetectImageType(data)
+	if err != nil {
+		return "", err
+	}
+	return saveGuestBlob(data, subdir, ext)
+}
+
+// saveGuestBlob เข้ารหัสไฟล์ของแขก เก็บลง BlobStore และคืน key (เข้ารหัสแล้ว) สำหรับเก็บลง DB
+func saveGuestBlob(data []byte, subdir, ext string) (string, error) {
+	sealed, err := EncryptPII(data)
+	if err != nil {
+		return "", fmt.Errorf("encrypt image: %w", err)
+	}
+
+	name, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		return "", fmt.Errorf("generate file name: %w", err)
+	}
+	key := strings.Trim(subdir, "/") + "/" + name + ext
+
+	if err := DefaultBlobStore().Put(context.Background(), key, sealed, "application/octet-stream"); err != nil {
+		return "", fmt.Errorf("store image: %w", err)
+	}
+	return EncryptPIIString(key)
+}
+
+// DeleteGuestImage ลบไฟล์ของแขกตาม key (เข้ารหัส) ที่เก็บใน DB — ไม่มีไฟล์แล้วไม่ถือว่า error
+func DeleteGuestImage(ctx context.Context, blobs BlobStore, stored string) error {
+	if strings.TrimSpace(stored) == "" {
+		return nil
+	}
+	path, err := DecryptPIIString(stored)
+	if err != nil {
+		return err
+	}
+	key, err := normalizeBlobKey(path)
+	if err != nil {
+		return nil
+	}
+	if err := blobs.Delete(ctx, key); err != nil && !errors.Is(err, ErrBlobNotFound) {
+		return err
+	}
+	return nil
+}
+
+// OpenGuestImage อ่านรูปของแขกจาก BlobStore และถอดรหัส (ไฟล์เก่าที่ไม่ได้เข้ารหัสคืนตามเดิม)
+func OpenGuestImage(ctx context.Context, blobs BlobStore, key string) ([]byte, string, error) {
+	body, _, err := blobs.Open(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+	defer body.Close()
+
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return nil, "", err
+	}
+	plain, err := DecryptPII(raw)
+	if err != nil {
+		return nil, "", err
+	}
+	contentType, _, err := DetectImageType(plain)
+	if err != nil {
+		contentType = "application/octet-stream"
+	}
+	return plain, contentType, nil
+}
+
+// rewrapGuestImage: เข้ารหัสไฟล์รูปเก่า หรือ re-wrap DEK ให้ใช้ active key
+func rewrapGuestImage(ctx context.Context, blobs BlobStore, key string) error {
+	body, _, err := blobs.Open(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrBlobNotFound) {
+			return nil
+		}
+		return err
+	}
+	raw, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return err
+	}
+
+	out, changed, err := rewrapPII(raw)
+	if err != nil || !changed {
+		return err
+	}
+	return blobs.Put(ctx, key, out, "application/octet-stream")
+}
+
+// BackfillGuestDOB ย้ายคอลัมน์ date_of_birth เดิมไป date_of_birth_enc (เข้ารหัสถ้าตั้ง key แล้ว)
+// ทำทุกครั้งตอน start ไม่ว่าจะเปิดการเข้ารหัสหรือไม่ — OpenGuestPII อ่านวันเกิดจาก date_of_birth_enc เท่านั้น
+func BackfillGuestDOB(db *gorm.DB) (int, error) {
+	if !db.Migrator().HasColumn(&models.Guest{}, "date_of_birth") {
+		return 0, nil
+	}
+	moved := 0
+	for {
+		var rows []struct {
+			ID          uint
+			DateOfBirth *time.Time
+		}
+		if err := db.Table("guests").Select("id", "date_of_birth").
+			Where("date_of_birth IS NOT NULL AND (date_of_birth_enc IS NULL OR date_of_birth_enc = '')").
+			Order("id ASC").Limit(200).Scan(&rows).Error; err != nil {
+			return moved, err
+		}
+		if len(rows) == 0 {
+			return moved, nil
+		}
+		for _, r := range rows {
+			enc, err := EncryptPIIString(r.DateOfBirth.UTC().Format(guestDOBLayout))
+			if err != nil {
+				return moved, fmt.Errorf("guest %d: %w", r.ID, err)
+			}
+			if err := db.Table("guests").Where("id = ?", r.ID).
+				Updates(map[string]interface{}{"date_of_birth_enc": enc, "date_of_birth": nil}).Error; err != nil {
+				return moved, err
+			}
+			moved++
+		}
+	}
+}
+
+// RotateGuestPII: เข้ารหัสข้อมูลแขกที่ยังเป็น plaintext และ re-wrap ข้อมูลที่ใช้ key เก่าให้เป็น active key
+// (รวมถึงย้ายคอลัมน์ date_of_birth เดิม และเติม blind index) — ทำทีละ batch, รันซ้ำได้
+func RotateGuestPII(ctx context.Context, db *gorm.DB) (int, error) {
+	if !PIIEncryptionEnabled() {
+		return 0, nil
+	}
+
+	hasLegacyDOB := db.Migrator().HasColumn(&models.Guest{}, "date_of_birth")
+	blobs := DefaultBlobStore()
+
+	updated := 0
+	lastID := uint(0)
+	for {
+		if err := ctx.Err(); err != nil {
+			return updated, err
+		}
+
+		var batch []models.Guest
+		if err := db.Unscoped().
+			Where("id > ?", lastID).
+			Order("id ASC").
+			Limit(200).
+			Find(&batch).Error; err != nil {
+			return updated, err
+		}
+		if len(batch) == 0 {
+			return updated, nil
+		}
+
+		for _, g := range batch {
+			lastID = g.ID
+			changed, err := rotateGuestRow(ctx, db, blobs, g, hasLegacyDOB)
+			if err != nil {
+				log.Printf("RotateGuestPII: guest %d: %v", g.ID, err)
+				continue
+			}
+			if changed {
+				updated++
+			}
+		}
+	}
+}
+
+func rotateGuestRow(ctx context.Context, db *gorm.DB, blobs BlobStore, g models.Guest, hasLegacyDOB bool) (bool, error) {
+	activeID := piiActiveKeyID()
+	if g.PIIKeyID == activeID {
+		return false, nil
+	}
+	updates := map[string]interface{}{"pii_key_id": activeID}
+
+	if hasLegacyDOB && g.DateOfBirthEnc == "" {
+		var legacy struct{ DateOfBirth *time.Time }
+		if err := db.Table("guests").Select("date_of_birth").Where("id = ?", g.ID).Scan(&legacy).Error; err != nil {
+			return false, err
+		}
+		if legacy.DateOfBirth != nil {
+			g.DateOfBirthEnc = legacy.DateOfBirth.UTC().Format(guestDOBLayout)
+			updates["date_of_birth"] = nil
+		}
+	}
+
+	if g.IDNumberIndex == "" && g.IDNumber != "" {
+		plain, err := DecryptPIIString(g.IDNumber)
+		if err != nil {
+			return false, err
+		}
+		updates["id_number_index"] = IDNumberBlindIndex(plain)
+	}
+
+	columns := map[string]string{
+		"id_number":           g.IDNumber,
+		"current_address":     g.CurrentAddress,
+		"date_of_birth_enc":   g.DateOfBirthEnc,
+		"face_image_path":     g.FaceImagePath,
+		"document_image_path": g.DocumentImagePath,
+	}
+	for column, value := range columns {
+		out, changed, err := rewrapPIIString(value)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", column, err)
+		}
+		if changed {
+			updates[column] = out
+		}
+	}
+	for _, stored := range []string{g.FaceImagePath, g.DocumentImagePath} {
+		path, err := DecryptPIIString(stored)
+		if err != nil {
+			return false, err
+		}
+		key, err := normalizeBlobKey(path)
+		if err != nil {
+			continue
+		}
+		if err := rewrapGuestImage(ctx, blobs, key); err != nil {
+			return false, fmt.Errorf("image %s: %w", key, err)
+		}
+	}
+
+	if err := db.Unscoped().Model(&models.Guest{}).Where("id = ?", g.ID).Updates(updates).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
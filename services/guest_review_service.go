@@ -0,0 +1,397 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"hotel-backend/models"
+	"hotel-backend/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GuestReviewService: คิวตรวจเอกสารยืนยันตัวตนของแขกโดยพนักงานต้อนรับ
+type GuestReviewService struct {
+	DB *gorm.DB
+}
+
+func NewGuestReviewService(db *gorm.DB) *GuestReviewService {
+	return &GuestReviewService{DB: db}
+}
+
+// IdentityApprovalRequired: อ่าน policy จาก hotel_settings (ไม่มีแถว = ปิด)
+func IdentityApprovalRequired(db *gorm.DB) bool {
+	var hotel models.HotelSetting
+	if err := db.Select("require_identity_approval").First(&hotel).Error; err != nil {
+		return false
+	}
+	return hotel.RequireIdentityApproval
+}
+
+// SignatureRequired: hotel บังคับให้แขกหลักเซ็นชื่อตอนเช็คอินออนไลน์หรือไม่
+func SignatureRequired(db *gorm.DB) bool {
+	var hotel models.HotelSetting
+	if err := db.Select("require_signature").First(&hotel).Error; err != nil {
+		return false
+	}
+	return hotel.RequireSignature
+}
+
+// GuestRoomNumber คืนเลขห้องแรกของ booking ที่ preload มาแล้ว (RoomCode ก่อน RoomNumber)
+func GuestRoomNumber(g models.Guest) string {
+	pick := func(r models.Room) string {
+		if strings.TrimSpace(r.RoomCode) != "" {
+			return strings.TrimSpace(r.RoomCode)
+		}
+		return strings.TrimSpace(r.RoomNumber)
+	}
+	if len(g.Booking.Rooms) > 0 && g.Booking.Rooms[0].Room.ID != 0 {
+		return pick(g.Booking.Rooms[0].Room)
+	}
+	if g.Booking.Room.ID != 0 {
+		return pick(g.Booking.Room)
+	}
+	return ""
+}
+
+// ListQueue: รายการ guest ตามสถานะ (ค่าเริ่มต้น pending) เรียงเก่าสุดก่อน
+func (s *GuestReviewService) ListQueue(status string) ([]models.Guest, error) {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if status == "" {
+		status = models.GuestReviewPending
+	}
+	switch status {
+	case models.GuestReviewPending, models.GuestReviewApproved, models.GuestReviewRejected:
+	default:
+		return nil, errors.New("invalid_review_status")
+	}
+
+	var guests []models.Guest
+	if err := s.DB.
+		Preload("Booking.Customer").
+		Preload("Booking.Room").
+		Preload("Booking.Rooms.Room").
+		Where("review_status = ?", status).
+		Order("created_at ASC, id ASC").
+		Find(&guests).Error; err != nil {
+		return nil, fmt.Errorf("failed to load review queue: %w", err)
+	}
+	if err := openGuests(guests); err != nil {
+		return nil, err
+	}
+
+	for i := range guests {
+		guests[i].RoomNumber = GuestRoomNumber(guests[i])
+	}
+	return guests, nil
+}
+
+// GetForReview โหลด guest หนึ่งคนพร้อม booking สำหรับหน้าจอตรวจ
+func (s *GuestReviewService) GetForReview(guestID uint) (*models.Guest, error) {
+	var g models.Guest
+	if err := s.DB.
+		Preload("Booking.Customer").
+		Preload("Booking.Room").
+		Preload("Booking.Rooms.Room").
+		First(&g, guestID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("guest_not_found")
+		}
+		return nil, err
+	}
+	if err := OpenGuestPII(&g); err != nil {
+		return nil, err
+	}
+	g.RoomNumber = GuestRoomNumber(g)
+	return &g, nil
+}
+
+func (s *GuestReviewService) ensureReviewer(tx *gorm.DB, reviewerID uint) error {
+	if reviewerID == 0 {
+		return errors.New("reviewer_required")
+	}
+	var admin models.Admin
+	if err := tx.Select("id").First(&admin, reviewerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("reviewer_not_found")
+		}
+		return err
+	}
+	return nil
+}
+
+// Approve: อนุมัติเอกสารของ guest
+// ถ้า policy เปิดอยู่และแขกทุกคนใน booking ผ่านแล้ว -> จบการเช็คอินให้อัตโนมัติ
+func (s *GuestReviewService) Approve(guestID, reviewerID uint) (models.Guest, error) {
+	var guest models.Guest
+	now := time.Now().UTC()
+	completed := false
+	var roomIDs []uint
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.ensureReviewer(tx, reviewerID); err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&guest, guestID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("guest_not_found")
+			}
+			return err
+		}
+
+		if err := tx.Model(&guest).Updates(map[string]interface{}{
+			"review_status": models.GuestReviewApproved,
+			"reviewed_by":   reviewerID,
+			"reviewed_at":   now,
+			"review_reason": "",
+		}).Error; err != nil {
+			return err
+		}
+
+		if guest.BookingID == nil {
+			return nil
+		}
+		done, err := completeCheckInIfApproved(tx, *guest.BookingID, now)
+		if err != nil || !done {
+			return err
+		}
+		// แขกคนสุดท้ายผ่านการตรวจ = เช็คอินเสร็จ (webhook เข้าคิวใน transaction เดียวกัน)
+		completed = true
+		if roomIDs, err = bookingRoomIDs(tx, *guest.BookingID); err != nil {
+			return err
+		}
+		return EnqueueWebhooks(tx, CheckInCompleted{BookingID: *guest.BookingID, RoomIDs: roomIDs, Reviewed: true, At: now})
+	})
+	if err != nil {
+		return models.Guest{}, err
+	}
+	if completed {
+		PublishEvents(CheckInCompleted{BookingID: *guest.BookingID, RoomIDs: roomIDs, Reviewed: true, At: now})
+	}
+
+	if err := s.DB.First(&guest, guestID).Error; err != nil {
+		return models.Guest{}, err
+	}
+	if err := OpenGuestPII(&guest); err != nil {
+		return models.Guest{}, err
+	}
+	return guest, nil
+}
+
+// Reject: ปฏิเสธเอกสาร พร้อมเหตุผล
+// requireReupload = true จะส่งอีเมลแจ้งแขกให้อัปโหลดเอกสารใหม่ (best-effort, คืน email_send_failed ถ้าส่งไม่ได้)
+func (s *GuestReviewService) Reject(guestID, reviewerID uint, reason string, requireReupload bool) (models.Guest, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return models.Guest{}, errors.New("reason_required")
+	}
+
+	var guest models.Guest
+	now := time.Now().UTC()
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.ensureReviewer(tx, reviewerID); err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&guest, guestID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("guest_not_found")
+			}
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"review_status": models.GuestReviewRejected,
+			"reviewed_by":   reviewerID,
+			"reviewed_at":   now,
+			"review_reason": reason,
+		}
+		if requireReupload {
+			updates["reupload_requested_at"] = now
+		}
+		return tx.Model(&guest).Updates(updates).Error
+	})
+	if err != nil {
+		return models.Guest{}, err
+	}
+	if err := s.DB.First(&guest, guestID).Error; err != nil {
+		return models.Guest{}, err
+	}
+	if err := OpenGuestPII(&guest); err != nil {
+		return models.Guest{}, err
+	}
+
+	if !requireReupload {
+		return guest, nil
+	}
+
+	if err := s.notifyReupload(guest, reason); err != nil {
+		log.Printf("GuestReviewService.Reject: notify guest %d failed: %v", guest.ID, err)
+		return guest, fmt.Errorf("email_send_failed: %w", err)
+	}
+	return guest, nil
+}
+
+// notifyReupload ส่งอีเมลพร้อมลิงก์อัปโหลดเอกสารใหม่ (ใช้ token ของ booking_info ล่าสุด)
+func (s *GuestReviewService) notifyReupload(guest models.Guest, reason string) error {
+	if guest.BookingID == nil {
+		return errors.New("guest has no booking")
+	}
+
+	var booking models.Booking
+	if err := s.DB.Preload("Customer").First(&booking, *guest.BookingID).Error; err != nil {
+		return fmt.Errorf("load booking: %w", err)
+	}
+
+	recipient := strings.TrimSpace(guest.Email)
+	if recipient == "" {
+		recipient = strings.TrimSpace(booking.Customer.Email)
+	}
+	if recipient == "" {
+		return errors.New("guest_email_missing")
+	}
+
+	var bi models.BookingInfo
+	if err := s.DB.
+		Where("booking_id = ? AND deleted_at IS NULL", booking.ID).
+		Order("id DESC").
+		First(&bi).Error; err != nil {
+		return fmt.Errorf("load booking info: %w", err)
+	}
+
+	frontend := strings.TrimRight(utils.EnvOrDefault("FRONTEND_URL", "http://localhost:3000"), "/")
+	link := fmt.Sprintf("%s/checkin/reupload?token=%s&guestId=%d", frontend, bi.Token, guest.ID)
+
+	return utils.SendIdentityReuploadEmail(recipient, guest.FullName, booking.ReferenceCode, reason, link)
+}
+
+// Reupload: แขกส่งรูปเอกสาร/ใบหน้าใหม่ผ่าน token ของการเช็คอิน -> กลับเข้าคิว pending
+func (s *GuestReviewService) Reupload(token string, guestID uint, faceB64, docB64 string) (models.Guest, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return models.Guest{}, errors.New("invalid_or_expired_token")
+	}
+	if strings.TrimSpace(faceB64) == "" && strings.TrimSpace(docB64) == "" {
+		return models.Guest{}, errors.New("images_required")
+	}
+
+	now := time.Now().UTC()
+	var bi models.BookingInfo
+	if err := s.DB.
+		Where("token = ? AND (expires_at IS NULL OR expires_at > ?)", token, now).
+		First(&bi).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Guest{}, errors.New("invalid_or_expired_token")
+		}
+		return models.Guest{}, err
+	}
+
+	var guest models.Guest
+	if err := s.DB.First(&guest, guestID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Guest{}, errors.New("guest_not_found")
+		}
+		return models.Guest{}, err
+	}
+	if guest.BookingID == nil || *guest.BookingID != bi.BookingID {
+		return models.Guest{}, errors.New("guest_not_found")
+	}
+	if guest.ReviewStatus != models.GuestReviewRejected {
+		return models.Guest{}, errors.New("reupload_not_requested")
+	}
+
+	updates := map[string]interface{}{
+		"review_status": models.GuestReviewPending,
+		"reviewed_by":   nil,
+		"reviewed_at":   nil,
+	}
+	// ไฟล์เดิมที่ถูกแทนที่ (ลบหลังบันทึกสำเร็จ) / ไฟล์ใหม่ (ลบถ้าบันทึกไม่สำเร็จ)
+	var replaced, saved []string
+	cleanup := func(keys []string) {
+		for _, key := range keys {
+			if err := DeleteGuestImage(context.Background(), DefaultBlobStore(), key); err != nil {
+				log.Printf("GuestReviewService.Reupload: delete image of guest %d: %v", guest.ID, err)
+			}
+		}
+	}
+	if strings.TrimSpace(faceB64) != "" {
+		path, err := SaveGuestImage(faceB64, "faces")
+		if err != nil {
+			return models.Guest{}, fmt.Errorf("save face image: %w", err)
+		}
+		updates["face_image_path"] = path
+		saved = append(saved, path)
+		replaced = append(replaced, guest.FaceImagePath)
+	}
+	if strings.TrimSpace(docB64) != "" {
+		path, err := SaveGuestImage(docB64, "documents")
+		if err != nil {
+			cleanup(saved)
+			return models.Guest{}, fmt.Errorf("save document image: %w", err)
+		}
+		updates["document_image_path"] = path
+		saved = append(saved, path)
+		replaced = append(replaced, guest.DocumentImagePath)
+	}
+
+	if err := s.DB.Model(&guest).Updates(updates).Error; err != nil {
+		cleanup(saved)
+		return models.Guest{}, err
+	}
+	cleanup(replaced)
+	if err := s.DB.First(&guest, guest.ID).Error; err != nil {
+		return models.Guest{}, err
+	}
+	if err := OpenGuestPII(&guest); err != nil {
+		return models.Guest{}, err
+	}
+	return guest, nil
+}
+
+// completeCheckInIfApproved: ใช้ใน transaction หลังอนุมัติ guest
+// เมื่อ booking รอตรวจ (Pending-Review) และแขกทุกคน approved แล้ว -> Checked-In + booking_info COMPLETED
+func completeCheckInIfApproved(tx *gorm.DB, bookingID uint, now time.Time) (bool, error) {
+	var booking models.Booking
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&booking, bookingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if booking.Status != "Pending-Review" || booking.CheckinCompleted {
+		return false, nil
+	}
+
+	var notApproved int64
+	if err := tx.Model(&models.Guest{}).
+		Where("booking_id = ? AND (review_status IS NULL OR review_status <> ?)", bookingID, models.GuestReviewApproved).
+		Count(&notApproved).Error; err != nil {
+		return false, err
+	}
+	if notApproved > 0 {
+		return false, nil
+	}
+
+	if err := tx.Model(&booking).Updates(map[string]interface{}{
+		"status":            "Checked-In",
+		"check_in":          now,
+		"checked_in_at":     now,
+		"checkin_completed": true,
+	}).Error; err != nil {
+		return false, err
+	}
+
+	if err := tx.Model(&models.BookingInfo{}).
+		Where("booking_id = ? AND status = ? AND deleted_at IS NULL", bookingID, "PENDING_REVIEW").
+		Update("status", "COMPLETED").Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"log"
+	"strings"
 
 	"hotel-backend/models"
 	"gorm.io/gorm"
@@ -19,7 +20,8 @@ func NewGuestService(db *gorm.DB) *GuestService {
 // CREATE — ต้องรับ pointer เพื่อให้ ID ถูกเติมกลับมาที่ตัวแปรจริง
 // ----------------------------------------------------
 func (s *GuestService) Create(guest *models.Guest) error {
-	log.Printf("➡️ GuestService.Create incoming: %+v", guest)
+	// ไม่ log ข้อมูลส่วนบุคคล (เลขบัตร/ที่อยู่/รูป)
+	log.Printf("➡️ GuestService.Create incoming: booking_id=%v main=%v", guest.BookingID, guest.IsMainGuest)
 
 	// ตรวจสอบหรือตั้งค่าอีเมล
 	if guest.Email == "" {
@@ -27,9 +29,31 @@ func (s *GuestService) Create(guest *models.Guest) error {
 		log.Println("⚠️ Guest does not have an email.")
 	}
 
-	err := s.DB.Create(guest).Error
+	// guest ใหม่ทุกคนเข้าคิวตรวจเอกสาร
+	if guest.ReviewStatus == "" {
+		guest.ReviewStatus = models.GuestReviewPending
+	}
+
+	// 🔒 เข้ารหัสข้อมูลส่วนบุคคลก่อนบันทึก แล้วถอดกลับให้ผู้เรียกใช้ต่อได้
+	if err := SealGuestPII(guest); err != nil {
+		return err
+	}
+	var added GuestAdded
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(guest).Error; err != nil {
+			return err
+		}
+		added = GuestAdded{GuestID: guest.ID, BookingID: guest.BookingID, IsMainGuest: guest.IsMainGuest, ReviewStatus: guest.ReviewStatus, At: guest.CreatedAt}
+		return EnqueueWebhooks(tx, added)
+	})
+	if err == nil {
+		PublishEvents(added)
+	}
+	if openErr := OpenGuestPII(guest); err == nil {
+		err = openErr
+	}
 
-	log.Printf("⬅️ GuestService.Create result: %+v (err: %v)", guest, err)
+	log.Printf("⬅️ GuestService.Create result: guest_id=%d (err: %v)", guest.ID, err)
 	return err
 }
 
@@ -55,6 +79,9 @@ func (s *GuestService) GetAll() ([]models.Guest, error) {
 		log.Printf("⬅️ GuestService.GetAll error: %v", err)
 		return nil, err
 	}
+	if err := openGuests(guests); err != nil {
+		return nil, err
+	}
 
 	// เติม roomNumber ให้ guest (ใช้เฉพาะ admin view)
 	for i := range guests {
@@ -102,6 +129,9 @@ func (s *GuestService) GetAllRaw() ([]models.Guest, error) {
 		log.Printf("⬅️ GuestService.GetAllRaw error: %v", err)
 		return nil, err
 	}
+	if err := openGuests(guests); err != nil {
+		return nil, err
+	}
 
 	log.Printf("⬅️ GuestService.GetAllRaw ok: %d guests", len(guests))
 	return guests, nil
@@ -118,6 +148,9 @@ func (s *GuestService) GetByID(id uint) (*models.Guest, error) {
 		log.Printf("⬅️ GuestService.GetByID error: %v", err)
 		return nil, err
 	}
+	if err := OpenGuestPII(&guest); err != nil {
+		return nil, err
+	}
 
 	log.Printf("⬅️ GuestService.GetByID ok: guest_id=%d", guest.ID)
 	return &guest, nil
@@ -135,9 +168,18 @@ func (s *GuestService) Update(guest *models.Guest) error {
 		log.Println("⚠️ Guest does not have an email.")
 	}
 
+	if err := SealGuestPII(guest); err != nil {
+		return err
+	}
+
+	// ผลการตรวจเอกสารแก้ได้ผ่าน GuestReviewService เท่านั้น
 	err := s.DB.Model(&models.Guest{}).
 		Where("id = ?", guest.ID).
+		Omit("review_status", "reviewed_by", "reviewed_at", "review_reason", "reupload_requested_at").
 		Updates(guest).Error
+	if openErr := OpenGuestPII(guest); err == nil {
+		err = openErr
+	}
 
 	log.Printf("⬅️ GuestService.Update err=%v", err)
 	return err
@@ -169,6 +211,9 @@ func (s *GuestService) GetByBookingID(bookingID uint) ([]models.Guest, error) {
 		log.Printf("⬅️ GuestService.GetByBookingID error: %v", err)
 		return nil, err
 	}
+	if err := openGuests(guests); err != nil {
+		return nil, err
+	}
 
 	log.Printf("⬅️ GuestService.GetByBookingID ok: %d guests", len(guests))
 	return guests, nil
@@ -190,7 +235,36 @@ func (s *GuestService) GetByBookingIDRaw(bookingID uint) ([]models.Guest, error)
 		log.Printf("⬅️ GuestService.GetByBookingIDRaw error: %v", err)
 		return nil, err
 	}
+	if err := openGuests(guests); err != nil {
+		return nil, err
+	}
 
 	log.Printf("⬅️ GuestService.GetByBookingIDRaw ok: %d guests", len(guests))
 	return guests, nil
 }
+
+// ----------------------------------------------------
+// 🔒 FindByIDNumber — ค้นหาจากเลขบัตร/พาสปอร์ตผ่าน blind index
+// (แถวเก่าที่ยังไม่ถูกเข้ารหัสค้นจากค่า plaintext ตรง ๆ)
+// ----------------------------------------------------
+func (s *GuestService) FindByIDNumber(idNumber string) ([]models.Guest, error) {
+	idNumber = strings.TrimSpace(idNumber)
+	log.Println("➡️ GuestService.FindByIDNumber")
+
+	var guests []models.Guest
+	q := s.DB.Where("id_number = ? AND (id_number_index IS NULL OR id_number_index = '')", idNumber)
+	if index := IDNumberBlindIndex(idNumber); index != "" {
+		q = s.DB.Where("id_number_index = ?", index).
+			Or("id_number = ? AND (id_number_index IS NULL OR id_number_index = '')", idNumber)
+	}
+	if err := q.Order("id DESC").Find(&guests).Error; err != nil {
+		log.Printf("⬅️ GuestService.FindByIDNumber error: %v", err)
+		return nil, err
+	}
+	if err := openGuests(guests); err != nil {
+		return nil, err
+	}
+
+	log.Printf("⬅️ GuestService.FindByIDNumber ok: %d guests", len(guests))
+	return guests, nil
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"hotel-backend/models"
+	"hotel-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// ลายเซ็นเก็บใน BlobStore ใต้ signatures/ (เข้ารหัสแบบเดียวกับรูปเอกสาร)
+const (
+	signatureDir       = "signatures"
+	signatureMaxBytes  = 1 << 20 // รูปลายเซ็นไม่เกิน 1MB
+	signatureMaxPoints = 10000
+	signatureMaxPixels = 4000
+)
+
+// SignaturePoint: จุดบน canvas ของ signature pad (หน่วย pixel, มุมซ้ายบน = 0,0)
+type SignaturePoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// SignatureInput: ลายเซ็นที่ส่งมากับ ConfirmCheckIn — ส่งอย่างใดอย่างหนึ่ง
+//   - image:   PNG/JPEG (raw base64 หรือ data URI)
+//   - strokes: เส้นแบบ vector พร้อมขนาด canvas (width/height)
+type SignatureInput struct {
+	Image   string             `json:"image,omitempty"`
+	Strokes [][]SignaturePoint `json:"strokes,omitempty"`
+	Width   float64            `json:"width,omitempty"`
+	Height  float64            `json:"height,omitempty"`
+}
+
+// SignatureStrokes: รูปแบบที่เก็บของลายเซ็นแบบ vector
+type SignatureStrokes struct {
+	Width   float64            `json:"width"`
+	Height  float64            `json:"height"`
+	Strokes [][]SignaturePoint `json:"strokes"`
+}
+
+// Empty: ไม่ได้เซ็น
+func (in *SignatureInput) Empty() bool {
+	return in == nil || (strings.TrimSpace(in.Image) == "" && len(in.Strokes) == 0)
+}
+
+// PreparedSignature: ลายเซ็นที่ตรวจแล้ว พร้อมบันทึก
+type PreparedSignature struct {
+	Kind string
+	Data []byte
+	Ext  string
+}
+
+// PrepareSignature ตรวจรูปแบบลายเซ็นก่อนเริ่ม transaction (error = "invalid_guest_signature: ...")
+func PrepareSignature(in *SignatureInput) (*PreparedSignature, error) {
+	if in.Empty() {
+		return nil, nil
+	}
+	if strings.TrimSpace(in.Image) != "" {
+		data, err := utils.DecodeBase64Image(in.Image)
+		if err != nil {
+			return nil, errors.New("invalid_guest_signature: " + err.Error())
+		}
+		if len(data) > signatureMaxBytes {
+			return nil, errors.New("invalid_guest_signature: image too large")
+		}
+		contentType, ext, err := DetectImageType(data)
+		if err != nil || (contentType != "image/png" && contentType != "image/jpeg") {
+			return nil, errors.New("invalid_guest_signature: image must be PNG or JPEG")
+		}
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, errors.New("invalid_guest_signature: " + err.Error())
+		}
+		if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > signatureMaxPixels || cfg.Height > signatureMaxPixels {
+			return nil, errors.New("invalid_guest_signature: bad image size")
+		}
+		return &PreparedSignature{Kind: models.SignatureKindImage, Data: data, Ext: ext}, nil
+	}
+
+	if in.Width <= 0 || in.Height <= 0 || in.Width > signatureMaxPixels || in.Height > signatureMaxPixels {
+		return nil, errors.New("invalid_guest_signature: width and height are required for strokes")
+	}
+	total := 0
+	strokes := make([][]SignaturePoint, 0, len(in.Strokes))
+	for _, stroke := range in.Strokes {
+		if len(stroke) == 0 {
+			continue
+		}
+		for _, p := range stroke {
+			if math.IsNaN(p.X) || math.IsNaN(p.Y) || p.X < 0 || p.Y < 0 || p.X > in.Width || p.Y > in.Height {
+				return nil, errors.New("invalid_guest_signature: point outside canvas")
+			}
+		}
+		total += len(stroke)
+		strokes = append(strokes, stroke)
+	}
+	if total < 2 {
+		return nil, errors.New("invalid_guest_signature: signature is empty")
+	}
+	if total > signatureMaxPoints {
+		return nil, errors.New("invalid_guest_signature: too many points")
+	}
+	data, err := json.Marshal(SignatureStrokes{Width: in.Width, Height: in.Height, Strokes: strokes})
+	if err != nil {
+		return nil, err
+	}
+	return &PreparedSignature{Kind: models.SignatureKindStrokes, Data: data, Ext: ".json"}, nil
+}
+
+// SaveGuestSignature เก็บลายเซ็น (เข้ารหัส) และบันทึกแถว GuestSignature ใน tx
+func SaveGuestSignature(tx *gorm.DB, guestID, bookingID uint, sig *PreparedSignature, audit ConsentAudit, now time.Time) (models.GuestSignature, error) {
+	var row models.GuestSignature
+	if sig == nil {
+		return row, errors.New("invalid_guest_signature: signature is empty")
+	}
+	key, err := saveGuestBlob(sig.Data, signatureDir, sig.Ext)
+	if err != nil {
+		return row, err
+	}
+	sum := sha256.Sum256(sig.Data)
+	userAgent := audit.UserAgent
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	row = models.GuestSignature{
+		GuestID:    guestID,
+		BookingID:  bookingID,
+		Kind:       sig.Kind,
+		StorageKey: key,
+		SHA256:     hex.EncodeToString(sum[:]),
+		SignedAt:   now,
+		IP:         audit.IP,
+		UserAgent:  userAgent,
+	}
+	if err := tx.Create(&row).Error; err != nil {
+		return row, err
+	}
+	return row, nil
+}
+
+// LatestGuestSignature ลายเซ็นล่าสุดของ booking (ของแขกหลัก)
+func LatestGuestSignature(db *gorm.DB, bookingID uint) (models.GuestSignature, error) {
+	var row models.GuestSignature
+	err := db.Where("booking_id = ? AND purged_at IS NULL AND storage_key <> ''", bookingID).
+		Order("id DESC").First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return row, errors.New("signature_not_found")
+	}
+	return row, err
+}
+
+// OpenGuestSignature อ่านลายเซ็นและตรวจ sha256 — คืนรูป (kind=image) หรือเส้น (kind=strokes)
+func OpenGuestSignature(ctx context.Context, blobs BlobStore, row models.GuestSignature) (image.Image, *SignatureStrokes, error) {
+	stored, err := DecryptPIIString(row.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := normalizeBlobKey(stored)
+	if err != nil {
+		return nil, nil, errors.New("signature_not_found")
+	}
+	body, _, err := blobs.Open(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrBlobNotFound) {
+			return nil, nil, errors.New("signature_not_found")
+		}
+		return nil, nil, err
+	}
+	defer body.Close()
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := DecryptPII(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != row.SHA256 {
+		return nil, nil, errors.New("signature_tampered")
+	}
+
+	if row.Kind == models.SignatureKindStrokes {
+		var strokes SignatureStrokes
+		if err := json.Unmarshal(data, &strokes); err != nil {
+			return nil, nil, err
+		}
+		return nil, &strokes, nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	return img, nil, nil
+}
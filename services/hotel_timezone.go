@@ -0,0 +1,51 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+	_ "time/tzdata" // container ที่ไม่มี /usr/share/zoneinfo ก็ยังโหลด timezone ได้
+
+	"hotel-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// DefaultHotelTimezone timezone ของโรงแรมเมื่อยังไม่ได้ตั้งค่า
+const DefaultHotelTimezone = "Asia/Bangkok"
+
+// ValidHotelTimezone ตรวจชื่อ timezone แบบ IANA (เช่น Asia/Bangkok)
+func ValidHotelTimezone(name string) bool {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+// HotelLocation timezone ของโรงแรมจาก hotel settings — ใช้ตัดสิน "วันนี้" และชั่วโมงของงานตามเวลา
+// (server ทำงานเป็น UTC) ไม่มีแถว/ค่าไม่ถูกต้อง = DefaultHotelTimezone
+func HotelLocation(db *gorm.DB) *time.Location {
+	name := DefaultHotelTimezone
+	var hotel models.HotelSetting
+	if err := db.Select("timezone").Order("id ASC").First(&hotel).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("HotelLocation: %v (using %s)", err, DefaultHotelTimezone)
+		}
+	} else if tz := strings.TrimSpace(hotel.Timezone); tz != "" {
+		name = tz
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("HotelLocation: invalid timezone %q (using %s)", name, DefaultHotelTimezone)
+		loc, _ = time.LoadLocation(DefaultHotelTimezone)
+	}
+	return loc
+}
+
+// hotelToday วันนี้ตามปฏิทินของโรงแรม ในรูปแบบเดียวกับ taskDate (00:00 UTC ของวันที่นั้น)
+func hotelToday(db *gorm.DB, now time.Time) time.Time {
+	return taskDate(now.In(HotelLocation(db)))
+}
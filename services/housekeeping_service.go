@@ -0,0 +1,491 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"hotel-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Housekeeping: งานทำความสะอาดห้อง
+//
+//	dirty -> in_progress (แม่บ้านเริ่มงาน) -> clean (ทำเสร็จ) -> inspected (หัวหน้าตรวจผ่าน)
+//	clean -> dirty (ตรวจไม่ผ่าน พร้อมเหตุผล)
+//
+// งาน checkout ขับ condition ของห้อง: dirty -> clean (ทำเสร็จ) -> inspected (ตรวจผ่านครบทุกงาน = ห้อง Available)
+// ห้องที่ out_of_order / out_of_service ไม่ถูกเปลี่ยนโดยงานทำความสะอาด
+
+type HousekeepingService struct {
+	DB *gorm.DB
+}
+
+func NewHousekeepingService(db *gorm.DB) *HousekeepingService {
+	return &HousekeepingService{DB: db}
+}
+
+func taskDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func dedupKey(format string, args ...interface{}) *string {
+	key := fmt.Sprintf(format, args...)
+	return &key
+}
+
+// GenerateRoomAccessCode รหัสเข้าห้อง 6 หลักที่ไม่ซ้ำกับห้องอื่น
+func GenerateRoomAccessCode(db *gorm.DB) (string, error) {
+	for i := 0; i < 5; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+		if err != nil {
+			return "", err
+		}
+		code := fmt.Sprintf("%06d", n.Int64())
+		var count int64
+		if err := db.Model(&models.Room{}).Where("access_code = ?", code).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return code, nil
+		}
+	}
+	return "", fmt.Errorf("failed to generate unique access code")
+}
+
+// RoomHasOpenTurnover: ห้องยังมีงาน checkout ที่ยังไม่ตรวจผ่าน (ห้ามตั้งเป็น Available เอง)
+func RoomHasOpenTurnover(db *gorm.DB, roomID uint) (bool, error) {
+	var n int64
+	err := db.Model(&models.HousekeepingTask{}).
+		Where("room_id = ? AND kind = ? AND status <> ?", roomID, models.HousekeepingCheckout, models.HousekeepingInspected).
+		Count(&n).Error
+	return n > 0, err
+}
+
+// OnCheckedOut: ห้องของ booking ที่เช็คเอาท์เป็น vacant + dirty และสร้างงาน checkout (ซ้ำไม่ได้ต่อ booking/ห้อง)
+// คืน event ของห้องและงานที่สร้างใหม่ ให้ผู้เรียก publish
+func (s *HousekeepingService) OnCheckedOut(bookingID uint, roomIDs []uint, at time.Time) ([]Event, error) {
+	if len(roomIDs) == 0 {
+		return nil, nil
+	}
+	var events []Event
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		roomEvents, err := applyRoomStatuses(tx, roomIDs, RoomStatusChange{
+			Occupancy: strPtr(models.RoomVacant),
+			Condition: strPtr(models.RoomDirty),
+			Source:    RoomSourceCheckout,
+			Reason:    fmt.Sprintf("booking #%d checked out", bookingID),
+		}, at)
+		if err != nil {
+			return err
+		}
+		events = append(events, roomEvents...)
+		for _, roomID := range roomIDs {
+			task := models.HousekeepingTask{
+				RoomID:    roomID,
+				BookingID: &bookingID,
+				Kind:      models.HousekeepingCheckout,
+				Status:    models.HousekeepingDirty,
+				TaskDate:  hotelToday(tx, at),
+				DedupKey:  dedupKey("checkout:%d:%d", bookingID, roomID),
+			}
+			res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&task)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected > 0 {
+				events = append(events, housekeepingChanged(task, at))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// setTurnoverCondition เปลี่ยน condition ของห้องเมื่อค่าปัจจุบันอยู่ใน from (ไม่แตะห้องที่ซ่อม/งดขาย)
+func setTurnoverCondition(tx *gorm.DB, roomID uint, from []string, to string, adminID *uint, reason string, now time.Time) ([]Event, error) {
+	var room models.Room
+	if err := tx.Select("id", "room_condition").First(&room, roomID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // ห้องถูกลบไปแล้ว
+		}
+		return nil, err
+	}
+	allowed := false
+	for _, c := range from {
+		if room.Condition == c {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return nil, nil
+	}
+	ev, err := ApplyRoomStatus(tx, roomID, RoomStatusChange{
+		Condition: &to,
+		Source:    RoomSourceHousekeeping,
+		Reason:    reason,
+		ChangedBy: adminID,
+	}, now)
+	if err != nil || ev == nil {
+		return nil, err
+	}
+	return []Event{*ev}, nil
+}
+
+// CreateStayoverTasks สร้างงานรายวันของห้องที่แขกพักต่อ (เช็คอินแล้ว และวันเช็คเอาท์ยังไม่ถึง) — เรียกซ้ำในวันเดียวกันได้
+func (s *HousekeepingService) CreateStayoverTasks(ctx context.Context, now time.Time) (int, error) {
+	var bookings []models.Booking
+	if err := s.DB.WithContext(ctx).Where("status = ?", "Checked-In").Find(&bookings).Error; err != nil {
+		return 0, err
+	}
+	// "วันนี้" ตามเวลาท้องถิ่นของโรงแรม
+	local := now.In(HotelLocation(s.DB))
+	today := taskDate(local)
+	created := 0
+	for _, b := range bookings {
+		if ctx.Err() != nil {
+			return created, ctx.Err()
+		}
+		_, departure := bookingStay(b)
+		if departure == nil || civilDays(local, *departure) <= 0 {
+			continue // ออกวันนี้ — ได้งาน checkout ตอนเช็คเอาท์แทน
+		}
+		roomIDs, err := bookingRoomIDs(s.DB, b.ID)
+		if err != nil {
+			return created, err
+		}
+		for _, roomID := range roomIDs {
+			bookingID := b.ID
+			task := models.HousekeepingTask{
+				RoomID:    roomID,
+				BookingID: &bookingID,
+				Kind:      models.HousekeepingStayover,
+				Status:    models.HousekeepingDirty,
+				TaskDate:  today,
+				DedupKey:  dedupKey("stayover:%d:%s", roomID, today.Format("2006-01-02")),
+			}
+			res := s.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&task)
+			if res.Error != nil {
+				return created, res.Error
+			}
+			if res.RowsAffected > 0 {
+				created++
+				PublishEvents(housekeepingChanged(task, now))
+			}
+		}
+	}
+	return created, nil
+}
+
+func housekeepingChanged(t models.HousekeepingTask, at time.Time) HousekeepingTaskChanged {
+	return HousekeepingTaskChanged{TaskID: t.ID, RoomID: t.RoomID, Kind: t.Kind, Status: t.Status, AssignedTo: t.AssignedTo, At: at}
+}
+
+// ------------------------------------------------------------
+// หน้า admin / แม่บ้าน
+// ------------------------------------------------------------
+
+// HousekeepingFilter: ตัวกรองรายการงาน
+type HousekeepingFilter struct {
+	Status     string
+	Kind       string
+	RoomID     uint
+	AssignedTo uint
+	Date       *time.Time
+	OpenOnly   bool // ยังไม่ inspected
+	Page       int
+	Limit      int
+}
+
+// List งานตามตัวกรอง (พร้อมข้อมูลห้อง) — งานค้างเก่าสุดก่อน
+func (s *HousekeepingService) List(f HousekeepingFilter) ([]models.HousekeepingTask, int64, error) {
+	if f.Limit <= 0 || f.Limit > 200 {
+		f.Limit = 100
+	}
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	q := s.DB.Model(&models.HousekeepingTask{})
+	if f.Status != "" {
+		q = q.Where("status = ?", f.Status)
+	}
+	if f.Kind != "" {
+		q = q.Where("kind = ?", f.Kind)
+	}
+	if f.RoomID != 0 {
+		q = q.Where("room_id = ?", f.RoomID)
+	}
+	if f.AssignedTo != 0 {
+		q = q.Where("assigned_to = ?", f.AssignedTo)
+	}
+	if f.Date != nil {
+		q = q.Where("task_date = ?", taskDate(*f.Date))
+	}
+	if f.OpenOnly {
+		q = q.Where("status <> ?", models.HousekeepingInspected)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []models.HousekeepingTask
+	err := q.Preload("Room").Order("task_date ASC, id ASC").Offset((f.Page - 1) * f.Limit).Limit(f.Limit).Find(&rows).Error
+	return rows, total, err
+}
+
+// Get งานตาม id
+func (s *HousekeepingService) Get(id uint) (models.HousekeepingTask, error) {
+	var task models.HousekeepingTask
+	if err := s.DB.Preload("Room").First(&task, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return task, errors.New("housekeeping_task_not_found")
+		}
+		return task, err
+	}
+	return task, nil
+}
+
+// HousekeepingTaskInput: หัวหน้าสร้างงานเอง
+type HousekeepingTaskInput struct {
+	RoomID     uint   `json:"roomId"`
+	Kind       string `json:"kind"` // checkout (ค่าเริ่มต้น — ห้องเป็น Cleaning จนตรวจผ่าน) | stayover
+	AssignedTo *uint  `json:"assignedTo"`
+	Notes      string `json:"notes"`
+}
+
+// Create สร้างงานเอง (เช่นห้องว่างที่ต้องทำความสะอาดซ้ำ)
+func (s *HousekeepingService) Create(in HousekeepingTaskInput, adminID uint) (models.HousekeepingTask, error) {
+	kind := strings.TrimSpace(in.Kind)
+	if kind == "" {
+		kind = models.HousekeepingCheckout
+	}
+	if kind != models.HousekeepingCheckout && kind != models.HousekeepingStayover {
+		return models.HousekeepingTask{}, errors.New("invalid_housekeeping_kind")
+	}
+	var room models.Room
+	if err := s.DB.Select("id").First(&room, in.RoomID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.HousekeepingTask{}, errors.New("room_not_found")
+		}
+		return models.HousekeepingTask{}, err
+	}
+	now := time.Now().UTC()
+	task := models.HousekeepingTask{
+		RoomID:    room.ID,
+		Kind:      kind,
+		Status:    models.HousekeepingDirty,
+		TaskDate:  hotelToday(s.DB, now),
+		Notes:     strings.TrimSpace(in.Notes),
+		CreatedBy: &adminID,
+	}
+	if in.AssignedTo != nil {
+		if err := s.ensureCleaner(*in.AssignedTo); err != nil {
+			return models.HousekeepingTask{}, err
+		}
+		task.AssignedTo, task.AssignedBy, task.AssignedAt = in.AssignedTo, &adminID, &now
+	}
+	var events []Event
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&task).Error; err != nil {
+			return err
+		}
+		events = append(events, housekeepingChanged(task, now))
+		if kind != models.HousekeepingCheckout {
+			return nil
+		}
+		roomEvents, err := setTurnoverCondition(tx, room.ID, []string{models.RoomClean, models.RoomInspected}, models.RoomDirty,
+			&adminID, fmt.Sprintf("housekeeping task #%d created", task.ID), now)
+		events = append(events, roomEvents...)
+		return err
+	})
+	if err != nil {
+		return models.HousekeepingTask{}, err
+	}
+	PublishEvents(events...)
+	return s.Get(task.ID)
+}
+
+// ensureCleaner: ผู้รับงานต้องเป็น admin ที่มีสิทธิ์ housekeeping.work
+func (s *HousekeepingService) ensureCleaner(adminID uint) error {
+	ok, err := AdminHasPermission(s.DB, adminID, "housekeeping.work")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("housekeeping_assignee_invalid")
+	}
+	return nil
+}
+
+// update: ล็อกงานแล้วให้ fn เปลี่ยนค่า (fn คืน error เมื่อเปลี่ยนสถานะไม่ได้)
+func (s *HousekeepingService) update(id uint, fn func(tx *gorm.DB, task *models.HousekeepingTask, now time.Time) ([]Event, error)) (models.HousekeepingTask, error) {
+	var task models.HousekeepingTask
+	var events []Event
+	now := time.Now().UTC()
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&task, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("housekeeping_task_not_found")
+			}
+			return err
+		}
+		extra, err := fn(tx, &task, now)
+		if err != nil {
+			return err
+		}
+		events = append([]Event{housekeepingChanged(task, now)}, extra...)
+		return tx.Omit(clause.Associations).Save(&task).Error
+	})
+	if err != nil {
+		return models.HousekeepingTask{}, err
+	}
+	PublishEvents(events...)
+	return s.Get(task.ID)
+}
+
+func invalidTransition(task *models.HousekeepingTask) error {
+	return errors.New("housekeeping_invalid_transition: " + task.Status)
+}
+
+// Assign มอบหมายงาน (assigneeID = nil คืนงานเข้ากอง)
+func (s *HousekeepingService) Assign(id uint, assigneeID *uint, adminID uint) (models.HousekeepingTask, error) {
+	if assigneeID != nil {
+		if err := s.ensureCleaner(*assigneeID); err != nil {
+			return models.HousekeepingTask{}, err
+		}
+	}
+	return s.update(id, func(tx *gorm.DB, task *models.HousekeepingTask, now time.Time) ([]Event, error) {
+		if task.Status == models.HousekeepingInspected {
+			return nil, invalidTransition(task)
+		}
+		task.AssignedTo, task.AssignedBy, task.AssignedAt = assigneeID, &adminID, &now
+		if assigneeID == nil {
+			task.AssignedAt = nil
+		}
+		return nil, nil
+	})
+}
+
+// canWork: แม่บ้านทำได้เฉพาะงานของตัวเอง (หรืองานที่ยังไม่มีคนรับ — รับเองอัตโนมัติ) เว้นแต่มีสิทธิ์ housekeeping.assign
+func (s *HousekeepingService) canWork(task *models.HousekeepingTask, adminID uint) error {
+	if task.AssignedTo == nil || *task.AssignedTo == adminID {
+		return nil
+	}
+	ok, err := AdminHasPermission(s.DB, adminID, "housekeeping.assign")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("housekeeping_not_assignee")
+	}
+	return nil
+}
+
+// Start dirty -> in_progress
+func (s *HousekeepingService) Start(id, adminID uint) (models.HousekeepingTask, error) {
+	return s.update(id, func(tx *gorm.DB, task *models.HousekeepingTask, now time.Time) ([]Event, error) {
+		if task.Status != models.HousekeepingDirty {
+			return nil, invalidTransition(task)
+		}
+		if err := s.canWork(task, adminID); err != nil {
+			return nil, err
+		}
+		if task.AssignedTo == nil {
+			task.AssignedTo, task.AssignedBy, task.AssignedAt = &adminID, &adminID, &now
+		}
+		task.Status = models.HousekeepingInProgress
+		task.StartedAt = &now
+		return nil, nil
+	})
+}
+
+// Finish in_progress -> clean (notes = สิ่งที่พบ เช่นของเสียหาย)
+func (s *HousekeepingService) Finish(id, adminID uint, notes string) (models.HousekeepingTask, error) {
+	return s.update(id, func(tx *gorm.DB, task *models.HousekeepingTask, now time.Time) ([]Event, error) {
+		if task.Status != models.HousekeepingInProgress {
+			return nil, invalidTransition(task)
+		}
+		if err := s.canWork(task, adminID); err != nil {
+			return nil, err
+		}
+		task.Status = models.HousekeepingClean
+		task.CleanedAt, task.CleanedBy = &now, &adminID
+		if notes = strings.TrimSpace(notes); notes != "" {
+			task.Notes = notes
+		}
+		if task.Kind != models.HousekeepingCheckout {
+			return nil, nil
+		}
+		return setTurnoverCondition(tx, task.RoomID, []string{models.RoomDirty}, models.RoomClean,
+			&adminID, fmt.Sprintf("housekeeping task #%d cleaned", task.ID), now)
+	})
+}
+
+// Inspect clean -> inspected (passed) หรือกลับเป็น dirty พร้อมเหตุผล
+// งาน checkout ที่ตรวจผ่านเป็นงานสุดท้ายของห้อง -> ห้อง Available + รหัสเข้าห้องใหม่
+func (s *HousekeepingService) Inspect(id, adminID uint, passed bool, note string) (models.HousekeepingTask, error) {
+	note = strings.TrimSpace(note)
+	if !passed && note == "" {
+		return models.HousekeepingTask{}, errors.New("inspection_note_required")
+	}
+	return s.update(id, func(tx *gorm.DB, task *models.HousekeepingTask, now time.Time) ([]Event, error) {
+		if task.Status != models.HousekeepingClean {
+			return nil, invalidTransition(task)
+		}
+		task.InspectionNote = note
+		if !passed {
+			task.Status = models.HousekeepingDirty
+			task.StartedAt, task.CleanedAt, task.CleanedBy = nil, nil, nil
+			if task.Kind != models.HousekeepingCheckout {
+				return nil, nil
+			}
+			return setTurnoverCondition(tx, task.RoomID, []string{models.RoomClean}, models.RoomDirty,
+				&adminID, fmt.Sprintf("housekeeping task #%d failed inspection: %s", task.ID, note), now)
+		}
+		task.Status = models.HousekeepingInspected
+		task.InspectedAt, task.InspectedBy = &now, &adminID
+		if task.Kind != models.HousekeepingCheckout {
+			return nil, nil
+		}
+		return s.releaseRoom(tx, task, adminID, now)
+	})
+}
+
+// releaseRoom: ไม่มีงาน checkout ค้างแล้ว -> ห้อง inspected (vacant = Available พร้อมรหัสเข้าห้องใหม่)
+func (s *HousekeepingService) releaseRoom(tx *gorm.DB, task *models.HousekeepingTask, adminID uint, now time.Time) ([]Event, error) {
+	var open int64
+	if err := tx.Model(&models.HousekeepingTask{}).
+		Where("room_id = ? AND kind = ? AND status <> ? AND id <> ?", task.RoomID, models.HousekeepingCheckout, models.HousekeepingInspected, task.ID).
+		Count(&open).Error; err != nil {
+		return nil, err
+	}
+	if open > 0 {
+		return nil, nil
+	}
+	events, err := setTurnoverCondition(tx, task.RoomID, []string{models.RoomDirty, models.RoomClean}, models.RoomInspected,
+		&adminID, fmt.Sprintf("housekeeping task #%d inspected", task.ID), now)
+	if err == nil && len(events) > 0 {
+		log.Printf("housekeeping: room %d inspected after task %d", task.RoomID, task.ID)
+	}
+	return events, err
+}
+
+// Cleaners admin ที่รับงานได้ (มีสิทธิ์ housekeeping.work) สำหรับ dropdown มอบหมายงาน
+func (s *HousekeepingService) Cleaners() ([]models.Admin, error) {
+	var admins []models.Admin
+	withPerm := s.DB.Model(&models.RoleMember{}).Select("role_members.admin_id").
+		Joins("JOIN role_permissions ON role_permissions.role_id = role_members.role_id").
+		Where("role_permissions.permission = ?", "housekeeping.work")
+	err := s.DB.Where("id IN (?)", withPerm).Order("full_name").Find(&admins).Error
+	return admins, err
+}
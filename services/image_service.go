@@ -1,12 +1,12 @@
 package services
 
 import (
-	"encoding/base64"
+	"context"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
-	"time"
+
+	"hotel-backend/utils"
 )
 
 func resolveUploadsDir() string {
@@ -17,29 +17,37 @@ func resolveUploadsDir() string {
 	return dir
 }
 
+// SaveBase64Image บันทึกรูป (raw base64 หรือ data URI) ลง BlobStore ใต้ subdir
+// นามสกุลไฟล์มาจาก content-type ที่ตรวจจากเนื้อไฟล์ และคืน key สำหรับเก็บลง DB เช่น "faces/xxx.png"
 func SaveBase64Image(b64 string, subdir string) (string, error) {
-	if idx := strings.Index(b64, "base64,"); idx >= 0 {
-		b64 = b64[idx+7:]
+	data, err := utils.DecodeBase64Image(b64)
+	if err != nil {
+		return "", err
 	}
 
-	data, err := base64.StdEncoding.DecodeString(b64)
+	contentType, ext, err := DetectImageType(data)
 	if err != nil {
-		return "", fmt.Errorf("decode base64: %w", err)
+		return "", err
 	}
 
-	baseDir := resolveUploadsDir()
-	dir := filepath.Join(baseDir, subdir)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return "", fmt.Errorf("mkdir uploads dir: %w", err)
+	// ชื่อไฟล์สุ่ม (เดาไม่ได้) แทน timestamp
+	name, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		return "", fmt.Errorf("generate file name: %w", err)
 	}
+	key := strings.Trim(subdir, "/") + "/" + name + ext
 
-	filename := fmt.Sprintf("%d.jpg", time.Now().UnixNano())
-	fullpath := filepath.Join(dir, filename)
-
-	if err := os.WriteFile(fullpath, data, 0644); err != nil {
-		return "", fmt.Errorf("write file: %w", err)
+	if err := DefaultBlobStore().Put(context.Background(), key, data, contentType); err != nil {
+		return "", fmt.Errorf("store image: %w", err)
 	}
+	return key, nil
+}
 
-	// เก็บลง DB เป็น "faces/xxx.jpg"
-	return filepath.ToSlash(filepath.Join(subdir, filename)), nil
+// DeleteStoredImage ลบไฟล์ตาม key ที่เก็บใน DB (ไม่ error ถ้าไม่มีไฟล์แล้ว)
+func DeleteStoredImage(stored string) error {
+	key, err := normalizeBlobKey(stored)
+	if err != nil {
+		return nil
+	}
+	return DefaultBlobStore().Delete(context.Background(), key)
 }
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Live stream: ส่ง event ให้หน้า dashboard ของ admin ทันทีผ่าน SSE (แทนการ refresh หน้า bookings)
+// ป้อนจาก domain event bus หลัง commit — admin เห็นเฉพาะ event ที่ role มี permission
+
+// ชนิดของ event ใน stream
+const (
+	LiveBookingCreated    = "booking.created"
+	LiveBookingDeleted    = "booking.deleted"
+	LiveCheckinCompleted  = "checkin.completed"
+	LiveCheckoutCompleted = "checkout.completed"
+	LiveGuestAdded        = "guest.added"
+	LiveRoomStatusChanged = "room.status_changed"
+	LiveHousekeeping      = "housekeeping.updated"
+	LiveWorkOrder         = "maintenance.updated"
+)
+
+// liveEventPermissions: permission ที่ต้องมีเพื่อรับ event แต่ละชนิด
+var liveEventPermissions = map[string]string{
+	LiveBookingCreated:    "bookingManagement.view",
+	LiveBookingDeleted:    "bookingManagement.view",
+	LiveCheckinCompleted:  "bookingManagement.view",
+	LiveCheckoutCompleted: "bookingManagement.view",
+	LiveGuestAdded:        "guestDocuments.view",
+	LiveRoomStatusChanged: "roomManagement.view",
+	LiveHousekeeping:      "housekeeping.view",
+	LiveWorkOrder:         "maintenance.view",
+}
+
+// LiveStreamAllowed: admin มี permission รับ event อย่างน้อยหนึ่งชนิดหรือไม่
+func LiveStreamAllowed(perms []string) bool {
+	for _, p := range perms {
+		for _, need := range liveEventPermissions {
+			if p == need {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+const (
+	liveBacklogSize    = 200 // เก็บไว้ให้ client ที่หลุดแล้วต่อใหม่ (Last-Event-ID)
+	liveSubscriberBuf  = 64
+	LiveStreamURLTTL   = time.Minute // อายุของ signed URL (ใช้ตอนเปิด stream เท่านั้น)
+	liveStreamSignKind = "live-stream"
+)
+
+// LiveMessage: event หนึ่งรายการใน stream (ID เรียงต่อกันภายใน process)
+type LiveMessage struct {
+	ID         uint64      `json:"id"`
+	Type       string      `json:"type"`
+	Data       interface{} `json:"data"`
+	At         time.Time   `json:"at"`
+	permission string
+}
+
+// LiveSubscriber: การเชื่อมต่อหนึ่งของ admin — C ถูกปิดเมื่อ client อ่านไม่ทัน หรือ server ปิด
+type LiveSubscriber struct {
+	C     chan LiveMessage
+	perms map[string]bool
+}
+
+func (s *LiveSubscriber) allowed(m LiveMessage) bool {
+	return s.perms[m.permission]
+}
+
+// LiveHub: กระจาย event ให้ทุก subscriber (in-process)
+type LiveHub struct {
+	mu     sync.Mutex
+	seq    uint64
+	subs   map[*LiveSubscriber]struct{}
+	recent []LiveMessage
+	closed bool
+}
+
+func NewLiveHub() *LiveHub {
+	return &LiveHub{subs: map[*LiveSubscriber]struct{}{}}
+}
+
+func permSet(perms []string) map[string]bool {
+	set := make(map[string]bool, len(perms))
+	for _, p := range perms {
+		set[p] = true
+	}
+	return set
+}
+
+// Subscribe เปิด subscriber ใหม่ พร้อม event ที่พลาดไปหลัง lastID (กรองตาม permission แล้ว)
+func (h *LiveHub) Subscribe(perms []string, lastID uint64) (*LiveSubscriber, []LiveMessage) {
+	sub := &LiveSubscriber{C: make(chan LiveMessage, liveSubscriberBuf), perms: permSet(perms)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(sub.C)
+		return sub, nil
+	}
+	var missed []LiveMessage
+	if lastID > 0 {
+		for _, m := range h.recent {
+			if m.ID > lastID && sub.allowed(m) {
+				missed = append(missed, m)
+			}
+		}
+	}
+	h.subs[sub] = struct{}{}
+	return sub, missed
+}
+
+// Unsubscribe ปิด subscriber (เรียกซ้ำได้)
+func (h *LiveHub) Unsubscribe(sub *LiveSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.C)
+	}
+}
+
+// SetPermissions เปลี่ยน permission ของ subscriber (role ถูกแก้ระหว่างเชื่อมต่ออยู่)
+func (h *LiveHub) SetPermissions(sub *LiveSubscriber, perms []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sub.perms = permSet(perms)
+}
+
+// Broadcast ส่ง event ให้ subscriber ที่มีสิทธิ์ — subscriber ที่ buffer เต็มจะถูกตัด (client ต่อใหม่พร้อม Last-Event-ID)
+func (h *LiveHub) Broadcast(eventType string, data interface{}) LiveMessage {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seq++
+	m := LiveMessage{ID: h.seq, Type: eventType, Data: data, At: time.Now().UTC(), permission: liveEventPermissions[eventType]}
+	h.recent = append(h.recent, m)
+	if len(h.recent) > liveBacklogSize {
+		h.recent = h.recent[len(h.recent)-liveBacklogSize:]
+	}
+	for sub := range h.subs {
+		if !sub.allowed(m) {
+			continue
+		}
+		select {
+		case sub.C <- m:
+		default:
+			delete(h.subs, sub)
+			close(sub.C)
+		}
+	}
+	return m
+}
+
+// Close ปิดทุก stream (ตอน shutdown — ไม่ให้ srv.Shutdown รอการเชื่อมต่อที่ค้างอยู่)
+func (h *LiveHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subs {
+		delete(h.subs, sub)
+		close(sub.C)
+	}
+}
+
+var defaultLiveHub = NewLiveHub()
+
+// DefaultLiveHub hub ของทั้ง process
+func DefaultLiveHub() *LiveHub {
+	return defaultLiveHub
+}
+
+// liveEventHandler แปลง domain event เป็น event ของ stream (ข้อมูลเท่าที่หน้าจอต้อง refresh — ไม่มีข้อมูลส่วนบุคคล)
+func liveEventHandler(hub *LiveHub) EventHandler {
+	return func(ctx context.Context, e Event) error {
+		switch ev := e.(type) {
+		case BookingCreated:
+			hub.Broadcast(LiveBookingCreated, map[string]interface{}{"bookingId": ev.BookingID, "roomIds": ev.RoomIDs})
+		case BookingDeleted:
+			hub.Broadcast(LiveBookingDeleted, map[string]interface{}{"bookingId": ev.BookingID, "referenceCode": ev.ReferenceCode})
+		case CheckInCompleted:
+			hub.Broadcast(LiveCheckinCompleted, map[string]interface{}{"bookingId": ev.BookingID, "roomIds": ev.RoomIDs, "reviewed": ev.Reviewed})
+		case CheckedOut:
+			hub.Broadcast(LiveCheckoutCompleted, map[string]interface{}{"bookingId": ev.BookingID, "roomIds": ev.RoomIDs, "auto": ev.Auto})
+		case GuestAdded:
+			hub.Broadcast(LiveGuestAdded, map[string]interface{}{"guestId": ev.GuestID, "bookingId": ev.BookingID, "reviewStatus": ev.ReviewStatus})
+		case RoomStatusChanged:
+			hub.Broadcast(LiveRoomStatusChanged, map[string]interface{}{"roomIds": ev.RoomIDs, "status": ev.Status, "occupancy": ev.Occupancy, "condition": ev.Condition})
+		case HousekeepingTaskChanged:
+			hub.Broadcast(LiveHousekeeping, ev)
+		case WorkOrderChanged:
+			hub.Broadcast(LiveWorkOrder, ev)
+		}
+		return nil
+	}
+}
+
+// ------------------------------------------------------------
+// Signed URL สำหรับ EventSource (ส่ง Authorization header ไม่ได้)
+// ------------------------------------------------------------
+
+func signLiveStream(adminID, sessionID uint, exp int64) string {
+	mac := hmac.New(sha256.New, documentURLSecret())
+	fmt.Fprintf(mac, "%s|%d|%d|%d", liveStreamSignKind, adminID, sessionID, exp)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// LiveStreamLink: URL สำหรับเปิด stream
+type LiveStreamLink struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// LiveStreamURL ออก signed URL ของ stream ให้ admin (ใช้เปิดการเชื่อมต่อภายใน LiveStreamURLTTL)
+// ผูกกับ session ที่ขอ (sid) — stream ปิดเมื่อ session นั้น logout/หมดอายุ
+func LiveStreamURL(adminID, sessionID uint) LiveStreamLink {
+	expiresAt := time.Now().UTC().Add(LiveStreamURLTTL)
+	exp := expiresAt.Unix()
+	q := url.Values{}
+	q.Set("aid", strconv.FormatUint(uint64(adminID), 10))
+	q.Set("sid", strconv.FormatUint(uint64(sessionID), 10))
+	q.Set("exp", strconv.FormatInt(exp, 10))
+	q.Set("sig", signLiveStream(adminID, sessionID, exp))
+	return LiveStreamLink{URL: "/api/live/stream?" + q.Encode(), ExpiresAt: expiresAt}
+}
+
+// VerifyLiveStreamURL ตรวจลายเซ็น/อายุของ URL
+func VerifyLiveStreamURL(adminID, sessionID uint, exp int64, sig string) error {
+	if time.Now().Unix() > exp {
+		return errors.New("link_invalid_or_expired")
+	}
+	if !hmac.Equal([]byte(sig), []byte(signLiveStream(adminID, sessionID, exp))) {
+		return errors.New("link_invalid_or_expired")
+	}
+	return nil
+}
@@ -0,0 +1,376 @@
+package services
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Envelope encryption สำหรับข้อมูลส่วนบุคคลของแขก (PDPA)
+//
+// ทุกค่าถูกเข้ารหัสด้วย data key (DEK) สุ่มใหม่ต่อค่า แล้ว DEK ถูกห่อ (wrap) ด้วย master key (KEK) จาก config:
+//
+//	PII_ENCRYPTION_KEYS = "k2:<base64 32 bytes>,k1:<base64 32 bytes>"
+//	PII_ACTIVE_KEY_ID   = "k2"   (ไม่ตั้ง = key แรกในรายการ)
+//	PII_BLIND_INDEX_KEY = "<base64 32 bytes>"  (สำหรับค้นหาเลขบัตรโดยไม่ต้องถอดรหัส)
+//
+// หมุน key: เพิ่ม key ใหม่เป็น active โดยคง key เก่าไว้ แล้ว restart — RotateGuestPII จะ re-wrap DEK
+// ของข้อมูลเดิมให้ใช้ key ใหม่ (ตัวข้อมูลไม่ต้องเข้ารหัสใหม่) หลังจากนั้นจึงลบ key เก่าออกได้
+//
+// รูปแบบ envelope (binary):
+//
+//	"PII1" | len(kid) u8 | kid | len(wrapped) u16 | wrapped DEK (nonce|ct) | nonce | ciphertext
+//
+// ค่าในคอลัมน์ DB เก็บเป็น "enc1:" + base64(envelope); ค่าที่ไม่มี prefix ถือเป็น plaintext เก่า
+const (
+	piiFieldPrefix = "enc1:"
+	piiKeySize     = 32
+)
+
+var piiMagic = []byte("PII1")
+
+type piiKeyring struct {
+	activeID string
+	keys     map[string][]byte
+	indexKey []byte
+}
+
+var (
+	piiOnce sync.Once
+	piiRing *piiKeyring
+)
+
+func decodePIIKey(raw string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != piiKeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", piiKeySize, len(key))
+	}
+	return key, nil
+}
+
+func loadPIIKeyring() (*piiKeyring, error) {
+	raw := strings.TrimSpace(os.Getenv("PII_ENCRYPTION_KEYS"))
+	if raw == "" {
+		return nil, nil
+	}
+
+	ring := &piiKeyring{keys: map[string][]byte{}}
+	firstID := ""
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, ":", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" || len(kv[0]) > 255 {
+			return nil, fmt.Errorf("PII_ENCRYPTION_KEYS: entry must be <id>:<base64 key>")
+		}
+		id := strings.TrimSpace(kv[0])
+		key, err := decodePIIKey(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("PII_ENCRYPTION_KEYS: key %q: %w", id, err)
+		}
+		ring.keys[id] = key
+		if firstID == "" {
+			firstID = id
+		}
+	}
+	if len(ring.keys) == 0 {
+		return nil, nil
+	}
+
+	ring.activeID = strings.TrimSpace(os.Getenv("PII_ACTIVE_KEY_ID"))
+	if ring.activeID == "" {
+		ring.activeID = firstID
+	}
+	if _, ok := ring.keys[ring.activeID]; !ok {
+		return nil, fmt.Errorf("PII_ACTIVE_KEY_ID %q not found in PII_ENCRYPTION_KEYS", ring.activeID)
+	}
+
+	indexKey, err := decodePIIKey(os.Getenv("PII_BLIND_INDEX_KEY"))
+	if err != nil {
+		return nil, fmt.Errorf("PII_BLIND_INDEX_KEY: %w", err)
+	}
+	ring.indexKey = indexKey
+	return ring, nil
+}
+
+// piiKeys คืน keyring (nil = ยังไม่ได้ตั้งค่า key -> เก็บแบบ plaintext)
+func piiKeys() *piiKeyring {
+	piiOnce.Do(func() {
+		ring, err := loadPIIKeyring()
+		if err != nil {
+			log.Fatalf("❌ invalid PII encryption config: %v", err)
+		}
+		if ring == nil {
+			log.Println("⚠️ PII_ENCRYPTION_KEYS not set; guest PII will be stored unencrypted")
+		}
+		piiRing = ring
+	})
+	return piiRing
+}
+
+// PIIEncryptionEnabled: ตั้งค่า key สำหรับเข้ารหัสข้อมูลแขกแล้วหรือยัง
+func PIIEncryptionEnabled() bool {
+	return piiKeys() != nil
+}
+
+// piiActiveKeyID: id ของ master key ที่ใช้เข้ารหัสข้อมูลใหม่ ("" = ไม่เข้ารหัส)
+func piiActiveKeyID() string {
+	if ring := piiKeys(); ring != nil {
+		return ring.activeID
+	}
+	return ""
+}
+
+func gcmSeal(key, plain []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plain, nil), nil
+}
+
+func gcmOpen(key, sealed []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("pii_ciphertext_too_short")
+	}
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+}
+
+type piiEnvelope struct {
+	kid     string
+	wrapped []byte
+	data    []byte // nonce|ciphertext ของข้อมูล (เข้ารหัสด้วย DEK)
+}
+
+func (e piiEnvelope) marshal() []byte {
+	var buf bytes.Buffer
+	buf.Write(piiMagic)
+	buf.WriteByte(byte(len(e.kid)))
+	buf.WriteString(e.kid)
+	binary.Write(&buf, binary.BigEndian, uint16(len(e.wrapped)))
+	buf.Write(e.wrapped)
+	buf.Write(e.data)
+	return buf.Bytes()
+}
+
+func parsePIIEnvelope(b []byte) (piiEnvelope, error) {
+	bad := errors.New("pii_invalid_envelope")
+	if !IsPIIEnvelope(b) {
+		return piiEnvelope{}, bad
+	}
+	r := bytes.NewReader(b[len(piiMagic):])
+
+	kidLen, err := r.ReadByte()
+	if err != nil {
+		return piiEnvelope{}, bad
+	}
+	kid := make([]byte, kidLen)
+	if _, err := io.ReadFull(r, kid); err != nil {
+		return piiEnvelope{}, bad
+	}
+	var wrappedLen uint16
+	if err := binary.Read(r, binary.BigEndian, &wrappedLen); err != nil {
+		return piiEnvelope{}, bad
+	}
+	wrapped := make([]byte, wrappedLen)
+	if _, err := io.ReadFull(r, wrapped); err != nil {
+		return piiEnvelope{}, bad
+	}
+	data, _ := io.ReadAll(r)
+	return piiEnvelope{kid: string(kid), wrapped: wrapped, data: data}, nil
+}
+
+// IsPIIEnvelope: bytes นี้เป็นข้อมูลที่เข้ารหัสแล้วหรือไม่ (ใช้แยกไฟล์รูปเก่าที่ยังไม่เข้ารหัส)
+func IsPIIEnvelope(b []byte) bool {
+	return bytes.HasPrefix(b, piiMagic)
+}
+
+// EncryptPII เข้ารหัส bytes ด้วย DEK ใหม่ที่ห่อด้วย active key
+// ถ้าไม่ได้ตั้งค่า key จะคืนข้อมูลเดิม
+func EncryptPII(plain []byte) ([]byte, error) {
+	ring := piiKeys()
+	if ring == nil {
+		return plain, nil
+	}
+
+	dek := make([]byte, piiKeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, err
+	}
+	data, err := gcmSeal(dek, plain)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := gcmSeal(ring.keys[ring.activeID], dek)
+	if err != nil {
+		return nil, err
+	}
+	return piiEnvelope{kid: ring.activeID, wrapped: wrapped, data: data}.marshal(), nil
+}
+
+func (ring *piiKeyring) unwrap(env piiEnvelope) ([]byte, error) {
+	kek, ok := ring.keys[env.kid]
+	if !ok {
+		return nil, fmt.Errorf("pii_unknown_key: %s", env.kid)
+	}
+	return gcmOpen(kek, env.wrapped)
+}
+
+// DecryptPII ถอดรหัส envelope (ข้อมูลที่ไม่ใช่ envelope คืนค่าเดิม)
+func DecryptPII(b []byte) ([]byte, error) {
+	if !IsPIIEnvelope(b) {
+		return b, nil
+	}
+	ring := piiKeys()
+	if ring == nil {
+		return nil, errors.New("pii_key_not_configured")
+	}
+	env, err := parsePIIEnvelope(b)
+	if err != nil {
+		return nil, err
+	}
+	dek, err := ring.unwrap(env)
+	if err != nil {
+		return nil, err
+	}
+	return gcmOpen(dek, env.data)
+}
+
+// rewrapPII: ห่อ DEK ใหม่ด้วย active key (ไม่แตะตัวข้อมูล)
+// ข้อมูล plaintext เก่าจะถูกเข้ารหัส; changed = false ถ้าใช้ active key อยู่แล้ว
+func rewrapPII(b []byte) (out []byte, changed bool, err error) {
+	ring := piiKeys()
+	if ring == nil {
+		return b, false, nil
+	}
+	if !IsPIIEnvelope(b) {
+		out, err := EncryptPII(b)
+		return out, err == nil, err
+	}
+
+	env, err := parsePIIEnvelope(b)
+	if err != nil {
+		return nil, false, err
+	}
+	if env.kid == ring.activeID {
+		return b, false, nil
+	}
+	dek, err := ring.unwrap(env)
+	if err != nil {
+		return nil, false, err
+	}
+	wrapped, err := gcmSeal(ring.keys[ring.activeID], dek)
+	if err != nil {
+		return nil, false, err
+	}
+	env.kid = ring.activeID
+	env.wrapped = wrapped
+	return env.marshal(), true, nil
+}
+
+// EncryptPIIString เข้ารหัสค่าคอลัมน์ (ค่าว่าง/ค่าที่เข้ารหัสแล้วคืนตามเดิม)
+func EncryptPIIString(s string) (string, error) {
+	if s == "" || strings.HasPrefix(s, piiFieldPrefix) || !PIIEncryptionEnabled() {
+		return s, nil
+	}
+	env, err := EncryptPII([]byte(s))
+	if err != nil {
+		return "", err
+	}
+	return piiFieldPrefix + base64.StdEncoding.EncodeToString(env), nil
+}
+
+// DecryptPIIString ถอดรหัสค่าคอลัมน์ (ค่า plaintext เก่าคืนตามเดิม)
+func DecryptPIIString(s string) (string, error) {
+	if !strings.HasPrefix(s, piiFieldPrefix) {
+		return s, nil
+	}
+	env, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(s, piiFieldPrefix))
+	if err != nil {
+		return "", errors.New("pii_invalid_envelope")
+	}
+	plain, err := DecryptPII(env)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// rewrapPIIString: เวอร์ชันคอลัมน์ของ rewrapPII
+func rewrapPIIString(s string) (string, bool, error) {
+	if s == "" || !PIIEncryptionEnabled() {
+		return s, false, nil
+	}
+	if !strings.HasPrefix(s, piiFieldPrefix) {
+		out, err := EncryptPIIString(s)
+		return out, err == nil, err
+	}
+	env, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(s, piiFieldPrefix))
+	if err != nil {
+		return "", false, errors.New("pii_invalid_envelope")
+	}
+	out, changed, err := rewrapPII(env)
+	if err != nil || !changed {
+		return s, false, err
+	}
+	return piiFieldPrefix + base64.StdEncoding.EncodeToString(out), true, nil
+}
+
+// normalizeIDNumber: ตัดช่องว่าง/ขีด และทำเป็นตัวพิมพ์ใหญ่ ให้ค้นหาได้ไม่ว่าจะพิมพ์รูปแบบไหน
+func normalizeIDNumber(idNumber string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(idNumber) {
+		if r == ' ' || r == '-' || r == '\t' {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// IDNumberBlindIndex: HMAC ของเลขบัตร สำหรับค้นหาแบบตรงตัวโดยไม่ต้องถอดรหัส
+// คืนค่าว่างถ้าไม่ได้ตั้งค่า key
+func IDNumberBlindIndex(idNumber string) string {
+	ring := piiKeys()
+	n := normalizeIDNumber(idNumber)
+	if ring == nil || n == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, ring.indexKey)
+	mac.Write([]byte(n))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"strings"
+	"time"
+
+	"hotel-backend/models"
+	"hotel-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// DocumentKindRegistrationCard: ใบลงทะเบียนผู้เข้าพัก (PDF) — log การเปิดดูเหมือนรูปเอกสาร
+const DocumentKindRegistrationCard = "registration_card"
+
+// RegistrationCard สร้างใบลงทะเบียนผู้เข้าพัก (ข้อมูล booking + แขกทุกคน + ลายเซ็นแขกหลัก) สำหรับแฟ้มหน้าเคาน์เตอร์
+// ทุกครั้งที่เปิดจะ log ใน DocumentAccessLog ของแขกทุกคนใน booking
+func (s *DocumentAccessService) RegistrationCard(ctx context.Context, bookingID, adminID uint, ip, userAgent string) ([]byte, error) {
+	var booking models.Booking
+	if err := s.DB.Preload("Customer").Preload("Rooms.Room").Preload("Room").First(&booking, bookingID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("booking_not_found")
+		}
+		return nil, err
+	}
+
+	var hotel models.HotelSetting
+	if err := s.DB.Order("id ASC").First(&hotel).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var guests []models.Guest
+	if err := s.DB.Where("booking_id = ?", bookingID).Order("is_main_guest DESC, id ASC").Find(&guests).Error; err != nil {
+		return nil, err
+	}
+	if len(guests) == 0 {
+		return nil, errors.New("guest_not_found")
+	}
+	if err := openGuests(guests); err != nil {
+		return nil, err
+	}
+
+	sig, err := LatestGuestSignature(s.DB, bookingID)
+	if err != nil && !strings.Contains(err.Error(), "signature_not_found") {
+		return nil, err
+	}
+	var card registrationCardSignature
+	if err == nil {
+		card.SignedAt = &sig.SignedAt
+		card.Image, card.Strokes, err = OpenGuestSignature(ctx, s.Blobs, sig)
+		if err != nil {
+			// ใบลงทะเบียนยังพิมพ์ได้ แต่ช่องลายเซ็นจะว่าง
+			log.Printf("registration card: open signature %d (booking=%d): %v", sig.ID, bookingID, err)
+			card = registrationCardSignature{Problem: err.Error()}
+		}
+	}
+
+	pdf, err := renderRegistrationCard(hotel, booking, guests, card, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, g := range guests {
+		s.logAccess(g.ID, adminID, DocumentKindRegistrationCard, "view", ip, userAgent)
+	}
+	return pdf, nil
+}
+
+type registrationCardSignature struct {
+	Image    image.Image
+	Strokes  *SignatureStrokes
+	SignedAt *time.Time
+	Problem  string
+}
+
+func registrationRoomNumbers(b models.Booking) string {
+	pick := func(r models.Room) string {
+		if strings.TrimSpace(r.RoomCode) != "" {
+			return strings.TrimSpace(r.RoomCode)
+		}
+		return strings.TrimSpace(r.RoomNumber)
+	}
+	var rooms []string
+	for _, br := range b.Rooms {
+		if br.Room.ID != 0 {
+			rooms = append(rooms, pick(br.Room))
+		}
+	}
+	if len(rooms) == 0 && b.Room.ID != 0 {
+		rooms = append(rooms, pick(b.Room))
+	}
+	if len(rooms) == 0 {
+		return "-"
+	}
+	return strings.Join(rooms, ", ")
+}
+
+func orDash(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "-"
+	}
+	return strings.TrimSpace(s)
+}
+
+// renderRegistrationCard วาดใบลงทะเบียนผู้เข้าพัก (A4) — ภาษาไทยต้องตั้ง PDF_FONT_PATH (ไม่มี font = ไม่ออกใบ)
+func renderRegistrationCard(hotel models.HotelSetting, booking models.Booking, guests []models.Guest, sig registrationCardSignature, now time.Time) ([]byte, error) {
+	doc, err := utils.NewPDFDocumentFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	const (
+		left   = 50.0
+		right  = utils.PDFPageWidth - 50
+		bottom = utils.PDFPageHeight - 60
+	)
+	width := right - left
+	y := 60.0
+
+	ensure := func(h float64) {
+		if y+h > bottom {
+			doc.AddPage()
+			y = 60
+		}
+	}
+	text := func(x, size float64, s string, maxWidth float64) {
+		for _, line := range doc.WrapText(s, size, maxWidth) {
+			ensure(size + 4)
+			doc.Text(x, y, size, line)
+			y += size + 4
+		}
+	}
+	field := func(x, labelWidth, valueWidth float64, label, value string) float64 {
+		top := y
+		doc.Text(x, y, 8, label)
+		y = top
+		text(x+labelWidth, 10, orDash(value), valueWidth)
+		return y
+	}
+
+	doc.AddPage()
+	text(left, 16, orDash(hotel.Name), width)
+	for _, s := range []string{hotel.Address, strings.Join(nonEmpty(hotel.Phone, hotel.Email, hotel.Website), "  |  ")} {
+		if strings.TrimSpace(s) != "" {
+			text(left, 9, s, width)
+		}
+	}
+	y += 6
+	doc.Line(left, y, right, y, 1)
+	y += 22
+	text(left, 14, "Guest Registration Card / ใบลงทะเบียนผู้เข้าพัก", width)
+	y += 6
+
+	half := width / 2
+	rowTop := y
+	ref := booking.ReferenceCode
+	if ref == "" {
+		ref = fmt.Sprintf("#%d", booking.ID)
+	}
+	yl := field(left, 80, half-90, "Booking ref.", ref)
+	y = rowTop
+	yr := field(left+half, 80, half-80, "Room", registrationRoomNumbers(booking))
+	y = maxFloat(yl, yr)
+
+	rowTop = y
+	yl = field(left, 80, half-90, "Arrival", receiptDate(booking.CheckInDate, booking.CheckIn))
+	y = rowTop
+	yr = field(left+half, 80, half-80, "Departure", receiptDate(booking.CheckOutDate, booking.CheckOut))
+	y = maxFloat(yl, yr)
+
+	rowTop = y
+	yl = field(left, 80, half-90, "Guests", fmt.Sprintf("%d adult(s), %d child(ren)", booking.Adults, booking.Children))
+	y = rowTop
+	checkedIn := "-"
+	if booking.CheckedInAt != nil {
+		checkedIn = booking.CheckedInAt.UTC().Format("2006-01-02 15:04 UTC")
+	}
+	yr = field(left+half, 80, half-80, "Checked in", checkedIn)
+	y = maxFloat(yl, yr) + 10
+
+	for i, g := range guests {
+		ensure(120)
+		doc.Line(left, y, right, y, 0.3)
+		y += 16
+		title := fmt.Sprintf("%d. %s", i+1, orDash(g.FullName))
+		if g.IsMainGuest {
+			title += "  (main guest)"
+		}
+		text(left, 11, title, width)
+		y += 2
+
+		dob := ""
+		if g.DateOfBirth != nil {
+			dob = g.DateOfBirth.Format("2006-01-02")
+		}
+		rowTop = y
+		yl = field(left+12, 80, half-100, "Nationality", g.Nationality)
+		y = rowTop
+		yr = field(left+half, 80, half-80, "Date of birth", dob)
+		y = maxFloat(yl, yr)
+
+		rowTop = y
+		yl = field(left+12, 80, half-100, "ID type", g.IDType)
+		y = rowTop
+		yr = field(left+half, 80, half-80, "ID number", g.IDNumber)
+		y = maxFloat(yl, yr)
+
+		rowTop = y
+		yl = field(left+12, 80, half-100, "Issued by", g.IDIssuedCountry)
+		y = rowTop
+		yr = field(left+half, 80, half-80, "Gender", g.Gender)
+		y = maxFloat(yl, yr)
+
+		field(left+12, 80, width-92, "Address", g.CurrentAddress)
+		y += 4
+	}
+
+	// ช่องลายเซ็นของแขกหลัก
+	const boxHeight = 90.0
+	ensure(boxHeight + 60)
+	y += 14
+	text(left, 10, "Guest signature / ลายมือชื่อผู้เข้าพัก", width)
+	boxTop := y + 4
+	boxWidth := half
+	doc.Rect(left, boxTop, boxWidth, boxHeight, 0.5)
+	switch {
+	case sig.Image != nil:
+		doc.DrawImage(doc.AddImage(sig.Image), left+6, boxTop+6, boxWidth-12, boxHeight-12)
+	case sig.Strokes != nil && sig.Strokes.Width > 0 && sig.Strokes.Height > 0:
+		drawSignatureStrokes(doc, sig.Strokes, left+6, boxTop+6, boxWidth-12, boxHeight-12)
+	default:
+		doc.Text(left+8, boxTop+boxHeight/2, 8, "Not signed online")
+	}
+	y = boxTop + boxHeight + 14
+	if sig.SignedAt != nil {
+		text(left, 8, "Signed online: "+sig.SignedAt.UTC().Format("2006-01-02 15:04:05 UTC"), width)
+	}
+	if sig.Problem != "" {
+		text(left, 8, "Signature could not be loaded: "+sig.Problem, width)
+	}
+
+	y += 10
+	text(left, 7, "Printed "+now.Format("2006-01-02 15:04 UTC")+" - contains personal data, keep in the front-desk file.", width)
+
+	return doc.Bytes()
+}
+
+// drawSignatureStrokes ย่อเส้นจาก canvas ให้พอดีกรอบโดยรักษาสัดส่วน
+func drawSignatureStrokes(doc *utils.PDFDocument, s *SignatureStrokes, x, y, w, h float64) {
+	scale := w / s.Width
+	if sh := h / s.Height; sh < scale {
+		scale = sh
+	}
+	offX := x + (w-s.Width*scale)/2
+	offY := y + (h-s.Height*scale)/2
+	for _, stroke := range s.Strokes {
+		points := make([][2]float64, 0, len(stroke))
+		for _, p := range stroke {
+			points = append(points, [2]float64{offX + p.X*scale, offY + p.Y*scale})
+		}
+		doc.Polyline(points, 1.2)
+	}
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
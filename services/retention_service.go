@@ -0,0 +1,371 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"hotel-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// RetentionPolicy: จำนวนวันหลัง check-out ที่เก็บข้อมูลแต่ละประเภท (0 = เก็บไว้ไม่ลบ)
+type RetentionPolicy struct {
+	FaceImageDays     int `json:"faceImageDays"`
+	DocumentImageDays int `json:"documentImageDays"`
+	GuestDataDays     int `json:"guestDataDays"`
+}
+
+// LoadRetentionPolicy อ่าน policy จาก hotel settings (ไม่มีแถว = ค่าเริ่มต้นของ model)
+func LoadRetentionPolicy(db *gorm.DB) RetentionPolicy {
+	policy := RetentionPolicy{FaceImageDays: 30, DocumentImageDays: 90}
+	var hotel models.HotelSetting
+	if err := db.Select("retention_face_image_days", "retention_document_image_days", "retention_guest_data_days").
+		First(&hotel).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("LoadRetentionPolicy: %v (using defaults)", err)
+		}
+		return policy
+	}
+	return RetentionPolicy{
+		FaceImageDays:     hotel.RetentionFaceImageDays,
+		DocumentImageDays: hotel.RetentionDocumentImageDays,
+		GuestDataDays:     hotel.RetentionGuestDataDays,
+	}
+}
+
+// PurgeSummary: ผลการรัน purge หนึ่งครั้ง
+type PurgeSummary struct {
+	Policy                RetentionPolicy `json:"policy"`
+	FaceImagesDeleted     int             `json:"faceImagesDeleted"`
+	DocumentImagesDeleted int             `json:"documentImagesDeleted"`
+	GuestsAnonymised      int             `json:"guestsAnonymised"`
+	Failures              int             `json:"failures"`
+}
+
+// RetentionService: ลบรูปเอกสาร/ใบหน้า และ anonymise ข้อมูลแขกที่เกินระยะเก็บ พร้อมบันทึก PurgeLog
+type RetentionService struct {
+	DB    *gorm.DB
+	Blobs BlobStore
+}
+
+func NewRetentionService(db *gorm.DB, blobs BlobStore) *RetentionService {
+	return &RetentionService{DB: db, Blobs: blobs}
+}
+
+type purgeCandidate struct {
+	ID                uint
+	BookingID         *uint
+	FaceImagePath     string
+	DocumentImagePath string
+	CheckOut          *time.Time
+}
+
+const purgeBatchSize = 200
+
+// dueGuests: guest ของ booking ที่ check-out แล้วก่อน cutoff (เรียงตาม id, ต่อจาก afterID)
+func (s *RetentionService) dueGuests(cutoff time.Time, afterID uint, extra string) ([]purgeCandidate, error) {
+	var rows []purgeCandidate
+	err := s.DB.Model(&models.Guest{}).
+		Select("guests.id, guests.booking_id, guests.face_image_path, guests.document_image_path, bookings.check_out").
+		Joins("JOIN bookings ON bookings.id = guests.booking_id").
+		Where("bookings.status = ? AND bookings.check_out IS NOT NULL AND bookings.check_out <= ?", "Checked-Out", cutoff).
+		Where("guests.id > ?", afterID).
+		Where(extra).
+		Order("guests.id ASC").
+		Limit(purgeBatchSize).
+		Scan(&rows).Error
+	return rows, err
+}
+
+func objectRef(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// deleteImage ลบไฟล์ของ path ที่เก็บใน DB แล้วคืน log entry (Action = deleted | missing | failed)
+func (s *RetentionService) deleteImage(ctx context.Context, stored string, entry models.PurgeLog) models.PurgeLog {
+	path, err := DecryptPIIString(stored)
+	if err != nil {
+		entry.Action = "failed"
+		entry.Error = err.Error()
+		return entry
+	}
+	key, err := normalizeBlobKey(path)
+	if err != nil {
+		entry.Action = "missing"
+		return entry
+	}
+	entry.ObjectRef = objectRef(key)
+
+	body, _, err := s.Blobs.Open(ctx, key)
+	if errors.Is(err, ErrBlobNotFound) {
+		entry.Action = "missing"
+		return entry
+	}
+	if err == nil {
+		body.Close()
+	}
+	if err := s.Blobs.Delete(ctx, key); err != nil {
+		entry.Action = "failed"
+		entry.Error = err.Error()
+		return entry
+	}
+	entry.Action = "deleted"
+	return entry
+}
+
+func (s *RetentionService) writeLog(entry models.PurgeLog) {
+	if err := s.DB.Create(&entry).Error; err != nil {
+		log.Printf("RetentionService: failed to write purge log (guest %d): %v", entry.GuestID, err)
+	}
+}
+
+// purgeImages: ลบรูปหนึ่งประเภท (column = face_image_path | document_image_path)
+func (s *RetentionService) purgeImages(ctx context.Context, now time.Time, dataClass, column string, days int, base models.PurgeLog) (deleted, failed int, err error) {
+	if days <= 0 {
+		return 0, 0, nil
+	}
+	cutoff := now.AddDate(0, 0, -days)
+
+	afterID := uint(0)
+	for {
+		if err := ctx.Err(); err != nil {
+			return deleted, failed, err
+		}
+		rows, err := s.dueGuests(cutoff, afterID, "guests."+column+" <> ''")
+		if err != nil {
+			return deleted, failed, err
+		}
+		if len(rows) == 0 {
+			return deleted, failed, nil
+		}
+
+		for _, row := range rows {
+			afterID = row.ID
+			stored := row.FaceImagePath
+			if dataClass == models.PurgeClassDocumentImage {
+				stored = row.DocumentImagePath
+			}
+
+			entry := base
+			entry.GuestID = row.ID
+			entry.BookingID = row.BookingID
+			entry.DataClass = dataClass
+			entry.RetentionDays = days
+			entry.CheckedOutAt = row.CheckOut
+			entry = s.deleteImage(ctx, stored, entry)
+
+			if entry.Action != "failed" {
+				if err := s.DB.Model(&models.Guest{}).Where("id = ?", row.ID).Update(column, "").Error; err != nil {
+					entry.Action = "failed"
+					entry.Error = err.Error()
+				}
+			}
+			s.writeLog(entry)
+
+			if entry.Action == "failed" {
+				failed++
+			} else {
+				deleted++
+			}
+		}
+	}
+}
+
+// anonymiseGuests: ลบข้อมูลระบุตัวตนของแขก (ชื่อ, เลขบัตร, วันเกิด, ที่อยู่, อีเมล, รูป) คงไว้เฉพาะข้อมูลเชิงสถิติ
+func (s *RetentionService) anonymiseGuests(ctx context.Context, now time.Time, days int, base models.PurgeLog) (anonymised, failed int, err error) {
+	if days <= 0 {
+		return 0, 0, nil
+	}
+	cutoff := now.AddDate(0, 0, -days)
+	hasLegacyDOB := s.DB.Migrator().HasColumn(&models.Guest{}, "date_of_birth")
+
+	afterID := uint(0)
+	for {
+		if err := ctx.Err(); err != nil {
+			return anonymised, failed, err
+		}
+		rows, err := s.dueGuests(cutoff, afterID, "guests.anonymised_at IS NULL")
+		if err != nil {
+			return anonymised, failed, err
+		}
+		if len(rows) == 0 {
+			return anonymised, failed, nil
+		}
+
+		for _, row := range rows {
+			afterID = row.ID
+			if entry := s.anonymiseGuest(ctx, now, row, days, base, hasLegacyDOB); entry.Action == "failed" {
+				failed++
+			} else {
+				anonymised++
+			}
+		}
+	}
+}
+
+// anonymiseGuest ลบรูปและข้อมูลระบุตัวตนของแขกหนึ่งคน แล้วคืน log entry ของ guest record
+func (s *RetentionService) anonymiseGuest(ctx context.Context, now time.Time, row purgeCandidate, days int, base models.PurgeLog, hasLegacyDOB bool) models.PurgeLog {
+	imageFailed := false
+	for class, stored := range map[string]string{
+		models.PurgeClassFaceImage:     row.FaceImagePath,
+		models.PurgeClassDocumentImage: row.DocumentImagePath,
+	} {
+		if stored == "" {
+			continue
+		}
+		entry := base
+		entry.GuestID = row.ID
+		entry.BookingID = row.BookingID
+		entry.DataClass = class
+		entry.RetentionDays = days
+		entry.CheckedOutAt = row.CheckOut
+		entry = s.deleteImage(ctx, stored, entry)
+		s.writeLog(entry)
+		if entry.Action == "failed" {
+			imageFailed = true
+		}
+	}
+
+	// ลายเซ็น (ใบลงทะเบียน) ลบพร้อมข้อมูลระบุตัวตน
+	var signatures []models.GuestSignature
+	if err := s.DB.Where("guest_id = ? AND purged_at IS NULL AND storage_key <> ''", row.ID).Find(&signatures).Error; err != nil {
+		imageFailed = true
+	}
+	for _, sig := range signatures {
+		entry := base
+		entry.GuestID = row.ID
+		entry.BookingID = row.BookingID
+		entry.DataClass = models.PurgeClassSignature
+		entry.RetentionDays = days
+		entry.CheckedOutAt = row.CheckOut
+		entry = s.deleteImage(ctx, sig.StorageKey, entry)
+		s.writeLog(entry)
+		if entry.Action == "failed" {
+			imageFailed = true
+			continue
+		}
+		if err := s.DB.Model(&sig).Updates(map[string]interface{}{"storage_key": "", "purged_at": now}).Error; err != nil {
+			imageFailed = true
+		}
+	}
+
+	entry := base
+	entry.GuestID = row.ID
+	entry.BookingID = row.BookingID
+	entry.DataClass = models.PurgeClassGuestRecord
+	entry.RetentionDays = days
+	entry.CheckedOutAt = row.CheckOut
+	entry.Action = "anonymised"
+
+	if imageFailed {
+		// ไม่ anonymise จนกว่าจะลบรูปได้ (path ยังต้องใช้ตอนลองใหม่)
+		entry.Action = "failed"
+		entry.Error = "image_delete_failed"
+	} else {
+		updates := map[string]interface{}{
+			"full_name":           "ANONYMISED",
+			"id_number":           "",
+			"id_number_index":     "",
+			"current_address":     "",
+			"date_of_birth_enc":   "",
+			"email":               "",
+			"face_image_path":     "",
+			"document_image_path": "",
+			"anonymised_at":       now,
+		}
+		if hasLegacyDOB {
+			updates["date_of_birth"] = nil
+		}
+		if err := s.DB.Model(&models.Guest{}).Where("id = ?", row.ID).Updates(updates).Error; err != nil {
+			entry.Action = "failed"
+			entry.Error = err.Error()
+		}
+	}
+	s.writeLog(entry)
+	return entry
+}
+
+// AnonymiseGuests anonymise แขกตามรายการ id ทันที (ไม่ดูระยะเก็บ) — ใช้กับคำขอลบข้อมูลของเจ้าของข้อมูล
+// trigger เช่น "erasure_request"
+func (s *RetentionService) AnonymiseGuests(ctx context.Context, guestIDs []uint, trigger string, triggeredBy *uint) (anonymised, failed int, err error) {
+	if len(guestIDs) == 0 {
+		return 0, 0, nil
+	}
+	var rows []purgeCandidate
+	if err := s.DB.Model(&models.Guest{}).
+		Select("guests.id, guests.booking_id, guests.face_image_path, guests.document_image_path, bookings.check_out").
+		Joins("LEFT JOIN bookings ON bookings.id = guests.booking_id").
+		Where("guests.id IN ? AND guests.anonymised_at IS NULL", guestIDs).
+		Order("guests.id ASC").
+		Scan(&rows).Error; err != nil {
+		return 0, 0, err
+	}
+
+	now := time.Now().UTC()
+	hasLegacyDOB := s.DB.Migrator().HasColumn(&models.Guest{}, "date_of_birth")
+	base := models.PurgeLog{Trigger: trigger, TriggeredBy: triggeredBy}
+	for _, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return anonymised, failed, err
+		}
+		if entry := s.anonymiseGuest(ctx, now, row, 0, base, hasLegacyDOB); entry.Action == "failed" {
+			failed++
+		} else {
+			anonymised++
+		}
+	}
+	return anonymised, failed, nil
+}
+
+// PurgeDue ลบข้อมูลทุกประเภทที่เกินระยะเก็บตาม policy ปัจจุบัน
+// trigger = "scheduled" | "manual", triggeredBy = admin ที่สั่ง (manual)
+func (s *RetentionService) PurgeDue(ctx context.Context, now time.Time, trigger string, triggeredBy *uint) (PurgeSummary, error) {
+	policy := LoadRetentionPolicy(s.DB)
+	summary := PurgeSummary{Policy: policy}
+	base := models.PurgeLog{Trigger: trigger, TriggeredBy: triggeredBy}
+	now = now.UTC()
+
+	// anonymise ก่อน (ลบรูปไปด้วย) แล้วค่อยลบรูปของแขกที่ยังไม่ถึงกำหนด anonymise
+	n, failed, err := s.anonymiseGuests(ctx, now, policy.GuestDataDays, base)
+	summary.GuestsAnonymised += n
+	summary.Failures += failed
+	if err != nil {
+		return summary, err
+	}
+
+	n, failed, err = s.purgeImages(ctx, now, models.PurgeClassFaceImage, "face_image_path", policy.FaceImageDays, base)
+	summary.FaceImagesDeleted += n
+	summary.Failures += failed
+	if err != nil {
+		return summary, err
+	}
+
+	n, failed, err = s.purgeImages(ctx, now, models.PurgeClassDocumentImage, "document_image_path", policy.DocumentImageDays, base)
+	summary.DocumentImagesDeleted += n
+	summary.Failures += failed
+	return summary, err
+}
+
+// ListPurgeLogs: ประวัติการลบข้อมูล (ล่าสุดก่อน) กรองด้วย guestId / dataClass ได้
+func (s *RetentionService) ListPurgeLogs(guestID uint, dataClass string, limit int) ([]models.PurgeLog, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+	q := s.DB.Model(&models.PurgeLog{})
+	if guestID != 0 {
+		q = q.Where("guest_id = ?", guestID)
+	}
+	if dataClass != "" {
+		q = q.Where("data_class = ?", dataClass)
+	}
+
+	var out []models.PurgeLog
+	err := q.Order("id DESC").Limit(limit).Find(&out).Error
+	return out, err
+}
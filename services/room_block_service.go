@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"hotel-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Room blocks: ช่วงวันที่ห้องเป็น out_of_order / out_of_service
+// - จองห้องที่ช่วงวันทับ block ไม่ได้ (CreateBookingMultiple)
+// - block เริ่ม: condition ของห้องเป็นชนิดของ block
+// - block จบ/ถูกยกเลิก: ห้องเป็น dirty + งาน housekeeping (ต้องตรวจผ่านก่อนขายได้)
+
+// RoomBlockService: จัดการ block จากหน้า admin + job เริ่ม/จบ block ตามวันที่
+type RoomBlockService struct {
+	DB *gorm.DB
+}
+
+func NewRoomBlockService(db *gorm.DB) *RoomBlockService {
+	return &RoomBlockService{DB: db}
+}
+
+// activeRoomBlock block ที่มีผลกับห้องอยู่ ณ now (ยังไม่ยกเลิก/จบ)
+func activeRoomBlock(tx *gorm.DB, roomID uint, now time.Time) (*models.RoomBlock, error) {
+	today := hotelToday(tx, now)
+	var block models.RoomBlock
+	err := tx.Where("room_id = ? AND ended_at IS NULL AND released_at IS NULL AND start_date <= ? AND end_date > ?", roomID, today, today).
+		Order("start_date").First(&block).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// EnsureRoomsBookable: ห้องต้องไม่มี block ทับช่วง [from, to) — ไม่มีวันที่ = ตรวจเฉพาะวันนี้
+func EnsureRoomsBookable(tx *gorm.DB, roomIDs []uint, from, to *time.Time) error {
+	if len(roomIDs) == 0 {
+		return nil
+	}
+	start := hotelToday(tx, time.Now())
+	if from != nil {
+		start = taskDate(*from)
+	}
+	end := start.AddDate(0, 0, 1)
+	if to != nil && taskDate(*to).After(start) {
+		end = taskDate(*to)
+	}
+	var block models.RoomBlock
+	err := tx.Where("room_id IN ? AND released_at IS NULL AND start_date < ? AND end_date > ?", roomIDs, end, start).
+		Order("start_date").First(&block).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("room_unavailable: room %d is %s from %s to %s", block.RoomID, block.Kind,
+		block.StartDate.Format("2006-01-02"), block.EndDate.Format("2006-01-02"))
+}
+
+// RoomBlockInput: สร้าง block
+type RoomBlockInput struct {
+	RoomID    uint   `json:"roomId"`
+	Kind      string `json:"kind"`      // out_of_order (ค่าเริ่มต้น) | out_of_service
+	StartDate string `json:"startDate"` // YYYY-MM-DD
+	EndDate   string `json:"endDate"`   // YYYY-MM-DD (วันแรกที่ห้องกลับมาขายได้)
+	Reason    string `json:"reason"`
+}
+
+// Create สร้าง block — ห้องที่มีแขกพักอยู่หรือมี booking ในช่วงนั้นก็สร้างได้ (แจ้งใน conflicts ให้ย้ายห้องเอง)
+func (s *RoomBlockService) Create(in RoomBlockInput, adminID uint) (models.RoomBlock, []uint, error) {
+	block, err := s.newBlock(in, adminID)
+	if err != nil {
+		return models.RoomBlock{}, nil, err
+	}
+	if err := s.DB.Create(&block).Error; err != nil {
+		return models.RoomBlock{}, nil, err
+	}
+	return s.activate(block)
+}
+
+// newBlock ตรวจ input แล้วคืน block ที่ยังไม่บันทึก (ผู้เรียกบันทึกใน transaction ของตัวเองได้ แล้วเรียก activate)
+func (s *RoomBlockService) newBlock(in RoomBlockInput, adminID uint) (models.RoomBlock, error) {
+	kind := strings.TrimSpace(in.Kind)
+	if kind == "" {
+		kind = models.RoomOutOfOrder
+	}
+	if kind != models.RoomOutOfOrder && kind != models.RoomOutOfService {
+		return models.RoomBlock{}, errors.New("invalid_block_kind")
+	}
+	start, err1 := time.Parse("2006-01-02", strings.TrimSpace(in.StartDate))
+	end, err2 := time.Parse("2006-01-02", strings.TrimSpace(in.EndDate))
+	if err1 != nil || err2 != nil || !end.After(start) {
+		return models.RoomBlock{}, errors.New("invalid_block_dates")
+	}
+	if strings.TrimSpace(in.Reason) == "" {
+		return models.RoomBlock{}, errors.New("block_reason_required")
+	}
+	var room models.Room
+	if err := s.DB.Select("id").First(&room, in.RoomID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.RoomBlock{}, errors.New("room_not_found")
+		}
+		return models.RoomBlock{}, err
+	}
+	return models.RoomBlock{
+		RoomID:    room.ID,
+		Kind:      kind,
+		StartDate: start,
+		EndDate:   end,
+		Reason:    strings.TrimSpace(in.Reason),
+		CreatedBy: &adminID,
+	}, nil
+}
+
+// activate หลังบันทึก block: หา booking ที่ทับช่วง และให้ block ที่เริ่มวันนี้มีผลทันที
+func (s *RoomBlockService) activate(block models.RoomBlock) (models.RoomBlock, []uint, error) {
+	conflicts, err := s.conflictingBookings(block)
+	if err != nil {
+		return block, nil, err
+	}
+	if err := s.Sync(context.Background(), time.Now()); err != nil {
+		log.Printf("room block %d: sync: %v", block.ID, err)
+	}
+	block, err = s.Get(block.ID)
+	return block, conflicts, err
+}
+
+// conflictingBookings booking ที่ยังไม่เช็คเอาท์และมีห้องนี้ในช่วง block
+func (s *RoomBlockService) conflictingBookings(block models.RoomBlock) ([]uint, error) {
+	var ids []uint
+	err := s.DB.Model(&models.Booking{}).
+		Joins("JOIN booking_rooms ON booking_rooms.booking_id = bookings.id AND booking_rooms.deleted_at IS NULL").
+		Where("booking_rooms.room_id = ? AND bookings.status NOT IN ?", block.RoomID, []string{"Checked-Out", "Cancelled"}).
+		Where("COALESCE(bookings.check_in_date, bookings.check_in) < ? AND COALESCE(bookings.check_out_date, bookings.check_out) > ?", block.EndDate, block.StartDate).
+		Distinct().Pluck("bookings.id", &ids).Error
+	return ids, err
+}
+
+// Get block ตาม id
+func (s *RoomBlockService) Get(id uint) (models.RoomBlock, error) {
+	var block models.RoomBlock
+	if err := s.DB.Preload("Room").First(&block, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return block, errors.New("room_block_not_found")
+		}
+		return block, err
+	}
+	return block, nil
+}
+
+// RoomBlockFilter: ตัวกรองรายการ block
+type RoomBlockFilter struct {
+	RoomID     uint
+	ActiveOnly bool // ยังไม่จบ/ไม่ถูกยกเลิก
+	From       *time.Time
+	To         *time.Time
+}
+
+// List block ตามตัวกรอง เรียงตามวันเริ่ม
+func (s *RoomBlockService) List(f RoomBlockFilter) ([]models.RoomBlock, error) {
+	q := s.DB.Preload("Room")
+	if f.RoomID != 0 {
+		q = q.Where("room_id = ?", f.RoomID)
+	}
+	if f.ActiveOnly {
+		q = q.Where("ended_at IS NULL AND released_at IS NULL")
+	}
+	if f.From != nil {
+		q = q.Where("end_date > ?", taskDate(*f.From))
+	}
+	if f.To != nil {
+		q = q.Where("start_date < ?", taskDate(*f.To))
+	}
+	var rows []models.RoomBlock
+	err := q.Order("start_date ASC, id ASC").Find(&rows).Error
+	return rows, err
+}
+
+// Release ยกเลิก block ก่อนกำหนด (ห้องกลับเป็น dirty รอทำความสะอาด/ตรวจ)
+func (s *RoomBlockService) Release(id, adminID uint) (models.RoomBlock, error) {
+	now := time.Now().UTC()
+	res := s.DB.Model(&models.RoomBlock{}).
+		Where("id = ? AND released_at IS NULL AND ended_at IS NULL", id).
+		Updates(map[string]interface{}{"released_at": now, "released_by": adminID})
+	if res.Error != nil {
+		return models.RoomBlock{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		block, err := s.Get(id)
+		if err != nil {
+			return block, err
+		}
+		return block, errors.New("room_block_already_ended")
+	}
+	if err := s.Sync(context.Background(), now); err != nil {
+		log.Printf("room block %d: sync after release: %v", id, err)
+	}
+	return s.Get(id)
+}
+
+// Sync เริ่ม block ที่ถึงวัน และจบ block ที่หมดช่วง/ถูกยกเลิก — เรียกจาก job และหลังสร้าง/ยกเลิก
+func (s *RoomBlockService) Sync(ctx context.Context, now time.Time) error {
+	today := hotelToday(s.DB, now)
+
+	var starting []models.RoomBlock
+	if err := s.DB.WithContext(ctx).
+		Where("applied_at IS NULL AND released_at IS NULL AND ended_at IS NULL AND start_date <= ? AND end_date > ?", today, today).
+		Find(&starting).Error; err != nil {
+		return err
+	}
+	for _, b := range starting {
+		if err := s.start(b, now); err != nil {
+			log.Printf("room block %d: start: %v", b.ID, err)
+		}
+	}
+
+	var ending []models.RoomBlock
+	if err := s.DB.WithContext(ctx).
+		Where("ended_at IS NULL AND (released_at IS NOT NULL OR end_date <= ?)", today).
+		Find(&ending).Error; err != nil {
+		return err
+	}
+	for _, b := range ending {
+		if err := s.end(b, now); err != nil {
+			log.Printf("room block %d: end: %v", b.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *RoomBlockService) start(b models.RoomBlock, now time.Time) error {
+	var events []Event
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.RoomBlock{}).Where("id = ? AND applied_at IS NULL", b.ID).Update("applied_at", now)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		ev, err := ApplyRoomStatus(tx, b.RoomID, RoomStatusChange{
+			Condition: strPtr(b.Kind),
+			Source:    RoomSourceMaintenance,
+			Reason:    fmt.Sprintf("block #%d: %s", b.ID, b.Reason),
+			ChangedBy: b.CreatedBy,
+		}, now)
+		if err != nil {
+			return err
+		}
+		if ev != nil {
+			events = append(events, *ev)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	PublishEvents(events...)
+	return nil
+}
+
+// end: block ที่เคยมีผล -> ห้อง dirty + งานทำความสะอาด (ถ้าไม่มี block อื่นมีผลต่อ)
+func (s *RoomBlockService) end(b models.RoomBlock, now time.Time) error {
+	var events []Event
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.RoomBlock{}).Where("id = ? AND ended_at IS NULL", b.ID).Update("ended_at", now)
+		if res.Error != nil || res.RowsAffected == 0 || b.AppliedAt == nil {
+			return res.Error
+		}
+		other, err := activeRoomBlock(tx, b.RoomID, now)
+		if err != nil {
+			return err
+		}
+		if other != nil && other.AppliedAt != nil {
+			return nil
+		}
+		ev, err := ApplyRoomStatus(tx, b.RoomID, RoomStatusChange{
+			Condition: strPtr(models.RoomDirty),
+			Source:    RoomSourceMaintenance,
+			Reason:    fmt.Sprintf("block #%d ended", b.ID),
+			ChangedBy: b.ReleasedBy,
+		}, now)
+		if err != nil {
+			if err.Error() == "room_not_found" {
+				return nil
+			}
+			return err
+		}
+		if ev != nil {
+			events = append(events, *ev)
+		}
+		task := models.HousekeepingTask{
+			RoomID:   b.RoomID,
+			Kind:     models.HousekeepingCheckout,
+			Status:   models.HousekeepingDirty,
+			TaskDate: hotelToday(tx, now),
+			DedupKey: dedupKey("block:%d", b.ID),
+			Notes:    "หลังซ่อม/งดขาย: " + b.Reason,
+		}
+		created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&task)
+		if created.Error != nil {
+			return created.Error
+		}
+		if created.RowsAffected > 0 {
+			events = append(events, housekeepingChanged(task, now))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	PublishEvents(events...)
+	return nil
+}
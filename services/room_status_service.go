@@ -0,0 +1,266 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"hotel-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Room status: occupancy (vacant/occupied) แยกจาก condition (clean/dirty/inspected/out_of_order/out_of_service)
+// ทุกการเปลี่ยนผ่าน ApplyRoomStatus -> อัปเดต rooms + Status แสดงผล + room_status_histories
+
+// ที่มาของการเปลี่ยนสถานะ
+const (
+	RoomSourceManual       = "manual"
+	RoomSourceCheckin      = "checkin"
+	RoomSourceCheckout     = "checkout"
+	RoomSourceHousekeeping = "housekeeping"
+	RoomSourceMaintenance  = "maintenance"
+	RoomSourceMigration    = "migration"
+)
+
+// RoomStatusChange: ค่าที่จะเปลี่ยน (nil = คงเดิม)
+type RoomStatusChange struct {
+	Occupancy *string
+	Condition *string
+	Source    string
+	Reason    string
+	ChangedBy *uint
+}
+
+func strPtr(s string) *string { return &s }
+
+// ApplyRoomStatus เปลี่ยนสถานะห้องใน transaction ของผู้เรียก พร้อมบันทึกประวัติ
+// คืน event (nil = ไม่มีอะไรเปลี่ยน) ให้ผู้เรียก publish หลัง commit
+// ห้องที่กลับมาเป็น Available จะได้รหัสเข้าห้องใหม่
+func ApplyRoomStatus(tx *gorm.DB, roomID uint, ch RoomStatusChange, at time.Time) (*RoomStatusChanged, error) {
+	if ch.Occupancy != nil && !models.ValidRoomOccupancy(*ch.Occupancy) {
+		return nil, errors.New("invalid_room_occupancy")
+	}
+	if ch.Condition != nil && !models.ValidRoomCondition(*ch.Condition) {
+		return nil, errors.New("invalid_room_condition")
+	}
+	var room models.Room
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "status", "occupancy", "room_condition").First(&room, roomID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("room_not_found")
+		}
+		return nil, err
+	}
+
+	occupancy, condition := room.Occupancy, room.Condition
+	if ch.Occupancy != nil {
+		occupancy = *ch.Occupancy
+	}
+	if ch.Condition != nil {
+		condition = *ch.Condition
+	}
+	status := models.RoomDisplayStatus(occupancy, condition)
+	if occupancy == room.Occupancy && condition == room.Condition && status == room.Status {
+		return nil, nil
+	}
+
+	updates := map[string]interface{}{
+		"occupancy":      occupancy,
+		"room_condition": condition,
+		"status":         status,
+	}
+	if status == models.RoomStatusAvailable && room.Status != models.RoomStatusAvailable {
+		code, err := GenerateRoomAccessCode(tx)
+		if err != nil {
+			return nil, err
+		}
+		updates["access_code"] = code
+	}
+	if err := tx.Model(&models.Room{}).Where("id = ?", room.ID).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+
+	source := ch.Source
+	if source == "" {
+		source = RoomSourceManual
+	}
+	reason := strings.TrimSpace(ch.Reason)
+	if len(reason) > 500 {
+		reason = reason[:500]
+	}
+	history := models.RoomStatusHistory{
+		RoomID:        room.ID,
+		FromOccupancy: room.Occupancy,
+		ToOccupancy:   occupancy,
+		FromCondition: room.Condition,
+		ToCondition:   condition,
+		FromStatus:    room.Status,
+		ToStatus:      status,
+		Source:        source,
+		Reason:        reason,
+		ChangedBy:     ch.ChangedBy,
+		CreatedAt:     at,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return nil, err
+	}
+	return &RoomStatusChanged{
+		RoomIDs:   []uint{room.ID},
+		Status:    status,
+		Occupancy: occupancy,
+		Condition: condition,
+		Source:    source,
+		ChangedBy: ch.ChangedBy,
+		At:        at,
+	}, nil
+}
+
+// applyRoomStatuses ApplyRoomStatus หลายห้อง แล้วรวม event
+func applyRoomStatuses(tx *gorm.DB, roomIDs []uint, ch RoomStatusChange, at time.Time) ([]Event, error) {
+	var events []Event
+	for _, id := range roomIDs {
+		ev, err := ApplyRoomStatus(tx, id, ch, at)
+		if err != nil {
+			if err.Error() == "room_not_found" {
+				continue // ห้องถูกลบไปแล้ว
+			}
+			return nil, err
+		}
+		if ev != nil {
+			events = append(events, *ev)
+		}
+	}
+	return events, nil
+}
+
+// LegacyRoomStatusChange แปลง Status แบบเดิม (PATCH /api/rooms/:id {status}) เป็นการเปลี่ยน occupancy/condition
+func LegacyRoomStatusChange(status string) (RoomStatusChange, error) {
+	occupancy, condition := models.RoomStateFromLegacyStatus(status)
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "available":
+		return RoomStatusChange{Occupancy: &occupancy, Condition: &condition}, nil
+	case "occupied":
+		return RoomStatusChange{Occupancy: &occupancy}, nil
+	case "cleaning", "dirty", "maintenance", "out of order", "out_of_order", "out of service", "out_of_service":
+		return RoomStatusChange{Condition: &condition}, nil
+	case "reserved":
+		// การจองเป็นช่วงวันที่ของ booking ไม่ใช่สถานะห้อง
+		return RoomStatusChange{}, nil
+	}
+	return RoomStatusChange{}, errors.New("invalid_room_status")
+}
+
+// RoomStatusService: เปลี่ยนสถานะจากหน้า admin + ประวัติ
+type RoomStatusService struct {
+	DB *gorm.DB
+}
+
+func NewRoomStatusService(db *gorm.DB) *RoomStatusService {
+	return &RoomStatusService{DB: db}
+}
+
+// Update เปลี่ยนสถานะห้องโดย admin
+// - ตั้ง inspected ไม่ได้ถ้ายังมีงานทำความสะอาดหลังเช็คเอาท์ค้าง
+// - ออกจาก out_of_order / out_of_service ไม่ได้ระหว่างมี block ที่ยังมีผล (ยกเลิก block แทน)
+func (s *RoomStatusService) Update(roomID uint, ch RoomStatusChange) (models.Room, error) {
+	now := time.Now().UTC()
+	ch.Source = RoomSourceManual
+	var ev *RoomStatusChanged
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if ch.Condition != nil {
+			if *ch.Condition == models.RoomInspected {
+				pending, err := RoomHasOpenTurnover(tx, roomID)
+				if err != nil {
+					return err
+				}
+				if pending {
+					return errors.New("housekeeping_pending")
+				}
+			}
+			if *ch.Condition != models.RoomOutOfOrder && *ch.Condition != models.RoomOutOfService {
+				block, err := activeRoomBlock(tx, roomID, now)
+				if err != nil {
+					return err
+				}
+				if block != nil {
+					return fmt.Errorf("room_blocked: block %d", block.ID)
+				}
+			}
+		}
+		var err error
+		ev, err = ApplyRoomStatus(tx, roomID, ch, now)
+		return err
+	})
+	if err != nil {
+		return models.Room{}, err
+	}
+	if ev != nil {
+		PublishEvents(*ev)
+	}
+	var room models.Room
+	err = s.DB.First(&room, roomID).Error
+	return room, err
+}
+
+// History ประวัติสถานะของห้อง ใหม่สุดก่อน
+func (s *RoomStatusService) History(roomID uint, page, limit int) ([]models.RoomStatusHistory, int64, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if page <= 0 {
+		page = 1
+	}
+	q := s.DB.Model(&models.RoomStatusHistory{}).Where("room_id = ?", roomID)
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []models.RoomStatusHistory
+	err := q.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&rows).Error
+	return rows, total, err
+}
+
+// BackfillRoomStatus ตั้ง occupancy/condition ของห้องเดิม (ก่อนมี model นี้) จาก Status เดิม + booking ที่เช็คอินอยู่
+func BackfillRoomStatus(db *gorm.DB) error {
+	var rooms []models.Room
+	if err := db.Where("room_condition IS NULL OR room_condition = '' OR occupancy IS NULL OR occupancy = ''").Find(&rooms).Error; err != nil {
+		return err
+	}
+	if len(rooms) == 0 {
+		return nil
+	}
+	var occupied []uint
+	if err := db.Model(&models.BookingRoom{}).
+		Joins("JOIN bookings ON bookings.id = booking_rooms.booking_id AND bookings.deleted_at IS NULL").
+		Where("bookings.status = ?", "Checked-In").
+		Pluck("booking_rooms.room_id", &occupied).Error; err != nil {
+		return err
+	}
+	occupiedSet := map[uint]bool{}
+	for _, id := range occupied {
+		occupiedSet[id] = true
+	}
+	now := time.Now().UTC()
+	for _, room := range rooms {
+		occupancy, condition := models.RoomStateFromLegacyStatus(room.Status)
+		if occupiedSet[room.ID] {
+			occupancy = models.RoomOccupied
+		}
+		ch := RoomStatusChange{
+			Occupancy: &occupancy,
+			Condition: &condition,
+			Source:    RoomSourceMigration,
+			Reason:    "status เดิม: " + room.Status,
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			_, err := ApplyRoomStatus(tx, room.ID, ch, now)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
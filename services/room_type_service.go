@@ -1,33 +1,460 @@
 package services
 
 import (
-	"hotel-backend/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
 	"hotel-backend/models"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Room types: ข้อมูลหลักของประเภทห้อง (ชื่อ ความจุ สิ่งอำนวยความสะดวก เตียง แกลเลอรี)
+// Room.Type เป็นสำเนาของ RoomType.TypeName — ตั้งจาก FK เสมอ (ResolveRoomType / ReconcileRoomTypes)
+
+const (
+	roomTypePhotoDir       = "room-types"
+	roomTypeMaxPhotos      = 20
+	roomTypeMaxAmenities   = 50
+	roomTypeDefaultGuests  = 2
+	roomTypeMaxBedsPerKind = 10
 )
 
-type RoomTypeService struct{}
+// RoomTypeService: จัดการประเภทห้องจากหน้า admin
+type RoomTypeService struct {
+	DB *gorm.DB
+}
+
+func NewRoomTypeService(db *gorm.DB) *RoomTypeService {
+	return &RoomTypeService{DB: db}
+}
 
-func (s RoomTypeService) Create(rt models.RoomType) error {
-	return config.DB.Create(&rt).Error
+// RoomTypeInput: สร้าง/แก้ไขประเภทห้อง (nil = คงเดิม)
+type RoomTypeInput struct {
+	TypeName         *string             `json:"typeName"`
+	Description      *string             `json:"description"`
+	MaxGuests        *uint               `json:"max_guests"`
+	BaseOccupancy    *uint               `json:"baseOccupancy"`
+	Amenities        *[]string           `json:"amenities"`
+	BedConfiguration *[]models.BedConfig `json:"bedConfiguration"`
 }
 
-func (s RoomTypeService) GetAll() ([]models.RoomType, error) {
+// List ประเภทห้องทั้งหมด พร้อมรูปและจำนวนห้องที่ใช้
+func (s *RoomTypeService) List() ([]models.RoomType, error) {
 	var types []models.RoomType
-	err := config.DB.Find(&types).Error
-	return types, err
+	if err := s.DB.Preload("Photos", orderRoomTypePhotos).Order("id ASC").Find(&types).Error; err != nil {
+		return nil, err
+	}
+	var counts []struct {
+		RoomTypeID uint
+		N          int64
+	}
+	if err := s.DB.Model(&models.Room{}).Select("room_type_id, COUNT(*) AS n").
+		Where("room_type_id IS NOT NULL").Group("room_type_id").Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	byType := map[uint]int64{}
+	for _, c := range counts {
+		byType[c.RoomTypeID] = c.N
+	}
+	for i := range types {
+		types[i].RoomCount = byType[types[i].ID]
+	}
+	return types, nil
 }
 
-func (s RoomTypeService) GetByID(id int) (models.RoomType, error) {
+// Get ประเภทห้องตาม id
+func (s *RoomTypeService) Get(id uint) (models.RoomType, error) {
 	var rt models.RoomType
-	err := config.DB.First(&rt, id).Error
-	return rt, err
+	if err := s.DB.Preload("Photos", orderRoomTypePhotos).First(&rt, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return rt, errors.New("room_type_not_found")
+		}
+		return rt, err
+	}
+	if err := s.DB.Model(&models.Room{}).Where("room_type_id = ?", id).Count(&rt.RoomCount).Error; err != nil {
+		return rt, err
+	}
+	return rt, nil
+}
+
+func orderRoomTypePhotos(db *gorm.DB) *gorm.DB {
+	return db.Order("sort_order ASC, id ASC")
 }
 
-func (s RoomTypeService) Update(rt models.RoomType) error {
-	// 🔥 แก้ไข: เปลี่ยน rt.RoomTypeID เป็น rt.ID
-	return config.DB.Model(&models.RoomType{}).Where("id = ?", rt.ID).Updates(rt).Error
+// Create สร้างประเภทห้อง (MaxGuests ค่าเริ่มต้น 2, BaseOccupancy ค่าเริ่มต้น = min(2, MaxGuests))
+func (s *RoomTypeService) Create(in RoomTypeInput) (models.RoomType, error) {
+	rt := models.RoomType{MaxGuests: roomTypeDefaultGuests}
+	if in.BaseOccupancy == nil {
+		base := uint(roomTypeDefaultGuests)
+		if in.MaxGuests != nil && *in.MaxGuests < base {
+			base = *in.MaxGuests
+		}
+		in.BaseOccupancy = &base
+	}
+	if err := s.apply(s.DB, &rt, in); err != nil {
+		return rt, err
+	}
+	if err := s.DB.Omit("Photos").Create(&rt).Error; err != nil {
+		return rt, err
+	}
+	return s.Get(rt.ID)
 }
 
-func (s RoomTypeService) Delete(id int) error {
-	return config.DB.Delete(&models.RoomType{}, id).Error
-}
\ No newline at end of file
+// Update แก้ไขประเภทห้อง — เปลี่ยนชื่อแล้ว Room.Type ของห้องประเภทนี้เปลี่ยนตาม
+func (s *RoomTypeService) Update(id uint, in RoomTypeInput) (models.RoomType, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var rt models.RoomType
+		if err := tx.First(&rt, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("room_type_not_found")
+			}
+			return err
+		}
+		oldName := rt.TypeName
+		if err := s.apply(tx, &rt, in); err != nil {
+			return err
+		}
+		if err := tx.Omit("Photos", "CreatedAt").Save(&rt).Error; err != nil {
+			return err
+		}
+		if rt.TypeName != oldName {
+			return tx.Model(&models.Room{}).Where("room_type_id = ?", rt.ID).Update("type", rt.TypeName).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return models.RoomType{}, err
+	}
+	return s.Get(id)
+}
+
+// apply ตรวจและเติมค่าจาก input ลง rt
+func (s *RoomTypeService) apply(tx *gorm.DB, rt *models.RoomType, in RoomTypeInput) error {
+	if in.TypeName != nil {
+		rt.TypeName = strings.TrimSpace(*in.TypeName)
+	}
+	if rt.TypeName == "" {
+		return errors.New("room_type_name_required")
+	}
+	var taken int64
+	if err := tx.Model(&models.RoomType{}).Where("LOWER(type_name) = LOWER(?) AND id <> ?", rt.TypeName, rt.ID).Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return errors.New("room_type_name_taken")
+	}
+	if in.Description != nil {
+		rt.Description = strings.TrimSpace(*in.Description)
+	}
+	if in.MaxGuests != nil {
+		rt.MaxGuests = *in.MaxGuests
+	}
+	if in.BaseOccupancy != nil {
+		rt.BaseOccupancy = *in.BaseOccupancy
+	}
+	if rt.MaxGuests == 0 {
+		return errors.New("invalid_max_guests")
+	}
+	if rt.BaseOccupancy == 0 || rt.BaseOccupancy > rt.MaxGuests {
+		return errors.New("invalid_base_occupancy")
+	}
+	if in.Amenities != nil {
+		amenities, err := normalizeAmenities(*in.Amenities)
+		if err != nil {
+			return err
+		}
+		rt.Amenities = amenities
+	}
+	if in.BedConfiguration != nil {
+		beds, err := normalizeBeds(*in.BedConfiguration)
+		if err != nil {
+			return err
+		}
+		rt.BedConfiguration = beds
+	}
+	return nil
+}
+
+// normalizeAmenities ตัดช่องว่าง/ตัวซ้ำ (ไม่สนตัวพิมพ์)
+func normalizeAmenities(in []string) (datatypes.JSON, error) {
+	out := make([]string, 0, len(in))
+	seen := map[string]bool{}
+	for _, a := range in {
+		a = strings.TrimSpace(a)
+		if a == "" || seen[strings.ToLower(a)] {
+			continue
+		}
+		if len(a) > 64 {
+			return nil, errors.New("invalid_amenity: " + truncate(a, 64))
+		}
+		seen[strings.ToLower(a)] = true
+		out = append(out, a)
+	}
+	if len(out) > roomTypeMaxAmenities {
+		return nil, errors.New("too_many_amenities")
+	}
+	data, err := json.Marshal(out)
+	return datatypes.JSON(data), err
+}
+
+// normalizeBeds ตรวจชนิด/จำนวนเตียง และรวมชนิดเดียวกัน
+func normalizeBeds(in []models.BedConfig) (datatypes.JSON, error) {
+	out := make([]models.BedConfig, 0, len(in))
+	index := map[string]int{}
+	for _, b := range in {
+		b.Type = strings.ToLower(strings.TrimSpace(b.Type))
+		if !models.ValidBedType(b.Type) || b.Count <= 0 {
+			return nil, errors.New("invalid_bed_configuration: " + b.Type)
+		}
+		if i, ok := index[b.Type]; ok {
+			out[i].Count += b.Count
+		} else {
+			index[b.Type] = len(out)
+			out = append(out, b)
+		}
+	}
+	for _, b := range out {
+		if b.Count > roomTypeMaxBedsPerKind {
+			return nil, errors.New("invalid_bed_configuration: " + b.Type)
+		}
+	}
+	data, err := json.Marshal(out)
+	return datatypes.JSON(data), err
+}
+
+// Delete ลบประเภทห้อง — ยังมีห้องใช้อยู่ต้องระบุ reassignTo (ย้ายห้องไปประเภทอื่นก่อนลบ)
+// คืนจำนวนห้องที่ถูกย้าย
+func (s *RoomTypeService) Delete(id uint, reassignTo *uint) (int64, error) {
+	var moved int64
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var rt models.RoomType
+		if err := tx.First(&rt, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("room_type_not_found")
+			}
+			return err
+		}
+		var inUse int64
+		if err := tx.Model(&models.Room{}).Where("room_type_id = ?", id).Count(&inUse).Error; err != nil {
+			return err
+		}
+		if inUse > 0 {
+			if reassignTo == nil || *reassignTo == 0 {
+				return fmt.Errorf("room_type_in_use: %d rooms", inUse)
+			}
+			if *reassignTo == id {
+				return errors.New("invalid_reassign_target")
+			}
+			var target models.RoomType
+			if err := tx.First(&target, *reassignTo).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errors.New("invalid_reassign_target")
+				}
+				return err
+			}
+			res := tx.Model(&models.Room{}).Where("room_type_id = ?", id).
+				Updates(map[string]interface{}{"room_type_id": target.ID, "type": target.TypeName})
+			if res.Error != nil {
+				return res.Error
+			}
+			moved = res.RowsAffected
+		}
+		return tx.Delete(&rt).Error
+	})
+	return moved, err
+}
+
+// ResolveRoomType หาประเภทห้องจาก id หรือชื่อ (ไม่สนตัวพิมพ์) สำหรับสร้าง/แก้ไขห้อง — ไม่ระบุทั้งคู่คืน nil
+func ResolveRoomType(db *gorm.DB, id *uint, name string) (*models.RoomType, error) {
+	var rt models.RoomType
+	var err error
+	switch {
+	case id != nil && *id != 0:
+		err = db.First(&rt, *id).Error
+	case strings.TrimSpace(name) != "":
+		err = db.Where("LOWER(type_name) = LOWER(?)", strings.TrimSpace(name)).Order("id ASC").First(&rt).Error
+	default:
+		return nil, nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("room_type_not_found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rt, nil
+}
+
+// ------------------------------------------------------------
+// แกลเลอรีรูป
+// ------------------------------------------------------------
+
+// AddPhoto เพิ่มรูปท้ายแกลเลอรี
+func (s *RoomTypeService) AddPhoto(id uint, image, caption string) (models.RoomType, error) {
+	if _, err := s.Get(id); err != nil {
+		return models.RoomType{}, err
+	}
+	var count int64
+	if err := s.DB.Model(&models.RoomTypePhoto{}).Where("room_type_id = ?", id).Count(&count).Error; err != nil {
+		return models.RoomType{}, err
+	}
+	if count >= roomTypeMaxPhotos {
+		return models.RoomType{}, errors.New("too_many_photos")
+	}
+	key, err := SaveBase64Image(image, roomTypePhotoDir)
+	if err != nil {
+		return models.RoomType{}, fmt.Errorf("invalid_photo: %w", err)
+	}
+	var maxOrder int
+	s.DB.Model(&models.RoomTypePhoto{}).Where("room_type_id = ?", id).Select("COALESCE(MAX(sort_order), 0)").Scan(&maxOrder)
+	photo := models.RoomTypePhoto{
+		RoomTypeID: id,
+		StorageKey: key,
+		Caption:    truncate(strings.TrimSpace(caption), 200),
+		SortOrder:  maxOrder + 1,
+	}
+	if err := s.DB.Create(&photo).Error; err != nil {
+		_ = DeleteStoredImage(key)
+		return models.RoomType{}, err
+	}
+	return s.Get(id)
+}
+
+// DeletePhoto ลบรูปออกจากแกลเลอรี (และไฟล์)
+func (s *RoomTypeService) DeletePhoto(id, photoID uint) (models.RoomType, error) {
+	var photo models.RoomTypePhoto
+	if err := s.DB.Where("id = ? AND room_type_id = ?", photoID, id).First(&photo).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.RoomType{}, errors.New("photo_not_found")
+		}
+		return models.RoomType{}, err
+	}
+	if err := s.DB.Delete(&photo).Error; err != nil {
+		return models.RoomType{}, err
+	}
+	if err := DeleteStoredImage(photo.StorageKey); err != nil {
+		log.Printf("room type %d: delete photo %d file: %v", id, photoID, err)
+	}
+	return s.Get(id)
+}
+
+// ReorderPhotos เรียงรูปตาม photoIDs (ต้องครบทุกรูปของประเภทห้อง)
+func (s *RoomTypeService) ReorderPhotos(id uint, photoIDs []uint) (models.RoomType, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var existing []uint
+		if err := tx.Model(&models.RoomTypePhoto{}).Where("room_type_id = ?", id).Pluck("id", &existing).Error; err != nil {
+			return err
+		}
+		owned := map[uint]bool{}
+		for _, pid := range existing {
+			owned[pid] = true
+		}
+		if len(photoIDs) != len(existing) {
+			return errors.New("invalid_photo_order")
+		}
+		for i, pid := range photoIDs {
+			if !owned[pid] {
+				return errors.New("invalid_photo_order")
+			}
+			delete(owned, pid)
+			if err := tx.Model(&models.RoomTypePhoto{}).Where("id = ?", pid).Update("sort_order", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return models.RoomType{}, err
+	}
+	return s.Get(id)
+}
+
+// OpenPhoto อ่านรูปจาก BlobStore (รูปสาธารณะ — ไม่เข้ารหัส)
+func (s *RoomTypeService) OpenPhoto(ctx context.Context, photoID uint) (io.ReadCloser, BlobInfo, error) {
+	var photo models.RoomTypePhoto
+	if err := s.DB.First(&photo, photoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, BlobInfo{}, errors.New("photo_not_found")
+		}
+		return nil, BlobInfo{}, err
+	}
+	body, info, err := DefaultBlobStore().Open(ctx, photo.StorageKey)
+	if errors.Is(err, ErrBlobNotFound) {
+		return nil, info, errors.New("photo_not_found")
+	}
+	return body, info, err
+}
+
+// ------------------------------------------------------------
+// Migration: Room.Type <-> RoomTypeID
+// ------------------------------------------------------------
+
+// ReconcileRoomTypes ทำให้ Room.Type กับ RoomTypeID ตรงกัน (ทำทุกครั้งตอน start — ห้องที่ตรงแล้วไม่ถูกแตะ)
+// - FK ชี้ไปประเภทที่ไม่มี/ถูกลบ: ล้าง FK แล้วหาใหม่จากชื่อ
+// - ไม่มี FK แต่มี Type: จับคู่ชื่อ (ไม่สนตัวพิมพ์) หรือสร้างประเภทใหม่
+// - มี FK: Type = RoomType.TypeName
+func ReconcileRoomTypes(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		live := tx.Model(&models.RoomType{}).Select("id")
+		if err := tx.Model(&models.Room{}).
+			Where("room_type_id IS NOT NULL AND room_type_id NOT IN (?)", live).
+			Update("room_type_id", nil).Error; err != nil {
+			return err
+		}
+
+		var orphans []models.Room
+		if err := tx.Select("id", "type", "max_occupancy").
+			Where("room_type_id IS NULL AND type IS NOT NULL AND TRIM(type) <> ''").
+			Find(&orphans).Error; err != nil {
+			return err
+		}
+		for _, room := range orphans {
+			rt, err := ResolveRoomType(tx, nil, room.Type)
+			if err != nil && err.Error() != "room_type_not_found" {
+				return err
+			}
+			if rt == nil {
+				guests := uint(roomTypeDefaultGuests)
+				if room.MaxOccupancy > 0 {
+					guests = uint(room.MaxOccupancy)
+				}
+				base := guests
+				if base > roomTypeDefaultGuests {
+					base = roomTypeDefaultGuests
+				}
+				rt = &models.RoomType{TypeName: strings.TrimSpace(room.Type), MaxGuests: guests, BaseOccupancy: base}
+				if err := tx.Omit("Photos").Create(rt).Error; err != nil {
+					return err
+				}
+				log.Printf("room types: created %q from room %d", rt.TypeName, room.ID)
+			}
+			if err := tx.Model(&models.Room{}).Where("id = ?", room.ID).Update("room_type_id", rt.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		// Type ตามชื่อของ FK
+		var types []models.RoomType
+		if err := tx.Find(&types).Error; err != nil {
+			return err
+		}
+		for _, rt := range types {
+			if err := tx.Model(&models.Room{}).
+				Where("room_type_id = ? AND (type IS NULL OR type <> ?)", rt.ID, rt.TypeName).
+				Update("type", rt.TypeName).Error; err != nil {
+				return err
+			}
+		}
+
+		// ประเภทเดิมที่ยังไม่มี BaseOccupancy
+		return tx.Model(&models.RoomType{}).Where("base_occupancy IS NULL OR base_occupancy = 0").
+			Update("base_occupancy", gorm.Expr("LEAST(max_guests, ?)", roomTypeDefaultGuests)).Error
+	})
+}
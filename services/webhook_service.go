@@ -0,0 +1,646 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"hotel-backend/models"
+	"hotel-backend/utils"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Event ที่ส่งออกไปยังระบบภายนอก
+const (
+	WebhookEventBookingCreated    = "booking.created"
+	WebhookEventBookingDeleted    = "booking.deleted"
+	WebhookEventCheckinCompleted  = "checkin.completed"
+	WebhookEventCheckoutCompleted = "checkout.completed"
+	WebhookEventGuestCreated      = "guest.created"
+)
+
+var webhookEvents = []string{
+	WebhookEventBookingCreated,
+	WebhookEventBookingDeleted,
+	WebhookEventCheckinCompleted,
+	WebhookEventCheckoutCompleted,
+	WebhookEventGuestCreated,
+}
+
+// WebhookEvents รายการ event ที่ endpoint สมัครรับได้ ("*" = ทุก event)
+func WebhookEvents() []string {
+	return append([]string(nil), webhookEvents...)
+}
+
+const (
+	webhookBatchSize    = 20
+	webhookPollInterval = 15 * time.Second
+	webhookMaxAttempts  = 8
+	webhookStuckAfter   = 5 * time.Minute
+	webhookBodyLimit    = 2 << 10
+)
+
+// webhookWake: ปลุก worker ให้ส่งทันที
+var webhookWake = make(chan struct{}, 1)
+
+func wakeWebhookWorker() {
+	select {
+	case webhookWake <- struct{}{}:
+	default:
+	}
+}
+
+// webhookEnvelope: body ที่ส่งไปยัง endpoint
+type webhookEnvelope struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// EnqueueWebhookEvent บันทึก event ลงคิวของทุก endpoint ที่สมัครรับ — เรียกภายใน transaction ของงานที่เกิด event
+// แถวจึง commit พร้อมกับข้อมูล (ไม่หายถ้า process ตายก่อน publish) แล้ว worker ส่งตามคิว
+func EnqueueWebhookEvent(tx *gorm.DB, event string, data interface{}) error {
+	var endpoints []models.WebhookEndpoint
+	if err := tx.Select("id", "events").Where("active = ?", true).Find(&endpoints).Error; err != nil {
+		return err
+	}
+	var targets []uint
+	for _, ep := range endpoints {
+		if webhookSubscribed(ep, event) {
+			targets = append(targets, ep.ID)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	token, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	payload, err := json.Marshal(webhookEnvelope{ID: "evt_" + token, Type: event, CreatedAt: now, Data: data})
+	if err != nil {
+		return err
+	}
+	rows := make([]models.WebhookDelivery, 0, len(targets))
+	for _, id := range targets {
+		rows = append(rows, models.WebhookDelivery{
+			EndpointID:    id,
+			EventID:       "evt_" + token,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        models.WebhookDeliveryPending,
+			MaxAttempts:   webhookMaxAttempts,
+			NextAttemptAt: &now,
+		})
+	}
+	return tx.Create(&rows).Error
+}
+
+func webhookEndpointEvents(ep models.WebhookEndpoint) []string {
+	var events []string
+	if len(ep.Events) > 0 {
+		_ = json.Unmarshal(ep.Events, &events)
+	}
+	return events
+}
+
+func webhookSubscribed(ep models.WebhookEndpoint, event string) bool {
+	for _, e := range webhookEndpointEvents(ep) {
+		if e == "*" || e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookBookingData: data ของ event booking.* / checkin / checkout (ไม่มีข้อมูลส่วนบุคคลของแขก)
+func webhookBookingData(tx *gorm.DB, bookingID uint) (map[string]interface{}, error) {
+	var booking models.Booking
+	if err := tx.Unscoped().Preload("Rooms.Room").First(&booking, bookingID).Error; err != nil {
+		return nil, err
+	}
+	rooms := make([]map[string]interface{}, 0, len(booking.Rooms))
+	for _, br := range booking.Rooms {
+		number := strings.TrimSpace(br.Room.RoomCode)
+		if number == "" {
+			number = strings.TrimSpace(br.Room.RoomNumber)
+		}
+		rooms = append(rooms, map[string]interface{}{"roomId": br.RoomID, "roomNumber": number})
+	}
+	arrival, departure := bookingStay(booking)
+	return map[string]interface{}{
+		"bookingId":     booking.ID,
+		"referenceCode": booking.ReferenceCode,
+		"status":        booking.Status,
+		"customerId":    booking.CustomerID,
+		"checkInDate":   receiptDate(arrival, nil),
+		"checkOutDate":  receiptDate(departure, nil),
+		"checkedInAt":   booking.CheckedInAt,
+		"adults":        booking.Adults,
+		"children":      booking.Children,
+		"rooms":         rooms,
+	}, nil
+}
+
+// EnqueueWebhooks แปลง domain event เป็น webhook delivery — service เรียกภายใน transaction ก่อน commit
+// แล้ว publish event เดิมหลัง commit (handler "webhooks" แค่ปลุก worker)
+func EnqueueWebhooks(tx *gorm.DB, events ...Event) error {
+	for _, e := range events {
+		var err error
+		switch ev := e.(type) {
+		case BookingCreated:
+			err = enqueueBookingWebhook(tx, WebhookEventBookingCreated, ev.BookingID, nil)
+		case BookingDeleted:
+			err = enqueueBookingWebhook(tx, WebhookEventBookingDeleted, ev.BookingID, nil)
+		case CheckInCompleted:
+			err = enqueueBookingWebhook(tx, WebhookEventCheckinCompleted, ev.BookingID, nil)
+		case CheckedOut:
+			err = enqueueBookingWebhook(tx, WebhookEventCheckoutCompleted, ev.BookingID, map[string]interface{}{"checkedOutAt": ev.At})
+		case GuestAdded:
+			// เฉพาะ id/สถานะ ไม่ส่งชื่อหรือเลขเอกสาร
+			err = EnqueueWebhookEvent(tx, WebhookEventGuestCreated, map[string]interface{}{
+				"guestId":      ev.GuestID,
+				"bookingId":    ev.BookingID,
+				"isMainGuest":  ev.IsMainGuest,
+				"reviewStatus": ev.ReviewStatus,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("enqueue webhook %s: %w", e.EventName(), err)
+		}
+	}
+	return nil
+}
+
+// enqueueBookingWebhook: event ที่ data เป็นข้อมูล booking (extra เติมทับ)
+func enqueueBookingWebhook(db *gorm.DB, event string, bookingID uint, extra map[string]interface{}) error {
+	data, err := webhookBookingData(db, bookingID)
+	if err != nil {
+		return err
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+	return EnqueueWebhookEvent(db, event, data)
+}
+
+// WebhookSignature: hex(HMAC-SHA256(secret, "<timestamp>.<body>")) — ส่งใน X-Webhook-Signature: v1=<hex>
+// ผู้รับคำนวณซ้ำแล้วเทียบ และปฏิเสธ timestamp ที่เก่าเกินไป
+func WebhookSignature(secret, timestamp string, body []byte) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(timestamp))
+	m.Write([]byte("."))
+	m.Write(body)
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// WebhookService: จัดการ endpoint + worker ส่ง event (retry แบบ exponential backoff)
+type WebhookService struct {
+	DB     *gorm.DB
+	Client *http.Client
+}
+
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	return &WebhookService{
+		DB: db,
+		Client: &http.Client{
+			Timeout: 15 * time.Second,
+			// ไม่ตาม redirect — endpoint ต้องตอบ 2xx ที่ URL ที่ลงทะเบียนไว้
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+	}
+}
+
+// Run: worker — ส่งรอบละ webhookBatchSize ทุก 15 วินาที หรือทันทีเมื่อมี event เข้าคิว
+func (s *WebhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	run := func() {
+		for {
+			processed, err := s.ProcessDue(ctx, time.Now())
+			if err != nil {
+				log.Printf("webhooks: %v", err)
+				return
+			}
+			if processed < webhookBatchSize || ctx.Err() != nil {
+				return
+			}
+		}
+	}
+
+	run()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("webhook worker stopped")
+			return
+		case <-ticker.C:
+			run()
+		case <-webhookWake:
+			run()
+		}
+	}
+}
+
+// ProcessDue ส่ง delivery ที่ถึงกำหนด — คืนจำนวนแถวที่หยิบมาทำ
+func (s *WebhookService) ProcessDue(ctx context.Context, now time.Time) (int, error) {
+	now = now.UTC()
+	if err := s.DB.Model(&models.WebhookDelivery{}).
+		Where("status = ? AND updated_at < ?", models.WebhookDeliverySending, now.Add(-webhookStuckAfter)).
+		Updates(map[string]interface{}{"status": models.WebhookDeliveryPending, "next_attempt_at": now}).Error; err != nil {
+		return 0, err
+	}
+
+	var due []models.WebhookDelivery
+	if err := s.DB.Where("status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)", models.WebhookDeliveryPending, now).
+		Order("next_attempt_at ASC, id ASC").Limit(webhookBatchSize).Find(&due).Error; err != nil {
+		return 0, err
+	}
+
+	for _, row := range due {
+		if ctx.Err() != nil {
+			return len(due), ctx.Err()
+		}
+		res := s.DB.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ?", row.ID, models.WebhookDeliveryPending).
+			Updates(map[string]interface{}{"status": models.WebhookDeliverySending, "updated_at": now})
+		if res.Error != nil {
+			return len(due), res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+		s.deliver(ctx, row, now)
+	}
+	return len(due), nil
+}
+
+// deliver ส่งหนึ่งครั้งแล้วบันทึกผล (SUCCEEDED / PENDING รอ backoff / FAILED)
+func (s *WebhookService) deliver(ctx context.Context, row models.WebhookDelivery, now time.Time) {
+	attempts := row.Attempts + 1
+	updates := map[string]interface{}{"attempts": attempts}
+
+	code, body, duration, err := s.post(ctx, row)
+	updates["response_code"] = code
+	updates["response_body"] = body
+	updates["duration_ms"] = duration
+	if err == nil && (code < 200 || code >= 300) {
+		err = fmt.Errorf("endpoint responded %d", code)
+	}
+
+	var endpointGone *webhookEndpointGoneError
+	switch {
+	case err == nil:
+		updates["status"] = models.WebhookDeliverySucceeded
+		updates["delivered_at"] = time.Now().UTC()
+		updates["last_error"] = ""
+		updates["next_attempt_at"] = nil
+	case errors.As(err, &endpointGone), attempts >= row.MaxAttempts:
+		updates["status"] = models.WebhookDeliveryFailed
+		updates["last_error"] = err.Error()
+		updates["next_attempt_at"] = nil
+		log.Printf("webhooks: delivery #%d (%s) failed permanently after %d attempts: %v", row.ID, row.Event, attempts, err)
+	default:
+		updates["status"] = models.WebhookDeliveryPending
+		updates["last_error"] = err.Error()
+		updates["next_attempt_at"] = now.Add(outboxBackoff(attempts))
+	}
+	if err := s.DB.Model(&models.WebhookDelivery{}).Where("id = ?", row.ID).Updates(updates).Error; err != nil {
+		log.Printf("webhooks: update delivery #%d: %v", row.ID, err)
+	}
+}
+
+type webhookEndpointGoneError struct{ reason string }
+
+func (e *webhookEndpointGoneError) Error() string { return "endpoint " + e.reason }
+
+// post ส่ง payload พร้อมลายเซ็น — คืน status code, body (ตัดแล้ว), เวลาที่ใช้ (ms)
+func (s *WebhookService) post(ctx context.Context, row models.WebhookDelivery) (int, string, int, error) {
+	var ep models.WebhookEndpoint
+	if err := s.DB.First(&ep, row.EndpointID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, "", 0, &webhookEndpointGoneError{reason: "deleted"}
+		}
+		return 0, "", 0, err
+	}
+	if !ep.Active {
+		return 0, "", 0, &webhookEndpointGoneError{reason: "disabled"}
+	}
+	secret, err := DecryptPIIString(ep.Secret)
+	if err != nil {
+		return 0, "", 0, err
+	}
+
+	body := []byte(row.Payload)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "hotel-backend-webhooks/1")
+	req.Header.Set("X-Webhook-Id", row.EventID)
+	req.Header.Set("X-Webhook-Event", row.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(row.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", ts)
+	req.Header.Set("X-Webhook-Signature", "v1="+WebhookSignature(secret, ts, body))
+
+	started := time.Now()
+	resp, err := s.Client.Do(req)
+	duration := int(time.Since(started) / time.Millisecond)
+	if err != nil {
+		return 0, "", duration, err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, webhookBodyLimit))
+	return resp.StatusCode, string(raw), duration, nil
+}
+
+// ------------------------------------------------------------
+// Endpoint management (หน้า admin)
+// ------------------------------------------------------------
+
+// WebhookEndpointInput: สร้าง/แก้ endpoint (nil = ไม่เปลี่ยน)
+type WebhookEndpointInput struct {
+	Name        *string   `json:"name"`
+	URL         *string   `json:"url"`
+	Description *string   `json:"description"`
+	Events      *[]string `json:"events"`
+	Active      *bool     `json:"active"`
+}
+
+// WebhookEndpointView: endpoint สำหรับหน้า admin — Secret มีค่าเฉพาะตอนสร้าง/หมุน secret
+type WebhookEndpointView struct {
+	models.WebhookEndpoint
+	Secret string `json:"secret,omitempty"`
+}
+
+func validateWebhookURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || len(raw) > 500 {
+		return "", errors.New("invalid_webhook_url")
+	}
+	return raw, nil
+}
+
+func validateWebhookEvents(events []string) (datatypes.JSON, error) {
+	var out []string
+	seen := map[string]bool{}
+	for _, e := range events {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e == "" || seen[e] {
+			continue
+		}
+		known := e == "*"
+		for _, k := range webhookEvents {
+			known = known || k == e
+		}
+		if !known {
+			return nil, errors.New("invalid_webhook_event: " + e)
+		}
+		seen[e] = true
+		out = append(out, e)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("invalid_webhook_event: at least one event is required")
+	}
+	b, err := json.Marshal(out)
+	return datatypes.JSON(b), err
+}
+
+func newWebhookSecret() (plain, sealed string, err error) {
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	plain = "whsec_" + token
+	sealed, err = EncryptPIIString(plain)
+	return plain, sealed, err
+}
+
+// ListEndpoints endpoint ทั้งหมด
+func (s *WebhookService) ListEndpoints() ([]models.WebhookEndpoint, error) {
+	var rows []models.WebhookEndpoint
+	err := s.DB.Order("id ASC").Find(&rows).Error
+	return rows, err
+}
+
+// GetEndpoint endpoint ตาม id
+func (s *WebhookService) GetEndpoint(id uint) (models.WebhookEndpoint, error) {
+	var ep models.WebhookEndpoint
+	err := s.DB.First(&ep, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ep, errors.New("webhook_endpoint_not_found")
+	}
+	return ep, err
+}
+
+// CreateEndpoint ลงทะเบียน endpoint ใหม่ (active ตั้งต้น) พร้อมสร้าง secret
+func (s *WebhookService) CreateEndpoint(in WebhookEndpointInput, adminID uint) (WebhookEndpointView, error) {
+	if in.URL == nil || in.Events == nil {
+		return WebhookEndpointView{}, errors.New("webhook_fields_required")
+	}
+	ep := models.WebhookEndpoint{Active: true}
+	if err := applyWebhookEndpointInput(&ep, in); err != nil {
+		return WebhookEndpointView{}, err
+	}
+	plain, sealed, err := newWebhookSecret()
+	if err != nil {
+		return WebhookEndpointView{}, err
+	}
+	ep.Secret = sealed
+	if adminID != 0 {
+		ep.CreatedBy = &adminID
+	}
+	if err := s.DB.Create(&ep).Error; err != nil {
+		return WebhookEndpointView{}, err
+	}
+	return WebhookEndpointView{WebhookEndpoint: ep, Secret: plain}, nil
+}
+
+func applyWebhookEndpointInput(ep *models.WebhookEndpoint, in WebhookEndpointInput) error {
+	if in.Name != nil {
+		name := strings.TrimSpace(*in.Name)
+		if len(name) > 100 {
+			name = name[:100]
+		}
+		ep.Name = name
+	}
+	if in.URL != nil {
+		u, err := validateWebhookURL(*in.URL)
+		if err != nil {
+			return err
+		}
+		ep.URL = u
+	}
+	if in.Description != nil {
+		desc := strings.TrimSpace(*in.Description)
+		if len(desc) > 500 {
+			desc = desc[:500]
+		}
+		ep.Description = desc
+	}
+	if in.Events != nil {
+		events, err := validateWebhookEvents(*in.Events)
+		if err != nil {
+			return err
+		}
+		ep.Events = events
+	}
+	if in.Active != nil {
+		ep.Active = *in.Active
+	}
+	return nil
+}
+
+// UpdateEndpoint แก้ชื่อ/URL/event/เปิด-ปิด
+func (s *WebhookService) UpdateEndpoint(id uint, in WebhookEndpointInput) (models.WebhookEndpoint, error) {
+	ep, err := s.GetEndpoint(id)
+	if err != nil {
+		return ep, err
+	}
+	if err := applyWebhookEndpointInput(&ep, in); err != nil {
+		return ep, err
+	}
+	err = s.DB.Model(&ep).Select("name", "url", "description", "events", "active").Updates(&ep).Error
+	return ep, err
+}
+
+// RotateSecret สร้าง secret ใหม่ (ของเดิมใช้ไม่ได้ทันที)
+func (s *WebhookService) RotateSecret(id uint) (WebhookEndpointView, error) {
+	ep, err := s.GetEndpoint(id)
+	if err != nil {
+		return WebhookEndpointView{}, err
+	}
+	plain, sealed, err := newWebhookSecret()
+	if err != nil {
+		return WebhookEndpointView{}, err
+	}
+	if err := s.DB.Model(&ep).Update("secret", sealed).Error; err != nil {
+		return WebhookEndpointView{}, err
+	}
+	return WebhookEndpointView{WebhookEndpoint: ep, Secret: plain}, nil
+}
+
+// DeleteEndpoint ลบ endpoint — delivery ที่ค้างอยู่จะ FAILED ตอน worker หยิบไปส่ง
+func (s *WebhookService) DeleteEndpoint(id uint) error {
+	res := s.DB.Delete(&models.WebhookEndpoint{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("webhook_endpoint_not_found")
+	}
+	return nil
+}
+
+// ------------------------------------------------------------
+// Delivery log + replay
+// ------------------------------------------------------------
+
+// WebhookDeliveryFilter: ตัวกรองหน้า admin
+type WebhookDeliveryFilter struct {
+	EndpointID uint
+	Event      string
+	EventID    string
+	Status     string
+	Page       int
+	Limit      int
+}
+
+// ListDeliveries log การส่ง (ไม่รวม payload) ใหม่สุดก่อน
+func (s *WebhookService) ListDeliveries(f WebhookDeliveryFilter) ([]models.WebhookDelivery, int64, error) {
+	if f.Limit <= 0 || f.Limit > 200 {
+		f.Limit = 50
+	}
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	q := s.DB.Model(&models.WebhookDelivery{})
+	if f.EndpointID != 0 {
+		q = q.Where("endpoint_id = ?", f.EndpointID)
+	}
+	if f.Event != "" {
+		q = q.Where("event = ?", f.Event)
+	}
+	if f.EventID != "" {
+		q = q.Where("event_id = ?", f.EventID)
+	}
+	if f.Status != "" {
+		q = q.Where("status = ?", strings.ToUpper(f.Status))
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []models.WebhookDelivery
+	err := q.Omit("payload").Order("id DESC").
+		Limit(f.Limit).Offset((f.Page - 1) * f.Limit).Find(&rows).Error
+	return rows, total, err
+}
+
+// GetDelivery delivery หนึ่งแถวพร้อม payload
+func (s *WebhookService) GetDelivery(id uint) (models.WebhookDelivery, error) {
+	var row models.WebhookDelivery
+	err := s.DB.First(&row, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return row, errors.New("webhook_delivery_not_found")
+	}
+	return row, err
+}
+
+// Replay ส่ง event เดิม (payload + event id เดิม) ไปยัง endpoint เดิมอีกครั้งเป็นแถวใหม่
+func (s *WebhookService) Replay(id, adminID uint) (models.WebhookDelivery, error) {
+	orig, err := s.GetDelivery(id)
+	if err != nil {
+		return orig, err
+	}
+	if orig.Status == models.WebhookDeliveryPending || orig.Status == models.WebhookDeliverySending {
+		return orig, errors.New("webhook_delivery_pending")
+	}
+	ep, err := s.GetEndpoint(orig.EndpointID)
+	if err != nil {
+		return orig, err
+	}
+	if !ep.Active {
+		return orig, errors.New("webhook_endpoint_disabled")
+	}
+	now := time.Now().UTC()
+	row := models.WebhookDelivery{
+		EndpointID:    orig.EndpointID,
+		EventID:       orig.EventID,
+		Event:         orig.Event,
+		Payload:       orig.Payload,
+		Status:        models.WebhookDeliveryPending,
+		MaxAttempts:   webhookMaxAttempts,
+		NextAttemptAt: &now,
+		ReplayOf:      &orig.ID,
+	}
+	if adminID != 0 {
+		row.RequestedBy = &adminID
+	}
+	if err := s.DB.Create(&row).Error; err != nil {
+		return row, err
+	}
+	wakeWebhookWorker()
+	return row, nil
+}
@@ -0,0 +1,637 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"hotel-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Work orders: งานซ่อมบำรุงของห้อง
+// open -> in_progress <-> on_hold -> resolved -> closed (resolved เปิดใหม่ได้ / ยกเลิกได้ก่อน resolved)
+// ปิดห้องระหว่างซ่อมด้วย RoomBlock (out_of_order) — จองห้องช่วงนั้นไม่ได้ และ block ถูกยกเลิกเมื่องาน resolved/cancelled
+
+const (
+	workOrderPhotoDir      = "work-orders"
+	workOrderMaxPhotos     = 10
+	WorkOrderPhotoURLTTL   = 10 * time.Minute
+	workOrderPhotoSignKind = "work-order-photo"
+)
+
+// WorkOrderService: งานซ่อมจากหน้า admin / ช่าง
+type WorkOrderService struct {
+	DB     *gorm.DB
+	Blocks *RoomBlockService
+}
+
+func NewWorkOrderService(db *gorm.DB) *WorkOrderService {
+	return &WorkOrderService{DB: db, Blocks: NewRoomBlockService(db)}
+}
+
+func workOrderChanged(o models.WorkOrder, at time.Time) WorkOrderChanged {
+	return WorkOrderChanged{
+		WorkOrderID: o.ID,
+		RoomID:      o.RoomID,
+		Category:    o.Category,
+		Priority:    o.Priority,
+		Status:      o.Status,
+		AssignedTo:  o.AssignedTo,
+		RoomBlockID: o.RoomBlockID,
+		At:          at,
+	}
+}
+
+// WorkOrderFilter: ตัวกรองรายการงานซ่อม
+type WorkOrderFilter struct {
+	RoomID     uint
+	Floor      string
+	Status     string
+	Category   string
+	Priority   string
+	AssignedTo uint
+	OpenOnly   bool // ยังไม่ resolved/closed/cancelled
+	Page       int
+	Limit      int
+}
+
+// workOrderOpenStatuses สถานะที่ยังต้องทำงาน
+var workOrderOpenStatuses = []string{models.WorkOrderOpen, models.WorkOrderInProgress, models.WorkOrderOnHold}
+
+// List งานซ่อมตามตัวกรอง — ด่วนก่อน แล้วเก่าสุดก่อน
+func (s *WorkOrderService) List(f WorkOrderFilter) ([]models.WorkOrder, int64, error) {
+	if f.Limit <= 0 || f.Limit > 200 {
+		f.Limit = 100
+	}
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	q := s.DB.Model(&models.WorkOrder{})
+	if f.RoomID != 0 {
+		q = q.Where("work_orders.room_id = ?", f.RoomID)
+	}
+	if f.Floor != "" {
+		q = q.Where("work_orders.room_id IN (?)", s.DB.Model(&models.Room{}).Select("id").Where("floor = ?", f.Floor))
+	}
+	if f.Status != "" {
+		q = q.Where("work_orders.status IN ?", strings.Split(f.Status, ","))
+	}
+	if f.Category != "" {
+		q = q.Where("work_orders.category = ?", f.Category)
+	}
+	if f.Priority != "" {
+		q = q.Where("work_orders.priority = ?", f.Priority)
+	}
+	if f.AssignedTo != 0 {
+		q = q.Where("work_orders.assigned_to = ?", f.AssignedTo)
+	}
+	if f.OpenOnly {
+		q = q.Where("work_orders.status IN ?", workOrderOpenStatuses)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []models.WorkOrder
+	err := q.Preload("Room").Preload("RoomBlock").
+		Order(clause.Expr{SQL: "FIELD(work_orders.priority, ?, ?, ?, ?), work_orders.created_at ASC, work_orders.id ASC",
+			Vars: []interface{}{models.WorkOrderUrgent, models.WorkOrderHigh, models.WorkOrderNormal, models.WorkOrderLow}}).
+		Offset((f.Page - 1) * f.Limit).Limit(f.Limit).Find(&rows).Error
+	return rows, total, err
+}
+
+// Get งานซ่อมตาม id พร้อมรูป (มี signed URL สำหรับ adminID)
+func (s *WorkOrderService) Get(id, adminID uint) (models.WorkOrder, error) {
+	var o models.WorkOrder
+	err := s.DB.Preload("Room").Preload("RoomBlock").
+		Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		First(&o, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return o, errors.New("work_order_not_found")
+		}
+		return o, err
+	}
+	for i := range o.Photos {
+		o.Photos[i].URL = WorkOrderPhotoURL(o.Photos[i].ID, adminID)
+	}
+	return o, nil
+}
+
+// WorkOrderInput: แจ้งซ่อม
+type WorkOrderInput struct {
+	RoomID      uint     `json:"roomId"`
+	Category    string   `json:"category"`
+	Priority    string   `json:"priority"` // ค่าเริ่มต้น normal
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	AssignedTo  *uint    `json:"assignedTo"`
+	Photos      []string `json:"photos"` // base64 / data URI
+
+	// ปิดห้องระหว่างซ่อม: [BlockStartDate, BlockEndDate) — ไม่ระบุวันเริ่ม = วันนี้
+	OutOfOrder     bool   `json:"outOfOrder"`
+	BlockStartDate string `json:"blockStartDate"`
+	BlockEndDate   string `json:"blockEndDate"`
+}
+
+// Create แจ้งซ่อม (และปิดห้องถ้า OutOfOrder) — คืน booking ที่ทับช่วงปิดห้องให้ย้ายห้องเอง
+func (s *WorkOrderService) Create(in WorkOrderInput, adminID uint) (models.WorkOrder, []uint, error) {
+	category := strings.TrimSpace(in.Category)
+	if !models.ValidWorkOrderCategory(category) {
+		return models.WorkOrder{}, nil, errors.New("invalid_work_order_category")
+	}
+	priority := strings.TrimSpace(in.Priority)
+	if priority == "" {
+		priority = models.WorkOrderNormal
+	}
+	if !models.ValidWorkOrderPriority(priority) {
+		return models.WorkOrder{}, nil, errors.New("invalid_work_order_priority")
+	}
+	title := strings.TrimSpace(in.Title)
+	description := strings.TrimSpace(in.Description)
+	if title == "" && description == "" {
+		return models.WorkOrder{}, nil, errors.New("work_order_description_required")
+	}
+	if len(in.Photos) > workOrderMaxPhotos {
+		return models.WorkOrder{}, nil, errors.New("too_many_photos")
+	}
+	var room models.Room
+	if err := s.DB.Select("id").First(&room, in.RoomID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.WorkOrder{}, nil, errors.New("room_not_found")
+		}
+		return models.WorkOrder{}, nil, err
+	}
+	if in.AssignedTo != nil {
+		if err := s.ensureTechnician(*in.AssignedTo); err != nil {
+			return models.WorkOrder{}, nil, err
+		}
+	}
+
+	var block *models.RoomBlock
+	if in.OutOfOrder {
+		b, err := s.Blocks.newBlock(s.blockInput(room.ID, category, title, description, in.BlockStartDate, in.BlockEndDate), adminID)
+		if err != nil {
+			return models.WorkOrder{}, nil, err
+		}
+		block = &b
+	}
+
+	// เก็บรูปก่อนเปิด transaction (ไฟล์ที่เก็บแล้วถูกลบถ้าบันทึกไม่สำเร็จ)
+	keys, err := saveWorkOrderPhotos(in.Photos)
+	if err != nil {
+		return models.WorkOrder{}, nil, err
+	}
+
+	now := time.Now().UTC()
+	order := models.WorkOrder{
+		RoomID:      room.ID,
+		Category:    category,
+		Priority:    priority,
+		Title:       title,
+		Description: description,
+		Status:      models.WorkOrderOpen,
+		ReportedBy:  &adminID,
+	}
+	if in.AssignedTo != nil {
+		order.AssignedTo, order.AssignedBy, order.AssignedAt = in.AssignedTo, &adminID, &now
+	}
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if block != nil {
+			if err := tx.Create(block).Error; err != nil {
+				return err
+			}
+			order.RoomBlockID = &block.ID
+		}
+		if err := tx.Omit(clause.Associations).Create(&order).Error; err != nil {
+			return err
+		}
+		if block != nil {
+			block.Reason = fmt.Sprintf("work order #%d: %s", order.ID, block.Reason)
+			if err := tx.Model(block).Update("reason", truncate(block.Reason, 500)).Error; err != nil {
+				return err
+			}
+		}
+		return insertWorkOrderPhotos(tx, order.ID, keys, adminID)
+	})
+	if err != nil {
+		deleteWorkOrderPhotos(keys)
+		return models.WorkOrder{}, nil, err
+	}
+
+	var conflicts []uint
+	if block != nil {
+		if _, conflicts, err = s.Blocks.activate(*block); err != nil {
+			log.Printf("work order %d: activate room block %d: %v", order.ID, block.ID, err)
+		}
+	}
+	PublishEvents(workOrderChanged(order, now))
+	order, err = s.Get(order.ID, adminID)
+	return order, conflicts, err
+}
+
+// blockInput RoomBlockInput ของงานซ่อม (ไม่ระบุวันเริ่ม = วันนี้)
+func (s *WorkOrderService) blockInput(roomID uint, category, title, description, start, end string) RoomBlockInput {
+	start = strings.TrimSpace(start)
+	if start == "" {
+		start = hotelToday(s.DB, time.Now()).Format("2006-01-02")
+	}
+	reason := title
+	if reason == "" {
+		reason = description
+	}
+	return RoomBlockInput{
+		RoomID:    roomID,
+		Kind:      models.RoomOutOfOrder,
+		StartDate: start,
+		EndDate:   end,
+		Reason:    truncate(category+": "+reason, 400),
+	}
+}
+
+// BlockRoom ปิดห้องของงานซ่อมที่ยังเปิดอยู่ (กรณีไม่ได้ปิดตอนแจ้งซ่อม หรือ block เดิมจบไปแล้ว)
+func (s *WorkOrderService) BlockRoom(id, adminID uint, startDate, endDate string) (models.WorkOrder, []uint, error) {
+	var conflicts []uint
+	var block models.RoomBlock
+	order, err := s.update(id, adminID, func(tx *gorm.DB, o *models.WorkOrder, now time.Time) error {
+		if !workOrderIsOpen(o.Status) {
+			return invalidWorkOrderTransition(o)
+		}
+		if o.RoomBlockID != nil {
+			var current models.RoomBlock
+			if err := tx.First(&current, *o.RoomBlockID).Error; err == nil && current.EndedAt == nil && current.ReleasedAt == nil {
+				return errors.New("work_order_already_blocked")
+			}
+		}
+		b, err := s.Blocks.newBlock(s.blockInput(o.RoomID, o.Category, o.Title, o.Description, startDate, endDate), adminID)
+		if err != nil {
+			return err
+		}
+		b.Reason = truncate(fmt.Sprintf("work order #%d: %s", o.ID, b.Reason), 500)
+		if err := tx.Create(&b).Error; err != nil {
+			return err
+		}
+		block = b
+		o.RoomBlockID = &block.ID
+		return nil
+	})
+	if err != nil {
+		return order, nil, err
+	}
+	if _, conflicts, err = s.Blocks.activate(block); err != nil {
+		log.Printf("work order %d: activate room block %d: %v", id, block.ID, err)
+	}
+	order, err = s.Get(id, adminID)
+	return order, conflicts, err
+}
+
+// ensureTechnician: ผู้รับงานต้องเป็น admin ที่มีสิทธิ์ maintenance.work
+func (s *WorkOrderService) ensureTechnician(adminID uint) error {
+	ok, err := AdminHasPermission(s.DB, adminID, "maintenance.work")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("work_order_assignee_invalid")
+	}
+	return nil
+}
+
+// Technicians admin ที่รับงานซ่อมได้ (มีสิทธิ์ maintenance.work) สำหรับ dropdown มอบหมายงาน
+func (s *WorkOrderService) Technicians() ([]models.Admin, error) {
+	var admins []models.Admin
+	withPerm := s.DB.Model(&models.RoleMember{}).Select("role_members.admin_id").
+		Joins("JOIN role_permissions ON role_permissions.role_id = role_members.role_id").
+		Where("role_permissions.permission = ?", "maintenance.work")
+	err := s.DB.Where("id IN (?)", withPerm).Order("full_name").Find(&admins).Error
+	return admins, err
+}
+
+// update: ล็อกงานแล้วให้ fn เปลี่ยนค่า แล้ว publish WorkOrderChanged หลัง commit
+func (s *WorkOrderService) update(id, adminID uint, fn func(tx *gorm.DB, o *models.WorkOrder, now time.Time) error) (models.WorkOrder, error) {
+	var order models.WorkOrder
+	now := time.Now().UTC()
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("work_order_not_found")
+			}
+			return err
+		}
+		if err := fn(tx, &order, now); err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Save(&order).Error
+	})
+	if err != nil {
+		return models.WorkOrder{}, err
+	}
+	PublishEvents(workOrderChanged(order, now))
+	return s.Get(order.ID, adminID)
+}
+
+func workOrderIsOpen(status string) bool {
+	for _, st := range workOrderOpenStatuses {
+		if status == st {
+			return true
+		}
+	}
+	return false
+}
+
+func invalidWorkOrderTransition(o *models.WorkOrder) error {
+	return errors.New("work_order_invalid_transition: " + o.Status)
+}
+
+// Assign มอบหมายงาน (assigneeID = nil คืนงานเข้ากอง)
+func (s *WorkOrderService) Assign(id uint, assigneeID *uint, adminID uint) (models.WorkOrder, error) {
+	if assigneeID != nil {
+		if err := s.ensureTechnician(*assigneeID); err != nil {
+			return models.WorkOrder{}, err
+		}
+	}
+	return s.update(id, adminID, func(tx *gorm.DB, o *models.WorkOrder, now time.Time) error {
+		if !workOrderIsOpen(o.Status) {
+			return invalidWorkOrderTransition(o)
+		}
+		o.AssignedTo, o.AssignedBy, o.AssignedAt = assigneeID, &adminID, &now
+		if assigneeID == nil {
+			o.AssignedAt = nil
+		}
+		return nil
+	})
+}
+
+// canWork: ช่างทำได้เฉพาะงานของตัวเอง (หรืองานที่ยังไม่มีคนรับ — รับเองอัตโนมัติ) เว้นแต่มีสิทธิ์ maintenance.assign
+func (s *WorkOrderService) canWork(o *models.WorkOrder, adminID uint) error {
+	if o.AssignedTo == nil || *o.AssignedTo == adminID {
+		return nil
+	}
+	ok, err := AdminHasPermission(s.DB, adminID, "maintenance.assign")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("work_order_not_assignee")
+	}
+	return nil
+}
+
+// Start open/on_hold -> in_progress
+func (s *WorkOrderService) Start(id, adminID uint) (models.WorkOrder, error) {
+	return s.update(id, adminID, func(tx *gorm.DB, o *models.WorkOrder, now time.Time) error {
+		if o.Status != models.WorkOrderOpen && o.Status != models.WorkOrderOnHold {
+			return invalidWorkOrderTransition(o)
+		}
+		if err := s.canWork(o, adminID); err != nil {
+			return err
+		}
+		if o.AssignedTo == nil {
+			o.AssignedTo, o.AssignedBy, o.AssignedAt = &adminID, &adminID, &now
+		}
+		if o.StartedAt == nil {
+			o.StartedAt = &now
+		}
+		o.Status = models.WorkOrderInProgress
+		return nil
+	})
+}
+
+// Hold in_progress -> on_hold (เช่นรออะไหล่ — ต้องมี note)
+func (s *WorkOrderService) Hold(id, adminID uint, note string) (models.WorkOrder, error) {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return models.WorkOrder{}, errors.New("work_order_note_required")
+	}
+	return s.update(id, adminID, func(tx *gorm.DB, o *models.WorkOrder, now time.Time) error {
+		if o.Status != models.WorkOrderInProgress {
+			return invalidWorkOrderTransition(o)
+		}
+		if err := s.canWork(o, adminID); err != nil {
+			return err
+		}
+		o.Status = models.WorkOrderOnHold
+		o.StatusNote = truncate(note, 500)
+		return nil
+	})
+}
+
+// Resolve งานที่ยังเปิด -> resolved (ต้องมีรายละเอียดการซ่อม) และเปิดห้องคืน (ห้อง dirty รอทำความสะอาด)
+func (s *WorkOrderService) Resolve(id, adminID uint, note string) (models.WorkOrder, error) {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return models.WorkOrder{}, errors.New("work_order_note_required")
+	}
+	order, err := s.update(id, adminID, func(tx *gorm.DB, o *models.WorkOrder, now time.Time) error {
+		if !workOrderIsOpen(o.Status) {
+			return invalidWorkOrderTransition(o)
+		}
+		if err := s.canWork(o, adminID); err != nil {
+			return err
+		}
+		o.Status = models.WorkOrderResolved
+		o.ResolvedAt, o.ResolvedBy = &now, &adminID
+		o.ResolutionNote = note
+		return nil
+	})
+	if err != nil {
+		return order, err
+	}
+	return s.releaseBlock(order, adminID)
+}
+
+// Close resolved -> closed (หัวหน้าตรวจงานแล้ว)
+func (s *WorkOrderService) Close(id, adminID uint) (models.WorkOrder, error) {
+	return s.update(id, adminID, func(tx *gorm.DB, o *models.WorkOrder, now time.Time) error {
+		if o.Status != models.WorkOrderResolved {
+			return invalidWorkOrderTransition(o)
+		}
+		o.Status = models.WorkOrderClosed
+		o.ClosedAt = &now
+		return nil
+	})
+}
+
+// Reopen resolved -> open (ซ่อมแล้วยังไม่หาย — ต้องมี note; ปิดห้องใหม่ผ่าน BlockRoom)
+func (s *WorkOrderService) Reopen(id, adminID uint, note string) (models.WorkOrder, error) {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return models.WorkOrder{}, errors.New("work_order_note_required")
+	}
+	return s.update(id, adminID, func(tx *gorm.DB, o *models.WorkOrder, now time.Time) error {
+		if o.Status != models.WorkOrderResolved {
+			return invalidWorkOrderTransition(o)
+		}
+		o.Status = models.WorkOrderOpen
+		o.ResolvedAt, o.ResolvedBy = nil, nil
+		o.StatusNote = truncate(note, 500)
+		return nil
+	})
+}
+
+// Cancel งานที่ยังเปิด -> cancelled (ต้องมีเหตุผล) และเปิดห้องคืน
+func (s *WorkOrderService) Cancel(id, adminID uint, note string) (models.WorkOrder, error) {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return models.WorkOrder{}, errors.New("work_order_note_required")
+	}
+	order, err := s.update(id, adminID, func(tx *gorm.DB, o *models.WorkOrder, now time.Time) error {
+		if !workOrderIsOpen(o.Status) {
+			return invalidWorkOrderTransition(o)
+		}
+		o.Status = models.WorkOrderCancelled
+		o.ClosedAt = &now
+		o.StatusNote = truncate(note, 500)
+		return nil
+	})
+	if err != nil {
+		return order, err
+	}
+	return s.releaseBlock(order, adminID)
+}
+
+// releaseBlock ยกเลิก block ของงาน (ถ้ายังมีผล) — ห้องกลับเป็น dirty + งานทำความสะอาด
+func (s *WorkOrderService) releaseBlock(order models.WorkOrder, adminID uint) (models.WorkOrder, error) {
+	if order.RoomBlockID == nil {
+		return order, nil
+	}
+	if _, err := s.Blocks.Release(*order.RoomBlockID, adminID); err != nil && err.Error() != "room_block_already_ended" {
+		log.Printf("work order %d: release room block %d: %v", order.ID, *order.RoomBlockID, err)
+	}
+	return s.Get(order.ID, adminID)
+}
+
+// AddPhotos เพิ่มรูปให้งานซ่อม (รวมไม่เกิน workOrderMaxPhotos)
+func (s *WorkOrderService) AddPhotos(id, adminID uint, photos []string) (models.WorkOrder, error) {
+	if len(photos) == 0 {
+		return models.WorkOrder{}, errors.New("photo_required")
+	}
+	var order models.WorkOrder
+	if err := s.DB.Select("id").First(&order, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return order, errors.New("work_order_not_found")
+		}
+		return order, err
+	}
+	var count int64
+	if err := s.DB.Model(&models.WorkOrderPhoto{}).Where("work_order_id = ?", id).Count(&count).Error; err != nil {
+		return order, err
+	}
+	if int(count)+len(photos) > workOrderMaxPhotos {
+		return order, errors.New("too_many_photos")
+	}
+	keys, err := saveWorkOrderPhotos(photos)
+	if err != nil {
+		return order, err
+	}
+	if err := insertWorkOrderPhotos(s.DB, id, keys, adminID); err != nil {
+		deleteWorkOrderPhotos(keys)
+		return order, err
+	}
+	return s.Get(id, adminID)
+}
+
+// OpenPhoto ตรวจลายเซ็น/อายุของ URL แล้วอ่านรูป (ถอดรหัสแล้ว) พร้อม content-type
+func (s *WorkOrderService) OpenPhoto(ctx context.Context, photoID, adminID uint, exp int64, sig string) ([]byte, string, error) {
+	if time.Now().Unix() > exp || !hmac.Equal([]byte(sig), []byte(signWorkOrderPhoto(photoID, adminID, exp))) {
+		return nil, "", errors.New("link_invalid_or_expired")
+	}
+	var photo models.WorkOrderPhoto
+	if err := s.DB.First(&photo, photoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", errors.New("file_not_found")
+		}
+		return nil, "", err
+	}
+	stored, err := DecryptPIIString(photo.StorageKey)
+	if err != nil {
+		return nil, "", err
+	}
+	key, err := normalizeBlobKey(stored)
+	if err != nil {
+		return nil, "", errors.New("file_not_found")
+	}
+	data, contentType, err := OpenGuestImage(ctx, DefaultBlobStore(), key)
+	if errors.Is(err, ErrBlobNotFound) {
+		return nil, "", errors.New("file_not_found")
+	}
+	return data, contentType, err
+}
+
+// saveWorkOrderPhotos เก็บรูปแบบเข้ารหัส (เหมือนรูปของแขก) และคืน key ที่เข้ารหัสแล้ว
+func saveWorkOrderPhotos(photos []string) ([]string, error) {
+	keys := make([]string, 0, len(photos))
+	for _, p := range photos {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		key, err := SaveGuestImage(p, workOrderPhotoDir)
+		if err != nil {
+			deleteWorkOrderPhotos(keys)
+			return nil, fmt.Errorf("invalid_photo: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func insertWorkOrderPhotos(tx *gorm.DB, orderID uint, keys []string, adminID uint) error {
+	for _, key := range keys {
+		if err := tx.Create(&models.WorkOrderPhoto{WorkOrderID: orderID, StorageKey: key, UploadedBy: &adminID}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteWorkOrderPhotos ลบไฟล์ที่เก็บไปแล้วเมื่อบันทึกงานไม่สำเร็จ
+func deleteWorkOrderPhotos(keys []string) {
+	for _, stored := range keys {
+		path, err := DecryptPIIString(stored)
+		if err != nil {
+			continue
+		}
+		if err := DeleteStoredImage(path); err != nil {
+			log.Printf("work order photo cleanup: %v", err)
+		}
+	}
+}
+
+func signWorkOrderPhoto(photoID, adminID uint, exp int64) string {
+	mac := hmac.New(sha256.New, documentURLSecret())
+	fmt.Fprintf(mac, "%s|%d|%d|%d", workOrderPhotoSignKind, photoID, adminID, exp)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WorkOrderPhotoURL signed URL ของรูปงานซ่อม (เปิดใน <img> ได้โดยไม่ต้องส่ง Authorization header)
+func WorkOrderPhotoURL(photoID, adminID uint) string {
+	exp := time.Now().UTC().Add(WorkOrderPhotoURLTTL).Unix()
+	q := url.Values{}
+	q.Set("aid", strconv.FormatUint(uint64(adminID), 10))
+	q.Set("exp", strconv.FormatInt(exp, 10))
+	q.Set("sig", signWorkOrderPhoto(photoID, adminID, exp))
+	return fmt.Sprintf("/api/work-orders/photos/%d?%s", photoID, q.Encode())
+}
+
+// truncate ตัดข้อความให้ไม่เกิน n ไบต์ (ไม่ตัดกลางตัวอักษร UTF-8)
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"log"
+	"strings"
+)
+
+// SendCheckInReminderEmail reminds a guest who has not completed online check-in yet.
+// arrivalToday switches the wording between the day-before and the morning-of-arrival reminder.
+// Content comes from the "checkin_reminder" template (see email_template.go).
+// bookingInfoID links delivery webhooks back to BookingInfo.EmailStatus; locale is the guest's language ("" = hotel default).
+func SendCheckInReminderEmail(bookingInfoID uint, locale, recipientEmail, guestName, bookingRef, checkinLink, checkInDate, confirmationCode string, arrivalToday bool) error {
+	safe := func(s string) string {
+		return strings.ReplaceAll(strings.TrimSpace(s), "\r\n", " ")
+	}
+
+	checkinLink = safe(checkinLink)
+	if !(strings.HasPrefix(checkinLink, "http://") || strings.HasPrefix(checkinLink, "https://")) {
+		checkinLink = "https://" + strings.TrimLeft(checkinLink, "/")
+	}
+	bookingRef = safe(bookingRef)
+
+	mail, err := RenderEmail(EmailKindCheckinReminder, locale, map[string]interface{}{
+		"GuestName":        safe(guestName),
+		"BookingRef":       bookingRef,
+		"ConfirmationCode": safe(confirmationCode),
+		"CheckInDate":      safe(checkInDate),
+		"CheckinLink":      checkinLink,
+		"ArrivalToday":     arrivalToday,
+	})
+	if err != nil {
+		log.Printf("Failed to render check-in reminder for %s: %v", recipientEmail, err)
+		return err
+	}
+
+	if err := deliverEmail(OutboundEmail{Kind: EmailKindCheckinReminder, Ref: BookingInfoEmailRef(bookingInfoID), To: []string{recipientEmail}, Subject: mail.Subject, HTML: mail.HTML, Text: mail.Text, FromName: mail.FromName}); err != nil {
+		log.Printf("Failed to send check-in reminder to %s: %v", recipientEmail, err)
+		return err
+	}
+
+	log.Printf("Check-in reminder sent to %s (booking %s)", recipientEmail, bookingRef)
+	return nil
+}
@@ -6,11 +6,9 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"math/big"
 	"os"
-	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
@@ -183,7 +181,12 @@ func MaskEmail(email string) string {
 
 // SendCheckInLinkEmail — send HTML + plain text email including confirmation code
 // NOTE: changed to accept rooms []RoomInfo so email can include number+type for every room.
+// เนื้อหามาจาก template "checkin_link" (ดู email_template.go) ในภาษาของแขก
+// bookingInfoID ใช้จับคู่ delivery webhook กลับไปที่ BookingInfo.EmailStatus (0 = ไม่ติดตาม)
+// locale: ภาษาของแขก (services.GuestEmailLocale) — "" = ภาษาตั้งต้นของโรงแรม
 func SendCheckInLinkEmail(
+	bookingInfoID uint,
+	locale string,
 	recipientEmail,
 	bookingRef,
 	checkinLink,
@@ -194,111 +197,34 @@ func SendCheckInLinkEmail(
 	confirmationCode string,
 ) error {
 
-	fromName := strings.TrimSpace(os.Getenv("SMTP_FROM_NAME"))
-	if fromName == "" {
-		fromName = strings.TrimSpace(os.Getenv("RESEND_FROM_NAME"))
-	}
-	if fromName == "" {
-		fromName = "Hotel"
-	}
-
 	// sanitize strings
 	safe := func(s string) string {
 		return strings.ReplaceAll(strings.TrimSpace(s), "\r\n", " ")
 	}
 
-	guestName = safe(guestName)
-	bookingRef = safe(bookingRef)
-	checkInDate = safe(checkInDate)
-	checkOutDate = safe(checkOutDate)
 	checkinLink = safe(checkinLink)
-	confirmationCode = safe(confirmationCode)
 
 	// Ensure scheme
 	if !(strings.HasPrefix(checkinLink, "http://") || strings.HasPrefix(checkinLink, "https://")) {
 		checkinLink = "https://" + strings.TrimLeft(checkinLink, "/")
 	}
 
-	// Build rooms textual & HTML representation
-	roomsText := roomsListText(rooms)    // plain text list
-	roomsHTML := roomsListHTML(rooms)    // html list
-
-	subject := fmt.Sprintf("Booking Confirmation and Pre-Check-in — %s", bookingRef)
-
-	//
-	// PLAIN TEXT
-	//
-	plainBody := fmt.Sprintf(
-		"Dear %s,\n\n"+ 
-			"Thank you for booking with us! Here are your booking details:\n\n"+
-			"Booking Reference: %s\n"+
-			"Confirmation Code: %s\n"+
-			"Rooms:\n%s\n"+
-			"Check-In: %s\n"+
-			"Check-Out: %s\n\n"+
-			"Complete your pre-check-in here: %s\n\n"+
-			"If you have any questions, feel free to contact us.\n\n"+
-			"Best regards,\n%s",
-		guestName,
-		bookingRef,
-		confirmationCode,
-		roomsText,
-		checkInDate,
-		checkOutDate,
-		checkinLink,
-		fromName,
-	)
-
-	//
-	// HTML EMAIL BODY
-	//
-	htmlBody := fmt.Sprintf(`<!doctype html>
-<html>
-<head>
-<meta charset="utf-8">
-<title>Pre Check-in</title>
-<style>
-body { background:#f5f7fb; font-family:Arial, Helvetica, sans-serif; color:#222; }
-.container { max-width:700px; margin:20px auto; }
-.card { background:#fff; border:1px solid #e6eef6; padding:24px; border-radius:8px; }
-.label { font-weight:700; width:160px; display:inline-block; vertical-align:top; }
-.btn { display:inline-block; padding:12px 20px; background:#0b74ff; color:#fff;
-       text-decoration:none; border-radius:6px; margin-top:18px; }
-.room-list { margin:12px 0 18px 0; padding-left:18px; }
-.room-item { margin:6px 0; }
-</style>
-</head>
-<body>
-<div class="container">
-  <div class="card">
-    <h2>Booking Confirmation & Pre-Check-in</h2>
-    <p>Dear %s,</p>
-    <p>Thank you for choosing our hotel. Below are your booking details:</p>
-
-    <p><span class="label">Booking Reference:</span> %s</p>
-    <p><span class="label">Confirmation Code:</span> %s</p>
-    <p><span class="label">Rooms:</span> %s</p>
-    <p><span class="label">Check-In:</span> %s</p>
-    <p><span class="label">Check-Out:</span> %s</p>
-
-    <a class="btn" href="%s" target="_blank">Complete Pre-Check-in</a>
-    <p>If you have any questions, feel free to contact us.</p>
-    <p>Best regards,<br>%s</p>
-  </div>
-</div>
-</body>
-</html>`,
-		guestName,
-		bookingRef,
-		confirmationCode,
-		roomsHTML,
-		checkInDate,
-		checkOutDate,
-		checkinLink,
-		fromName,
-	)
+	confirmationCode = safe(confirmationCode)
+	mail, err := RenderEmail(EmailKindCheckinLink, locale, map[string]interface{}{
+		"GuestName":        safe(guestName),
+		"BookingRef":       safe(bookingRef),
+		"ConfirmationCode": confirmationCode,
+		"Rooms":            rooms,
+		"CheckInDate":      safe(checkInDate),
+		"CheckOutDate":     safe(checkOutDate),
+		"CheckinLink":      checkinLink,
+	})
+	if err != nil {
+		log.Printf("❌ Failed to render check-in email for %s: %v", recipientEmail, err)
+		return err
+	}
 
-	if err := sendResendEmail([]string{recipientEmail}, subject, htmlBody, plainBody, "", fromName); err != nil {
+	if err := deliverEmail(OutboundEmail{Kind: EmailKindCheckinLink, Ref: BookingInfoEmailRef(bookingInfoID), To: []string{recipientEmail}, Subject: mail.Subject, HTML: mail.HTML, Text: mail.Text, FromName: mail.FromName}); err != nil {
 		log.Printf("❌ Failed to send email to %s: %v", recipientEmail, err)
 		return err
 	}
@@ -307,44 +233,6 @@ body { background:#f5f7fb; font-family:Arial, Helvetica, sans-serif; color:#222;
 	return nil
 }
 
-// helper: produce plain text list for rooms
-func roomsListText(rooms []RoomInfo) string {
-	if len(rooms) == 0 {
-		return "N/A"
-	}
-	var b strings.Builder
-	for _, r := range rooms {
-		num := strings.TrimSpace(r.Number)
-		typ := strings.TrimSpace(r.Type)
-		if typ != "" {
-			b.WriteString(fmt.Sprintf(" - %s (%s)\n", num, typ))
-		} else {
-			b.WriteString(fmt.Sprintf(" - %s\n", num))
-		}
-	}
-	return b.String()
-}
-
-// helper: produce HTML list for rooms
-func roomsListHTML(rooms []RoomInfo) string {
-	if len(rooms) == 0 {
-		return "<em>N/A</em>"
-	}
-	var b strings.Builder
-	b.WriteString("<ul class=\"room-list\">")
-	for _, r := range rooms {
-		num := strings.TrimSpace(r.Number)
-		typ := strings.TrimSpace(r.Type)
-		if typ != "" {
-			b.WriteString(fmt.Sprintf("<li class=\"room-item\">%s (%s)</li>", htmlEscape(num), htmlEscape(typ)))
-		} else {
-			b.WriteString(fmt.Sprintf("<li class=\"room-item\">%s</li>", htmlEscape(num)))
-		}
-	}
-	b.WriteString("</ul>")
-	return b.String()
-}
-
 // minimal html escaper for the small strings we use
 func htmlEscape(s string) string {
 	replacer := strings.NewReplacer(
@@ -428,41 +316,19 @@ func UpdateBookingState(bookingId int, mainGuest, email, status string, raw map[
 // ===========================================================
 //
 
-// SaveBase64Image decodes base64 image string and writes to destDir.
-// Returns the saved filepath (absolute) or error.
+// DecodeBase64Image แปลง base64 เป็น bytes ของรูป
 // base64Str may be either raw base64 payload or a data URI like "data:image/png;base64,...."
-func SaveBase64Image(base64Str string, destDir string) (string, error) {
+// (mime ใน data URI ไม่ถูกใช้ — ให้ตรวจชนิดไฟล์จากเนื้อไฟล์เอง)
+func DecodeBase64Image(base64Str string) ([]byte, error) {
 	base64Str = strings.TrimSpace(base64Str)
 	if base64Str == "" {
-		return "", fmt.Errorf("empty base64 string")
+		return nil, fmt.Errorf("empty base64 string")
 	}
 
-	// If data URI, try to detect extension and strip prefix
-	ext := ""
+	// strip data URI prefix: data:<mime>;base64,<payload>
 	if strings.HasPrefix(base64Str, "data:") {
-		// format: data:<mime>;base64,<payload>
-		parts := strings.SplitN(base64Str, ";base64,", 2)
-		if len(parts) == 2 {
-			meta := parts[0] // e.g. data:image/png
-			base64Str = parts[1]
-			if strings.HasPrefix(meta, "data:") {
-				mime := strings.TrimPrefix(meta, "data:")
-				switch mime {
-				case "image/png":
-					ext = ".png"
-				case "image/jpeg", "image/jpg":
-					ext = ".jpg"
-				case "image/gif":
-					ext = ".gif"
-				default:
-					// unknown mime -> leave ext empty
-				}
-			}
-		} else {
-			// if not the expected separator, try to remove "data:" prefix only
-			if idx := strings.Index(base64Str, ","); idx != -1 {
-				base64Str = base64Str[idx+1:]
-			}
+		if idx := strings.Index(base64Str, ","); idx != -1 {
+			base64Str = base64Str[idx+1:]
 		}
 	}
 
@@ -471,32 +337,11 @@ func SaveBase64Image(base64Str string, destDir string) (string, error) {
 	if err != nil {
 		data, err = base64.URLEncoding.DecodeString(base64Str)
 		if err != nil {
-			return "", fmt.Errorf("base64 decode failed: %v", err)
+			return nil, fmt.Errorf("base64 decode failed: %v", err)
 		}
 	}
-
-	// ensure dest dir exists
-	if err := os.MkdirAll(destDir, 0755); err != nil {
-		return "", fmt.Errorf("mkdir failed: %v", err)
-	}
-
-	// create filename with timestamp + random hex
-	randBytes := make([]byte, 6)
-	if _, err := rand.Read(randBytes); err != nil {
-		// fallback to timestamp only
-		randBytes = []byte(fmt.Sprintf("%d", time.Now().UnixNano()))
-	}
-	name := fmt.Sprintf("face_%d_%s%s", time.Now().UnixNano(), hexEncode(randBytes), ext)
-	fullpath := filepath.Join(destDir, name)
-
-	if err := ioutil.WriteFile(fullpath, data, 0644); err != nil {
-		return "", fmt.Errorf("write file failed: %v", err)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty image data")
 	}
-
-	return fullpath, nil
-}
-
-// helper to hex-encode bytes (small wrapper to avoid importing encoding/hex in many places)
-func hexEncode(b []byte) string {
-	return fmt.Sprintf("%x", b)
+	return data, nil
 }
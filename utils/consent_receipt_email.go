@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// SendConsentReceiptEmail sends the signed consent receipt PDF to the guest after check-in.
+func SendConsentReceiptEmail(recipientEmail, guestName, bookingRef, hotelName, filename string, pdf []byte) error {
+	fromName := emailFromName()
+
+	safe := func(s string) string {
+		return strings.ReplaceAll(strings.TrimSpace(s), "\r\n", " ")
+	}
+
+	guestName = safe(guestName)
+	bookingRef = safe(bookingRef)
+	hotelName = safe(hotelName)
+	if guestName == "" {
+		guestName = "Guest"
+	}
+	if hotelName == "" {
+		hotelName = fromName
+	}
+
+	subject := fmt.Sprintf("Your consent receipt — %s", bookingRef)
+
+	plainBody := fmt.Sprintf(
+		"Dear %s,\n\n"+
+			"Thank you for checking in with %s. Attached is a receipt of the consents you gave for booking %s.\n"+
+			"Please keep it for your records. You may withdraw your consent at any time by contacting us.\n\n"+
+			"Best regards,\n%s",
+		guestName, hotelName, bookingRef, fromName,
+	)
+
+	htmlBody := fmt.Sprintf(`<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Consent receipt</title>
+<style>
+body { background:#f5f7fb; font-family:Arial, Helvetica, sans-serif; color:#222; }
+.container { max-width:640px; margin:20px auto; }
+.card { background:#fff; border:1px solid #e6eef6; padding:24px; border-radius:8px; }
+.muted { color:#667085; font-size:13px; margin-top:16px; }
+</style>
+</head>
+<body>
+<div class="container">
+  <div class="card">
+    <h2>Your consent receipt</h2>
+    <p>Dear %s,</p>
+    <p>Thank you for checking in with %s. Attached is a receipt of the consents you gave for booking <strong>%s</strong>.</p>
+    <p class="muted">Please keep it for your records. You may withdraw your consent at any time by contacting us.</p>
+    <p>Best regards,<br>%s</p>
+  </div>
+</div>
+</body>
+</html>`,
+		htmlEscape(guestName), htmlEscape(hotelName), htmlEscape(bookingRef), htmlEscape(fromName),
+	)
+
+	attachment := EmailAttachment{Filename: filename, ContentType: "application/pdf", Content: pdf}
+	if err := deliverEmail(OutboundEmail{Kind: "consent_receipt", To: []string{recipientEmail}, Subject: subject, HTML: htmlBody, Text: plainBody, FromName: fromName, Attachments: []EmailAttachment{attachment}}); err != nil {
+		log.Printf("Failed to send consent receipt to %s: %v", recipientEmail, err)
+		return err
+	}
+
+	log.Printf("Consent receipt sent to %s", recipientEmail)
+	return nil
+}
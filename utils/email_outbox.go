@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// OutboundEmail: อีเมลหนึ่งฉบับก่อนส่ง (ใช้ทั้งส่งตรงและเข้าคิว outbox)
+type OutboundEmail struct {
+	Kind        string // ประเภทข้อความ เช่น checkin_link, admin_invite (ใช้กรองในหน้า admin)
+	To          []string
+	Subject     string
+	HTML        string
+	Text        string
+	FromEmail   string
+	FromName    string
+	Attachments []EmailAttachment
+
+	// Ref: สิ่งที่อีเมลนี้เกี่ยวข้อง เช่น "booking_info:12" — webhook ของ provider อัปเดตสถานะกลับไปที่นั่น
+	Ref string
+	// OutboxID: ตั้งโดย worker — ส่งไปกับ provider (custom args / tags) เพื่อจับคู่ event กลับมา
+	OutboxID uint
+}
+
+// EmailRefBookingInfo: Ref ของอีเมลลิงก์/เตือนเช็คอิน (ดู BookingInfoEmailRef)
+const EmailRefBookingInfo = "booking_info"
+
+func BookingInfoEmailRef(bookingInfoID uint) string {
+	if bookingInfoID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", EmailRefBookingInfo, bookingInfoID)
+}
+
+// EmailEnqueuer รับอีเมลเข้าคิว (services.EmailOutboxService.Enqueue)
+type EmailEnqueuer func(OutboundEmail) error
+
+var (
+	emailEnqueuerMu sync.RWMutex
+	emailEnqueuer   EmailEnqueuer
+)
+
+// SetEmailEnqueuer ตั้งคิว outbox ตอนเริ่ม server — ไม่ได้ตั้ง = ส่งตรงแบบเดิม
+func SetEmailEnqueuer(fn EmailEnqueuer) {
+	emailEnqueuerMu.Lock()
+	defer emailEnqueuerMu.Unlock()
+	emailEnqueuer = fn
+}
+
+// EmailQueueEnabled: มี outbox รับอีเมลอยู่ — SendXxxEmail สำเร็จแปลว่าเข้าคิวแล้ว ยังไม่ได้ส่งจริง
+func EmailQueueEnabled() bool {
+	emailEnqueuerMu.RLock()
+	defer emailEnqueuerMu.RUnlock()
+	return emailEnqueuer != nil
+}
+
+// deliverEmail: ทุก SendXxxEmail เรียกที่นี่ — เข้าคิวถ้ามี outbox ไม่งั้นส่งตรง
+func deliverEmail(msg OutboundEmail) error {
+	emailEnqueuerMu.RLock()
+	enqueue := emailEnqueuer
+	emailEnqueuerMu.RUnlock()
+	if enqueue != nil {
+		return enqueue(msg)
+	}
+	return sendResendEmail(msg)
+}
+
+// Email providers ที่ worker ของ outbox ไล่ลองตามลำดับ (failover)
+const (
+	EmailProviderSendGrid = "sendgrid"
+	EmailProviderResend   = "resend"
+	EmailProviderSMTP     = "smtp"
+	EmailProviderMock     = "mock" // ไม่ได้ตั้งค่า provider ใดเลย — แค่ log
+)
+
+func smtpConfigured() bool {
+	for _, name := range []string{"SMTP_HOST", "SMTP_PORT", "SMTP_USERNAME", "SMTP_PASSWORD"} {
+		if strings.TrimSpace(os.Getenv(name)) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// EmailProviders: provider ที่ตั้งค่าไว้ เรียงตามลำดับเดียวกับ sendResendEmail
+func EmailProviders() []string {
+	var providers []string
+	if strings.TrimSpace(os.Getenv("SENDGRID_API_KEY")) != "" {
+		providers = append(providers, EmailProviderSendGrid)
+	}
+	if strings.TrimSpace(os.Getenv("RESEND_API_KEY")) != "" {
+		providers = append(providers, EmailProviderResend)
+	}
+	if smtpConfigured() {
+		providers = append(providers, EmailProviderSMTP)
+	}
+	if len(providers) == 0 {
+		providers = append(providers, EmailProviderMock)
+	}
+	return providers
+}
+
+// SendEmailVia ส่งผ่าน provider ที่ระบุ — คืน message id ของ provider (SMTP/mock ไม่มี)
+func SendEmailVia(provider string, msg OutboundEmail) (string, error) {
+	switch provider {
+	case EmailProviderSendGrid:
+		return sendSendGridEmail(msg, strings.TrimSpace(os.Getenv("SENDGRID_API_KEY")))
+	case EmailProviderResend:
+		return sendResendAPIEmail(msg, strings.TrimSpace(os.Getenv("RESEND_API_KEY")))
+	case EmailProviderSMTP, EmailProviderMock:
+		return "", sendSMTPEmail(msg.To, msg.Subject, msg.HTML, msg.Text, msg.FromEmail, msg.FromName, msg.Attachments...)
+	default:
+		return "", fmt.Errorf("unknown email provider %q", provider)
+	}
+}
+
+// SendEmailWithFailover ลองทุก provider ตามลำดับจนกว่าจะสำเร็จ — คืนชื่อ provider และ message id ที่ส่งได้
+func SendEmailWithFailover(msg OutboundEmail) (string, string, error) {
+	var errs []string
+	for _, provider := range EmailProviders() {
+		messageID, err := SendEmailVia(provider, msg)
+		if err == nil {
+			return provider, messageID, nil
+		}
+		log.Printf("email %q via %s failed: %v", msg.Subject, provider, err)
+		errs = append(errs, provider+": "+err.Error())
+	}
+	return "", "", errors.New(strings.Join(errs, "; "))
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"net/http"
 	"net/smtp"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -17,12 +19,32 @@ import (
 const resendAPIURL = "https://api.resend.com/emails"
 const sendGridAPIURL = "https://api.sendgrid.com/v3/mail/send"
 
+// EmailAttachment: ไฟล์แนบ (เช่น PDF ใบยืนยัน consent)
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
 type resendEmailPayload struct {
-	From    string   `json:"from"`
-	To      []string `json:"to"`
-	Subject string   `json:"subject"`
-	Html    string   `json:"html,omitempty"`
-	Text    string   `json:"text,omitempty"`
+	From        string             `json:"from"`
+	To          []string           `json:"to"`
+	Subject     string             `json:"subject"`
+	Html        string             `json:"html,omitempty"`
+	Text        string             `json:"text,omitempty"`
+	Attachments []resendAttachment `json:"attachments,omitempty"`
+	Tags        []resendTag        `json:"tags,omitempty"`
+}
+
+// resendTag กลับมาใน webhook (data.tags) — ใช้จับคู่ event กับแถวใน outbox
+type resendTag struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type resendAttachment struct {
+	Filename string `json:"filename"`
+	Content  string `json:"content"`
 }
 
 type sendGridEmailPayload struct {
@@ -30,12 +52,24 @@ type sendGridEmailPayload struct {
 	From             sendGridAddress           `json:"from"`
 	Subject          string                    `json:"subject"`
 	Content          []sendGridContent         `json:"content"`
+	Attachments      []sendGridAttachment      `json:"attachments,omitempty"`
+}
+
+type sendGridAttachment struct {
+	Content     string `json:"content"`
+	Filename    string `json:"filename"`
+	Type        string `json:"type,omitempty"`
+	Disposition string `json:"disposition"`
 }
 
 type sendGridPersonalization struct {
-	To []sendGridAddress `json:"to"`
+	To         []sendGridAddress `json:"to"`
+	CustomArgs map[string]string `json:"custom_args,omitempty"` // กลับมาใน Event Webhook
 }
 
+// EmailOutboxIDArg: ชื่อ custom arg / tag ที่ส่ง OutboundEmail.OutboxID ไปกับ provider
+const EmailOutboxIDArg = "outbox_id"
+
 type sendGridAddress struct {
 	Email string `json:"email"`
 	Name  string `json:"name,omitempty"`
@@ -109,23 +143,38 @@ func resolveFromEmail(requested string) (string, error) {
 	return value, nil
 }
 
-func sendResendEmail(to []string, subject, htmlBody, textBody, fromEmail, fromName string) error {
+func attachmentContentType(a EmailAttachment) string {
+	if ct := strings.TrimSpace(a.ContentType); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// sendResendEmail ส่งตรงผ่าน provider แรกที่ตั้งค่าไว้ (SendGrid > Resend > SMTP/mock) — ไม่ผ่าน outbox
+func sendResendEmail(msg OutboundEmail) error {
 	sendgridKey := strings.TrimSpace(os.Getenv("SENDGRID_API_KEY"))
 	if sendgridKey != "" {
-		return sendSendGridEmail(to, subject, htmlBody, textBody, fromEmail, fromName, sendgridKey)
+		_, err := sendSendGridEmail(msg, sendgridKey)
+		return err
 	}
 
 	apiKey := strings.TrimSpace(os.Getenv("RESEND_API_KEY"))
 	if apiKey == "" {
-		return sendSMTPEmail(to, subject, htmlBody, textBody, fromEmail, fromName)
+		return sendSMTPEmail(msg.To, msg.Subject, msg.HTML, msg.Text, msg.FromEmail, msg.FromName, msg.Attachments...)
 	}
+	_, err := sendResendAPIEmail(msg, apiKey)
+	return err
+}
+
+func sendResendAPIEmail(msg OutboundEmail, apiKey string) (string, error) {
+	to, subject, htmlBody, textBody, fromName := msg.To, msg.Subject, msg.HTML, msg.Text, msg.FromName
 	if len(to) == 0 {
-		return errors.New("recipient list is empty")
+		return "", errors.New("recipient list is empty")
 	}
 
-	fromEmail, err := resolveFromEmail(fromEmail)
+	fromEmail, err := resolveFromEmail(msg.FromEmail)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	fromName = strings.TrimSpace(fromName)
@@ -145,15 +194,24 @@ func sendResendEmail(to []string, subject, htmlBody, textBody, fromEmail, fromNa
 		Html:    htmlBody,
 		Text:    textBody,
 	}
+	if msg.OutboxID != 0 {
+		payload.Tags = []resendTag{{Name: EmailOutboxIDArg, Value: strconv.FormatUint(uint64(msg.OutboxID), 10)}}
+	}
+	for _, a := range msg.Attachments {
+		payload.Attachments = append(payload.Attachments, resendAttachment{
+			Filename: a.Filename,
+			Content:  base64.StdEncoding.EncodeToString(a.Content),
+		})
+	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to serialize email payload: %w", err)
+		return "", fmt.Errorf("failed to serialize email payload: %w", err)
 	}
 
 	req, err := http.NewRequest(http.MethodPost, resendAPIURL, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("failed to build resend request: %w", err)
+		return "", fmt.Errorf("failed to build resend request: %w", err)
 	}
 	req.Header.Set("Authorization", "Bearer "+apiKey)
 	req.Header.Set("Content-Type", "application/json")
@@ -161,26 +219,30 @@ func sendResendEmail(to []string, subject, htmlBody, textBody, fromEmail, fromNa
 	client := &http.Client{Timeout: 15 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("resend request failed: %w", err)
+		return "", fmt.Errorf("resend request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("resend error: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+		return "", fmt.Errorf("resend error: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(respBody)))
 	}
 
-	return nil
+	var sent struct {
+		ID string `json:"id"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&sent)
+	return sent.ID, nil
 }
 
-func sendSMTPEmail(to []string, subject, htmlBody, textBody, fromEmail, fromName string) error {
+func sendSMTPEmail(to []string, subject, htmlBody, textBody, fromEmail, fromName string, attachments ...EmailAttachment) error {
 	smtpHost := strings.TrimSpace(os.Getenv("SMTP_HOST"))
 	smtpPort := strings.TrimSpace(os.Getenv("SMTP_PORT"))
 	smtpUser := strings.TrimSpace(os.Getenv("SMTP_USERNAME"))
 	smtpPass := strings.TrimSpace(os.Getenv("SMTP_PASSWORD"))
 
 	if smtpHost == "" || smtpPort == "" || smtpUser == "" || smtpPass == "" {
-		log.Printf("[MOCK EMAIL] to:%v subject:%s", to, subject)
+		log.Printf("[MOCK EMAIL] to:%v subject:%s attachments:%d", to, subject, len(attachments))
 		return nil
 	}
 	if len(to) == 0 {
@@ -206,11 +268,17 @@ func sendSMTPEmail(to []string, subject, htmlBody, textBody, fromEmail, fromName
 	}
 
 	boundary := "----=_EMAIL_BOUNDARY"
+	mixedBoundary := "----=_EMAIL_MIXED_BOUNDARY"
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("From: %s\r\n", fromHeader))
 	sb.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(to, ",")))
 	sb.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
 	sb.WriteString("MIME-Version: 1.0\r\n")
+	if len(attachments) > 0 {
+		// multipart/mixed ครอบ alternative (text/html) แล้วตามด้วยไฟล์แนบ
+		sb.WriteString(fmt.Sprintf("Content-Type: multipart/mixed; boundary=\"%s\"\r\n\r\n", mixedBoundary))
+		sb.WriteString(fmt.Sprintf("--%s\r\n", mixedBoundary))
+	}
 	sb.WriteString(fmt.Sprintf("Content-Type: multipart/alternative; boundary=\"%s\"\r\n\r\n", boundary))
 
 	if textBody != "" {
@@ -227,6 +295,22 @@ func sendSMTPEmail(to []string, subject, htmlBody, textBody, fromEmail, fromName
 
 	sb.WriteString(fmt.Sprintf("--%s--\r\n", boundary))
 
+	if len(attachments) > 0 {
+		for _, a := range attachments {
+			sb.WriteString(fmt.Sprintf("--%s\r\n", mixedBoundary))
+			sb.WriteString(fmt.Sprintf("Content-Type: %s; name=\"%s\"\r\n", attachmentContentType(a), a.Filename))
+			sb.WriteString("Content-Transfer-Encoding: base64\r\n")
+			sb.WriteString(fmt.Sprintf("Content-Disposition: attachment; filename=\"%s\"\r\n\r\n", a.Filename))
+			encoded := base64.StdEncoding.EncodeToString(a.Content)
+			for len(encoded) > 76 {
+				sb.WriteString(encoded[:76] + "\r\n")
+				encoded = encoded[76:]
+			}
+			sb.WriteString(encoded + "\r\n")
+		}
+		sb.WriteString(fmt.Sprintf("--%s--\r\n", mixedBoundary))
+	}
+
 	auth := smtp.PlainAuth("", smtpUser, smtpPass, smtpHost)
 	addr := fmt.Sprintf("%s:%s", smtpHost, smtpPort)
 	if err := smtp.SendMail(addr, auth, smtpUser, to, []byte(sb.String())); err != nil {
@@ -236,14 +320,15 @@ func sendSMTPEmail(to []string, subject, htmlBody, textBody, fromEmail, fromName
 	return nil
 }
 
-func sendSendGridEmail(to []string, subject, htmlBody, textBody, fromEmail, fromName, apiKey string) error {
+func sendSendGridEmail(msg OutboundEmail, apiKey string) (string, error) {
+	to, subject, htmlBody, textBody, fromName := msg.To, msg.Subject, msg.HTML, msg.Text, msg.FromName
 	if len(to) == 0 {
-		return errors.New("recipient list is empty")
+		return "", errors.New("recipient list is empty")
 	}
 
-	fromEmail, err := resolveFromEmail(fromEmail)
+	fromEmail, err := resolveFromEmail(msg.FromEmail)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	fromName = strings.TrimSpace(fromName)
@@ -259,7 +344,7 @@ func sendSendGridEmail(to []string, subject, htmlBody, textBody, fromEmail, from
 		}
 	}
 	if len(toList) == 0 {
-		return errors.New("recipient list is empty")
+		return "", errors.New("recipient list is empty")
 	}
 
 	content := make([]sendGridContent, 0, 2)
@@ -270,7 +355,7 @@ func sendSendGridEmail(to []string, subject, htmlBody, textBody, fromEmail, from
 		content = append(content, sendGridContent{Type: "text/html", Value: htmlBody})
 	}
 	if len(content) == 0 {
-		return errors.New("email body is empty")
+		return "", errors.New("email body is empty")
 	}
 
 	payload := sendGridEmailPayload{
@@ -282,15 +367,26 @@ func sendSendGridEmail(to []string, subject, htmlBody, textBody, fromEmail, from
 		Subject: subject,
 		Content: content,
 	}
+	if msg.OutboxID != 0 {
+		payload.Personalizations[0].CustomArgs = map[string]string{EmailOutboxIDArg: strconv.FormatUint(uint64(msg.OutboxID), 10)}
+	}
+	for _, a := range msg.Attachments {
+		payload.Attachments = append(payload.Attachments, sendGridAttachment{
+			Content:     base64.StdEncoding.EncodeToString(a.Content),
+			Filename:    a.Filename,
+			Type:        attachmentContentType(a),
+			Disposition: "attachment",
+		})
+	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to serialize sendgrid payload: %w", err)
+		return "", fmt.Errorf("failed to serialize sendgrid payload: %w", err)
 	}
 
 	req, err := http.NewRequest(http.MethodPost, sendGridAPIURL, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("failed to build sendgrid request: %w", err)
+		return "", fmt.Errorf("failed to build sendgrid request: %w", err)
 	}
 	req.Header.Set("Authorization", "Bearer "+apiKey)
 	req.Header.Set("Content-Type", "application/json")
@@ -298,14 +394,15 @@ func sendSendGridEmail(to []string, subject, htmlBody, textBody, fromEmail, from
 	client := &http.Client{Timeout: 15 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("sendgrid request failed: %w", err)
+		return "", fmt.Errorf("sendgrid request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("sendgrid error: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+		return "", fmt.Errorf("sendgrid error: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(respBody)))
 	}
 
-	return nil
+	// X-Message-Id = ส่วนแรกของ sg_message_id ใน Event Webhook
+	return resp.Header.Get("X-Message-Id"), nil
 }
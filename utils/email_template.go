@@ -0,0 +1,300 @@
+package utils
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+)
+
+// template ตั้งต้น: email_templates/<kind>/<locale>.subject|.html|.txt
+// layout.html / layout.txt มี block ที่ทุก template เรียกใช้ได้ (header, footer, style, signature)
+//
+//go:embed email_templates
+var builtinEmailTemplates embed.FS
+
+// ประเภทอีเมลที่ใช้ template (admin แก้ไขได้ผ่าน /api/email-templates)
+const (
+	EmailKindCheckinLink     = "checkin_link"
+	EmailKindCheckinReminder = "checkin_reminder"
+	EmailKindAdminInvite     = "admin_invite"
+)
+
+const defaultEmailLocale = "en"
+
+// EmailLocales ภาษาที่มี template ตั้งต้นครบทุก kind
+var EmailLocales = []string{"en", "th"}
+
+// EmailTemplateKind: คำอธิบาย + ตัวแปรที่ใช้ได้ใน template (แสดงในหน้า admin)
+type EmailTemplateKind struct {
+	Kind        string   `json:"kind"`
+	Description string   `json:"description"`
+	Variables   []string `json:"variables"`
+}
+
+var emailTemplateKinds = []EmailTemplateKind{
+	{
+		Kind:        EmailKindCheckinLink,
+		Description: "ยืนยันการจอง + ลิงก์เช็คอินออนไลน์",
+		Variables:   []string{"GuestName", "BookingRef", "ConfirmationCode", "Rooms", "CheckInDate", "CheckOutDate", "CheckinLink"},
+	},
+	{
+		Kind:        EmailKindCheckinReminder,
+		Description: "เตือนเช็คอินออนไลน์ (D-1 / เช้าวันเข้าพัก)",
+		Variables:   []string{"GuestName", "BookingRef", "ConfirmationCode", "CheckInDate", "CheckinLink", "ArrivalToday"},
+	},
+	{
+		Kind:        EmailKindAdminInvite,
+		Description: "เชิญพนักงานตั้งรหัสผ่าน",
+		Variables:   []string{"Name", "Role", "InviteLink"},
+	},
+}
+
+// EmailTemplateKinds รายการ kind ทั้งหมด (ทุก template ใช้ .Hotel.Name/.LogoURL/.Phone/.Address/.Email/.Website ได้)
+func EmailTemplateKinds() []EmailTemplateKind {
+	return append([]EmailTemplateKind(nil), emailTemplateKinds...)
+}
+
+func IsEmailTemplateKind(kind string) bool {
+	for _, k := range emailTemplateKinds {
+		if k.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeEmailLocale: "th-TH" -> "th" (ภาษาที่ไม่รองรับคืน "")
+func NormalizeEmailLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		locale = locale[:i]
+	}
+	for _, l := range EmailLocales {
+		if l == locale {
+			return l
+		}
+	}
+	return ""
+}
+
+// EmailBranding: ข้อมูลโรงแรมจาก hotel settings (.Hotel ใน template)
+type EmailBranding struct {
+	Name    string `json:"name"`
+	LogoURL string `json:"logoUrl"`
+	Phone   string `json:"phone"`
+	Address string `json:"address"`
+	Email   string `json:"email"`
+	Website string `json:"website"`
+}
+
+// EmailTemplateSource: template หนึ่งชุด (subject/text = text/template, html = html/template)
+type EmailTemplateSource struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+// EmailTemplateStore: template ที่ admin แก้ไขไว้ + branding/ภาษาตั้งต้นจาก hotel settings
+// (services.EmailTemplateService) — ไม่ได้ตั้ง = ใช้ template ตั้งต้นและชื่อผู้ส่งจาก env
+type EmailTemplateStore interface {
+	EmailTemplate(kind, locale string) (EmailTemplateSource, bool)
+	EmailBranding() (EmailBranding, string)
+}
+
+var (
+	emailTemplateStoreMu sync.RWMutex
+	emailTemplateStore   EmailTemplateStore
+)
+
+func SetEmailTemplateStore(store EmailTemplateStore) {
+	emailTemplateStoreMu.Lock()
+	defer emailTemplateStoreMu.Unlock()
+	emailTemplateStore = store
+}
+
+func currentEmailTemplateStore() EmailTemplateStore {
+	emailTemplateStoreMu.RLock()
+	defer emailTemplateStoreMu.RUnlock()
+	return emailTemplateStore
+}
+
+// CurrentEmailBranding branding + ภาษาตั้งต้น (ชื่อโรงแรมว่าง = ชื่อผู้ส่งจาก env)
+func CurrentEmailBranding() (EmailBranding, string) {
+	var brand EmailBranding
+	locale := ""
+	if store := currentEmailTemplateStore(); store != nil {
+		brand, locale = store.EmailBranding()
+	}
+	if strings.TrimSpace(brand.Name) == "" {
+		brand.Name = emailFromName()
+	}
+	if locale = NormalizeEmailLocale(locale); locale == "" {
+		locale = defaultEmailLocale
+	}
+	return brand, locale
+}
+
+// BuiltinEmailTemplate template ตั้งต้นที่ฝังมากับโปรแกรม
+func BuiltinEmailTemplate(kind, locale string) (EmailTemplateSource, error) {
+	if !IsEmailTemplateKind(kind) {
+		return EmailTemplateSource{}, errors.New("template_kind_unknown")
+	}
+	if NormalizeEmailLocale(locale) == "" {
+		return EmailTemplateSource{}, errors.New("locale_unsupported")
+	}
+	read := func(ext string) (string, error) {
+		b, err := builtinEmailTemplates.ReadFile("email_templates/" + kind + "/" + locale + ext)
+		return string(b), err
+	}
+	var src EmailTemplateSource
+	var err error
+	if src.Subject, err = read(".subject"); err != nil {
+		return src, err
+	}
+	if src.HTML, err = read(".html"); err != nil {
+		return src, err
+	}
+	if src.Text, err = read(".txt"); err != nil {
+		return src, err
+	}
+	src.Subject = strings.TrimSpace(src.Subject)
+	return src, nil
+}
+
+// ResolveEmailTemplate template ที่ใช้จริง: ของ admin -> ตั้งต้นของภาษานั้น
+func ResolveEmailTemplate(kind, locale string) (EmailTemplateSource, bool, error) {
+	if store := currentEmailTemplateStore(); store != nil {
+		if src, ok := store.EmailTemplate(kind, locale); ok {
+			return src, true, nil
+		}
+	}
+	src, err := BuiltinEmailTemplate(kind, locale)
+	return src, false, err
+}
+
+// RenderedEmail: ผลการ render พร้อมส่ง
+type RenderedEmail struct {
+	Locale   string `json:"locale"`
+	Subject  string `json:"subject"`
+	HTML     string `json:"html"`
+	Text     string `json:"text"`
+	FromName string `json:"fromName"`
+}
+
+// RenderEmailTemplate render template ชุดหนึ่งกับ data (+ .Hotel) — ตัวแปรที่ไม่มีใน data ถือเป็น error
+func RenderEmailTemplate(src EmailTemplateSource, data map[string]interface{}, brand EmailBranding) (RenderedEmail, error) {
+	view := make(map[string]interface{}, len(data)+1)
+	for k, v := range data {
+		view[k] = v
+	}
+	view["Hotel"] = brand
+
+	layoutHTML, err := builtinEmailTemplates.ReadFile("email_templates/layout.html")
+	if err != nil {
+		return RenderedEmail{}, err
+	}
+	layoutText, err := builtinEmailTemplates.ReadFile("email_templates/layout.txt")
+	if err != nil {
+		return RenderedEmail{}, err
+	}
+
+	out := RenderedEmail{FromName: brand.Name}
+	if out.Subject, err = execTextTemplate("subject", "", src.Subject, view); err != nil {
+		return out, err
+	}
+	out.Subject = strings.Join(strings.Fields(out.Subject), " ")
+	if out.Text, err = execTextTemplate("text", string(layoutText), src.Text, view); err != nil {
+		return out, err
+	}
+
+	ht, err := htmltemplate.New("html").Option("missingkey=error").Parse(string(layoutHTML))
+	if err == nil {
+		ht, err = ht.Parse(src.HTML)
+	}
+	if err != nil {
+		return out, fmt.Errorf("template_invalid: html: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := ht.Execute(&buf, view); err != nil {
+		return out, fmt.Errorf("template_invalid: html: %w", err)
+	}
+	out.HTML = buf.String()
+	return out, nil
+}
+
+func execTextTemplate(name, layout, body string, view map[string]interface{}) (string, error) {
+	t, err := texttemplate.New(name).Option("missingkey=error").Parse(layout)
+	if err == nil {
+		t, err = t.Parse(body)
+	}
+	if err != nil {
+		return "", fmt.Errorf("template_invalid: %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, view); err != nil {
+		return "", fmt.Errorf("template_invalid: %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// RenderEmail render อีเมลตาม kind ในภาษาที่ระบุ (ว่าง = ภาษาตั้งต้นของโรงแรม)
+// template ของ admin ที่ render ไม่ผ่านจะถอยไปใช้ template ตั้งต้น เพื่อไม่ให้อีเมลตกหล่น
+func RenderEmail(kind, locale string, data map[string]interface{}) (RenderedEmail, error) {
+	brand, defaultLocale := CurrentEmailBranding()
+	if locale = NormalizeEmailLocale(locale); locale == "" {
+		locale = defaultLocale
+	}
+
+	src, custom, err := ResolveEmailTemplate(kind, locale)
+	if err != nil {
+		return RenderedEmail{}, err
+	}
+	out, err := RenderEmailTemplate(src, data, brand)
+	if err != nil && custom {
+		log.Printf("email template %s/%s: %v (using built-in template)", kind, locale, err)
+		if src, err = BuiltinEmailTemplate(kind, locale); err != nil {
+			return RenderedEmail{}, err
+		}
+		out, err = RenderEmailTemplate(src, data, brand)
+	}
+	out.Locale = locale
+	return out, err
+}
+
+// EmailTemplateSample ข้อมูลตัวอย่างสำหรับ preview / ตรวจ template ก่อนบันทึก
+func EmailTemplateSample(kind string) map[string]interface{} {
+	switch kind {
+	case EmailKindCheckinLink:
+		return map[string]interface{}{
+			"GuestName":        "Somchai Jaidee",
+			"BookingRef":       "BK-240101-0001",
+			"ConfirmationCode": "ABCD-EFGH",
+			"Rooms":            []RoomInfo{{Number: "101", Type: "Deluxe King"}, {Number: "102", Type: "Superior Twin"}},
+			"CheckInDate":      "2024-01-10",
+			"CheckOutDate":     "2024-01-12",
+			"CheckinLink":      "https://example.com/checkin?token=preview",
+		}
+	case EmailKindCheckinReminder:
+		return map[string]interface{}{
+			"GuestName":        "Somchai Jaidee",
+			"BookingRef":       "BK-240101-0001",
+			"ConfirmationCode": "ABCD-EFGH",
+			"CheckInDate":      "2024-01-10",
+			"CheckinLink":      "https://example.com/checkin?token=preview",
+			"ArrivalToday":     false,
+		}
+	case EmailKindAdminInvite:
+		return map[string]interface{}{
+			"Name":       "Suda Manager",
+			"Role":       "Front Desk",
+			"InviteLink": "https://example.com/#/setup-account?token=preview",
+		}
+	}
+	return map[string]interface{}{}
+}
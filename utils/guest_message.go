@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ข้อความสั้นสำหรับ SMS / LINE (ภาษาตั้งต้นของโรงแรม) — ขึ้นต้นด้วยชื่อโรงแรมเพื่อให้แขกรู้ว่ามาจากใคร
+
+// RoomAccess: ห้อง + รหัสเข้าห้องสำหรับข้อความหลังเช็คอิน
+type RoomAccess struct {
+	Number     string
+	AccessCode string
+}
+
+func guestMessageHeader() (string, string) {
+	brand, locale := CurrentEmailBranding()
+	return "[" + strings.TrimSpace(brand.Name) + "] ", locale
+}
+
+// CheckinLinkText คำเชิญเช็คอินออนไลน์ (ลิงก์ + รหัสเช็คอิน)
+func CheckinLinkText(bookingRef, checkinLink, checkinCode string) string {
+	head, locale := guestMessageHeader()
+	if locale == "th" {
+		return fmt.Sprintf("%sเช็คอินออนไลน์ล่วงหน้าสำหรับการจอง %s: %s รหัสเช็คอิน %s", head, bookingRef, checkinLink, checkinCode)
+	}
+	return fmt.Sprintf("%sCheck in online for booking %s: %s Check-in code: %s", head, bookingRef, checkinLink, checkinCode)
+}
+
+// CheckinReminderText เตือนเช็คอินออนไลน์ (D-1 / เช้าวันเข้าพัก)
+func CheckinReminderText(bookingRef, checkinLink, checkInDate string, arrivalToday bool) string {
+	head, locale := guestMessageHeader()
+	if locale == "th" {
+		when := "พรุ่งนี้ (" + checkInDate + ")"
+		if arrivalToday {
+			when = "วันนี้"
+		}
+		return fmt.Sprintf("%sเข้าพัก%s การจอง %s ยังไม่ได้เช็คอินออนไลน์: %s", head, when, bookingRef, checkinLink)
+	}
+	when := "tomorrow (" + checkInDate + ")"
+	if arrivalToday {
+		when = "today"
+	}
+	return fmt.Sprintf("%sYour stay starts %s. Booking %s is not checked in yet: %s", head, when, bookingRef, checkinLink)
+}
+
+// CheckinCodeText รหัสเช็คอินที่แขกขอส่งใหม่
+func CheckinCodeText(bookingRef, checkinCode string) string {
+	head, locale := guestMessageHeader()
+	if bookingRef != "" {
+		bookingRef = " " + bookingRef
+	}
+	if locale == "th" {
+		return fmt.Sprintf("%sรหัสเช็คอินการจอง%s: %s", head, bookingRef, checkinCode)
+	}
+	return fmt.Sprintf("%sCheck-in code for booking%s: %s", head, bookingRef, checkinCode)
+}
+
+// RoomAccessText รหัสเข้าห้องหลังเช็คอินเสร็จ
+func RoomAccessText(bookingRef string, rooms []RoomAccess) string {
+	head, locale := guestMessageHeader()
+	parts := make([]string, 0, len(rooms))
+	for _, r := range rooms {
+		if locale == "th" {
+			parts = append(parts, fmt.Sprintf("ห้อง %s รหัส %s", r.Number, r.AccessCode))
+		} else {
+			parts = append(parts, fmt.Sprintf("Room %s code %s", r.Number, r.AccessCode))
+		}
+	}
+	if locale == "th" {
+		return fmt.Sprintf("%sเช็คอินการจอง %s เรียบร้อย %s", head, bookingRef, strings.Join(parts, ", "))
+	}
+	return fmt.Sprintf("%sYou're checked in (booking %s). %s", head, bookingRef, strings.Join(parts, ", "))
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// SendIdentityReuploadEmail tells a guest their ID documents were rejected and links to the re-upload page.
+func SendIdentityReuploadEmail(recipientEmail, guestName, bookingRef, reason, reuploadLink string) error {
+	fromName := emailFromName()
+
+	safe := func(s string) string {
+		return strings.ReplaceAll(strings.TrimSpace(s), "\r\n", " ")
+	}
+
+	guestName = safe(guestName)
+	bookingRef = safe(bookingRef)
+	reason = safe(reason)
+	reuploadLink = safe(reuploadLink)
+
+	if !(strings.HasPrefix(reuploadLink, "http://") || strings.HasPrefix(reuploadLink, "https://")) {
+		reuploadLink = "https://" + strings.TrimLeft(reuploadLink, "/")
+	}
+
+	subject := fmt.Sprintf("Action required: please re-upload your documents — %s", bookingRef)
+
+	plainBody := fmt.Sprintf(
+		"Dear %s,\n\n"+
+			"We could not verify the identity documents you submitted for booking %s.\n"+
+			"Reason: %s\n\n"+
+			"Please upload a new photo of your document and face using the link below:\n%s\n\n"+
+			"Best regards,\n%s",
+		guestName, bookingRef, reason, reuploadLink, fromName,
+	)
+
+	htmlBody := fmt.Sprintf(`<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Document re-upload</title>
+<style>
+body { background:#f5f7fb; font-family:Arial, Helvetica, sans-serif; color:#222; }
+.container { max-width:640px; margin:20px auto; }
+.card { background:#fff; border:1px solid #e6eef6; padding:24px; border-radius:8px; }
+.reason { background:#fff6e5; border-left:4px solid #f5a623; padding:12px; margin:16px 0; }
+.btn { display:inline-block; padding:12px 20px; background:#0b74ff; color:#fff; text-decoration:none; border-radius:6px; margin-top:16px; }
+</style>
+</head>
+<body>
+<div class="container">
+  <div class="card">
+    <h2>Please re-upload your documents</h2>
+    <p>Dear %s,</p>
+    <p>We could not verify the identity documents you submitted for booking <strong>%s</strong>.</p>
+    <div class="reason">%s</div>
+    <a class="btn" href="%s" target="_blank">Upload new documents</a>
+    <p>Best regards,<br>%s</p>
+  </div>
+</div>
+</body>
+</html>`,
+		htmlEscape(guestName), htmlEscape(bookingRef), htmlEscape(reason), reuploadLink, htmlEscape(fromName),
+	)
+
+	if err := deliverEmail(OutboundEmail{Kind: "identity_reupload", To: []string{recipientEmail}, Subject: subject, HTML: htmlBody, Text: plainBody, FromName: fromName}); err != nil {
+		log.Printf("Failed to send re-upload email to %s: %v", recipientEmail, err)
+		return err
+	}
+
+	log.Printf("Re-upload email sent to %s", recipientEmail)
+	return nil
+}
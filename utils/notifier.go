@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ช่องทางแจ้งเตือนแขก — อีเมลส่งผ่าน SendXxxEmail/outbox เสมอ ส่วน SMS / LINE ส่งผ่าน Notifier
+// ตามที่ลูกค้าเลือกไว้ใน Customer.NotifyChannels
+const (
+	NotifyChannelEmail = "email"
+	NotifyChannelSMS   = "sms"
+	NotifyChannelLine  = "line"
+)
+
+// Notifier ส่งข้อความสั้นหนึ่งช่องทาง (to = เบอร์โทรสำหรับ SMS, LINE userId สำหรับ LINE)
+type Notifier interface {
+	Channel() string
+	Notify(ctx context.Context, to, text string) error
+}
+
+var (
+	notifiersMu sync.RWMutex
+	notifiers   = map[string]Notifier{}
+)
+
+// SetNotifier ลงทะเบียน Notifier ของช่องทางนั้นตอนเริ่ม server (ช่องทางที่ไม่ได้ตั้ง = ข้ามไป)
+func SetNotifier(n Notifier) {
+	notifiersMu.Lock()
+	defer notifiersMu.Unlock()
+	notifiers[n.Channel()] = n
+}
+
+// NotifierFor Notifier ที่ตั้งค่าไว้ของช่องทาง
+func NotifierFor(channel string) (Notifier, bool) {
+	notifiersMu.RLock()
+	defer notifiersMu.RUnlock()
+	n, ok := notifiers[channel]
+	return n, ok
+}
+
+// NotifyChannelsConfigured ช่องทางนอกจากอีเมลที่ส่งได้จริง (แสดงในหน้า admin)
+func NotifyChannelsConfigured() []string {
+	var out []string
+	for _, ch := range []string{NotifyChannelSMS, NotifyChannelLine} {
+		if _, ok := NotifierFor(ch); ok {
+			out = append(out, ch)
+		}
+	}
+	return out
+}
+
+// ParseNotifyChannels "SMS, line,line" -> ["sms","line"] (ช่องทางที่ไม่รู้จักเป็น error)
+func ParseNotifyChannels(raw string) ([]string, error) {
+	var out []string
+	seen := map[string]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		ch := strings.ToLower(strings.TrimSpace(part))
+		if ch == "" || seen[ch] {
+			continue
+		}
+		switch ch {
+		case NotifyChannelEmail, NotifyChannelSMS, NotifyChannelLine:
+		default:
+			return nil, errors.New("notify_channel_unknown: " + ch)
+		}
+		seen[ch] = true
+		out = append(out, ch)
+	}
+	return out, nil
+}
+
+var phoneDigits = regexp.MustCompile(`^\+?[0-9]{9,15}$`)
+
+// NormalizePhone ตัดช่องว่าง/ขีด/วงเล็บ: "081-234 5678" -> "0812345678", "+66 81 234 5678" -> "+66812345678"
+// รูปแบบที่ไม่ใช่เบอร์โทรคืน ""
+func NormalizePhone(phone string) string {
+	phone = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')', '.':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phone))
+	if !phoneDigits.MatchString(phone) {
+		return ""
+	}
+	return phone
+}
+
+var lineUserIDPattern = regexp.MustCompile(`^U[0-9a-f]{32}$`)
+
+// ValidLineUserID userId ของ LINE Messaging API ("U" + hex 32 ตัว)
+func ValidLineUserID(id string) bool {
+	return lineUserIDPattern.MatchString(id)
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultLineAPIURL = "https://api.line.me"
+
+// LINE จำกัดข้อความ text ละ 5000 ตัวอักษร
+const lineTextLimit = 5000
+
+// LineNotifier ส่ง push message ผ่าน LINE Messaging API (แขกต้องเพิ่ม LINE OA ของโรงแรมเป็นเพื่อนก่อน)
+// BaseURL และ Client ตั้งเองได้ (เช่นชี้ไป httptest server)
+type LineNotifier struct {
+	BaseURL     string
+	AccessToken string
+	Client      *http.Client
+}
+
+// NewLineNotifierFromEnv: LINE_CHANNEL_ACCESS_TOKEN (ว่าง = ไม่ส่ง LINE), LINE_API_URL
+func NewLineNotifierFromEnv() *LineNotifier {
+	token := strings.TrimSpace(os.Getenv("LINE_CHANNEL_ACCESS_TOKEN"))
+	if token == "" {
+		return nil
+	}
+	return &LineNotifier{
+		BaseURL:     EnvOrDefault("LINE_API_URL", defaultLineAPIURL),
+		AccessToken: token,
+		Client:      &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (l *LineNotifier) Channel() string { return NotifyChannelLine }
+
+func (l *LineNotifier) Notify(ctx context.Context, to, text string) error {
+	to = strings.TrimSpace(to)
+	if !ValidLineUserID(to) {
+		return errors.New("invalid_line_user_id")
+	}
+	if r := []rune(text); len(r) > lineTextLimit {
+		text = string(r[:lineTextLimit])
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"to":       to,
+		"messages": []map[string]string{{"type": "text", "text": text}},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(l.BaseURL, "/")+"/v2/bot/message/push", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+l.AccessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := l.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		var out struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &out) == nil && out.Message != "" {
+			return fmt.Errorf("line api %d: %s", resp.StatusCode, out.Message)
+		}
+		return fmt.Errorf("line api %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+const testLineUserID = "U0123456789abcdef0123456789abcdef"
+
+type linePushRequest struct {
+	To       string `json:"to"`
+	Messages []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"messages"`
+}
+
+func TestLineNotifierPushesMessage(t *testing.T) {
+	var (
+		path, auth, contentType string
+		push                    linePushRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		auth = r.Header.Get("Authorization")
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&push); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	n := &LineNotifier{BaseURL: srv.URL + "/", AccessToken: "token", Client: srv.Client()}
+	if err := n.Notify(context.Background(), " "+testLineUserID+" ", "ห้อง 101 รหัส 123456"); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if path != "/v2/bot/message/push" {
+		t.Errorf("path = %q, want /v2/bot/message/push", path)
+	}
+	if auth != "Bearer token" || contentType != "application/json" {
+		t.Errorf("headers = %q / %q", auth, contentType)
+	}
+	if push.To != testLineUserID || len(push.Messages) != 1 {
+		t.Fatalf("push = %+v", push)
+	}
+	if m := push.Messages[0]; m.Type != "text" || m.Text != "ห้อง 101 รหัส 123456" {
+		t.Errorf("message = %+v", m)
+	}
+}
+
+func TestLineNotifierTruncatesLongText(t *testing.T) {
+	var push linePushRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&push)
+	}))
+	defer srv.Close()
+
+	n := &LineNotifier{BaseURL: srv.URL, AccessToken: "token", Client: srv.Client()}
+	if err := n.Notify(context.Background(), testLineUserID, strings.Repeat("ก", lineTextLimit+10)); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if len(push.Messages) != 1 || utf8.RuneCountInString(push.Messages[0].Text) != lineTextLimit {
+		t.Fatalf("text was not truncated to %d runes", lineTextLimit)
+	}
+}
+
+func TestLineNotifierAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message":"The request body has 1 error(s)"}`))
+	}))
+	defer srv.Close()
+
+	n := &LineNotifier{BaseURL: srv.URL, AccessToken: "token", Client: srv.Client()}
+	err := n.Notify(context.Background(), testLineUserID, "hi")
+	if err == nil || err.Error() != "line api 400: The request body has 1 error(s)" {
+		t.Fatalf("Notify error = %v", err)
+	}
+}
+
+func TestLineNotifierRejectsInvalidUserID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("API should not be called for an invalid userId")
+	}))
+	defer srv.Close()
+
+	n := &LineNotifier{BaseURL: srv.URL, AccessToken: "token", Client: srv.Client()}
+	err := n.Notify(context.Background(), "@hotel-guest", "hi")
+	if err == nil || !strings.Contains(err.Error(), "invalid_line_user_id") {
+		t.Fatalf("Notify error = %v, want invalid_line_user_id", err)
+	}
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultSMSAPIURL = "https://api-v2.thaibulksms.com/sms"
+
+// SMSNotifier ส่ง SMS ผ่าน HTTP API แบบ ThaiBulkSMS (POST form msisdn/message/sender + Basic auth)
+// URL และ Client ตั้งเองได้ (เช่นชี้ไป httptest server)
+type SMSNotifier struct {
+	URL    string
+	Key    string
+	Secret string
+	Sender string
+	Client *http.Client
+}
+
+// NewSMSNotifierFromEnv: SMS_API_KEY + SMS_API_SECRET (ว่าง = ไม่ส่ง SMS), SMS_SENDER, SMS_API_URL
+func NewSMSNotifierFromEnv() *SMSNotifier {
+	key := strings.TrimSpace(os.Getenv("SMS_API_KEY"))
+	secret := strings.TrimSpace(os.Getenv("SMS_API_SECRET"))
+	if key == "" || secret == "" {
+		return nil
+	}
+	return &SMSNotifier{
+		URL:    EnvOrDefault("SMS_API_URL", defaultSMSAPIURL),
+		Key:    key,
+		Secret: secret,
+		Sender: strings.TrimSpace(os.Getenv("SMS_SENDER")),
+		Client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (s *SMSNotifier) Channel() string { return NotifyChannelSMS }
+
+func (s *SMSNotifier) Notify(ctx context.Context, to, text string) error {
+	to = NormalizePhone(to)
+	if to == "" {
+		return errors.New("invalid_phone")
+	}
+	form := url.Values{"msisdn": {to}, "message": {text}}
+	if s.Sender != "" {
+		form.Set("sender", s.Sender)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.Key, s.Secret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var out struct {
+			Error struct {
+				Code        interface{} `json:"code"`
+				Description string      `json:"description"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(body, &out) == nil && out.Error.Description != "" {
+			return fmt.Errorf("sms api %d: %s", resp.StatusCode, out.Error.Description)
+		}
+		return fmt.Errorf("sms api %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSMSNotifierSendsForm(t *testing.T) {
+	var got struct {
+		method, contentType, user, pass string
+		msisdn, message, sender         string
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.method = r.Method
+		got.contentType = r.Header.Get("Content-Type")
+		got.user, got.pass, _ = r.BasicAuth()
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("ParseForm: %v", err)
+		}
+		got.msisdn = r.PostForm.Get("msisdn")
+		got.message = r.PostForm.Get("message")
+		got.sender = r.PostForm.Get("sender")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"remaining_credit":99}`))
+	}))
+	defer srv.Close()
+
+	n := &SMSNotifier{URL: srv.URL, Key: "key", Secret: "secret", Sender: "HOTEL", Client: srv.Client()}
+	if err := n.Notify(context.Background(), "081-234 5678", "รหัสเช็คอิน 1234-5678"); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if got.method != http.MethodPost || got.contentType != "application/x-www-form-urlencoded" {
+		t.Errorf("request = %s %s, want POST form", got.method, got.contentType)
+	}
+	if got.user != "key" || got.pass != "secret" {
+		t.Errorf("basic auth = %q/%q, want key/secret", got.user, got.pass)
+	}
+	if got.msisdn != "0812345678" {
+		t.Errorf("msisdn = %q, want normalized 0812345678", got.msisdn)
+	}
+	if got.message != "รหัสเช็คอิน 1234-5678" || got.sender != "HOTEL" {
+		t.Errorf("message/sender = %q/%q", got.message, got.sender)
+	}
+}
+
+func TestSMSNotifierAPIError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"json error", http.StatusBadRequest, `{"error":{"code":400,"description":"Invalid msisdn"}}`, "sms api 400: Invalid msisdn"},
+		{"plain error", http.StatusBadGateway, "upstream down", "sms api 502: upstream down"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			n := &SMSNotifier{URL: srv.URL, Key: "key", Secret: "secret", Client: srv.Client()}
+			err := n.Notify(context.Background(), "+66812345678", "hi")
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("Notify error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSMSNotifierRejectsInvalidPhone(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("API should not be called for an invalid phone number")
+	}))
+	defer srv.Close()
+
+	n := &SMSNotifier{URL: srv.URL, Key: "key", Secret: "secret", Client: srv.Client()}
+	err := n.Notify(context.Background(), "call me", "hi")
+	if err == nil || !strings.Contains(err.Error(), "invalid_phone") {
+		t.Fatalf("Notify error = %v, want invalid_phone", err)
+	}
+}
+
+func TestNewSMSNotifierFromEnv(t *testing.T) {
+	t.Setenv("SMS_API_KEY", "")
+	t.Setenv("SMS_API_SECRET", "secret")
+	if n := NewSMSNotifierFromEnv(); n != nil {
+		t.Fatal("notifier should be disabled without SMS_API_KEY")
+	}
+
+	t.Setenv("SMS_API_KEY", "key")
+	t.Setenv("SMS_API_URL", "http://sms.test/send")
+	n := NewSMSNotifierFromEnv()
+	if n == nil || n.URL != "http://sms.test/send" || n.Channel() != NotifyChannelSMS {
+		t.Fatalf("NewSMSNotifierFromEnv = %+v", n)
+	}
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// SendPasswordResetEmail sends an admin a link to choose a new password.
+func SendPasswordResetEmail(recipientEmail, name, resetLink string, expiresAt time.Time) error {
+	fromName := emailFromName()
+
+	name = strings.ReplaceAll(strings.TrimSpace(name), "\r\n", " ")
+	if name == "" {
+		name = recipientEmail
+	}
+	resetLink = strings.TrimSpace(resetLink)
+
+	subject := "Reset your password"
+	intro := fmt.Sprintf("Hi %s, we received a request to reset the password for your hotel admin account.", name)
+	footer := fmt.Sprintf("This link expires on %s. If you did not ask for a password reset, you can ignore this email.",
+		expiresAt.UTC().Format("2006-01-02 15:04 UTC"))
+
+	plainBody := fmt.Sprintf("%s\n\nChoose a new password:\n%s\n\n%s\n\nBest regards,\n%s", intro, resetLink, footer, fromName)
+	htmlBody := privacyEmailHTML(subject, intro, "Reset password", resetLink, footer, fromName)
+
+	if err := deliverEmail(OutboundEmail{Kind: "password_reset", To: []string{recipientEmail}, Subject: subject, HTML: htmlBody, Text: plainBody, FromName: fromName}); err != nil {
+		log.Printf("Failed to send password reset email: %v", err)
+		return err
+	}
+	return nil
+}
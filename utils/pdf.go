@@ -0,0 +1,671 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// PDF ขนาด A4 (หน่วย point, 1/72 นิ้ว)
+const (
+	PDFPageWidth  = 595.28
+	PDFPageHeight = 841.89
+)
+
+// PDFDocument: ตัวเขียน PDF แบบง่าย (ข้อความ + เส้น + รูป) สำหรับเอกสารที่ระบบออกให้แขก
+// เช่น ใบยืนยันการให้ความยินยอม, ใบลงทะเบียนผู้เข้าพัก
+//
+// ถ้ามี font TrueType (เช่น PDF_FONT_PATH) จะฝังทั้งไฟล์แบบ Identity-H เพื่อให้แสดงภาษาไทยได้
+// ถ้าไม่มีจะใช้ Helvetica มาตรฐาน (ตัวอักษรนอก Latin-1 จะกลายเป็น '?') — เอกสารของแขกต้องสร้างผ่าน NewPDFDocumentFromEnv
+type PDFDocument struct {
+	pages  []*bytes.Buffer
+	font   *pdfTrueType
+	images []*PDFImage
+}
+
+// PDFImage: รูปที่ลงทะเบียนกับเอกสารแล้ว (วาดซ้ำได้หลายครั้ง/หลายหน้า)
+type PDFImage struct {
+	name   string
+	width  int
+	height int
+	rgb    []byte
+}
+
+// NewPDFDocument สร้างเอกสารเปล่า fontPath ว่าง = ใช้ Helvetica
+func NewPDFDocument(fontPath string) (*PDFDocument, error) {
+	doc := &PDFDocument{}
+	fontPath = strings.TrimSpace(fontPath)
+	if fontPath != "" {
+		data, err := os.ReadFile(fontPath)
+		if err != nil {
+			return nil, fmt.Errorf("read pdf font: %w", err)
+		}
+		font, err := parsePDFTrueType(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse pdf font: %w", err)
+		}
+		doc.font = font
+	}
+	return doc, nil
+}
+
+// pdfSystemFonts: font ภาษาไทยที่มักติดตั้งมากับระบบ (fonts-thai-tlwg / fonts-noto) ใช้เมื่อไม่ได้ตั้ง PDF_FONT_PATH
+var pdfSystemFonts = []string{
+	"/usr/share/fonts/truetype/tlwg/Garuda.ttf",
+	"/usr/share/fonts/truetype/tlwg/Loma.ttf",
+	"/usr/share/fonts/truetype/noto/NotoSansThai-Regular.ttf",
+	"/usr/share/fonts/noto/NotoSansThai-Regular.ttf",
+}
+
+// NewPDFDocumentFromEnv ใช้ font จาก PDF_FONT_PATH (หรือ font ไทยของระบบ)
+// ไม่มี font / อ่านไม่ได้ = error "pdf_font_not_configured" — ไม่ออกเอกสารที่ภาษาไทยกลายเป็น '?'
+func NewPDFDocumentFromEnv() (*PDFDocument, error) {
+	if path := strings.TrimSpace(os.Getenv("PDF_FONT_PATH")); path != "" {
+		doc, err := NewPDFDocument(path)
+		if err != nil {
+			return nil, fmt.Errorf("pdf_font_not_configured: %w", err)
+		}
+		return doc, nil
+	}
+	for _, path := range pdfSystemFonts {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if doc, err := NewPDFDocument(path); err == nil {
+			return doc, nil
+		}
+	}
+	return nil, errors.New("pdf_font_not_configured")
+}
+
+// AddPage เพิ่มหน้าใหม่ คำสั่งวาดหลังจากนี้จะไปอยู่ในหน้านี้
+func (d *PDFDocument) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+// PageCount จำนวนหน้าปัจจุบัน
+func (d *PDFDocument) PageCount() int {
+	return len(d.pages)
+}
+
+func (d *PDFDocument) current() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// Text วาดข้อความที่ตำแหน่ง (x, y) โดย y นับจากขอบบนของหน้า
+func (d *PDFDocument) Text(x, y, size float64, s string) {
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(d.current(), "BT /F1 %.2f Tf %.2f %.2f Td %s Tj ET\n", size, x, PDFPageHeight-y, d.encode(s))
+}
+
+// Line วาดเส้นตรง (y นับจากขอบบน)
+func (d *PDFDocument) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.current(), "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, PDFPageHeight-y1, x2, PDFPageHeight-y2)
+}
+
+// Polyline วาดเส้นต่อเนื่องผ่านจุด (x, y) ตามลำดับ (y นับจากขอบบน) — ใช้วาดลายเซ็นแบบ vector
+func (d *PDFDocument) Polyline(points [][2]float64, width float64) {
+	if len(points) == 0 {
+		return
+	}
+	buf := d.current()
+	fmt.Fprintf(buf, "%.2f w 1 J 1 j %.2f %.2f m", width, points[0][0], PDFPageHeight-points[0][1])
+	if len(points) == 1 {
+		// จุดเดียว = จุดเล็ก ๆ (หัวเส้นแบบมน)
+		fmt.Fprintf(buf, " %.2f %.2f l", points[0][0], PDFPageHeight-points[0][1])
+	}
+	for _, p := range points[1:] {
+		fmt.Fprintf(buf, " %.2f %.2f l", p[0], PDFPageHeight-p[1])
+	}
+	buf.WriteString(" S\n")
+}
+
+// Rect วาดกรอบสี่เหลี่ยม (y นับจากขอบบน)
+func (d *PDFDocument) Rect(x, y, w, h, width float64) {
+	fmt.Fprintf(d.current(), "%.2f w %.2f %.2f %.2f %.2f re S\n", width, x, PDFPageHeight-y-h, w, h)
+}
+
+// AddImage ลงทะเบียนรูป (พื้นโปร่งใสจะถูกวางบนพื้นขาว)
+func (d *PDFDocument) AddImage(img image.Image) *PDFImage {
+	b := img.Bounds()
+	rgb := make([]byte, 0, b.Dx()*b.Dy()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			// alpha-premultiplied -> ผสมกับพื้นขาว
+			white := 0xFFFF - a
+			rgb = append(rgb, byte((r+white)>>8), byte((g+white)>>8), byte((bl+white)>>8))
+		}
+	}
+	pi := &PDFImage{name: fmt.Sprintf("Im%d", len(d.images)+1), width: b.Dx(), height: b.Dy(), rgb: rgb}
+	d.images = append(d.images, pi)
+	return pi
+}
+
+// DrawImage วาดรูปให้พอดีกรอบ (x, y, w, h) โดยรักษาสัดส่วน (y นับจากขอบบน)
+func (d *PDFDocument) DrawImage(img *PDFImage, x, y, w, h float64) {
+	if img == nil || img.width == 0 || img.height == 0 {
+		return
+	}
+	scale := w / float64(img.width)
+	if s := h / float64(img.height); s < scale {
+		scale = s
+	}
+	dw, dh := float64(img.width)*scale, float64(img.height)*scale
+	x += (w - dw) / 2
+	y += (h - dh) / 2
+	fmt.Fprintf(d.current(), "q %.2f 0 0 %.2f %.2f %.2f cm /%s Do Q\n", dw, dh, x, PDFPageHeight-y-dh, img.name)
+}
+
+// TextWidth ความกว้างของข้อความ (point) ที่ขนาด font size
+func (d *PDFDocument) TextWidth(s string, size float64) float64 {
+	var units float64
+	for _, r := range s {
+		if d.font != nil {
+			units += d.font.advance(r)
+		} else {
+			units += helveticaWidth(r)
+		}
+	}
+	return units * size / 1000
+}
+
+// WrapText ตัดบรรทัดให้ไม่เกิน maxWidth — ตัดที่ช่องว่างก่อน ถ้าคำยาวเกิน (เช่น ภาษาไทยที่ไม่เว้นวรรค) ตัดตามตัวอักษร
+func (d *PDFDocument) WrapText(s string, size, maxWidth float64) []string {
+	var lines []string
+	for _, para := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		words := strings.Fields(para)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		line := ""
+		for _, w := range words {
+			candidate := w
+			if line != "" {
+				candidate = line + " " + w
+			}
+			if d.TextWidth(candidate, size) <= maxWidth {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			for d.TextWidth(w, size) > maxWidth {
+				cut := d.fitRunes(w, size, maxWidth)
+				lines = append(lines, w[:cut])
+				w = w[cut:]
+			}
+			line = w
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// fitRunes คืน byte offset ที่ยาวที่สุดที่ยังกว้างไม่เกิน maxWidth (อย่างน้อย 1 ตัวอักษร)
+func (d *PDFDocument) fitRunes(s string, size, maxWidth float64) int {
+	cut := 0
+	for i, r := range s {
+		next := i + utf8.RuneLen(r)
+		if cut > 0 && d.TextWidth(s[:next], size) > maxWidth {
+			break
+		}
+		cut = next
+	}
+	return cut
+}
+
+func (d *PDFDocument) encode(s string) string {
+	if d.font != nil {
+		var sb strings.Builder
+		sb.WriteByte('<')
+		for _, r := range s {
+			gid := d.font.glyph(r)
+			fmt.Fprintf(&sb, "%04X", gid)
+		}
+		sb.WriteByte('>')
+		return sb.String()
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('(')
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r >= 32 && r < 127:
+			sb.WriteRune(r)
+		case r >= 160 && r < 256:
+			fmt.Fprintf(&sb, "\\%03o", r)
+		default:
+			sb.WriteByte('?')
+		}
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
+// Bytes เขียนเอกสารทั้งหมดเป็นไฟล์ PDF
+func (d *PDFDocument) Bytes() ([]byte, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	var objects [][]byte
+	add := func(body []byte) int {
+		objects = append(objects, body)
+		return len(objects)
+	}
+	reserve := func() int {
+		objects = append(objects, nil)
+		return len(objects)
+	}
+	stream := func(dict string, data []byte) []byte {
+		var b bytes.Buffer
+		fmt.Fprintf(&b, "<< %s /Length %d >>\nstream\n", dict, len(data))
+		b.Write(data)
+		b.WriteString("\nendstream")
+		return b.Bytes()
+	}
+
+	catalogID := reserve()
+	pagesID := reserve()
+
+	var fontID int
+	if d.font != nil {
+		ids, err := d.font.writeObjects(add, stream)
+		if err != nil {
+			return nil, err
+		}
+		fontID = ids
+	} else {
+		fontID = add([]byte("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"))
+	}
+
+	resources := fmt.Sprintf("/Font << /F1 %d 0 R >>", fontID)
+	if len(d.images) > 0 {
+		var xobj strings.Builder
+		for _, img := range d.images {
+			compressed, err := pdfDeflate(img.rgb)
+			if err != nil {
+				return nil, err
+			}
+			id := add(stream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode",
+				img.width, img.height), compressed))
+			fmt.Fprintf(&xobj, " /%s %d 0 R", img.name, id)
+		}
+		resources += " /XObject <<" + xobj.String() + " >>"
+	}
+
+	kids := make([]string, 0, len(d.pages))
+	for _, page := range d.pages {
+		compressed, err := pdfDeflate(page.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		contentID := add(stream("/Filter /FlateDecode", compressed))
+		pageID := add([]byte(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources << %s >> /Contents %d 0 R >>",
+			pagesID, PDFPageWidth, PDFPageHeight, resources, contentID)))
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageID))
+	}
+	objects[catalogID-1] = []byte(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+	objects[pagesID-1] = []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	offsets := make([]int, len(objects))
+	for i, body := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		out.Write(body)
+		out.WriteString("\nendobj\n")
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, catalogID, xref)
+	return out.Bytes(), nil
+}
+
+func pdfDeflate(data []byte) ([]byte, error) {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// ------------------------------------------------------------
+// Helvetica (font มาตรฐาน ไม่ต้องฝัง)
+// ------------------------------------------------------------
+
+// ความกว้าง Helvetica (1/1000 em) สำหรับ ASCII 32..126
+var helveticaWidths = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+func helveticaWidth(r rune) float64 {
+	if r >= 32 && r <= 126 {
+		return float64(helveticaWidths[r-32])
+	}
+	return 556
+}
+
+// ------------------------------------------------------------
+// TrueType (ฝังทั้งไฟล์, CID = glyph id)
+// ------------------------------------------------------------
+
+type pdfTrueType struct {
+	data       []byte
+	name       string
+	unitsPerEm float64
+	bbox       [4]int
+	ascent     int
+	descent    int
+	widths     []uint16
+	cmap       map[rune]uint16
+	used       map[uint16]rune
+}
+
+func parsePDFTrueType(data []byte) (*pdfTrueType, error) {
+	if len(data) < 12 {
+		return nil, errors.New("font too short")
+	}
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	tables := map[string][]byte{}
+	for i := 0; i < numTables; i++ {
+		rec := 12 + 16*i
+		if rec+16 > len(data) {
+			return nil, errors.New("bad table directory")
+		}
+		tag := string(data[rec : rec+4])
+		off := int(binary.BigEndian.Uint32(data[rec+8:]))
+		length := int(binary.BigEndian.Uint32(data[rec+12:]))
+		if off < 0 || length < 0 || off+length > len(data) {
+			return nil, fmt.Errorf("bad table %s", tag)
+		}
+		tables[tag] = data[off : off+length]
+	}
+	for _, tag := range []string{"head", "hhea", "hmtx", "cmap"} {
+		if _, ok := tables[tag]; !ok {
+			return nil, fmt.Errorf("missing %s table", tag)
+		}
+	}
+	if _, ok := tables["glyf"]; !ok {
+		return nil, errors.New("only TrueType outlines (glyf) are supported")
+	}
+
+	head, hhea, hmtx := tables["head"], tables["hhea"], tables["hmtx"]
+	if len(head) < 54 || len(hhea) < 36 {
+		return nil, errors.New("bad head/hhea table")
+	}
+	f := &pdfTrueType{
+		data:       data,
+		name:       "EmbeddedFont",
+		unitsPerEm: float64(binary.BigEndian.Uint16(head[18:])),
+		ascent:     int(int16(binary.BigEndian.Uint16(hhea[4:]))),
+		descent:    int(int16(binary.BigEndian.Uint16(hhea[6:]))),
+		cmap:       map[rune]uint16{},
+		used:       map[uint16]rune{},
+	}
+	if f.unitsPerEm == 0 {
+		return nil, errors.New("bad unitsPerEm")
+	}
+	for i := 0; i < 4; i++ {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+	numHMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	if numHMetrics*4 > len(hmtx) {
+		return nil, errors.New("bad hmtx table")
+	}
+	f.widths = make([]uint16, numHMetrics)
+	for i := 0; i < numHMetrics; i++ {
+		f.widths[i] = binary.BigEndian.Uint16(hmtx[4*i:])
+	}
+	if name := fontPostScriptName(tables["name"]); name != "" {
+		f.name = name
+	}
+	if err := f.parseCmap(tables["cmap"]); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *pdfTrueType) parseCmap(cmap []byte) error {
+	if len(cmap) < 4 {
+		return errors.New("bad cmap")
+	}
+	n := int(binary.BigEndian.Uint16(cmap[2:]))
+	var fmt4, fmt12 []byte
+	for i := 0; i < n; i++ {
+		rec := 4 + 8*i
+		if rec+8 > len(cmap) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(cmap[rec:])
+		encoding := binary.BigEndian.Uint16(cmap[rec+2:])
+		off := int(binary.BigEndian.Uint32(cmap[rec+4:]))
+		if off+4 > len(cmap) {
+			continue
+		}
+		sub := cmap[off:]
+		switch binary.BigEndian.Uint16(sub) {
+		case 12:
+			if platform == 3 && encoding == 10 || platform == 0 {
+				fmt12 = sub
+			}
+		case 4:
+			if platform == 3 && encoding == 1 || platform == 0 {
+				fmt4 = sub
+			}
+		}
+	}
+
+	switch {
+	case fmt12 != nil && len(fmt12) >= 16:
+		groups := int(binary.BigEndian.Uint32(fmt12[12:]))
+		for g := 0; g < groups; g++ {
+			p := 16 + 12*g
+			if p+12 > len(fmt12) {
+				break
+			}
+			start := binary.BigEndian.Uint32(fmt12[p:])
+			end := binary.BigEndian.Uint32(fmt12[p+4:])
+			gid := binary.BigEndian.Uint32(fmt12[p+8:])
+			for c := start; c <= end && c < 0x110000; c++ {
+				f.cmap[rune(c)] = uint16(gid + c - start)
+			}
+		}
+	case fmt4 != nil && len(fmt4) >= 14:
+		segX2 := int(binary.BigEndian.Uint16(fmt4[6:]))
+		endOff := 14
+		startOff := endOff + segX2 + 2
+		deltaOff := startOff + segX2
+		rangeOff := deltaOff + segX2
+		if rangeOff+segX2 > len(fmt4) {
+			return errors.New("bad cmap format 4")
+		}
+		for s := 0; s < segX2/2; s++ {
+			end := binary.BigEndian.Uint16(fmt4[endOff+2*s:])
+			start := binary.BigEndian.Uint16(fmt4[startOff+2*s:])
+			delta := binary.BigEndian.Uint16(fmt4[deltaOff+2*s:])
+			ro := int(binary.BigEndian.Uint16(fmt4[rangeOff+2*s:]))
+			for c := uint32(start); c <= uint32(end) && c != 0xFFFF; c++ {
+				var gid uint16
+				if ro == 0 {
+					gid = uint16(c) + delta
+				} else {
+					p := rangeOff + 2*s + ro + 2*int(c-uint32(start))
+					if p+2 > len(fmt4) {
+						continue
+					}
+					gid = binary.BigEndian.Uint16(fmt4[p:])
+					if gid != 0 {
+						gid += delta
+					}
+				}
+				if gid != 0 {
+					f.cmap[rune(c)] = gid
+				}
+			}
+		}
+	default:
+		return errors.New("no unicode cmap")
+	}
+	return nil
+}
+
+// fontPostScriptName อ่าน nameID 6 (PostScript name) แบบ ASCII
+func fontPostScriptName(table []byte) string {
+	if len(table) < 6 {
+		return ""
+	}
+	count := int(binary.BigEndian.Uint16(table[2:]))
+	strOff := int(binary.BigEndian.Uint16(table[4:]))
+	for i := 0; i < count; i++ {
+		rec := 6 + 12*i
+		if rec+12 > len(table) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(table[rec:])
+		nameID := binary.BigEndian.Uint16(table[rec+6:])
+		length := int(binary.BigEndian.Uint16(table[rec+8:]))
+		off := strOff + int(binary.BigEndian.Uint16(table[rec+10:]))
+		if nameID != 6 || off+length > len(table) {
+			continue
+		}
+		raw := table[off : off+length]
+		var sb strings.Builder
+		if platform == 3 || platform == 0 {
+			for j := 0; j+1 < len(raw); j += 2 {
+				sb.WriteByte(raw[j+1])
+			}
+		} else {
+			sb.Write(raw)
+		}
+		name := strings.Map(func(r rune) rune {
+			if r > 32 && r < 127 && !strings.ContainsRune("[](){}<>/%#", r) {
+				return r
+			}
+			return -1
+		}, sb.String())
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+func (f *pdfTrueType) glyph(r rune) uint16 {
+	gid := f.cmap[r]
+	if _, ok := f.used[gid]; !ok {
+		f.used[gid] = r
+	}
+	return gid
+}
+
+func (f *pdfTrueType) glyphWidth(gid uint16) float64 {
+	if len(f.widths) == 0 {
+		return 0
+	}
+	w := f.widths[len(f.widths)-1]
+	if int(gid) < len(f.widths) {
+		w = f.widths[gid]
+	}
+	return float64(w) * 1000 / f.unitsPerEm
+}
+
+func (f *pdfTrueType) advance(r rune) float64 {
+	return f.glyphWidth(f.cmap[r])
+}
+
+func (f *pdfTrueType) scale(v int) int {
+	return int(float64(v) * 1000 / f.unitsPerEm)
+}
+
+// writeObjects เขียน Type0 font + CIDFont + descriptor + font file + ToUnicode คืน id ของ Type0
+func (f *pdfTrueType) writeObjects(add func([]byte) int, stream func(string, []byte) []byte) (int, error) {
+	compressed, err := pdfDeflate(f.data)
+	if err != nil {
+		return 0, err
+	}
+	fileID := add(stream(fmt.Sprintf("/Filter /FlateDecode /Length1 %d", len(f.data)), compressed))
+
+	descID := add([]byte(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		f.name, f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3]),
+		f.scale(f.ascent), f.scale(f.descent), f.scale(f.ascent), fileID)))
+
+	gids := make([]int, 0, len(f.used))
+	for gid := range f.used {
+		gids = append(gids, int(gid))
+	}
+	sort.Ints(gids)
+
+	var w strings.Builder
+	for _, gid := range gids {
+		fmt.Fprintf(&w, "%d [%d] ", gid, int(f.glyphWidth(uint16(gid))))
+	}
+	cidID := add([]byte(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /DW 1000 /W [%s] /CIDToGIDMap /Identity >>",
+		f.name, descID, strings.TrimSpace(w.String()))))
+
+	var cmap strings.Builder
+	cmap.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	cmap.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	cmap.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for i := 0; i < len(gids); i += 100 {
+		end := i + 100
+		if end > len(gids) {
+			end = len(gids)
+		}
+		fmt.Fprintf(&cmap, "%d beginbfchar\n", end-i)
+		for _, gid := range gids[i:end] {
+			r := f.used[uint16(gid)]
+			var units [2]uint16
+			utf := fmt.Sprintf("%04X", r)
+			if r > 0xFFFF {
+				r -= 0x10000
+				units[0] = uint16(0xD800 + (r >> 10))
+				units[1] = uint16(0xDC00 + (r & 0x3FF))
+				utf = fmt.Sprintf("%04X%04X", units[0], units[1])
+			}
+			fmt.Fprintf(&cmap, "<%04X> <%s>\n", gid, utf)
+		}
+		cmap.WriteString("endbfchar\n")
+	}
+	cmap.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend")
+	toUnicode, err := pdfDeflate([]byte(cmap.String()))
+	if err != nil {
+		return 0, err
+	}
+	toUnicodeID := add(stream("/Filter /FlateDecode", toUnicode))
+
+	return add([]byte(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		f.name, cidID, toUnicodeID))), nil
+}
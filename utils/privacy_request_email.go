@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+var privacyRequestLabels = map[string]string{
+	"withdraw_consent": "withdraw your consent",
+	"access":           "receive a copy of your personal data",
+	"erasure":          "erase your personal data",
+}
+
+func privacyEmailHTML(title, intro, buttonLabel, link, footer, fromName string) string {
+	return fmt.Sprintf(`<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { background:#f5f7fb; font-family:Arial, Helvetica, sans-serif; color:#222; }
+.container { max-width:640px; margin:20px auto; }
+.card { background:#fff; border:1px solid #e6eef6; padding:24px; border-radius:8px; }
+.btn { display:inline-block; padding:12px 20px; background:#0b74ff; color:#fff; text-decoration:none; border-radius:6px; margin-top:16px; }
+.muted { color:#667085; font-size:13px; margin-top:16px; }
+</style>
+</head>
+<body>
+<div class="container">
+  <div class="card">
+    <h2>%s</h2>
+    <p>%s</p>
+    <a class="btn" href="%s" target="_blank">%s</a>
+    <p class="muted">%s</p>
+    <p>Best regards,<br>%s</p>
+  </div>
+</div>
+</body>
+</html>`,
+		htmlEscape(title), htmlEscape(title), htmlEscape(intro), link, htmlEscape(buttonLabel), htmlEscape(footer), htmlEscape(fromName),
+	)
+}
+
+// SendPrivacyVerificationEmail asks the requester to confirm a data-subject request from their mailbox.
+func SendPrivacyVerificationEmail(recipientEmail, requestType, verifyLink string, expiresAt time.Time) error {
+	fromName := emailFromName()
+
+	label, ok := privacyRequestLabels[requestType]
+	if !ok {
+		label = "process your privacy request"
+	}
+	verifyLink = strings.TrimSpace(verifyLink)
+
+	subject := "Please confirm your personal data request"
+	intro := fmt.Sprintf("We received a request to %s. To protect your data, please confirm that this request came from you.", label)
+	footer := fmt.Sprintf("This link expires on %s. If you did not make this request, you can ignore this email.",
+		expiresAt.UTC().Format("2006-01-02 15:04 UTC"))
+
+	plainBody := fmt.Sprintf("%s\n\nConfirm your request:\n%s\n\n%s\n\nBest regards,\n%s", intro, verifyLink, footer, fromName)
+	htmlBody := privacyEmailHTML(subject, intro, "Confirm request", verifyLink, footer, fromName)
+
+	if err := deliverEmail(OutboundEmail{Kind: "privacy_verification", To: []string{recipientEmail}, Subject: subject, HTML: htmlBody, Text: plainBody, FromName: fromName}); err != nil {
+		log.Printf("Failed to send privacy verification email: %v", err)
+		return err
+	}
+	return nil
+}
+
+// SendPrivacyExportEmail sends the download link for a completed data access request.
+func SendPrivacyExportEmail(recipientEmail, downloadLink string, expiresAt time.Time) error {
+	fromName := emailFromName()
+	downloadLink = strings.TrimSpace(downloadLink)
+
+	subject := "Your personal data export is ready"
+	intro := "The copy of your personal data you requested is ready to download."
+	footer := fmt.Sprintf("For your security this link can be used until %s.", expiresAt.UTC().Format("2006-01-02 15:04 UTC"))
+
+	plainBody := fmt.Sprintf("%s\n\nDownload:\n%s\n\n%s\n\nBest regards,\n%s", intro, downloadLink, footer, fromName)
+	htmlBody := privacyEmailHTML(subject, intro, "Download my data", downloadLink, footer, fromName)
+
+	if err := deliverEmail(OutboundEmail{Kind: "privacy_export", To: []string{recipientEmail}, Subject: subject, HTML: htmlBody, Text: plainBody, FromName: fromName}); err != nil {
+		log.Printf("Failed to send privacy export email: %v", err)
+		return err
+	}
+	return nil
+}